@@ -0,0 +1,17 @@
+package commands
+
+import (
+	"time"
+)
+
+// CacheFlags configures the in-process cache of snippets.
+type CacheFlags struct {
+	CacheSize          int           `kong:"optional,name=cache-size,default=10000,group='Cache',env=CACHE_SIZE,help='Max number of snippets cached in memory, 0 disables the cache.'"`
+	CacheTTL           time.Duration `kong:"optional,name=cache-ttl,default=1m,group='Cache',env=CACHE_TTL,help='How long a snippet is cached. It bounds staleness if a change notification of another replica is missed.'"`
+	CacheStatsInterval time.Duration `kong:"optional,name=cache-stats-interval,default=1m,group='Cache',env=CACHE_STATS_INTERVAL,help='How often cache hits, misses, invalidations and evictions are logged.'"`
+}
+
+// Enabled reports whether snippets are cached
+func (f CacheFlags) Enabled() bool {
+	return f.CacheSize > 0 && f.CacheTTL > 0
+}
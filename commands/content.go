@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/editing"
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/api"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/encryption"
+)
+
+// ContentFlags configures how snippets content is stored at rest.
+// It's shared by all commands that write snippets.
+type ContentFlags struct {
+	ContentKeyFile string `kong:"optional,name=content-key-file,group='Encryption',env=CONTENT_KEY_FILE,help='Path to a file with a base64-encoded 256-bit key. If set, snippets content is encrypted at rest.'"`
+
+	ContentCompression          string `kong:"optional,name=content-compression,group='Storage',enum='none,gzip,zstd',default=zstd,env=CONTENT_COMPRESSION,help='Algorithm used to compress large snippets content (${enum}).'"`
+	ContentCompressionThreshold int    `kong:"optional,name=content-compression-threshold,group='Storage',default=1024,env=CONTENT_COMPRESSION_THRESHOLD,help='Minimal size of snippet content in bytes to be compressed.'"`
+}
+
+// ServiceOptions returns snippets.ServiceOption list to enable content encryption.
+func (c ContentFlags) ServiceOptions() ([]snippets.ServiceOption, error) {
+	envelope, err := c.envelope()
+	if err != nil || envelope == nil {
+		return nil, err
+	}
+
+	return []snippets.ServiceOption{snippets.WithEnvelope(envelope)}, nil
+}
+
+// IdempotencyOptions returns api.IdempotencyOption list, so responses with snippets content aren't stored in clear:
+// responses of protected snippets are encrypted with their passphrase, and all of them with the content key, if it's set.
+func (c ContentFlags) IdempotencyOptions() ([]api.IdempotencyOption, error) {
+	envelope, err := c.envelope()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []api.IdempotencyOption{api.WithPassphraseHeader(snippets.PassphraseHeader)}
+	if envelope != nil {
+		opts = append(opts, api.WithResponseEnvelope(envelope))
+	}
+
+	return opts, nil
+}
+
+// EditingOptions returns editing.ServiceOption list to disable live editing if content is encrypted at rest.
+func (c ContentFlags) EditingOptions() []editing.ServiceOption {
+	if c.ContentKeyFile == "" {
+		return nil
+	}
+
+	return []editing.ServiceOption{editing.WithContentEncryptedAtRest()}
+}
+
+// envelope returns nil if content isn't encrypted at rest
+func (c ContentFlags) envelope() (*encryption.Envelope, error) {
+	if c.ContentKeyFile == "" {
+		return nil, nil
+	}
+
+	key, err := encryption.LoadKeyFile(c.ContentKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load content key: %w", err)
+	}
+
+	envelope, err := encryption.NewEnvelope(key)
+	if err != nil {
+		return nil, fmt.Errorf("init content encryption: %w", err)
+	}
+
+	return envelope, nil
+}
+
+// StorageOptions returns snippets.PGStorageOption list to configure content compression.
+func (c ContentFlags) StorageOptions() ([]snippets.PGStorageOption, error) {
+	compression, err := snippets.ParseCompression(c.ContentCompression)
+	if err != nil {
+		return nil, fmt.Errorf("parse content compression: %w", err)
+	}
+
+	return []snippets.PGStorageOption{
+		snippets.WithCompression(compression, c.ContentCompressionThreshold),
+	}, nil
+}
+
+// SQLiteStorageOptions returns snippets.SQLiteStorageOption list to configure content compression.
+func (c ContentFlags) SQLiteStorageOptions() ([]snippets.SQLiteStorageOption, error) {
+	compression, err := snippets.ParseCompression(c.ContentCompression)
+	if err != nil {
+		return nil, fmt.Errorf("parse content compression: %w", err)
+	}
+
+	return []snippets.SQLiteStorageOption{
+		snippets.WithSQLiteCompression(compression, c.ContentCompressionThreshold),
+	}, nil
+}
@@ -0,0 +1,10 @@
+package commands
+
+import (
+	"time"
+)
+
+// EditingFlags configures live editing of snippets.
+type EditingFlags struct {
+	DraftPersistInterval time.Duration `kong:"optional,name=draft-persist-interval,default=5s,group='Live Editing',env=DRAFT_PERSIST_INTERVAL,help='How often drafts edited live are saved to their snippets.'"`
+}
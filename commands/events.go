@@ -0,0 +1,13 @@
+package commands
+
+import (
+	"time"
+)
+
+// EventFlags configures relaying of domain events from the outbox.
+type EventFlags struct {
+	OutboxPollInterval  time.Duration `kong:"optional,name=outbox-poll-interval,default=1s,group='Events',env=OUTBOX_POLL_INTERVAL,help='How often new events are relayed from the outbox.'"`
+	OutboxRetention     time.Duration `kong:"optional,name=outbox-retention,default=24h,group='Events',env=OUTBOX_RETENTION,help='How long published events are kept in the outbox.'"`
+	ExpiryCheckInterval time.Duration `kong:"optional,name=expiry-check-interval,default=30s,group='Events',env=EXPIRY_CHECK_INTERVAL,help='How often expired snippets are checked to emit snippet.expired events.'"`
+	EventLog            bool          `kong:"optional,name=event-log,group='Events',env=EVENT_LOG,help='Write every relayed event to the log.'"`
+}
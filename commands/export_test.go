@@ -0,0 +1,5 @@
+package commands
+
+func (c ServerCmd) Redacted() ServerCmd {
+	return c.redacted()
+}
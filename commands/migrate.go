@@ -2,6 +2,7 @@ package commands
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log/slog"
 
@@ -9,9 +10,21 @@ import (
 	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres"
 	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres/pgmigrator"
 	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres/pgtest"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/sqlite"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/sqlite/sqlitemigrator"
 	"github.com/titusjaka/go-sample/v2/migrations"
+	sqlitemigrations "github.com/titusjaka/go-sample/v2/migrations/sqlite"
 )
 
+// dialectSQLite is the --dialect value to migrate SQLite databases, PostgreSQL is migrated by default
+const dialectSQLite = "sqlite"
+
+// migrator applies migrations of a database dialect
+type migrator interface {
+	Up(ctx context.Context) (int, error)
+	Down(ctx context.Context, maxSteps int) (int, error)
+}
+
 // MigrateCmd implements kong.Command for migrations. To use this command you need to add migrate.Command
 // to the application structure and bind a migration source.
 //
@@ -36,10 +49,13 @@ import (
 // CLI usage:
 //
 //	$ go run main.go migrate up
+//	$ go run main.go migrate up --dialect=sqlite --sqlite-path=./go-sample.db
+//	$ go run main.go migrate wait --postgres-connect-deadline=2m
 type MigrateCmd struct {
 	Create CreateCmd `kong:"cmd,name=create,help='Create a new blank migration file. Pass a [name] as the first argument.'"`
 	Up     UpCmd     `kong:"cmd,name=up,default=1,help='Apply all database migrations.'"`
 	Down   DownCmd   `kong:"cmd,name=down,help='Rollback a [number] of migrations. Pass a [number] as the flag.'"`
+	Wait   WaitCmd   `kong:"cmd,name=wait,help='Block until PostgreSQL is reachable, e.g. in an init container.'"`
 
 	TestDB InitTestDBCmd `kong:"cmd,name=init-test-db,help='Init test database template.'"`
 }
@@ -47,28 +63,79 @@ type MigrateCmd struct {
 // ============================================================================
 // Sub-commands
 
-// CreateCmd represents a CLI sub-command to create a new migration file
+// CreateCmd represents a CLI sub-command to create a new migration file.
+// Migrations of the SQLite dialect are stored in ./migrations/sqlite.
 type CreateCmd struct {
-	Directory string `kong:"default='./migrations',help='Directory to store migration files'"`
+	Directory string `kong:"default='./migrations',help='Directory to store migration files, ./migrations/sqlite for SQLite ones'"`
 	Name      string `kong:"arg,required,help='Migration name'"`
 
 	Logger flags.Logger `kong:"embed"`
 }
 
+// DialectFlags selects the database to migrate
+type DialectFlags struct {
+	Dialect string `kong:"optional,name=dialect,enum='postgres,sqlite',default=postgres,env=MIGRATIONS_DIALECT,help='Database dialect (${enum}).'"`
+
+	Postgres postgres.Flags `kong:"embed"`
+	SQLite   sqlite.Flags   `kong:"embed"`
+}
+
+// openMigrator opens the database of the dialect and returns its migrator along with a function to close the database
+func (f DialectFlags) openMigrator(logger *slog.Logger) (migrator, func(), error) {
+	var (
+		db     *sql.DB
+		source migrator
+		err    error
+	)
+
+	if f.Dialect == dialectSQLite {
+		if db, err = f.SQLite.OpenStdSQLDB(); err != nil {
+			return nil, nil, fmt.Errorf("init DB: %w", err)
+		}
+		source = sqlitemigrator.NewMigrator(db, sqlitemigrations.Dir)
+	} else {
+		if err = f.Postgres.Wait(context.Background(), logger); err != nil {
+			return nil, nil, fmt.Errorf("wait for DB: %w", err)
+		}
+
+		if db, err = f.Postgres.OpenStdSQLDB(); err != nil {
+			return nil, nil, fmt.Errorf("init DB: %w", err)
+		}
+		source = pgmigrator.NewMigrator(db, migrations.Dir)
+	}
+
+	closeFunc := func() {
+		if closeErr := db.Close(); closeErr != nil {
+			logger.Error("close db connection", slog.Any("err", closeErr))
+		}
+	}
+
+	return source, closeFunc, nil
+}
+
 // UpCmd represents a CLI sub-command to apply all migrations to DB
 type UpCmd struct {
-	Postgres postgres.Flags `kong:"embed"`
-	Logger   flags.Logger   `kong:"embed"`
+	DialectFlags `kong:"embed"`
+
+	Logger flags.Logger `kong:"embed"`
 }
 
 // DownCmd represents a CLI sub-command to roll back a specified number of migrations
 type DownCmd struct {
-	Postgres postgres.Flags `kong:"embed"`
-	Logger   flags.Logger   `kong:"embed"`
+	DialectFlags `kong:"embed"`
+
+	Logger flags.Logger `kong:"embed"`
 
 	Steps int `kong:"required,default='1',name=steps,help='Number of migrations to revert'"`
 }
 
+// WaitCmd represents a CLI sub-command that blocks until PostgreSQL is reachable.
+// It's retried with --postgres-connect-* flags and fails once the deadline passes.
+type WaitCmd struct {
+	Postgres postgres.Flags `kong:"embed"`
+	Logger   flags.Logger   `kong:"embed"`
+}
+
 // InitTestDBCmd represents a CLI sub-command to create a new template database for testing
 type InitTestDBCmd struct {
 	Postgres pgtest.Flags `kong:"embed"`
@@ -96,18 +163,13 @@ func (c CreateCmd) Run() error {
 func (c UpCmd) Run() error {
 	logger := c.Logger.Init()
 
-	db, err := c.Postgres.OpenStdSQLDB()
+	m, closeFunc, err := c.openMigrator(logger)
 	if err != nil {
-		return fmt.Errorf("init DB: %w", err)
+		return err
 	}
+	defer closeFunc()
 
-	defer func() {
-		if closeErr := db.Close(); closeErr != nil {
-			logger.Error("close pg connection", slog.Any("err", closeErr))
-		}
-	}()
-
-	applied, err := pgmigrator.NewMigrator(db, migrations.Dir).Up(context.Background())
+	applied, err := m.Up(context.Background())
 	if err != nil {
 		return fmt.Errorf("apply migrations: %w", err)
 	}
@@ -121,18 +183,13 @@ func (c UpCmd) Run() error {
 func (c DownCmd) Run() error {
 	logger := c.Logger.Init()
 
-	db, err := c.Postgres.OpenStdSQLDB()
+	m, closeFunc, err := c.openMigrator(logger)
 	if err != nil {
-		return fmt.Errorf("init DB: %w", err)
+		return err
 	}
+	defer closeFunc()
 
-	defer func() {
-		if closeErr := db.Close(); closeErr != nil {
-			logger.Error("close pg connection", slog.Any("err", closeErr))
-		}
-	}()
-
-	reverted, err := pgmigrator.NewMigrator(db, migrations.Dir).Down(context.Background(), c.Steps)
+	reverted, err := m.Down(context.Background(), c.Steps)
 	if err != nil {
 		return fmt.Errorf("revert migrations: %w", err)
 	}
@@ -142,6 +199,21 @@ func (c DownCmd) Run() error {
 	return nil
 }
 
+// Run (WaitCmd) waits for PostgreSQL to become reachable
+func (c WaitCmd) Run() error {
+	logger := c.Logger.Init()
+
+	logger.Info("⏳ ➡ waiting for PostgreSQL...", slog.Duration("deadline", c.Postgres.ConnectDeadline))
+
+	if err := c.Postgres.Wait(context.Background(), logger); err != nil {
+		return err
+	}
+
+	logger.Info("🐘 ➡ PostgreSQL is ready")
+
+	return nil
+}
+
 // Run (InitTestDBCmd) creates a new template database for testing
 func (c InitTestDBCmd) Run() error {
 	logger := c.Logger.Init()
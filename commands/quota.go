@@ -0,0 +1,21 @@
+package commands
+
+import (
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+)
+
+// QuotaFlags configures quotas of API callers, every caller gets the same limits.
+type QuotaFlags struct {
+	QuotaMaxSnippets     uint   `kong:"optional,name=quota-max-snippets,default=0,group='Quotas',env=QUOTA_MAX_SNIPPETS,help='Max number of active snippets of an API caller, 0 means no limit.'"`
+	QuotaMaxContentBytes uint64 `kong:"optional,name=quota-max-content-bytes,default=0,group='Quotas',env=QUOTA_MAX_CONTENT_BYTES,help='Max total size of contents of active snippets of an API caller in bytes, 0 means no limit.'"`
+	QuotaDailyCreations  uint   `kong:"optional,name=quota-daily-creations,default=0,group='Quotas',env=QUOTA_DAILY_CREATIONS,help='Max number of snippets an API caller creates in 24 hours, 0 means no limit.'"`
+}
+
+// ServiceOption returns snippets.ServiceOption to enforce quotas.
+func (f QuotaFlags) ServiceOption() snippets.ServiceOption {
+	return snippets.WithQuota(snippets.Quota{
+		MaxSnippets:     f.QuotaMaxSnippets,
+		MaxContentBytes: f.QuotaMaxContentBytes,
+		DailyCreations:  f.QuotaDailyCreations,
+	})
+}
@@ -18,19 +18,55 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/titusjaka/go-sample/v2/commands/flags"
+	"github.com/titusjaka/go-sample/v2/internal/business/collections"
+	"github.com/titusjaka/go-sample/v2/internal/business/comments"
+	"github.com/titusjaka/go-sample/v2/internal/business/editing"
 	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/business/webhooks"
 	"github.com/titusjaka/go-sample/v2/internal/infrastructure/api"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
 	"github.com/titusjaka/go-sample/v2/internal/infrastructure/kongflag"
 	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/sqlite"
+)
+
+// minPoolHeadroom is the number of connections required besides the ones held by listeners,
+// so notification handlers and requests are able to query the database
+const minPoolHeadroom = 2
+
+// Values of --storage flag other than PostgreSQL
+const (
+	storageMemory = "memory"
+	storageSQLite = "sqlite"
 )
 
 // ServerCmd implements kong.Command for the main server command.
 type ServerCmd struct {
-	Postgres postgres.Flags `kong:"embed"`
-	Logger   flags.Logger   `kong:"embed"`
+	Postgres postgres.Flags        `kong:"embed"`
+	Replicas postgres.ReplicaFlags `kong:"embed"`
+	SQLite   sqlite.Flags          `kong:"embed"`
+	Logger   flags.Logger          `kong:"embed"`
+
+	Storage string `kong:"optional,name=storage,default=postgres,enum='postgres,sqlite,memory',group='Storage',env=STORAGE,help='Snippets storage (${enum}). Only snippets endpoints are served with SQLite and memory storages, snippets kept in memory are lost on restart.'"`
 
 	Listen string `kong:"optional,default=':4040',group='HTTP Server',env=HTTP_LISTEN,help='HTTP network address'"`
-	Token  string `kong:"optional,env=API_TOKEN,group='HTTP Server',help='authentication token used for inter-service communication'"`
+	Token  string `kong:"optional,env=API_TOKEN,group='HTTP Server',help='authentication token used for inter-service communication'" json:"-"`
+
+	CallerTokens map[string]string `kong:"optional,name=caller-token,env=API_CALLER_TOKENS,group='HTTP Server',help='Tokens of named API callers as name=token pairs. Quotas are applied per caller, the shared token belongs to the default caller.'" json:"-"`
+
+	IdempotencyTTL   time.Duration `kong:"optional,name=idempotency-ttl,default=24h,group='HTTP Server',env=IDEMPOTENCY_TTL,help='How long responses of requests with Idempotency-Key header are kept for replay.'"`
+	IdempotencyLease time.Duration `kong:"optional,name=idempotency-lease,default=1m,group='HTTP Server',env=IDEMPOTENCY_LEASE,help='How long a request with Idempotency-Key header holds the key. A key left by a crashed request is free again after it.'"`
+
+	ExpiredRetention time.Duration `kong:"optional,name=expired-retention,default=720h,group='Storage',env=EXPIRED_RETENTION,help='How long expired and deleted snippets are kept before they are purged with their unshared content.'"`
+
+	ViewsFlushInterval time.Duration `kong:"optional,name=views-flush-interval,default=10s,group='HTTP Server',env=VIEWS_FLUSH_INTERVAL,help='How often buffered snippets views are written to DB.'"`
+
+	Content  ContentFlags `kong:"embed"`
+	Events   EventFlags   `kong:"embed"`
+	Webhooks WebhookFlags `kong:"embed"`
+	Editing  EditingFlags `kong:"embed"`
+	Quota    QuotaFlags   `kong:"embed"`
+	Cache    CacheFlags   `kong:"embed"`
 }
 
 // Run (ServerCmd) runs the main server command.
@@ -56,11 +92,43 @@ func (c ServerCmd) Run(kVars kong.Vars) error {
 			slog.String("git_branch", kVars[kongflag.GitBranch]),
 			slog.String("go_version", kVars[kongflag.GoVersion]),
 		),
-		slog.Any("config", c),
+		slog.Any("config", c.redacted()),
 	)
 
+	// =========================================================================
+	// Init Content Encryption
+	serviceOpts, err := c.Content.ServiceOptions()
+	if err != nil {
+		return err
+	}
+
+	serviceOpts = append(serviceOpts, c.Quota.ServiceOption())
+
+	switch c.Storage {
+	case storageMemory:
+		logger.Warn("⚠️ snippets are kept in memory, they are lost on restart")
+
+		return c.runStandalone(ctx, gr, logger, serviceOpts, snippets.NewMemoryStorage(
+			func() time.Time { return time.Now().UTC() },
+		))
+	case storageSQLite:
+		return c.runWithSQLite(ctx, gr, logger, serviceOpts)
+	}
+
 	// =========================================================================
 	// Init PostgreSQL Connection
+	if err = c.checkPoolSize(); err != nil {
+		return err
+	}
+
+	if err = c.Webhooks.Validate(); err != nil {
+		return err
+	}
+
+	if err = c.Postgres.Wait(ctx, logger); err != nil {
+		return fmt.Errorf("wait for database: %w", err)
+	}
+
 	db, err := c.Postgres.OpenStdSQLDB()
 	if err != nil {
 		return fmt.Errorf("open database connection: %w", err)
@@ -78,14 +146,213 @@ func (c ServerCmd) Run(kVars kong.Vars) error {
 	// ================================================
 	// Apply SQL Migrations
 	migrationCmd := UpCmd{
-		Postgres: c.Postgres,
-		Logger:   c.Logger,
+		DialectFlags: DialectFlags{Postgres: c.Postgres},
+		Logger:       c.Logger,
 	}
 
 	if err = migrationCmd.Run(); err != nil {
 		return fmt.Errorf("run migrations: %w", err)
 	}
 
+	// =========================================================================
+	// Init Idempotency Store
+	idempotencyStore := postgres.NewIdempotencyStore(
+		db,
+		c.IdempotencyTTL,
+		postgres.WithLockLease(c.IdempotencyLease),
+	)
+
+	gr.Go(func() error {
+		return runIdempotencyPurge(
+			ctx,
+			logger.With(
+				slog.String("module", "idempotency-purge"),
+			),
+			idempotencyStore,
+		)
+	})
+
+	// =========================================================================
+	// Init Snippets View Counter
+	viewCounter := snippets.NewViewCounter(
+		snippets.NewPGStorage(db),
+		logger.With(slog.String("module", "views-counter")),
+		func() time.Time { return time.Now().UTC() },
+	)
+
+	gr.Go(func() error {
+		return viewCounter.Run(ctx, c.ViewsFlushInterval)
+	})
+
+	// =========================================================================
+	// Init Webhooks Delivery
+	webhookStorage := webhooks.NewPGStorage(db)
+	webhookService := webhooks.NewService(
+		webhookStorage,
+		logger.With(slog.String("service", "webhooks")),
+		func() time.Time { return time.Now().UTC() },
+	)
+
+	webhookDispatcher := webhooks.NewDispatcher(
+		webhookStorage,
+		logger.With(slog.String("module", "webhooks-dispatcher")),
+		func() time.Time { return time.Now().UTC() },
+		c.Webhooks.DispatcherOptions()...,
+	)
+
+	gr.Go(func() error {
+		return webhookDispatcher.Run(ctx, c.Webhooks.WebhookPollInterval)
+	})
+
+	// =========================================================================
+	// Init Events Relay
+	outbox := postgres.NewOutbox(db)
+	eventBus := events.NewBus()
+
+	sinks := []events.Publisher{webhookService, eventBus}
+	if c.Events.EventLog {
+		sinks = append(sinks, events.NewLogSink(logger.With(slog.String("module", "events"))))
+	}
+
+	eventRelay := events.NewRelay(
+		outbox,
+		logger.With(slog.String("module", "events-relay")),
+		sinks...,
+	)
+
+	gr.Go(func() error {
+		return eventRelay.Run(ctx, c.Events.OutboxPollInterval)
+	})
+
+	gr.Go(func() error {
+		return runOutboxPurge(
+			ctx,
+			logger.With(
+				slog.String("module", "outbox-purge"),
+			),
+			outbox,
+			c.Events.OutboxRetention,
+		)
+	})
+
+	// =========================================================================
+	// Init Events Stream
+	eventBroadcaster := events.NewBroadcaster(outbox)
+
+	gr.Go(func() error {
+		return outbox.Watch(
+			ctx,
+			logger.With(slog.String("module", "events-stream")),
+			eventBroadcaster,
+		)
+	})
+
+	gr.Go(func() error {
+		// The HTTP server shutdown waits for streaming responses, they end once the broadcaster is closed
+		<-ctx.Done()
+		eventBroadcaster.Close()
+		return nil
+	})
+
+	// =========================================================================
+	// Init Snippets Expiry Notifier
+	expiryNotifier := snippets.NewService(
+		snippets.NewPGStorage(db),
+		logger.With(slog.String("module", "expiry-notifier")),
+		func() time.Time { return time.Now().UTC() },
+	)
+
+	gr.Go(func() error {
+		return expiryNotifier.RunExpiryNotifier(ctx, c.Events.ExpiryCheckInterval)
+	})
+
+	gr.Go(func() error {
+		return expiryNotifier.RunExpiredPurge(ctx, expiredPurgeInterval, c.ExpiredRetention)
+	})
+
+	// =========================================================================
+	// Init Snippets Transactions
+	// Quota checks and creations of snippets run in transactions of the primary.
+	// Without replicas, the total and the page of snippets are read in one transaction too.
+	// With replicas, they're read from replicas separately, a transaction would pin them to the primary.
+	serviceOpts = append(serviceOpts, snippets.WithTransactor(
+		postgres.NewTxManager(db, logger.With(slog.String("module", "transactions"))),
+	))
+	if len(c.Replicas.Hosts) > 0 {
+		serviceOpts = append(serviceOpts, snippets.WithoutListSnapshot())
+	}
+
+	// =========================================================================
+	// Init Live Editing
+	storageOpts, err := c.Content.StorageOptions()
+	if err != nil {
+		return err
+	}
+
+	editingService := editing.NewService(
+		editing.NewPGStorage(db),
+		snippets.NewService(
+			snippets.NewPGStorage(db, storageOpts...),
+			logger.With(slog.String("service", "snippets")),
+			func() time.Time { return time.Now().UTC() },
+			serviceOpts...,
+		),
+		logger.With(slog.String("service", "editing")),
+		func() time.Time { return time.Now().UTC() },
+		c.Content.EditingOptions()...,
+	)
+
+	gr.Go(func() error {
+		return editingService.Run(ctx, c.Editing.DraftPersistInterval)
+	})
+
+	// =========================================================================
+	// Init Read Replicas
+	var replicaOpts []snippets.PGStorageOption
+
+	if len(c.Replicas.Hosts) > 0 {
+		replicas, err := c.Replicas.Open(c.Postgres)
+		if err != nil {
+			return fmt.Errorf("open replicas: %w", err)
+		}
+
+		router := postgres.NewRouter(db, logger.With(slog.String("module", "replicas")), replicas...)
+
+		defer func() {
+			if closeErr := router.Close(); closeErr != nil {
+				logger.Error("unable to close replica connections", slog.Any("err", closeErr))
+			}
+		}()
+
+		gr.Go(func() error {
+			return router.Run(ctx, c.Replicas.HealthCheckInterval)
+		})
+
+		replicaOpts = append(replicaOpts, snippets.WithReplicas(router))
+	}
+
+	// =========================================================================
+	// Init Snippets Cache
+	pgStorage := snippets.NewPGStorage(db, append(storageOpts, replicaOpts...)...)
+
+	var snippetStorage snippets.Storage = pgStorage
+	if c.Cache.Enabled() {
+		cachingStorage := snippets.NewCachingStorage(
+			pgStorage,
+			c.Cache.CacheSize,
+			c.Cache.CacheTTL,
+			logger.With(slog.String("module", "snippets-cache")),
+			func() time.Time { return time.Now().UTC() },
+			snippets.WithChangeListener(pgStorage),
+		)
+
+		gr.Go(func() error {
+			return cachingStorage.Run(ctx, c.Cache.CacheStatsInterval)
+		})
+
+		snippetStorage = cachingStorage
+	}
+
 	// =========================================================================
 	// Start Private API Server
 	gr.Go(func() error {
@@ -95,6 +362,13 @@ func (c ServerCmd) Run(kVars kong.Vars) error {
 				slog.String("module", "http-server"),
 			),
 			db,
+			snippetStorage,
+			serviceOpts,
+			idempotencyStore,
+			viewCounter,
+			eventBroadcaster,
+			webhookService,
+			editingService,
 		)
 	})
 
@@ -108,8 +382,265 @@ func (c ServerCmd) Run(kVars kong.Vars) error {
 	return nil
 }
 
+// redacted returns a copy of the config that is safe to log: secrets are replaced in case the text format is used,
+// which doesn't respect json tags
+func (c ServerCmd) redacted() ServerCmd {
+	const mask = "[redacted]"
+
+	if c.Token != "" {
+		c.Token = mask
+	}
+
+	callerTokens := make(map[string]string, len(c.CallerTokens))
+	for name := range c.CallerTokens {
+		callerTokens[name] = mask
+	}
+	c.CallerTokens = callerTokens
+
+	if c.Postgres.Password != "" {
+		c.Postgres.Password = mask
+	}
+
+	if c.Postgres.DSN != "" {
+		c.Postgres.DSN = mask
+	}
+
+	return c
+}
+
+// checkPoolSize rejects a limit of open connections that leaves no room for queries: listeners of outbox events,
+// live editing and the snippets cache hold a connection each, and handlers of their notifications query the database.
+func (c ServerCmd) checkPoolSize() error {
+	if c.Postgres.MaxOpenConns == 0 {
+		return nil
+	}
+
+	listeners := 2
+	if c.Cache.Enabled() {
+		listeners++
+	}
+
+	if minConns := listeners + minPoolHeadroom; c.Postgres.MaxOpenConns < minConns {
+		return fmt.Errorf(
+			"--postgres-max-open-conns must be 0 (unlimited) or at least %d: %d connections are held by listeners",
+			minConns,
+			listeners,
+		)
+	}
+
+	return nil
+}
+
 // runHTTPServer starts the HTTP server.
-func (c ServerCmd) runHTTPServer(ctx context.Context, logger *slog.Logger, db *sql.DB) error {
+func (c ServerCmd) runHTTPServer(
+	ctx context.Context,
+	logger *slog.Logger,
+	db *sql.DB,
+	snippetStorage snippets.Storage,
+	serviceOpts []snippets.ServiceOption,
+	idempotencyStore api.IdempotencyStore,
+	viewRecorder snippets.ViewRecorder,
+	eventStream snippets.EventStream,
+	webhookService *webhooks.WebhookService,
+	editingService editing.Service,
+) error {
+	// =========================================================================
+	// Init Snippets Module
+
+	snippetService := snippets.NewService(
+		snippetStorage,
+		logger.With(slog.String("service", "snippets")),
+		func() time.Time { return time.Now().UTC() },
+		serviceOpts...,
+	)
+	snippetTransport := snippets.NewTransport(
+		snippetService,
+		logger,
+		snippets.WithViewRecorder(viewRecorder),
+		snippets.WithEventStream(eventStream),
+	)
+
+	// =========================================================================
+	// Init Comments Module
+
+	commentService := comments.NewService(
+		comments.NewPGStorage(db),
+		snippetService,
+		logger.With(slog.String("service", "comments")),
+		func() time.Time { return time.Now().UTC() },
+	)
+	commentTransport := comments.NewTransport(commentService, logger)
+
+	// =========================================================================
+	// Init Collections Module
+
+	collectionService := collections.NewService(
+		collections.NewPGStorage(db),
+		logger.With(slog.String("service", "collections")),
+		func() time.Time { return time.Now().UTC() },
+	)
+	collectionTransport := collections.NewTransport(collectionService, logger)
+
+	// =========================================================================
+	// Init Webhooks Module
+
+	webhookTransport := webhooks.NewTransport(webhookService, logger)
+
+	// =========================================================================
+	// Init Live Editing Module
+
+	editingTransport := editing.NewTransport(editingService, logger)
+
+	// =========================================================================
+	// Init Idempotency Middleware
+
+	idempotencyOpts, err := c.Content.IdempotencyOptions()
+	if err != nil {
+		return err
+	}
+
+	// =========================================================================
+	// Mount API Routes
+
+	return c.serveHTTP(ctx, logger, func(r chi.Router) {
+		r.Use(api.AuthorizationHeader)
+		r.Use(api.Callers(c.callerTokens(), logger))
+		r.Use(api.Idempotency(idempotencyStore, logger, idempotencyOpts...))
+		r.Use(postgres.ReadYourWrites)
+		r.Mount("/snippets", snippetTransport.Routes())
+		r.Mount("/snippets/{snippet_id}/comments", commentTransport.Routes())
+		r.Mount("/snippets/{snippet_id}/live", editingTransport.Routes())
+		snippetTransport.RegisterBatchRoutes(r)
+		snippetTransport.RegisterUsageRoutes(r)
+		r.Mount("/collections", collectionTransport.Routes())
+		r.Mount("/webhooks", webhookTransport.Routes())
+	})
+}
+
+// runWithSQLite runs the server with snippets stored in SQLite, migrations of the SQLite dialect are applied first.
+func (c ServerCmd) runWithSQLite(
+	ctx context.Context,
+	gr *errgroup.Group,
+	logger *slog.Logger,
+	serviceOpts []snippets.ServiceOption,
+) error {
+	// =========================================================================
+	// Init SQLite Database
+	db, err := c.SQLite.OpenStdSQLDB()
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+
+	defer func() {
+		if closeErr := db.Close(); closeErr != nil {
+			logger.Error(
+				"unable to close database",
+				slog.Any("err", closeErr),
+			)
+		}
+	}()
+
+	// ================================================
+	// Apply SQL Migrations
+	migrationCmd := UpCmd{
+		DialectFlags: DialectFlags{Dialect: dialectSQLite, SQLite: c.SQLite},
+		Logger:       c.Logger,
+	}
+
+	if err = migrationCmd.Run(); err != nil {
+		return fmt.Errorf("run migrations: %w", err)
+	}
+
+	storageOpts, err := c.Content.SQLiteStorageOptions()
+	if err != nil {
+		return err
+	}
+
+	return c.runStandalone(ctx, gr, logger, serviceOpts, snippets.NewSQLiteStorage(
+		db,
+		func() time.Time { return time.Now().UTC() },
+		storageOpts...,
+	))
+}
+
+// standaloneStorage is a snippets storage used without PostgreSQL
+type standaloneStorage interface {
+	snippets.Storage
+	snippets.ViewStorage
+}
+
+// runStandalone runs the server without PostgreSQL. Only snippets endpoints are served, modules that need
+// PostgreSQL (idempotency, events, webhooks, comments, collections and live editing) are disabled.
+func (c ServerCmd) runStandalone(
+	ctx context.Context,
+	gr *errgroup.Group,
+	logger *slog.Logger,
+	serviceOpts []snippets.ServiceOption,
+	snippetStorage standaloneStorage,
+) error {
+	// =========================================================================
+	// Init Snippets View Counter
+	viewCounter := snippets.NewViewCounter(
+		snippetStorage,
+		logger.With(slog.String("module", "views-counter")),
+		func() time.Time { return time.Now().UTC() },
+	)
+
+	gr.Go(func() error {
+		return viewCounter.Run(ctx, c.ViewsFlushInterval)
+	})
+
+	// =========================================================================
+	// Init Snippets Module
+	snippetService := snippets.NewService(
+		snippetStorage,
+		logger.With(slog.String("service", "snippets")),
+		func() time.Time { return time.Now().UTC() },
+		serviceOpts...,
+	)
+	snippetTransport := snippets.NewTransport(
+		snippetService,
+		logger,
+		snippets.WithViewRecorder(viewCounter),
+	)
+
+	// =========================================================================
+	// Init Snippets Purge
+	// There are no events without PostgreSQL, expired snippets are only marked to be purged later
+	gr.Go(func() error {
+		return snippetService.RunExpiryNotifier(ctx, c.Events.ExpiryCheckInterval)
+	})
+
+	gr.Go(func() error {
+		return snippetService.RunExpiredPurge(ctx, expiredPurgeInterval, c.ExpiredRetention)
+	})
+
+	// =========================================================================
+	// Start Private API Server
+	gr.Go(func() error {
+		httpLogger := logger.With(slog.String("module", "http-server"))
+
+		return c.serveHTTP(ctx, httpLogger, func(r chi.Router) {
+			r.Use(api.AuthorizationHeader)
+			r.Use(api.Callers(c.callerTokens(), httpLogger))
+			r.Mount("/snippets", snippetTransport.Routes())
+			snippetTransport.RegisterBatchRoutes(r)
+			snippetTransport.RegisterUsageRoutes(r)
+		})
+	})
+
+	// =========================================================================
+	// Wait for stop signal
+	if err := gr.Wait(); err != nil {
+		return fmt.Errorf("error during wait: %w", err)
+	}
+
+	logger.Info("💀 ➡ web server gracefully stopped")
+	return nil
+}
+
+// serveHTTP starts the HTTP server with API routes mounted under /v1.
+func (c ServerCmd) serveHTTP(ctx context.Context, logger *slog.Logger, routes func(r chi.Router)) error {
 	// =========================================================================
 	// Init Chi Router
 
@@ -130,6 +661,15 @@ func (c ServerCmd) runHTTPServer(ctx context.Context, logger *slog.Logger, db *s
 			"Accept",
 			"Authorization",
 			"Content-Type",
+			"If-None-Match",
+			"If-Modified-Since",
+			snippets.PassphraseHeader,
+			snippets.LastEventIDHeader,
+			api.IdempotencyKeyHeader,
+		},
+		ExposedHeaders: []string{
+			"ETag",
+			api.IdempotentReplayedHeader,
 		},
 	})
 	r.Use(corsOpts.Handler)
@@ -140,25 +680,7 @@ func (c ServerCmd) runHTTPServer(ctx context.Context, logger *slog.Logger, db *s
 	r.NotFound(api.NewNotFoundHandler(logger))
 	r.MethodNotAllowed(api.NewMethodNotAllowedHandler(logger))
 
-	// =========================================================================
-	// Init Snippets Module
-
-	snippetStorage := snippets.NewPGStorage(db)
-	snippetService := snippets.NewService(
-		snippetStorage,
-		logger.With(slog.String("service", "snippets")),
-		func() time.Time { return time.Now().UTC() },
-	)
-	snippetTransport := snippets.NewTransport(snippetService, logger)
-
-	// =========================================================================
-	// Mount API Routes
-
-	r.Route("/v1", func(r chi.Router) {
-		r.Use(api.AuthorizationHeader)
-		r.Use(api.InternalCommunication(c.Token, logger))
-		r.Mount("/snippets", snippetTransport.Routes())
-	})
+	r.Route("/v1", routes)
 
 	// =========================================================================
 	// Start HTTP Server
@@ -191,3 +713,72 @@ func (c ServerCmd) runHTTPServer(ctx context.Context, logger *slog.Logger, db *s
 		return err
 	}
 }
+
+// callerTokens returns tokens of API callers keyed by caller names. The shared token belongs to api.DefaultCaller.
+// An empty shared token is kept only if there are no named callers, so the API stays open as before.
+func (c ServerCmd) callerTokens() map[string]string {
+	tokens := make(map[string]string, len(c.CallerTokens)+1)
+	for caller, token := range c.CallerTokens {
+		// An empty token would authenticate requests without a token
+		if token != "" {
+			tokens[caller] = token
+		}
+	}
+
+	if c.Token != "" || len(tokens) == 0 {
+		tokens[api.DefaultCaller] = c.Token
+	}
+
+	return tokens
+}
+
+// expiredPurgeInterval is how often expired snippets are purged.
+const expiredPurgeInterval = time.Hour
+
+// idempotencyPurgeInterval is how often expired idempotency keys are deleted.
+const idempotencyPurgeInterval = time.Hour
+
+// runIdempotencyPurge periodically deletes expired idempotency keys.
+func runIdempotencyPurge(ctx context.Context, logger *slog.Logger, store *postgres.IdempotencyStore) error {
+	ticker := time.NewTicker(idempotencyPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			deleted, err := store.DeleteExpired(ctx)
+			if err != nil {
+				logger.Error("failed to purge expired idempotency keys", slog.Any("err", err))
+				continue
+			}
+
+			logger.Debug("expired idempotency keys purged", slog.Int64("deleted", deleted))
+		}
+	}
+}
+
+// outboxPurgeInterval is how often published outbox events are deleted.
+const outboxPurgeInterval = time.Hour
+
+// runOutboxPurge periodically deletes outbox events published more than retention ago.
+func runOutboxPurge(ctx context.Context, logger *slog.Logger, outbox *postgres.Outbox, retention time.Duration) error {
+	ticker := time.NewTicker(outboxPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			deleted, err := outbox.DeletePublished(ctx, retention)
+			if err != nil {
+				logger.Error("failed to purge published outbox events", slog.Any("err", err))
+				continue
+			}
+
+			logger.Debug("published outbox events purged", slog.Int64("deleted", deleted))
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/commands/flags"
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/sqlite"
+)
+
+// SnippetsCmd implements kong.Command for snippets maintenance.
+//
+// CLI usage:
+//
+//	$ go run main.go snippets import ./snippets.ndjson --dry-run
+//	$ go run main.go snippets import ./snippets.ndjson --storage=sqlite --sqlite-path=./go-sample.db
+type SnippetsCmd struct {
+	Import ImportCmd `kong:"cmd,name=import,help='Import snippets from a NDJSON, JSON or CSV file produced by GET /v1/snippets/export.'"`
+}
+
+// ImportCmd represents a CLI sub-command to import snippets from a file
+type ImportCmd struct {
+	Postgres postgres.Flags `kong:"embed"`
+	SQLite   sqlite.Flags   `kong:"embed"`
+	Logger   flags.Logger   `kong:"embed"`
+	Content  ContentFlags   `kong:"embed"`
+
+	// Snippets kept in memory don't outlive the command, so they can't be imported
+	Storage string `kong:"optional,name=storage,default=postgres,enum='postgres,sqlite',group='Storage',env=STORAGE,help='Snippets storage to import to (${enum}). Migrations are applied to a SQLite database first.'"`
+
+	File   string `kong:"arg,required,type=existingfile,help='File to import.'"`
+	Format string `kong:"optional,name=format,enum='auto,ndjson,json,csv',default=auto,help='File format (${enum}). By default it is detected by the file extension.'"`
+	DryRun bool   `kong:"optional,name=dry-run,help='Only validate the file, do not import snippets.'"`
+}
+
+// Run (ImportCmd) imports snippets from a file.
+// Every record is validated with CreateSnippetRequest.Validate, invalid records are reported and skipped.
+func (c ImportCmd) Run() error {
+	logger := c.Logger.Init()
+
+	format, err := c.detectFormat()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(c.File)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	create := func(context.Context, snippets.Snippet) error { return nil }
+
+	if !c.DryRun {
+		snippetService, closeFunc, err := c.initService(logger)
+		if err != nil {
+			return err
+		}
+		defer closeFunc()
+
+		create = func(ctx context.Context, snippet snippets.Snippet) error {
+			if _, svcErr := snippetService.Create(ctx, snippet); svcErr != nil {
+				return svcErr
+			}
+			return nil
+		}
+	}
+
+	report := func(record int, err error) {
+		logger.Error("❌ ➡ snippet is not imported", slog.Int("record", record), slog.Any("err", err))
+	}
+
+	imported, failed, err := snippets.ImportSnippets(context.Background(), file, format, create, report)
+	if err != nil {
+		return fmt.Errorf("import snippets: %w", err)
+	}
+
+	logger.Info(
+		"📥 ➡ snippets import finished",
+		slog.Int("imported", imported),
+		slog.Int("failed", failed),
+		slog.Bool("dry_run", c.DryRun),
+	)
+
+	if failed > 0 {
+		return fmt.Errorf("%d snippet(s) failed to import", failed)
+	}
+
+	return nil
+}
+
+// initService opens a database connection and creates snippets.SnippetService on top of it
+func (c ImportCmd) initService(logger *slog.Logger) (*snippets.SnippetService, func(), error) {
+	serviceOpts, err := c.Content.ServiceOptions()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	openStorage := c.openPGStorage
+	if c.Storage == storageSQLite {
+		openStorage = c.openSQLiteStorage
+	}
+
+	storage, closeFunc, err := openStorage(logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	snippetService := snippets.NewService(
+		storage,
+		logger.With(slog.String("service", "snippets")),
+		func() time.Time { return time.Now().UTC() },
+		serviceOpts...,
+	)
+
+	return snippetService, closeFunc, nil
+}
+
+// openPGStorage opens a PostgreSQL connection and creates snippets.PGStorage on top of it
+func (c ImportCmd) openPGStorage(logger *slog.Logger) (snippets.Storage, func(), error) {
+	storageOpts, err := c.Content.StorageOptions()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := c.Postgres.OpenStdSQLDB()
+	if err != nil {
+		return nil, nil, fmt.Errorf("init DB: %w", err)
+	}
+
+	closeFunc := func() {
+		if closeErr := db.Close(); closeErr != nil {
+			logger.Error("close pg connection", slog.Any("err", closeErr))
+		}
+	}
+
+	return snippets.NewPGStorage(db, storageOpts...), closeFunc, nil
+}
+
+// openSQLiteStorage opens a SQLite database, applies its migrations and creates snippets.SQLiteStorage on top of it
+func (c ImportCmd) openSQLiteStorage(logger *slog.Logger) (snippets.Storage, func(), error) {
+	storageOpts, err := c.Content.SQLiteStorageOptions()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	migrationCmd := UpCmd{
+		DialectFlags: DialectFlags{Dialect: dialectSQLite, SQLite: c.SQLite},
+		Logger:       c.Logger,
+	}
+
+	if err = migrationCmd.Run(); err != nil {
+		return nil, nil, fmt.Errorf("run migrations: %w", err)
+	}
+
+	db, err := c.SQLite.OpenStdSQLDB()
+	if err != nil {
+		return nil, nil, fmt.Errorf("init DB: %w", err)
+	}
+
+	closeFunc := func() {
+		if closeErr := db.Close(); closeErr != nil {
+			logger.Error("close sqlite database", slog.Any("err", closeErr))
+		}
+	}
+
+	storage := snippets.NewSQLiteStorage(
+		db,
+		func() time.Time { return time.Now().UTC() },
+		storageOpts...,
+	)
+
+	return storage, closeFunc, nil
+}
+
+// detectFormat returns the file format set by the flag or detected by the file extension
+func (c ImportCmd) detectFormat() (snippets.ExportFormat, error) {
+	if c.Format != "auto" {
+		return snippets.ExportFormat(c.Format), nil
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(c.File)); ext {
+	case ".ndjson", ".jsonl":
+		return snippets.ExportFormatNDJSON, nil
+	case ".json":
+		return snippets.ExportFormatJSON, nil
+	case ".csv":
+		return snippets.ExportFormatCSV, nil
+	default:
+		return "", fmt.Errorf("unable to detect format of %q, use --format flag", c.File)
+	}
+}
@@ -0,0 +1,64 @@
+package commands_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/commands"
+	"github.com/titusjaka/go-sample/v2/commands/flags"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/sqlite"
+)
+
+func TestImportCmd_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Import to SQLite", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		expiresAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+		file := filepath.Join(dir, "snippets.ndjson")
+		require.NoError(t, os.WriteFile(file, []byte(fmt.Sprintf(
+			"{\"title\":\"First\",\"content\":\"Hello\",\"expires_at\":%[1]q}\n"+
+				"{\"title\":\"Second\",\"content\":\"World\",\"expires_at\":%[1]q}\n",
+			expiresAt,
+		)), 0o600))
+
+		sqliteFlags := sqlite.Flags{Path: filepath.Join(dir, "go-sample.db")}
+
+		cmd := commands.ImportCmd{
+			SQLite:  sqliteFlags,
+			Logger:  flags.Logger{Level: "off"},
+			Content: commands.ContentFlags{ContentCompression: "none"},
+			Storage: "sqlite",
+			File:    file,
+			Format:  "auto",
+		}
+		require.NoError(t, cmd.Run())
+
+		db, err := sqliteFlags.OpenStdSQLDB()
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+
+		var titles []string
+		rows, err := db.Query(`SELECT title FROM snippets ORDER BY id`)
+		require.NoError(t, err)
+		defer func() { _ = rows.Close() }()
+
+		for rows.Next() {
+			var title string
+			require.NoError(t, rows.Scan(&title))
+			titles = append(titles, title)
+		}
+		require.NoError(t, rows.Err())
+
+		assert.Equal(t, []string{"First", "Second"}, titles)
+	})
+}
@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/webhooks"
+)
+
+// WebhookFlags configures delivery of webhooks.
+type WebhookFlags struct {
+	WebhookPollInterval time.Duration `kong:"optional,name=webhook-poll-interval,default=5s,group='Webhooks',env=WEBHOOK_POLL_INTERVAL,help='How often queued webhooks are checked.'"`
+	WebhookMaxAttempts  uint          `kong:"optional,name=webhook-max-attempts,default=10,group='Webhooks',env=WEBHOOK_MAX_ATTEMPTS,help='Number of attempts after which a webhook delivery is given up.'"`
+	WebhookTimeout      time.Duration `kong:"optional,name=webhook-timeout,default=10s,group='Webhooks',env=WEBHOOK_TIMEOUT,help='Timeout of a single webhook request, shorter than a minute.'"`
+}
+
+// Validate rejects a delivery timeout that outlasts the delivery lease: another dispatcher would send
+// the same delivery again while the first attempt is still running.
+func (c WebhookFlags) Validate() error {
+	if c.WebhookTimeout <= 0 || c.WebhookTimeout >= webhooks.DeliveryLease {
+		return fmt.Errorf(
+			"--webhook-timeout must be positive and shorter than %s: %s",
+			webhooks.DeliveryLease,
+			c.WebhookTimeout,
+		)
+	}
+
+	return nil
+}
+
+// DispatcherOptions returns webhooks.DispatcherOption list to configure webhooks delivery.
+func (c WebhookFlags) DispatcherOptions() []webhooks.DispatcherOption {
+	return []webhooks.DispatcherOption{
+		webhooks.WithHTTPClient(webhooks.NewHTTPClient(c.WebhookTimeout)),
+		webhooks.WithMaxAttempts(c.WebhookMaxAttempts),
+	}
+}
@@ -0,0 +1,39 @@
+package commands_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/titusjaka/go-sample/v2/commands"
+)
+
+func TestWebhookFlags_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		wantErr bool
+	}{
+		{name: "Default timeout", timeout: 10 * time.Second},
+		{name: "Almost a minute", timeout: time.Minute - time.Second},
+		{name: "As long as the lease", timeout: time.Minute, wantErr: true},
+		{name: "Longer than the lease", timeout: 2 * time.Minute, wantErr: true},
+		{name: "No timeout", timeout: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := commands.WebhookFlags{WebhookTimeout: tt.timeout}.Validate()
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "--webhook-timeout")
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package collections
+
+import (
+	"time"
+)
+
+// Collection model struct. It's a named ordered list of snippets.
+type Collection struct {
+	ID         uint
+	Name       string
+	SnippetIDs []uint
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
@@ -0,0 +1,51 @@
+package collections
+
+import (
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// maxCollectionSize is the maximum number of snippets in a single collection
+const maxCollectionSize = 1000
+
+// ListCollectionsRequest represents a request struct for GET /collections?limit=<x>&offset=<y> method
+type ListCollectionsRequest struct {
+	Limit  uint `schema:"limit"`
+	Offset uint `schema:"offset"`
+}
+
+// CollectionRequest represents a request struct for POST /collections and PUT /collections/{collection_id} methods
+type CollectionRequest struct {
+	Name       string `json:"name"`
+	SnippetIDs []uint `json:"snippet_ids"`
+}
+
+// Validate implements ozzo-validation.Validatable interface and used to check user request
+func (r *CollectionRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(
+			&r.SnippetIDs,
+			validation.Length(0, maxCollectionSize),
+			validation.Each(validation.Required),
+			validation.By(unique),
+		),
+	)
+}
+
+// unique checks that a list of IDs has no duplicates
+func unique(value any) error {
+	ids, _ := value.([]uint)
+
+	seen := make(map[uint]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			return errors.New("must not contain duplicates")
+		}
+		seen[id] = struct{}{}
+	}
+
+	return nil
+}
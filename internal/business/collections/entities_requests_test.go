@@ -0,0 +1,75 @@
+package collections_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/collections"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/utils/testutils"
+)
+
+func TestCollectionRequest_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		request collections.CollectionRequest
+		wantErr string
+	}{
+		{
+			name: "Valid CollectionRequest",
+			request: collections.CollectionRequest{
+				Name:       "Favourites",
+				SnippetIDs: []uint{3, 1, 2},
+			},
+			wantErr: "",
+		},
+		{
+			name: "Valid: empty collection",
+			request: collections.CollectionRequest{
+				Name: "Favourites",
+			},
+			wantErr: "",
+		},
+		{
+			name: "Invalid: empty name",
+			request: collections.CollectionRequest{
+				SnippetIDs: []uint{1},
+			},
+			wantErr: "name: cannot be blank.",
+		},
+		{
+			name: "Invalid: name is too long",
+			request: collections.CollectionRequest{
+				Name: strings.Repeat("a", 101),
+			},
+			wantErr: "name: the length must be between 1 and 100.",
+		},
+		{
+			name: "Invalid: zero snippet id",
+			request: collections.CollectionRequest{
+				Name:       "Favourites",
+				SnippetIDs: []uint{1, 0},
+			},
+			wantErr: "snippet_ids: (1: cannot be blank.).",
+		},
+		{
+			name: "Invalid: duplicate snippet ids",
+			request: collections.CollectionRequest{
+				Name:       "Favourites",
+				SnippetIDs: []uint{1, 2, 1},
+			},
+			wantErr: "snippet_ids: must not contain duplicates.",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.request.Validate()
+			testutils.AssertError(t, tt.wantErr, err)
+		})
+	}
+}
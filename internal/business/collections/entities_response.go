@@ -0,0 +1,47 @@
+package collections
+
+import (
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+// CollectionResponse represents a common collection-response struct
+type CollectionResponse struct {
+	ID         uint      `json:"id"`
+	Name       string    `json:"name"`
+	SnippetIDs []uint    `json:"snippet_ids"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// ListCollectionsResponse represents a response struct for GET /collections?limit=<x>&offset=<y> method
+type ListCollectionsResponse struct {
+	Collections []CollectionResponse `json:"collections,omitempty"`
+	Pagination  service.Pagination   `json:"pagination"`
+}
+
+// convertToListCollectionsResponse is used to map []Collection -> []CollectionResponse
+func convertToListCollectionsResponse(collections []Collection) []CollectionResponse {
+	response := make([]CollectionResponse, len(collections))
+	for i := range collections {
+		response[i] = convertToCollectionResponse(collections[i])
+	}
+	return response
+}
+
+// convertToCollectionResponse is used to map Collection -> CollectionResponse
+func convertToCollectionResponse(collection Collection) CollectionResponse {
+	snippetIDs := collection.SnippetIDs
+	if snippetIDs == nil {
+		snippetIDs = []uint{}
+	}
+
+	return CollectionResponse{
+		ID:         collection.ID,
+		Name:       collection.Name,
+		SnippetIDs: snippetIDs,
+		CreatedAt:  collection.CreatedAt,
+		UpdatedAt:  collection.UpdatedAt,
+	}
+}
@@ -0,0 +1,160 @@
+package collections
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+//go:generate go run go.uber.org/mock/mockgen -typed -source=service.go -destination ./service_mock_test.go -package collections_test -mock_names Storage=MockStorage
+
+// Storage is used to manipulate data in DB
+type Storage interface {
+	Get(ctx context.Context, id uint) (Collection, error)
+	List(ctx context.Context, pagination service.Pagination) ([]Collection, error)
+	Total(ctx context.Context) (uint, error)
+	Create(ctx context.Context, collection Collection) (uint, error)
+	Update(ctx context.Context, collection Collection) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// CollectionService represents service struct. It holds storage and logger.
+type CollectionService struct {
+	storage Storage
+	logger  *slog.Logger
+
+	now func() time.Time
+}
+
+// NewService returns new instance of CollectionService
+func NewService(storage Storage, logger *slog.Logger, nowFunc func() time.Time) *CollectionService {
+	return &CollectionService{
+		storage: storage,
+		logger:  logger,
+
+		now: nowFunc,
+	}
+}
+
+// Get returns a single collection
+func (s *CollectionService) Get(ctx context.Context, id uint) (Collection, *service.Error) {
+	collection, err := s.storage.Get(ctx, id)
+	switch {
+	case err == nil:
+		return collection, nil
+	case errors.Is(err, ErrNotFound):
+		return Collection{}, &service.Error{
+			Type: service.NotFound,
+			Base: ErrNotFound,
+		}
+	default:
+		s.logger.Error("failed to get a collection", slog.Any("err", err))
+		return Collection{}, &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to get collection: %w", err),
+		}
+	}
+}
+
+// List returns a list of collections and a pagination struct
+func (s *CollectionService) List(ctx context.Context, limit uint, offset uint) ([]Collection, service.Pagination, *service.Error) {
+	collectionsCount, err := s.storage.Total(ctx)
+	if err != nil {
+		s.logger.Error("failed to query total amount of collections", slog.Any("err", err))
+		return nil, service.Pagination{}, &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to query total amount of collections: %w", err),
+		}
+	}
+
+	pagination := snippets.NewPagination(limit, offset, collectionsCount)
+
+	collections, err := s.storage.List(ctx, pagination)
+	if err != nil {
+		s.logger.Error("failed to list collections", slog.Any("err", err))
+		return nil, pagination, &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to list collections: %w", err),
+		}
+	}
+
+	return collections, pagination, nil
+}
+
+// Create creates a single collection
+func (s *CollectionService) Create(ctx context.Context, collection Collection) (Collection, *service.Error) {
+	now := s.now()
+	collection.CreatedAt = now
+	collection.UpdatedAt = now
+
+	id, err := s.storage.Create(ctx, collection)
+	if err != nil {
+		return Collection{}, s.wrapWriteErr("create", err)
+	}
+
+	collection.ID = id
+	return collection, nil
+}
+
+// Update replaces the name and the snippets of a collection
+func (s *CollectionService) Update(ctx context.Context, collection Collection) (Collection, *service.Error) {
+	current, svcErr := s.Get(ctx, collection.ID)
+	if svcErr != nil {
+		return Collection{}, svcErr
+	}
+
+	collection.CreatedAt = current.CreatedAt
+	collection.UpdatedAt = s.now()
+
+	if err := s.storage.Update(ctx, collection); err != nil {
+		return Collection{}, s.wrapWriteErr("update", err)
+	}
+
+	return collection, nil
+}
+
+// Delete removes a single collection. Snippets of the collection are not deleted.
+func (s *CollectionService) Delete(ctx context.Context, id uint) *service.Error {
+	switch err := s.storage.Delete(ctx, id); {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrNotFound):
+		return &service.Error{
+			Type: service.NotFound,
+			Base: ErrNotFound,
+		}
+	default:
+		s.logger.Error("failed to delete collection", slog.Any("err", err))
+		return &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to delete collection: %w", err),
+		}
+	}
+}
+
+// wrapWriteErr converts storage errors of create and update operations to service errors
+func (s *CollectionService) wrapWriteErr(operation string, err error) *service.Error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return &service.Error{
+			Type: service.NotFound,
+			Base: ErrNotFound,
+		}
+	case errors.Is(err, ErrSnippetNotFound):
+		return &service.Error{
+			Type: service.BadRequest,
+			Base: ErrSnippetNotFound,
+		}
+	default:
+		s.logger.Error("failed to save collection", slog.String("operation", operation), slog.Any("err", err))
+		return &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to %s collection: %w", operation, err),
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package collections_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/collections"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+func TestCollectionService_Get(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Successfully get a collection", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		collectionService := collections.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return time.Now().UTC() },
+		)
+
+		// ===============================================
+		// Init test data
+		expected := collections.Collection{
+			ID:         1,
+			Name:       "Favourites",
+			SnippetIDs: []uint{3, 1},
+		}
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Get(ctx, uint(1)).Return(expected, nil)
+
+		// ===============================================
+		// Run Test
+		collection, svcErr := collectionService.Get(ctx, 1)
+		require.Nil(t, svcErr)
+		assert.Equal(t, expected, collection)
+	})
+
+	t.Run("Collection not found", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		collectionService := collections.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return time.Now().UTC() },
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Get(ctx, uint(1)).Return(collections.Collection{}, collections.ErrNotFound)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := collectionService.Get(ctx, 1)
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.NotFound, svcErr.Type)
+	})
+}
+
+func TestCollectionService_List(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	// ===============================================
+	// Init Mocks and Service
+	mockStorage := NewMockStorage(ctrl)
+
+	collectionService := collections.NewService(
+		mockStorage,
+		nopslog.NewNoplogger(),
+		func() time.Time { return time.Now().UTC() },
+	)
+
+	// ===============================================
+	// Init test data
+	expected := []collections.Collection{
+		{ID: 2, Name: "Second"},
+		{ID: 1, Name: "First", SnippetIDs: []uint{1}},
+	}
+
+	expectedPagination := service.Pagination{
+		Limit:       10,
+		Offset:      0,
+		Total:       2,
+		TotalPages:  1,
+		CurrentPage: 1,
+	}
+
+	// ===============================================
+	// Describe Mock Calls
+	mockStorage.EXPECT().Total(ctx).Return(uint(2), nil)
+	mockStorage.EXPECT().List(ctx, expectedPagination).Return(expected, nil)
+
+	// ===============================================
+	// Run Test
+	list, pagination, svcErr := collectionService.List(ctx, 10, 0)
+	require.Nil(t, svcErr)
+	assert.Equal(t, expected, list)
+	assert.Equal(t, expectedPagination, pagination)
+}
+
+func TestCollectionService_Create(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Successfully create a collection", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		collectionService := collections.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+		)
+
+		// ===============================================
+		// Init test data
+		expected := collections.Collection{
+			Name:       "Favourites",
+			SnippetIDs: []uint{3, 1},
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Create(ctx, expected).Return(uint(1), nil)
+
+		// ===============================================
+		// Run Test
+		collection, svcErr := collectionService.Create(ctx, collections.Collection{
+			Name:       "Favourites",
+			SnippetIDs: []uint{3, 1},
+		})
+		require.Nil(t, svcErr)
+
+		expected.ID = 1
+		assert.Equal(t, expected, collection)
+	})
+
+	t.Run("Snippet not found", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		collectionService := collections.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Create(ctx, gomock.Any()).Return(
+			uint(0),
+			fmt.Errorf("failed to add collection: %w", collections.ErrSnippetNotFound),
+		)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := collectionService.Create(ctx, collections.Collection{
+			Name:       "Favourites",
+			SnippetIDs: []uint{42},
+		})
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.BadRequest, svcErr.Type)
+		assert.ErrorIs(t, svcErr, collections.ErrSnippetNotFound)
+	})
+
+	t.Run("Storage error", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		collectionService := collections.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+		)
+
+		// ===============================================
+		// Init test data
+		expectedErr := errors.New("something wrong happen 😱")
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Create(ctx, gomock.Any()).Return(uint(0), expectedErr)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := collectionService.Create(ctx, collections.Collection{Name: "Favourites"})
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.InternalError, svcErr.Type)
+		assert.ErrorIs(t, svcErr, expectedErr)
+	})
+}
+
+func TestCollectionService_Update(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Successfully update a collection", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		collectionService := collections.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+		)
+
+		// ===============================================
+		// Init test data
+		expected := collections.Collection{
+			ID:         1,
+			Name:       "Renamed",
+			SnippetIDs: []uint{2},
+			CreatedAt:  createdAt,
+			UpdatedAt:  now,
+		}
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Get(ctx, uint(1)).Return(collections.Collection{
+			ID:         1,
+			Name:       "Favourites",
+			SnippetIDs: []uint{1},
+			CreatedAt:  createdAt,
+			UpdatedAt:  createdAt,
+		}, nil)
+		mockStorage.EXPECT().Update(ctx, expected).Return(nil)
+
+		// ===============================================
+		// Run Test
+		collection, svcErr := collectionService.Update(ctx, collections.Collection{
+			ID:         1,
+			Name:       "Renamed",
+			SnippetIDs: []uint{2},
+		})
+		require.Nil(t, svcErr)
+		assert.Equal(t, expected, collection)
+	})
+
+	t.Run("Collection not found", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		collectionService := collections.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Get(ctx, uint(1)).Return(collections.Collection{}, collections.ErrNotFound)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := collectionService.Update(ctx, collections.Collection{ID: 1, Name: "Renamed"})
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.NotFound, svcErr.Type)
+	})
+}
+
+func TestCollectionService_Delete(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		storageErr error
+		wantType   service.ErrorType
+	}{
+		{
+			name:       "Successfully delete a collection",
+			storageErr: nil,
+		},
+		{
+			name:       "Collection not found",
+			storageErr: collections.ErrNotFound,
+			wantType:   service.NotFound,
+		},
+		{
+			name:       "Storage error",
+			storageErr: errors.New("something wrong happen 😱"),
+			wantType:   service.InternalError,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+
+			// ===============================================
+			// Init Mocks and Service
+			mockStorage := NewMockStorage(ctrl)
+
+			collectionService := collections.NewService(
+				mockStorage,
+				nopslog.NewNoplogger(),
+				func() time.Time { return time.Now().UTC() },
+			)
+
+			// ===============================================
+			// Describe Mock Calls
+			mockStorage.EXPECT().Delete(ctx, uint(1)).Return(tt.storageErr)
+
+			// ===============================================
+			// Run Test
+			svcErr := collectionService.Delete(ctx, 1)
+			if tt.storageErr == nil {
+				assert.Nil(t, svcErr)
+				return
+			}
+
+			require.NotNil(t, svcErr)
+			assert.Equal(t, tt.wantType, svcErr.Type)
+		})
+	}
+}
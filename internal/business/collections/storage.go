@@ -0,0 +1,347 @@
+package collections
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+var (
+	// ErrNotFound error used to signal higher level about sql.ErrNoRows error
+	ErrNotFound = errors.New("not found")
+	// ErrSnippetNotFound error is returned when a collection refers to a snippet that doesn't exist or is deleted
+	ErrSnippetNotFound = errors.New("snippet not found")
+)
+
+// PGStorage implements storage interface and provides methods to manipulate data in PostgreSQL storage
+type PGStorage struct {
+	conn *sql.DB
+}
+
+// NewPGStorage returns a new instance of PGStorage
+func NewPGStorage(conn *sql.DB) *PGStorage {
+	return &PGStorage{
+		conn: conn,
+	}
+}
+
+// Get returns a single collection from storage
+func (pg *PGStorage) Get(ctx context.Context, id uint) (Collection, error) {
+	query := `
+		SELECT
+			id,
+			name,
+			created_at,
+			updated_at
+		FROM collections
+		WHERE id = $1
+	`
+
+	var collection Collection
+	switch err := postgres.Conn(ctx, pg.conn).QueryRowContext(ctx, query, id).Scan(
+		&collection.ID,
+		&collection.Name,
+		&collection.CreatedAt,
+		&collection.UpdatedAt,
+	); {
+	case err == nil:
+		break
+	case errors.Is(err, sql.ErrNoRows):
+		return Collection{}, ErrNotFound
+	default:
+		return Collection{}, fmt.Errorf("failed to scan collection: %w", err)
+	}
+
+	snippetIDs, err := pg.snippetIDs(ctx, []uint{id})
+	if err != nil {
+		return Collection{}, err
+	}
+
+	collection.SnippetIDs = snippetIDs[id]
+	return collection, nil
+}
+
+// List returns a list of collections from storage
+func (pg *PGStorage) List(ctx context.Context, pagination service.Pagination) ([]Collection, error) {
+	query := `
+		SELECT
+			id,
+			name,
+			created_at,
+			updated_at
+		FROM collections
+		ORDER BY created_at DESC, id DESC
+		%s
+	`
+
+	rows, err := postgres.Conn(ctx, pg.conn).QueryContext(
+		ctx,
+		fmt.Sprintf(query, snippets.ConvertPaginationToSQLExpression(pagination)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var (
+		results []Collection
+		ids     []uint
+	)
+	for rows.Next() {
+		var collection Collection
+		if err = rows.Scan(
+			&collection.ID,
+			&collection.Name,
+			&collection.CreatedAt,
+			&collection.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan collection row: %w", err)
+		}
+
+		results = append(results, collection)
+		ids = append(ids, collection.ID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error from iterating collections rows: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return results, nil
+	}
+
+	snippetIDs, err := pg.snippetIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		results[i].SnippetIDs = snippetIDs[results[i].ID]
+	}
+
+	return results, nil
+}
+
+// Total returns a total amount of collections
+func (pg *PGStorage) Total(ctx context.Context) (uint, error) {
+	var total uint
+	if err := postgres.Conn(ctx, pg.conn).QueryRowContext(ctx, `SELECT COUNT(*) FROM collections`).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count collections: %w", err)
+	}
+
+	return total, nil
+}
+
+// Create saves a single collection to storage
+func (pg *PGStorage) Create(ctx context.Context, collection Collection) (uint, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("failed to add collection: %w", err)
+	}
+
+	tx, err := postgres.BeginTx(ctx, pg.conn)
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	query := `
+		INSERT INTO collections
+		(
+			name,
+			created_at,
+			updated_at
+		)
+		VALUES
+		(
+			$1,
+			$2,
+			$3
+		)
+		RETURNING id
+	`
+
+	var id uint
+	if err = tx.QueryRowContext(
+		ctx,
+		query,
+		collection.Name,
+		collection.CreatedAt,
+		collection.UpdatedAt,
+	).Scan(&id); err != nil {
+		return 0, wrapErr(err)
+	}
+
+	if err = setSnippets(ctx, tx, id, collection.SnippetIDs); err != nil {
+		return 0, wrapErr(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, wrapErr(err)
+	}
+
+	return id, nil
+}
+
+// Update replaces the name and the snippets of a collection
+func (pg *PGStorage) Update(ctx context.Context, collection Collection) error {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("failed to update collection: %w", err)
+	}
+
+	tx, err := postgres.BeginTx(ctx, pg.conn)
+	if err != nil {
+		return wrapErr(err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	query := `
+		UPDATE collections
+		SET
+			name = $2,
+			updated_at = $3
+		WHERE id = $1
+	`
+
+	result, err := tx.ExecContext(ctx, query, collection.ID, collection.Name, collection.UpdatedAt)
+	if err != nil {
+		return wrapErr(err)
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return wrapErr(err)
+	} else if affected == 0 {
+		return ErrNotFound
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM collection_snippets WHERE collection_id = $1`, collection.ID); err != nil {
+		return wrapErr(err)
+	}
+
+	if err = setSnippets(ctx, tx, collection.ID, collection.SnippetIDs); err != nil {
+		return wrapErr(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return wrapErr(err)
+	}
+
+	return nil
+}
+
+// Delete removes a collection. Snippets are not affected.
+func (pg *PGStorage) Delete(ctx context.Context, id uint) error {
+	result, err := postgres.Conn(ctx, pg.conn).ExecContext(ctx, `DELETE FROM collections WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete collection: %w", err)
+	}
+
+	switch affected, err := result.RowsAffected(); {
+	case err != nil:
+		return fmt.Errorf("failed to delete collection: %w", err)
+	case affected == 0:
+		return ErrNotFound
+	default:
+		return nil
+	}
+}
+
+// snippetIDs returns active snippet IDs of the collections in their order
+func (pg *PGStorage) snippetIDs(ctx context.Context, collectionIDs []uint) (map[uint][]uint, error) {
+	query := `
+		SELECT
+			cs.collection_id,
+			cs.snippet_id
+		FROM collection_snippets cs
+			JOIN snippets s ON s.id = cs.snippet_id
+		WHERE
+			cs.collection_id = ANY($1)
+			AND s.expires_at > NOW()
+		ORDER BY cs.collection_id, cs.position
+	`
+
+	rows, err := postgres.Conn(ctx, pg.conn).QueryContext(ctx, query, toInt64s(collectionIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query collection snippets: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	results := make(map[uint][]uint, len(collectionIDs))
+	for rows.Next() {
+		var collectionID, snippetID uint
+		if err = rows.Scan(&collectionID, &snippetID); err != nil {
+			return nil, fmt.Errorf("failed to scan collection snippet row: %w", err)
+		}
+
+		results[collectionID] = append(results[collectionID], snippetID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error from iterating collection snippets rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// setSnippets adds snippets to a collection keeping their order. All snippets must be active.
+func setSnippets(ctx context.Context, tx postgres.Querier, collectionID uint, snippetIDs []uint) error {
+	if len(snippetIDs) == 0 {
+		return nil
+	}
+
+	ids := toInt64s(snippetIDs)
+
+	var active int
+	if err := tx.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*) FROM snippets WHERE id = ANY($1) AND expires_at > NOW()`,
+		ids,
+	).Scan(&active); err != nil {
+		return fmt.Errorf("failed to check snippets: %w", err)
+	}
+
+	if active != len(snippetIDs) {
+		return ErrSnippetNotFound
+	}
+
+	query := `
+		INSERT INTO collection_snippets
+		(
+			collection_id,
+			snippet_id,
+			position
+		)
+		SELECT $1, t.id, t.position
+		FROM UNNEST($2::integer[]) WITH ORDINALITY AS t(id, position)
+	`
+
+	if _, err := tx.ExecContext(ctx, query, collectionID, ids); err != nil {
+		return fmt.Errorf("failed to add collection snippets: %w", err)
+	}
+
+	return nil
+}
+
+func toInt64s(ids []uint) []int64 {
+	result := make([]int64, len(ids))
+	for i, id := range ids {
+		result[i] = int64(id)
+	}
+	return result
+}
@@ -0,0 +1,122 @@
+package collections_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/collections"
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres/pgtest"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+const envFile = "../../../.env"
+
+func TestPGStorage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip integration test due to 'short' flag")
+	}
+	t.Parallel()
+
+	pgConn := pgtest.InitTestDatabase(
+		t,
+		pgtest.WithConfigFiles(envFile),
+	)
+
+	ctx := context.Background()
+	snippetStorage := snippets.NewPGStorage(pgConn)
+	pgStorage := collections.NewPGStorage(pgConn)
+
+	fakeTimeCreated := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+	fakeTimeUpdated := time.Date(2020, 10, 8, 12, 0, 0, 0, time.UTC)
+	fakeTimeExpires := time.Date(2050, 1, 1, 1, 1, 1, 0, time.UTC)
+
+	for i := 1; i <= 3; i++ {
+		_, err := snippetStorage.Create(ctx, snippets.Snippet{
+			Title:     fmt.Sprintf("Snippet title #%d", i),
+			Content:   "Snippet content",
+			CreatedAt: fakeTimeCreated,
+			UpdatedAt: fakeTimeCreated,
+			ExpiresAt: fakeTimeExpires,
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("Create a collection", func(t *testing.T) {
+		id, err := pgStorage.Create(ctx, collections.Collection{
+			Name:       "Favourites",
+			SnippetIDs: []uint{3, 1, 2},
+			CreatedAt:  fakeTimeCreated,
+			UpdatedAt:  fakeTimeCreated,
+		})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, id)
+
+		collection, err := pgStorage.Get(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, collections.Collection{
+			ID:         1,
+			Name:       "Favourites",
+			SnippetIDs: []uint{3, 1, 2},
+			CreatedAt:  fakeTimeCreated,
+			UpdatedAt:  fakeTimeCreated,
+		}, collection)
+	})
+
+	t.Run("Unknown snippets are rejected", func(t *testing.T) {
+		_, err := pgStorage.Create(ctx, collections.Collection{
+			Name:       "Broken",
+			SnippetIDs: []uint{1, 42},
+			CreatedAt:  fakeTimeCreated,
+			UpdatedAt:  fakeTimeCreated,
+		})
+		require.ErrorIs(t, err, collections.ErrSnippetNotFound)
+
+		total, err := pgStorage.Total(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, total)
+	})
+
+	t.Run("Update a collection", func(t *testing.T) {
+		err := pgStorage.Update(ctx, collections.Collection{
+			ID:         1,
+			Name:       "Renamed",
+			SnippetIDs: []uint{2, 3},
+			UpdatedAt:  fakeTimeUpdated,
+		})
+		require.NoError(t, err)
+
+		collection, err := pgStorage.Get(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Renamed", collection.Name)
+		assert.Equal(t, []uint{2, 3}, collection.SnippetIDs)
+		assert.Equal(t, fakeTimeUpdated, collection.UpdatedAt)
+	})
+
+	t.Run("Deleted snippets are hidden", func(t *testing.T) {
+		require.NoError(t, snippetStorage.SoftDelete(ctx, 2))
+
+		list, err := pgStorage.List(ctx, service.Pagination{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		assert.Equal(t, []uint{3}, list[0].SnippetIDs)
+	})
+
+	t.Run("Delete a collection", func(t *testing.T) {
+		require.NoError(t, pgStorage.Delete(ctx, 1))
+
+		_, err := pgStorage.Get(ctx, 1)
+		require.ErrorIs(t, err, collections.ErrNotFound)
+
+		err = pgStorage.Delete(ctx, 1)
+		require.ErrorIs(t, err, collections.ErrNotFound)
+
+		err = pgStorage.Update(ctx, collections.Collection{ID: 1, Name: "Renamed"})
+		require.ErrorIs(t, err, collections.ErrNotFound)
+	})
+}
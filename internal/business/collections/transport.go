@@ -0,0 +1,198 @@
+package collections
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/gorilla/schema"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/api"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+//go:generate go run go.uber.org/mock/mockgen -typed -source=transport.go -destination ./transport_mock_test.go -package collections_test -mock_names Service=MockService
+
+// Service is used to manipulate data over collections
+type Service interface {
+	Get(ctx context.Context, id uint) (Collection, *service.Error)
+	List(ctx context.Context, limit uint, offset uint) ([]Collection, service.Pagination, *service.Error)
+	Create(ctx context.Context, collection Collection) (Collection, *service.Error)
+	Update(ctx context.Context, collection Collection) (Collection, *service.Error)
+	Delete(ctx context.Context, id uint) *service.Error
+}
+
+// Transport is a struct that holds all endpoints for collections
+type Transport struct {
+	logger  *slog.Logger
+	service Service
+}
+
+// NewTransport creates a new Transport instance
+func NewTransport(s Service, l *slog.Logger) *Transport {
+	return &Transport{
+		logger:  l,
+		service: s,
+	}
+}
+
+// Routes initialize all endpoints for route /collections
+func (t *Transport) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", t.listCollections)
+	r.Post("/", t.createCollection)
+	r.Route("/{collection_id}", func(r chi.Router) {
+		r.Get("/", t.getCollection)
+		r.Put("/", t.updateCollection)
+		r.Delete("/", t.deleteCollection)
+	})
+
+	return r
+}
+
+// listCollections is an endpoint for GET /collections method
+func (t *Transport) listCollections(w http.ResponseWriter, r *http.Request) {
+	var listReq ListCollectionsRequest
+	if err := schema.NewDecoder().Decode(&listReq, r.URL.Query()); err != nil {
+		t.logger.Error("failed to decode request params", slog.Any("err", err))
+		_ = render.Render(w, r, api.ErrBadRequest(err))
+		return
+	}
+
+	collections, pagination, svcErr := t.service.List(r.Context(), listReq.Limit, listReq.Offset)
+	if svcErr != nil {
+		t.logger.Error("failed to list collections", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	render.JSON(w, r, &ListCollectionsResponse{
+		Collections: convertToListCollectionsResponse(collections),
+		Pagination:  pagination,
+	})
+}
+
+// getCollection is an endpoint for GET /collections/{collection_id} method
+func (t *Transport) getCollection(w http.ResponseWriter, r *http.Request) {
+	collectionID, svcErr := parseCollectionID(r)
+	if svcErr != nil {
+		t.logger.Error("failed to parse collection id", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	collection, svcErr := t.service.Get(r.Context(), collectionID)
+	if svcErr != nil {
+		t.logger.Error("failed to get collection", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	render.JSON(w, r, convertToCollectionResponse(collection))
+}
+
+// createCollection is an endpoint for POST /collections method
+func (t *Transport) createCollection(w http.ResponseWriter, r *http.Request) {
+	collectionReq, ok := t.decodeCollectionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	collection, svcErr := t.service.Create(r.Context(), Collection{
+		Name:       collectionReq.Name,
+		SnippetIDs: collectionReq.SnippetIDs,
+	})
+	if svcErr != nil {
+		t.logger.Error("failed to create collection", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	render.JSON(w, r, convertToCollectionResponse(collection))
+}
+
+// updateCollection is an endpoint for PUT /collections/{collection_id} method
+func (t *Transport) updateCollection(w http.ResponseWriter, r *http.Request) {
+	collectionID, svcErr := parseCollectionID(r)
+	if svcErr != nil {
+		t.logger.Error("failed to parse collection id", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	collectionReq, ok := t.decodeCollectionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	collection, svcErr := t.service.Update(r.Context(), Collection{
+		ID:         collectionID,
+		Name:       collectionReq.Name,
+		SnippetIDs: collectionReq.SnippetIDs,
+	})
+	if svcErr != nil {
+		t.logger.Error("failed to update collection", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	render.JSON(w, r, convertToCollectionResponse(collection))
+}
+
+// deleteCollection is an endpoint for DELETE /collections/{collection_id} method
+func (t *Transport) deleteCollection(w http.ResponseWriter, r *http.Request) {
+	collectionID, svcErr := parseCollectionID(r)
+	if svcErr != nil {
+		t.logger.Error("failed to parse collection id", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	if svcErr = t.service.Delete(r.Context(), collectionID); svcErr != nil {
+		t.logger.Error("failed to delete collection", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	render.NoContent(w, r)
+}
+
+// decodeCollectionRequest decodes and validates CollectionRequest. It renders an error response on failure.
+func (t *Transport) decodeCollectionRequest(w http.ResponseWriter, r *http.Request) (CollectionRequest, bool) {
+	var collectionReq CollectionRequest
+	if err := render.Decode(r, &collectionReq); err != nil {
+		t.logger.Error("failed to decode request params", slog.Any("err", err))
+		_ = render.Render(w, r, api.ErrBadRequest(err))
+		return CollectionRequest{}, false
+	}
+
+	if validationErr := collectionReq.Validate(); validationErr != nil {
+		t.logger.Info("request is not valid", slog.Any("validation_err", validationErr))
+		_ = render.Render(w, r, api.ErrBadRequest(validationErr))
+		return CollectionRequest{}, false
+	}
+
+	return collectionReq, true
+}
+
+func parseCollectionID(r *http.Request) (uint, *service.Error) {
+	id, err := strconv.Atoi(chi.URLParam(r, "collection_id"))
+	switch {
+	case err != nil:
+		return 0, &service.Error{
+			Type: service.BadRequest,
+			Base: err,
+		}
+	case id <= 0:
+		return 0, &service.Error{
+			Type: service.BadRequest,
+			Base: fmt.Errorf("invalid id param: %d", id),
+		}
+	default:
+		return uint(id), nil
+	}
+}
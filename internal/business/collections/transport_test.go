@@ -0,0 +1,439 @@
+package collections_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"go.uber.org/mock/gomock"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/collections"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+func TestTransport_listCollections(t *testing.T) {
+	t.Parallel()
+
+	// ================================================
+	// Init mocks and service
+	ctrl := gomock.NewController(t)
+
+	mockService := NewMockService(ctrl)
+	transport := collections.NewTransport(mockService, nopslog.NewNoplogger())
+	handler := transport.Routes()
+
+	// ================================================
+	// Create httpexpect instance
+	expect := httpexpect.WithConfig(httpexpect.Config{
+		Client: &http.Client{
+			Transport: httpexpect.NewBinder(handler),
+		},
+		Reporter: httpexpect.NewAssertReporter(t),
+	})
+
+	// ================================================
+	// Init test data
+	fakeTime := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	list := []collections.Collection{
+		{ID: 2, Name: "Second", CreatedAt: fakeTime, UpdatedAt: fakeTime},
+		{ID: 1, Name: "First", SnippetIDs: []uint{3, 1}, CreatedAt: fakeTime, UpdatedAt: fakeTime},
+	}
+
+	pagination := service.Pagination{
+		Limit:       10,
+		Offset:      0,
+		Total:       2,
+		TotalPages:  1,
+		CurrentPage: 1,
+	}
+
+	// ================================================
+	// Describe mock calls
+	mockService.EXPECT().List(gomock.Any(), uint(10), uint(0)).Return(list, pagination, nil)
+
+	// ================================================
+	// Run test
+	expected := collections.ListCollectionsResponse{
+		Collections: []collections.CollectionResponse{
+			{ID: 2, Name: "Second", SnippetIDs: []uint{}, CreatedAt: fakeTime, UpdatedAt: fakeTime},
+			{ID: 1, Name: "First", SnippetIDs: []uint{3, 1}, CreatedAt: fakeTime, UpdatedAt: fakeTime},
+		},
+		Pagination: pagination,
+	}
+
+	expect.GET("/").
+		WithQuery("limit", 10).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().IsEqual(expected)
+}
+
+func TestTransport_getCollection(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Successfully get collection", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := collections.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Init test data
+		fakeTime := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Get(gomock.Any(), uint(1)).Return(collections.Collection{
+			ID:         1,
+			Name:       "Favourites",
+			SnippetIDs: []uint{3, 1},
+			CreatedAt:  fakeTime,
+			UpdatedAt:  fakeTime,
+		}, nil)
+
+		// ================================================
+		// Run test
+		expect.GET("/{id}", 1).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().IsEqual(collections.CollectionResponse{
+			ID:         1,
+			Name:       "Favourites",
+			SnippetIDs: []uint{3, 1},
+			CreatedAt:  fakeTime,
+			UpdatedAt:  fakeTime,
+		})
+	})
+
+	t.Run("Collection not found", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := collections.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Get(gomock.Any(), uint(1)).Return(
+			collections.Collection{},
+			&service.Error{Type: service.NotFound, Base: collections.ErrNotFound},
+		)
+
+		// ================================================
+		// Run test
+		expect.GET("/{id}", 1).
+			Expect().
+			Status(http.StatusNotFound).
+			JSON().Object().IsEqual(map[string]any{
+			"error": "not found",
+		})
+	})
+
+	t.Run("Bad request", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := collections.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Run test
+		expect.GET("/{id}", "abc").
+			Expect().
+			Status(http.StatusBadRequest)
+	})
+}
+
+func TestTransport_createCollection(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Successfully create collection", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := collections.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Init test data
+		fakeTime := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Create(gomock.Any(), collections.Collection{
+			Name:       "Favourites",
+			SnippetIDs: []uint{3, 1},
+		}).Return(collections.Collection{
+			ID:         1,
+			Name:       "Favourites",
+			SnippetIDs: []uint{3, 1},
+			CreatedAt:  fakeTime,
+			UpdatedAt:  fakeTime,
+		}, nil)
+
+		// ================================================
+		// Run test
+		expect.POST("/").
+			WithJSON(collections.CollectionRequest{
+				Name:       "Favourites",
+				SnippetIDs: []uint{3, 1},
+			}).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().IsEqual(collections.CollectionResponse{
+			ID:         1,
+			Name:       "Favourites",
+			SnippetIDs: []uint{3, 1},
+			CreatedAt:  fakeTime,
+			UpdatedAt:  fakeTime,
+		})
+	})
+
+	t.Run("Bad request: snippet not found", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := collections.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Create(gomock.Any(), gomock.Any()).Return(
+			collections.Collection{},
+			&service.Error{Type: service.BadRequest, Base: collections.ErrSnippetNotFound},
+		)
+
+		// ================================================
+		// Run test
+		expect.POST("/").
+			WithJSON(collections.CollectionRequest{
+				Name:       "Favourites",
+				SnippetIDs: []uint{42},
+			}).
+			Expect().
+			Status(http.StatusBadRequest).
+			JSON().Object().IsEqual(map[string]any{
+			"error": "snippet not found",
+		})
+	})
+
+	t.Run("Bad request: validation error", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := collections.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Run test
+		expect.POST("/").
+			WithJSON(collections.CollectionRequest{}).
+			Expect().
+			Status(http.StatusBadRequest).
+			JSON().Object().IsEqual(map[string]any{
+			"error": "name: cannot be blank.",
+		})
+	})
+}
+
+func TestTransport_updateCollection(t *testing.T) {
+	t.Parallel()
+
+	// ================================================
+	// Init mocks and service
+	ctrl := gomock.NewController(t)
+
+	mockService := NewMockService(ctrl)
+	transport := collections.NewTransport(mockService, nopslog.NewNoplogger())
+	handler := transport.Routes()
+
+	// ================================================
+	// Create httpexpect instance
+	expect := httpexpect.WithConfig(httpexpect.Config{
+		Client: &http.Client{
+			Transport: httpexpect.NewBinder(handler),
+		},
+		Reporter: httpexpect.NewAssertReporter(t),
+	})
+
+	// ================================================
+	// Init test data
+	fakeTime := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	// ================================================
+	// Describe mock calls
+	mockService.EXPECT().Update(gomock.Any(), collections.Collection{
+		ID:         1,
+		Name:       "Renamed",
+		SnippetIDs: []uint{2},
+	}).Return(collections.Collection{
+		ID:         1,
+		Name:       "Renamed",
+		SnippetIDs: []uint{2},
+		CreatedAt:  fakeTime,
+		UpdatedAt:  fakeTime,
+	}, nil)
+
+	// ================================================
+	// Run test
+	expect.PUT("/{id}", 1).
+		WithJSON(collections.CollectionRequest{
+			Name:       "Renamed",
+			SnippetIDs: []uint{2},
+		}).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().Value("name").String().IsEqual("Renamed")
+}
+
+func TestTransport_deleteCollection(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Successfully delete collection", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := collections.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Delete(gomock.Any(), uint(1)).Return(nil)
+
+		// ================================================
+		// Run test
+		expect.DELETE("/{id}", 1).
+			Expect().
+			Status(http.StatusNoContent)
+	})
+
+	t.Run("Service error", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := collections.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Delete(gomock.Any(), uint(1)).Return(&service.Error{
+			Type: service.InternalError,
+			Base: errors.New("internal error"),
+		})
+
+		// ================================================
+		// Run test
+		expect.DELETE("/{id}", 1).
+			Expect().
+			Status(http.StatusInternalServerError)
+	})
+}
@@ -0,0 +1,16 @@
+package comments
+
+import (
+	"time"
+)
+
+// Comment model struct. It's a review note on a snippet, optionally anchored to a line of the snippet content.
+type Comment struct {
+	ID        uint
+	SnippetID uint
+	Body      string
+	// Line is a 1-based line number of the snippet content, 0 means the comment isn't anchored
+	Line      uint
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
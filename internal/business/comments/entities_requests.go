@@ -0,0 +1,27 @@
+package comments
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// ListCommentsRequest represents a request struct for GET /snippets/{snippet_id}/comments?limit=<x>&offset=<y> method
+type ListCommentsRequest struct {
+	Limit  uint `schema:"limit"`
+	Offset uint `schema:"offset"`
+}
+
+// CommentRequest represents a request struct for POST /snippets/{snippet_id}/comments
+// and PUT /snippets/{snippet_id}/comments/{comment_id} methods
+type CommentRequest struct {
+	Body string `json:"body"`
+	// Line is an optional 1-based line number of the snippet content
+	Line uint `json:"line"`
+}
+
+// Validate implements ozzo-validation.Validatable interface and used to check user request
+func (r *CommentRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Body, validation.Required, validation.Length(1, 5000)),
+	)
+}
@@ -0,0 +1,59 @@
+package comments_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/comments"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/utils/testutils"
+)
+
+func TestCommentRequest_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		request comments.CommentRequest
+		wantErr string
+	}{
+		{
+			name: "Valid CommentRequest",
+			request: comments.CommentRequest{
+				Body: "Looks good to me",
+				Line: 3,
+			},
+			wantErr: "",
+		},
+		{
+			name: "Valid: comment without line",
+			request: comments.CommentRequest{
+				Body: "Looks good to me",
+			},
+			wantErr: "",
+		},
+		{
+			name: "Invalid: empty body",
+			request: comments.CommentRequest{
+				Line: 3,
+			},
+			wantErr: "body: cannot be blank.",
+		},
+		{
+			name: "Invalid: body is too long",
+			request: comments.CommentRequest{
+				Body: strings.Repeat("a", 5001),
+			},
+			wantErr: "body: the length must be between 1 and 5000.",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.request.Validate()
+			testutils.AssertError(t, tt.wantErr, err)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package comments
+
+import (
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+// CommentResponse represents a common comment-response struct
+type CommentResponse struct {
+	ID        uint      `json:"id"`
+	SnippetID uint      `json:"snippet_id"`
+	Body      string    `json:"body"`
+	Line      uint      `json:"line,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListCommentsResponse represents a response struct for GET /snippets/{snippet_id}/comments?limit=<x>&offset=<y> method
+type ListCommentsResponse struct {
+	Comments   []CommentResponse  `json:"comments,omitempty"`
+	Pagination service.Pagination `json:"pagination"`
+}
+
+// convertToListCommentsResponse is used to map []Comment -> []CommentResponse
+func convertToListCommentsResponse(comments []Comment) []CommentResponse {
+	response := make([]CommentResponse, len(comments))
+	for i := range comments {
+		response[i] = convertToCommentResponse(comments[i])
+	}
+	return response
+}
+
+// convertToCommentResponse is used to map Comment -> CommentResponse
+func convertToCommentResponse(comment Comment) CommentResponse {
+	return CommentResponse{
+		ID:        comment.ID,
+		SnippetID: comment.SnippetID,
+		Body:      comment.Body,
+		Line:      comment.Line,
+		CreatedAt: comment.CreatedAt,
+		UpdatedAt: comment.UpdatedAt,
+	}
+}
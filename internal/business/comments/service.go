@@ -0,0 +1,201 @@
+package comments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+//go:generate go run go.uber.org/mock/mockgen -typed -source=service.go -destination ./service_mock_test.go -package comments_test -mock_names Storage=MockStorage,Snippets=MockSnippets
+
+// ErrLineOutOfRange error is returned when a comment is anchored to a line the snippet doesn't have
+var ErrLineOutOfRange = errors.New("line is out of the snippet content range")
+
+// Storage is used to manipulate data in DB
+type Storage interface {
+	Get(ctx context.Context, snippetID uint, id uint) (Comment, error)
+	List(ctx context.Context, snippetID uint, pagination service.Pagination) ([]Comment, error)
+	Total(ctx context.Context, snippetID uint) (uint, error)
+	Create(ctx context.Context, comment Comment) (uint, error)
+	Update(ctx context.Context, comment Comment) error
+	Delete(ctx context.Context, snippetID uint, id uint) error
+}
+
+// Snippets is used to read the commented snippet.
+// Comments of a protected snippet are only available with the snippet passphrase in the context.
+type Snippets interface {
+	Get(ctx context.Context, id uint) (snippets.Snippet, *service.Error)
+}
+
+// CommentService represents service struct. It holds storage, snippets service and logger.
+type CommentService struct {
+	storage  Storage
+	snippets Snippets
+	logger   *slog.Logger
+
+	now func() time.Time
+}
+
+// NewService returns new instance of CommentService
+func NewService(storage Storage, snippets Snippets, logger *slog.Logger, nowFunc func() time.Time) *CommentService {
+	return &CommentService{
+		storage:  storage,
+		snippets: snippets,
+		logger:   logger,
+
+		now: nowFunc,
+	}
+}
+
+// List returns a list of comments of a snippet and a pagination struct
+func (s *CommentService) List(
+	ctx context.Context,
+	snippetID uint,
+	limit uint,
+	offset uint,
+) ([]Comment, service.Pagination, *service.Error) {
+	if _, svcErr := s.snippet(ctx, snippetID); svcErr != nil {
+		return nil, service.Pagination{}, svcErr
+	}
+
+	commentsCount, err := s.storage.Total(ctx, snippetID)
+	if err != nil {
+		s.logger.Error("failed to query total amount of comments", slog.Any("err", err))
+		return nil, service.Pagination{}, &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to query total amount of comments: %w", err),
+		}
+	}
+
+	pagination := snippets.NewPagination(limit, offset, commentsCount)
+
+	comments, err := s.storage.List(ctx, snippetID, pagination)
+	if err != nil {
+		s.logger.Error("failed to list comments", slog.Any("err", err))
+		return nil, pagination, &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to list comments: %w", err),
+		}
+	}
+
+	return comments, pagination, nil
+}
+
+// Create adds a comment to a snippet
+func (s *CommentService) Create(ctx context.Context, comment Comment) (Comment, *service.Error) {
+	snippet, svcErr := s.snippet(ctx, comment.SnippetID)
+	if svcErr != nil {
+		return Comment{}, svcErr
+	}
+
+	if svcErr = checkLine(snippet, comment.Line); svcErr != nil {
+		return Comment{}, svcErr
+	}
+
+	now := s.now()
+	comment.CreatedAt = now
+	comment.UpdatedAt = now
+
+	id, err := s.storage.Create(ctx, comment)
+	if err != nil {
+		return Comment{}, s.wrapStorageErr("create", err)
+	}
+
+	comment.ID = id
+	return comment, nil
+}
+
+// Update replaces the body and the line anchor of a comment
+func (s *CommentService) Update(ctx context.Context, comment Comment) (Comment, *service.Error) {
+	snippet, svcErr := s.snippet(ctx, comment.SnippetID)
+	if svcErr != nil {
+		return Comment{}, svcErr
+	}
+
+	if svcErr = checkLine(snippet, comment.Line); svcErr != nil {
+		return Comment{}, svcErr
+	}
+
+	current, err := s.storage.Get(ctx, comment.SnippetID, comment.ID)
+	if err != nil {
+		return Comment{}, s.wrapStorageErr("get", err)
+	}
+
+	comment.CreatedAt = current.CreatedAt
+	comment.UpdatedAt = s.now()
+
+	if err = s.storage.Update(ctx, comment); err != nil {
+		return Comment{}, s.wrapStorageErr("update", err)
+	}
+
+	return comment, nil
+}
+
+// Delete removes a comment of a snippet
+func (s *CommentService) Delete(ctx context.Context, snippetID uint, id uint) *service.Error {
+	if _, svcErr := s.snippet(ctx, snippetID); svcErr != nil {
+		return svcErr
+	}
+
+	if err := s.storage.Delete(ctx, snippetID, id); err != nil {
+		return s.wrapStorageErr("delete", err)
+	}
+
+	return nil
+}
+
+// snippet returns the commented snippet. Deleted snippets are not found.
+func (s *CommentService) snippet(ctx context.Context, id uint) (snippets.Snippet, *service.Error) {
+	snippet, svcErr := s.snippets.Get(ctx, id)
+	if svcErr != nil {
+		return snippets.Snippet{}, svcErr
+	}
+
+	if !snippet.ExpiresAt.After(s.now()) {
+		return snippets.Snippet{}, &service.Error{
+			Type: service.NotFound,
+			Base: snippets.ErrNotFound,
+		}
+	}
+
+	return snippet, nil
+}
+
+// wrapStorageErr converts storage errors to service errors
+func (s *CommentService) wrapStorageErr(operation string, err error) *service.Error {
+	if errors.Is(err, ErrNotFound) {
+		return &service.Error{
+			Type: service.NotFound,
+			Base: ErrNotFound,
+		}
+	}
+
+	s.logger.Error("comment storage failed", slog.String("operation", operation), slog.Any("err", err))
+	return &service.Error{
+		Type: service.InternalError,
+		Base: fmt.Errorf("failed to %s comment: %w", operation, err),
+	}
+}
+
+// checkLine checks that the snippet content has the line. Zero line means the comment isn't anchored.
+func checkLine(snippet snippets.Snippet, line uint) *service.Error {
+	if line == 0 {
+		return nil
+	}
+
+	lines := strings.Count(strings.TrimSuffix(snippet.Content, "\n"), "\n") + 1
+	if line > uint(lines) {
+		return &service.Error{
+			Type: service.BadRequest,
+			Base: fmt.Errorf("%w: the snippet has %d line(s)", ErrLineOutOfRange, lines),
+		}
+	}
+
+	return nil
+}
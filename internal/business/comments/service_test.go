@@ -0,0 +1,393 @@
+package comments_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/comments"
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+var (
+	fakeTimeNow     = time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+	fakeTimeExpires = time.Date(2050, 1, 1, 1, 1, 1, 0, time.UTC)
+)
+
+func TestCommentService_List(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	// ===============================================
+	// Init Mocks and Service
+	mockStorage := NewMockStorage(ctrl)
+	mockSnippets := NewMockSnippets(ctrl)
+
+	commentService := comments.NewService(
+		mockStorage,
+		mockSnippets,
+		nopslog.NewNoplogger(),
+		func() time.Time { return fakeTimeNow },
+	)
+
+	// ===============================================
+	// Init test data
+	list := []comments.Comment{
+		{ID: 1, SnippetID: 1, Body: "First", Line: 2},
+		{ID: 2, SnippetID: 1, Body: "Second"},
+	}
+
+	expectedPagination := service.Pagination{
+		Limit:       10,
+		Offset:      0,
+		Total:       2,
+		TotalPages:  1,
+		CurrentPage: 1,
+	}
+
+	// ===============================================
+	// Describe Mock Calls
+	mockSnippets.EXPECT().Get(ctx, uint(1)).Return(snippets.Snippet{ID: 1, ExpiresAt: fakeTimeExpires}, nil)
+	mockStorage.EXPECT().Total(ctx, uint(1)).Return(uint(2), nil)
+	mockStorage.EXPECT().List(ctx, uint(1), expectedPagination).Return(list, nil)
+
+	// ===============================================
+	// Run Test
+	result, pagination, svcErr := commentService.List(ctx, 1, 10, 0)
+	require.Nil(t, svcErr)
+	assert.Equal(t, list, result)
+	assert.Equal(t, expectedPagination, pagination)
+}
+
+func TestCommentService_Create(t *testing.T) {
+	t.Parallel()
+
+	snippet := snippets.Snippet{
+		ID:        1,
+		Content:   "line 1\nline 2\nline 3\n",
+		ExpiresAt: fakeTimeExpires,
+	}
+
+	t.Run("Successfully create an anchored comment", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+		mockSnippets := NewMockSnippets(ctrl)
+
+		commentService := comments.NewService(
+			mockStorage,
+			mockSnippets,
+			nopslog.NewNoplogger(),
+			func() time.Time { return fakeTimeNow },
+		)
+
+		// ===============================================
+		// Init test data
+		expected := comments.Comment{
+			SnippetID: 1,
+			Body:      "Typo here",
+			Line:      3,
+			CreatedAt: fakeTimeNow,
+			UpdatedAt: fakeTimeNow,
+		}
+
+		// ===============================================
+		// Describe Mock Calls
+		mockSnippets.EXPECT().Get(ctx, uint(1)).Return(snippet, nil)
+		mockStorage.EXPECT().Create(ctx, expected).Return(uint(7), nil)
+
+		// ===============================================
+		// Run Test
+		comment, svcErr := commentService.Create(ctx, comments.Comment{
+			SnippetID: 1,
+			Body:      "Typo here",
+			Line:      3,
+		})
+		require.Nil(t, svcErr)
+
+		expected.ID = 7
+		assert.Equal(t, expected, comment)
+	})
+
+	t.Run("Line is out of range", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+		mockSnippets := NewMockSnippets(ctrl)
+
+		commentService := comments.NewService(
+			mockStorage,
+			mockSnippets,
+			nopslog.NewNoplogger(),
+			func() time.Time { return fakeTimeNow },
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockSnippets.EXPECT().Get(ctx, uint(1)).Return(snippet, nil)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := commentService.Create(ctx, comments.Comment{
+			SnippetID: 1,
+			Body:      "Typo here",
+			Line:      4,
+		})
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.BadRequest, svcErr.Type)
+		assert.ErrorIs(t, svcErr.Base, comments.ErrLineOutOfRange)
+	})
+
+	t.Run("Snippet is deleted", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+		mockSnippets := NewMockSnippets(ctrl)
+
+		commentService := comments.NewService(
+			mockStorage,
+			mockSnippets,
+			nopslog.NewNoplogger(),
+			func() time.Time { return fakeTimeNow },
+		)
+
+		// ===============================================
+		// Init test data
+		deleted := snippet
+		deleted.ExpiresAt = fakeTimeNow.Add(-time.Minute)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockSnippets.EXPECT().Get(ctx, uint(1)).Return(deleted, nil)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := commentService.Create(ctx, comments.Comment{
+			SnippetID: 1,
+			Body:      "Typo here",
+		})
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.NotFound, svcErr.Type)
+	})
+
+	t.Run("Protected snippet without passphrase", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+		mockSnippets := NewMockSnippets(ctrl)
+
+		commentService := comments.NewService(
+			mockStorage,
+			mockSnippets,
+			nopslog.NewNoplogger(),
+			func() time.Time { return fakeTimeNow },
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockSnippets.EXPECT().Get(ctx, uint(1)).Return(snippets.Snippet{}, &service.Error{
+			Type: service.Unauthorized,
+			Base: snippets.ErrPassphraseRequired,
+		})
+
+		// ===============================================
+		// Run Test
+		_, svcErr := commentService.Create(ctx, comments.Comment{
+			SnippetID: 1,
+			Body:      "Typo here",
+		})
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.Unauthorized, svcErr.Type)
+	})
+}
+
+func TestCommentService_Update(t *testing.T) {
+	t.Parallel()
+
+	fakeTimeCreated := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	snippet := snippets.Snippet{
+		ID:        1,
+		Content:   "line 1\nline 2",
+		ExpiresAt: fakeTimeExpires,
+	}
+
+	t.Run("Successfully update a comment", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+		mockSnippets := NewMockSnippets(ctrl)
+
+		commentService := comments.NewService(
+			mockStorage,
+			mockSnippets,
+			nopslog.NewNoplogger(),
+			func() time.Time { return fakeTimeNow },
+		)
+
+		// ===============================================
+		// Init test data
+		expected := comments.Comment{
+			ID:        7,
+			SnippetID: 1,
+			Body:      "Fixed",
+			Line:      2,
+			CreatedAt: fakeTimeCreated,
+			UpdatedAt: fakeTimeNow,
+		}
+
+		// ===============================================
+		// Describe Mock Calls
+		mockSnippets.EXPECT().Get(ctx, uint(1)).Return(snippet, nil)
+		mockStorage.EXPECT().Get(ctx, uint(1), uint(7)).Return(comments.Comment{
+			ID:        7,
+			SnippetID: 1,
+			Body:      "Typo here",
+			CreatedAt: fakeTimeCreated,
+			UpdatedAt: fakeTimeCreated,
+		}, nil)
+		mockStorage.EXPECT().Update(ctx, expected).Return(nil)
+
+		// ===============================================
+		// Run Test
+		comment, svcErr := commentService.Update(ctx, comments.Comment{
+			ID:        7,
+			SnippetID: 1,
+			Body:      "Fixed",
+			Line:      2,
+		})
+		require.Nil(t, svcErr)
+		assert.Equal(t, expected, comment)
+	})
+
+	t.Run("Comment not found", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+		mockSnippets := NewMockSnippets(ctrl)
+
+		commentService := comments.NewService(
+			mockStorage,
+			mockSnippets,
+			nopslog.NewNoplogger(),
+			func() time.Time { return fakeTimeNow },
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockSnippets.EXPECT().Get(ctx, uint(1)).Return(snippet, nil)
+		mockStorage.EXPECT().Get(ctx, uint(1), uint(7)).Return(comments.Comment{}, comments.ErrNotFound)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := commentService.Update(ctx, comments.Comment{
+			ID:        7,
+			SnippetID: 1,
+			Body:      "Fixed",
+		})
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.NotFound, svcErr.Type)
+	})
+}
+
+func TestCommentService_Delete(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		storageErr error
+		wantType   service.ErrorType
+	}{
+		{
+			name:       "Successfully delete a comment",
+			storageErr: nil,
+		},
+		{
+			name:       "Comment not found",
+			storageErr: comments.ErrNotFound,
+			wantType:   service.NotFound,
+		},
+		{
+			name:       "Storage error",
+			storageErr: errors.New("unexpected error"),
+			wantType:   service.InternalError,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+
+			// ===============================================
+			// Init Mocks and Service
+			mockStorage := NewMockStorage(ctrl)
+			mockSnippets := NewMockSnippets(ctrl)
+
+			commentService := comments.NewService(
+				mockStorage,
+				mockSnippets,
+				nopslog.NewNoplogger(),
+				func() time.Time { return fakeTimeNow },
+			)
+
+			// ===============================================
+			// Describe Mock Calls
+			mockSnippets.EXPECT().Get(ctx, uint(1)).Return(snippets.Snippet{ID: 1, ExpiresAt: fakeTimeExpires}, nil)
+			mockStorage.EXPECT().Delete(ctx, uint(1), uint(7)).Return(tt.storageErr)
+
+			// ===============================================
+			// Run Test
+			svcErr := commentService.Delete(ctx, 1, 7)
+			if tt.storageErr == nil {
+				assert.Nil(t, svcErr)
+				return
+			}
+
+			require.NotNil(t, svcErr)
+			assert.Equal(t, tt.wantType, svcErr.Type)
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package comments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+// ErrNotFound error used to signal higher level about sql.ErrNoRows error
+var ErrNotFound = errors.New("not found")
+
+// PGStorage implements storage interface and provides methods to manipulate data in PostgreSQL storage.
+//
+// Comments of deleted (expired) snippets are never returned or modified,
+// and they are removed together with their snippet.
+type PGStorage struct {
+	conn *sql.DB
+}
+
+// NewPGStorage returns a new instance of PGStorage
+func NewPGStorage(conn *sql.DB) *PGStorage {
+	return &PGStorage{
+		conn: conn,
+	}
+}
+
+// Get returns a single comment of a snippet from storage
+func (pg *PGStorage) Get(ctx context.Context, snippetID uint, id uint) (Comment, error) {
+	query := `
+		SELECT
+			c.id,
+			c.snippet_id,
+			c.body,
+			COALESCE(c.line, 0),
+			c.created_at,
+			c.updated_at
+		FROM snippet_comments c
+			JOIN snippets s ON s.id = c.snippet_id
+		WHERE
+			c.id = $1
+			AND c.snippet_id = $2
+			AND s.expires_at > NOW()
+	`
+
+	var comment Comment
+	switch err := postgres.Conn(ctx, pg.conn).QueryRowContext(ctx, query, id, snippetID).Scan(
+		&comment.ID,
+		&comment.SnippetID,
+		&comment.Body,
+		&comment.Line,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+	); {
+	case err == nil:
+		return comment, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return Comment{}, ErrNotFound
+	default:
+		return Comment{}, fmt.Errorf("failed to scan comment: %w", err)
+	}
+}
+
+// List returns a list of comments of a snippet in the order they were created
+func (pg *PGStorage) List(ctx context.Context, snippetID uint, pagination service.Pagination) ([]Comment, error) {
+	query := `
+		SELECT
+			c.id,
+			c.snippet_id,
+			c.body,
+			COALESCE(c.line, 0),
+			c.created_at,
+			c.updated_at
+		FROM snippet_comments c
+			JOIN snippets s ON s.id = c.snippet_id
+		WHERE
+			c.snippet_id = $1
+			AND s.expires_at > NOW()
+		ORDER BY c.created_at, c.id
+		%s
+	`
+
+	rows, err := postgres.Conn(ctx, pg.conn).QueryContext(
+		ctx,
+		fmt.Sprintf(query, snippets.ConvertPaginationToSQLExpression(pagination)),
+		snippetID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var results []Comment
+	for rows.Next() {
+		var comment Comment
+		if err = rows.Scan(
+			&comment.ID,
+			&comment.SnippetID,
+			&comment.Body,
+			&comment.Line,
+			&comment.CreatedAt,
+			&comment.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan comment row: %w", err)
+		}
+
+		results = append(results, comment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error from iterating comments rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// Total returns a total amount of comments of a snippet
+func (pg *PGStorage) Total(ctx context.Context, snippetID uint) (uint, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM snippet_comments c
+			JOIN snippets s ON s.id = c.snippet_id
+		WHERE
+			c.snippet_id = $1
+			AND s.expires_at > NOW()
+	`
+
+	var total uint
+	if err := postgres.Conn(ctx, pg.conn).QueryRowContext(ctx, query, snippetID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count comments: %w", err)
+	}
+
+	return total, nil
+}
+
+// Create saves a single comment to storage
+func (pg *PGStorage) Create(ctx context.Context, comment Comment) (uint, error) {
+	query := `
+		INSERT INTO snippet_comments
+		(
+			snippet_id,
+			body,
+			line,
+			created_at,
+			updated_at
+		)
+		SELECT $1, $2, NULLIF($3, 0), $4, $5
+		FROM snippets
+		WHERE
+			id = $1
+			AND expires_at > NOW()
+		RETURNING id
+	`
+
+	var id uint
+	switch err := postgres.Conn(ctx, pg.conn).QueryRowContext(
+		ctx,
+		query,
+		comment.SnippetID,
+		comment.Body,
+		comment.Line,
+		comment.CreatedAt,
+		comment.UpdatedAt,
+	).Scan(&id); {
+	case err == nil:
+		return id, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, ErrNotFound
+	default:
+		return 0, fmt.Errorf("failed to add comment: %w", err)
+	}
+}
+
+// Update replaces the body and the line anchor of a comment
+func (pg *PGStorage) Update(ctx context.Context, comment Comment) error {
+	query := `
+		UPDATE snippet_comments c
+		SET
+			body = $3,
+			line = NULLIF($4, 0),
+			updated_at = $5
+		FROM snippets s
+		WHERE
+			c.id = $1
+			AND c.snippet_id = $2
+			AND s.id = c.snippet_id
+			AND s.expires_at > NOW()
+	`
+
+	result, err := postgres.Conn(ctx, pg.conn).ExecContext(
+		ctx,
+		query,
+		comment.ID,
+		comment.SnippetID,
+		comment.Body,
+		comment.Line,
+		comment.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update comment: %w", err)
+	}
+
+	switch affected, err := result.RowsAffected(); {
+	case err != nil:
+		return fmt.Errorf("failed to update comment: %w", err)
+	case affected == 0:
+		return ErrNotFound
+	default:
+		return nil
+	}
+}
+
+// Delete removes a comment of a snippet
+func (pg *PGStorage) Delete(ctx context.Context, snippetID uint, id uint) error {
+	result, err := postgres.Conn(ctx, pg.conn).ExecContext(
+		ctx,
+		`DELETE FROM snippet_comments WHERE id = $1 AND snippet_id = $2`,
+		id,
+		snippetID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+
+	switch affected, err := result.RowsAffected(); {
+	case err != nil:
+		return fmt.Errorf("failed to delete comment: %w", err)
+	case affected == 0:
+		return ErrNotFound
+	default:
+		return nil
+	}
+}
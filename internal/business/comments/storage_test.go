@@ -0,0 +1,138 @@
+package comments_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/comments"
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres/pgtest"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+const envFile = "../../../.env"
+
+func TestPGStorage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip integration test due to 'short' flag")
+	}
+	t.Parallel()
+
+	pgConn := pgtest.InitTestDatabase(
+		t,
+		pgtest.WithConfigFiles(envFile),
+	)
+
+	ctx := context.Background()
+	snippetStorage := snippets.NewPGStorage(pgConn)
+	pgStorage := comments.NewPGStorage(pgConn)
+
+	fakeTimeCreated := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+	fakeTimeUpdated := time.Date(2020, 10, 8, 12, 0, 0, 0, time.UTC)
+	fakeTimeExpires := time.Date(2050, 1, 1, 1, 1, 1, 0, time.UTC)
+
+	for i := 1; i <= 2; i++ {
+		_, err := snippetStorage.Create(ctx, snippets.Snippet{
+			Title:     fmt.Sprintf("Snippet title #%d", i),
+			Content:   "Snippet content",
+			CreatedAt: fakeTimeCreated,
+			UpdatedAt: fakeTimeCreated,
+			ExpiresAt: fakeTimeExpires,
+		})
+		require.NoError(t, err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		_, err := pgStorage.Create(ctx, comments.Comment{
+			SnippetID: 1,
+			Body:      fmt.Sprintf("Comment #%d", i),
+			Line:      uint(i - 1),
+			CreatedAt: fakeTimeCreated.Add(time.Duration(i) * time.Minute),
+			UpdatedAt: fakeTimeCreated.Add(time.Duration(i) * time.Minute),
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("Comments are listed in the order they were created", func(t *testing.T) {
+		total, err := pgStorage.Total(ctx, 1)
+		require.NoError(t, err)
+		assert.EqualValues(t, 3, total)
+
+		list, err := pgStorage.List(ctx, 1, service.Pagination{Limit: 2, Offset: 1})
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		assert.Equal(t, "Comment #2", list[0].Body)
+		assert.EqualValues(t, 1, list[0].Line)
+		assert.Equal(t, "Comment #3", list[1].Body)
+	})
+
+	t.Run("Comment without line", func(t *testing.T) {
+		comment, err := pgStorage.Get(ctx, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, comments.Comment{
+			ID:        1,
+			SnippetID: 1,
+			Body:      "Comment #1",
+			CreatedAt: fakeTimeCreated.Add(time.Minute),
+			UpdatedAt: fakeTimeCreated.Add(time.Minute),
+		}, comment)
+	})
+
+	t.Run("Update a comment", func(t *testing.T) {
+		require.NoError(t, pgStorage.Update(ctx, comments.Comment{
+			ID:        2,
+			SnippetID: 1,
+			Body:      "Updated",
+			Line:      0,
+			UpdatedAt: fakeTimeUpdated,
+		}))
+
+		comment, err := pgStorage.Get(ctx, 1, 2)
+		require.NoError(t, err)
+		assert.Equal(t, "Updated", comment.Body)
+		assert.Zero(t, comment.Line)
+		assert.Equal(t, fakeTimeUpdated, comment.UpdatedAt)
+	})
+
+	t.Run("Comment of another snippet is not found", func(t *testing.T) {
+		_, err := pgStorage.Get(ctx, 2, 1)
+		require.ErrorIs(t, err, comments.ErrNotFound)
+
+		err = pgStorage.Update(ctx, comments.Comment{ID: 1, SnippetID: 2, Body: "Updated"})
+		require.ErrorIs(t, err, comments.ErrNotFound)
+
+		err = pgStorage.Delete(ctx, 2, 1)
+		require.ErrorIs(t, err, comments.ErrNotFound)
+	})
+
+	t.Run("Delete a comment", func(t *testing.T) {
+		require.NoError(t, pgStorage.Delete(ctx, 1, 3))
+
+		_, err := pgStorage.Get(ctx, 1, 3)
+		require.ErrorIs(t, err, comments.ErrNotFound)
+	})
+
+	t.Run("Comments of a deleted snippet are gone", func(t *testing.T) {
+		require.NoError(t, snippetStorage.SoftDelete(ctx, 1))
+
+		total, err := pgStorage.Total(ctx, 1)
+		require.NoError(t, err)
+		assert.Zero(t, total)
+
+		_, err = pgStorage.Get(ctx, 1, 1)
+		require.ErrorIs(t, err, comments.ErrNotFound)
+
+		_, err = pgStorage.Create(ctx, comments.Comment{
+			SnippetID: 1,
+			Body:      "Too late",
+			CreatedAt: fakeTimeUpdated,
+			UpdatedAt: fakeTimeUpdated,
+		})
+		require.ErrorIs(t, err, comments.ErrNotFound)
+	})
+}
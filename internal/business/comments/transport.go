@@ -0,0 +1,214 @@
+package comments
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/gorilla/schema"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/api"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+//go:generate go run go.uber.org/mock/mockgen -typed -source=transport.go -destination ./transport_mock_test.go -package comments_test -mock_names Service=MockService
+
+// Service is used to manipulate data over comments
+type Service interface {
+	List(ctx context.Context, snippetID uint, limit uint, offset uint) ([]Comment, service.Pagination, *service.Error)
+	Create(ctx context.Context, comment Comment) (Comment, *service.Error)
+	Update(ctx context.Context, comment Comment) (Comment, *service.Error)
+	Delete(ctx context.Context, snippetID uint, id uint) *service.Error
+}
+
+// Transport is a struct that holds all endpoints for comments
+type Transport struct {
+	logger  *slog.Logger
+	service Service
+}
+
+// NewTransport creates a new Transport instance
+func NewTransport(s Service, l *slog.Logger) *Transport {
+	return &Transport{
+		logger:  l,
+		service: s,
+	}
+}
+
+// Routes initialize all endpoints for route /snippets/{snippet_id}/comments.
+// The router must be mounted under a route with {snippet_id} URL param.
+func (t *Transport) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", t.listComments)
+	r.Post("/", t.createComment)
+	r.Route("/{comment_id}", func(r chi.Router) {
+		r.Put("/", t.updateComment)
+		r.Delete("/", t.deleteComment)
+	})
+
+	return r
+}
+
+// listComments is an endpoint for GET /snippets/{snippet_id}/comments method
+func (t *Transport) listComments(w http.ResponseWriter, r *http.Request) {
+	snippetID, svcErr := parseID(r, "snippet_id")
+	if svcErr != nil {
+		t.logger.Error("failed to parse snippet id", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	var listReq ListCommentsRequest
+	if err := schema.NewDecoder().Decode(&listReq, r.URL.Query()); err != nil {
+		t.logger.Error("failed to decode request params", slog.Any("err", err))
+		_ = render.Render(w, r, api.ErrBadRequest(err))
+		return
+	}
+
+	comments, pagination, svcErr := t.service.List(requestContext(r), snippetID, listReq.Limit, listReq.Offset)
+	if svcErr != nil {
+		t.logger.Error("failed to list comments", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	render.JSON(w, r, &ListCommentsResponse{
+		Comments:   convertToListCommentsResponse(comments),
+		Pagination: pagination,
+	})
+}
+
+// createComment is an endpoint for POST /snippets/{snippet_id}/comments method
+func (t *Transport) createComment(w http.ResponseWriter, r *http.Request) {
+	snippetID, svcErr := parseID(r, "snippet_id")
+	if svcErr != nil {
+		t.logger.Error("failed to parse snippet id", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	commentReq, ok := t.decodeCommentRequest(w, r)
+	if !ok {
+		return
+	}
+
+	comment, svcErr := t.service.Create(requestContext(r), Comment{
+		SnippetID: snippetID,
+		Body:      commentReq.Body,
+		Line:      commentReq.Line,
+	})
+	if svcErr != nil {
+		t.logger.Error("failed to create comment", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	render.JSON(w, r, convertToCommentResponse(comment))
+}
+
+// updateComment is an endpoint for PUT /snippets/{snippet_id}/comments/{comment_id} method
+func (t *Transport) updateComment(w http.ResponseWriter, r *http.Request) {
+	snippetID, commentID, svcErr := parseIDs(r)
+	if svcErr != nil {
+		t.logger.Error("failed to parse ids", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	commentReq, ok := t.decodeCommentRequest(w, r)
+	if !ok {
+		return
+	}
+
+	comment, svcErr := t.service.Update(requestContext(r), Comment{
+		ID:        commentID,
+		SnippetID: snippetID,
+		Body:      commentReq.Body,
+		Line:      commentReq.Line,
+	})
+	if svcErr != nil {
+		t.logger.Error("failed to update comment", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	render.JSON(w, r, convertToCommentResponse(comment))
+}
+
+// deleteComment is an endpoint for DELETE /snippets/{snippet_id}/comments/{comment_id} method
+func (t *Transport) deleteComment(w http.ResponseWriter, r *http.Request) {
+	snippetID, commentID, svcErr := parseIDs(r)
+	if svcErr != nil {
+		t.logger.Error("failed to parse ids", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	if svcErr = t.service.Delete(requestContext(r), snippetID, commentID); svcErr != nil {
+		t.logger.Error("failed to delete comment", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	render.NoContent(w, r)
+}
+
+// decodeCommentRequest decodes and validates CommentRequest. It renders an error response on failure.
+func (t *Transport) decodeCommentRequest(w http.ResponseWriter, r *http.Request) (CommentRequest, bool) {
+	var commentReq CommentRequest
+	if err := render.Decode(r, &commentReq); err != nil {
+		t.logger.Error("failed to decode request params", slog.Any("err", err))
+		_ = render.Render(w, r, api.ErrBadRequest(err))
+		return CommentRequest{}, false
+	}
+
+	if validationErr := commentReq.Validate(); validationErr != nil {
+		t.logger.Info("request is not valid", slog.Any("validation_err", validationErr))
+		_ = render.Render(w, r, api.ErrBadRequest(validationErr))
+		return CommentRequest{}, false
+	}
+
+	return commentReq, true
+}
+
+// requestContext returns the request context with the snippet passphrase, so comments of protected snippets are available
+func requestContext(r *http.Request) context.Context {
+	return snippets.ContextWithPassphrase(r.Context(), r.Header.Get(snippets.PassphraseHeader))
+}
+
+func parseIDs(r *http.Request) (uint, uint, *service.Error) {
+	snippetID, svcErr := parseID(r, "snippet_id")
+	if svcErr != nil {
+		return 0, 0, svcErr
+	}
+
+	commentID, svcErr := parseID(r, "comment_id")
+	if svcErr != nil {
+		return 0, 0, svcErr
+	}
+
+	return snippetID, commentID, nil
+}
+
+func parseID(r *http.Request, param string) (uint, *service.Error) {
+	id, err := strconv.Atoi(chi.URLParam(r, param))
+	switch {
+	case err != nil:
+		return 0, &service.Error{
+			Type: service.BadRequest,
+			Base: err,
+		}
+	case id <= 0:
+		return 0, &service.Error{
+			Type: service.BadRequest,
+			Base: fmt.Errorf("invalid %s param: %d", param, id),
+		}
+	default:
+		return uint(id), nil
+	}
+}
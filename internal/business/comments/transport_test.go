@@ -0,0 +1,368 @@
+package comments_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/mock/gomock"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/comments"
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+func TestTransport_listComments(t *testing.T) {
+	t.Parallel()
+
+	// ================================================
+	// Init mocks and service
+	ctrl := gomock.NewController(t)
+
+	mockService := NewMockService(ctrl)
+	transport := comments.NewTransport(mockService, nopslog.NewNoplogger())
+	handler := chi.NewRouter()
+	handler.Mount("/snippets/{snippet_id}/comments", transport.Routes())
+
+	// ================================================
+	// Create httpexpect instance
+	expect := httpexpect.WithConfig(httpexpect.Config{
+		Client: &http.Client{
+			Transport: httpexpect.NewBinder(handler),
+		},
+		Reporter: httpexpect.NewAssertReporter(t),
+	})
+
+	// ================================================
+	// Init test data
+	fakeTime := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+	passphrase := "correct horse battery staple"
+
+	list := []comments.Comment{
+		{ID: 1, SnippetID: 3, Body: "First", Line: 2, CreatedAt: fakeTime, UpdatedAt: fakeTime},
+		{ID: 2, SnippetID: 3, Body: "Second", CreatedAt: fakeTime, UpdatedAt: fakeTime},
+	}
+
+	pagination := service.Pagination{
+		Limit:       10,
+		Offset:      0,
+		Total:       2,
+		TotalPages:  1,
+		CurrentPage: 1,
+	}
+
+	// ================================================
+	// Describe mock calls
+	mockService.EXPECT().List(
+		gomock.Cond(func(ctx context.Context) bool {
+			actual, ok := snippets.PassphraseFromContext(ctx)
+			return ok && actual == passphrase
+		}),
+		uint(3),
+		uint(10),
+		uint(0),
+	).Return(list, pagination, nil)
+
+	// ================================================
+	// Run test
+	expected := comments.ListCommentsResponse{
+		Comments: []comments.CommentResponse{
+			{ID: 1, SnippetID: 3, Body: "First", Line: 2, CreatedAt: fakeTime, UpdatedAt: fakeTime},
+			{ID: 2, SnippetID: 3, Body: "Second", CreatedAt: fakeTime, UpdatedAt: fakeTime},
+		},
+		Pagination: pagination,
+	}
+
+	expect.GET("/snippets/{id}/comments", 3).
+		WithQuery("limit", 10).
+		WithHeader(snippets.PassphraseHeader, passphrase).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().IsEqual(expected)
+}
+
+func TestTransport_createComment(t *testing.T) {
+	t.Parallel()
+
+	fakeTime := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Successfully create comment", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := comments.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := chi.NewRouter()
+		handler.Mount("/snippets/{snippet_id}/comments", transport.Routes())
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Create(gomock.Any(), comments.Comment{
+			SnippetID: 3,
+			Body:      "Typo here",
+			Line:      2,
+		}).Return(comments.Comment{
+			ID:        1,
+			SnippetID: 3,
+			Body:      "Typo here",
+			Line:      2,
+			CreatedAt: fakeTime,
+			UpdatedAt: fakeTime,
+		}, nil)
+
+		// ================================================
+		// Run test
+		expect.POST("/snippets/{id}/comments", 3).
+			WithJSON(comments.CommentRequest{Body: "Typo here", Line: 2}).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().IsEqual(comments.CommentResponse{
+			ID:        1,
+			SnippetID: 3,
+			Body:      "Typo here",
+			Line:      2,
+			CreatedAt: fakeTime,
+			UpdatedAt: fakeTime,
+		})
+	})
+
+	t.Run("Bad request: line is out of range", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := comments.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := chi.NewRouter()
+		handler.Mount("/snippets/{snippet_id}/comments", transport.Routes())
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Create(gomock.Any(), comments.Comment{
+			SnippetID: 3,
+			Body:      "Typo here",
+			Line:      20,
+		}).Return(comments.Comment{}, &service.Error{
+			Type: service.BadRequest,
+			Base: comments.ErrLineOutOfRange,
+		})
+
+		// ================================================
+		// Run test
+		expect.POST("/snippets/{id}/comments", 3).
+			WithJSON(comments.CommentRequest{Body: "Typo here", Line: 20}).
+			Expect().
+			Status(http.StatusBadRequest)
+	})
+
+	t.Run("Bad request: validation error", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := comments.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := chi.NewRouter()
+		handler.Mount("/snippets/{snippet_id}/comments", transport.Routes())
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Run test
+		expect.POST("/snippets/{id}/comments", 3).
+			WithJSON(comments.CommentRequest{}).
+			Expect().
+			Status(http.StatusBadRequest)
+	})
+}
+
+func TestTransport_updateComment(t *testing.T) {
+	t.Parallel()
+
+	// ================================================
+	// Init mocks and service
+	ctrl := gomock.NewController(t)
+
+	mockService := NewMockService(ctrl)
+	transport := comments.NewTransport(mockService, nopslog.NewNoplogger())
+	handler := chi.NewRouter()
+	handler.Mount("/snippets/{snippet_id}/comments", transport.Routes())
+
+	// ================================================
+	// Create httpexpect instance
+	expect := httpexpect.WithConfig(httpexpect.Config{
+		Client: &http.Client{
+			Transport: httpexpect.NewBinder(handler),
+		},
+		Reporter: httpexpect.NewAssertReporter(t),
+	})
+
+	// ================================================
+	// Init test data
+	fakeTimeCreated := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+	fakeTimeUpdated := time.Date(2020, 10, 8, 12, 0, 0, 0, time.UTC)
+
+	// ================================================
+	// Describe mock calls
+	mockService.EXPECT().Update(gomock.Any(), comments.Comment{
+		ID:        1,
+		SnippetID: 3,
+		Body:      "Fixed",
+	}).Return(comments.Comment{
+		ID:        1,
+		SnippetID: 3,
+		Body:      "Fixed",
+		CreatedAt: fakeTimeCreated,
+		UpdatedAt: fakeTimeUpdated,
+	}, nil)
+
+	// ================================================
+	// Run test
+	expect.PUT("/snippets/{id}/comments/{comment_id}", 3, 1).
+		WithJSON(comments.CommentRequest{Body: "Fixed"}).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().IsEqual(comments.CommentResponse{
+		ID:        1,
+		SnippetID: 3,
+		Body:      "Fixed",
+		CreatedAt: fakeTimeCreated,
+		UpdatedAt: fakeTimeUpdated,
+	})
+}
+
+func TestTransport_deleteComment(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Successfully delete comment", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := comments.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := chi.NewRouter()
+		handler.Mount("/snippets/{snippet_id}/comments", transport.Routes())
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Delete(gomock.Any(), uint(3), uint(1)).Return(nil)
+
+		// ================================================
+		// Run test
+		expect.DELETE("/snippets/{id}/comments/{comment_id}", 3, 1).
+			Expect().
+			Status(http.StatusNoContent)
+	})
+
+	t.Run("Service error", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := comments.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := chi.NewRouter()
+		handler.Mount("/snippets/{snippet_id}/comments", transport.Routes())
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Delete(gomock.Any(), uint(3), uint(1)).Return(&service.Error{
+			Type: service.InternalError,
+			Base: errors.New("unexpected error"),
+		})
+
+		// ================================================
+		// Run test
+		expect.DELETE("/snippets/{id}/comments/{comment_id}", 3, 1).
+			Expect().
+			Status(http.StatusInternalServerError)
+	})
+
+	t.Run("Bad request: invalid comment id", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := comments.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := chi.NewRouter()
+		handler.Mount("/snippets/{snippet_id}/comments", transport.Routes())
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Run test
+		expect.DELETE("/snippets/{id}/comments/{comment_id}", 3, "abc").
+			Expect().
+			Status(http.StatusBadRequest)
+	})
+}
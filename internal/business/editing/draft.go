@@ -0,0 +1,47 @@
+package editing
+
+import (
+	"time"
+)
+
+// Draft is the live content of a snippet being edited
+type Draft struct {
+	SnippetID uint
+	Content   string
+	// Revision is the number of edits applied to the draft
+	Revision uint64
+}
+
+// Edit is an operation applied to a draft
+type Edit struct {
+	SnippetID uint
+	// Revision of the draft produced by the edit
+	Revision  uint64
+	ClientID  string
+	Operation Operation
+	CreatedAt time.Time
+}
+
+// Cursor is a caret position of a client in a draft, in Unicode code points.
+// SelectionEnd equals Position if nothing is selected.
+type Cursor struct {
+	Position     int `json:"position"`
+	SelectionEnd int `json:"selection_end"`
+}
+
+// Presence describes a client connected to a draft
+type Presence struct {
+	SnippetID uint    `json:"snippet_id"`
+	ClientID  string  `json:"client_id"`
+	Name      string  `json:"name,omitempty"`
+	Cursor    *Cursor `json:"cursor,omitempty"`
+	// Left is set once the client disconnects
+	Left bool `json:"left,omitempty"`
+}
+
+// Notification is sent to all replicas when a draft is edited or a client presence changes
+type Notification struct {
+	SnippetID uint `json:"snippet_id"`
+	// Presence is nil for notifications about new edits
+	Presence *Presence `json:"presence,omitempty"`
+}
@@ -0,0 +1,59 @@
+package editing
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// JoinRequest represents a request struct for GET /snippets/{snippet_id}/live?name=<x> method
+type JoinRequest struct {
+	// Name is shown to other clients
+	Name string `schema:"name"`
+}
+
+// Validate implements ozzo-validation.Validatable interface and used to check user request
+func (r *JoinRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Name, validation.Length(0, 100)),
+	)
+}
+
+// Types of messages sent by clients
+const (
+	// ClientMessageEdit submits an operation made on the given revision of the draft
+	ClientMessageEdit = "edit"
+	// ClientMessageCursor moves the cursor of the client
+	ClientMessageCursor = "cursor"
+)
+
+// ClientMessageRequest represents a message sent by a client over WebSocket
+type ClientMessageRequest struct {
+	Type      string         `json:"type"`
+	Revision  uint64         `json:"revision"`
+	Operation *Operation     `json:"operation"`
+	Cursor    *CursorRequest `json:"cursor"`
+}
+
+// CursorRequest represents a cursor of ClientMessageRequest
+type CursorRequest struct {
+	Position     int `json:"position"`
+	SelectionEnd int `json:"selection_end"`
+}
+
+// Validate implements ozzo-validation.Validatable interface and used to check user request
+func (r *ClientMessageRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Type, validation.Required, validation.In(ClientMessageEdit, ClientMessageCursor)),
+		validation.Field(&r.Operation, validation.When(r.Type == ClientMessageEdit, validation.NotNil)),
+	)
+}
+
+// Validate implements ozzo-validation.Validatable interface and used to check user request
+func (r *CursorRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Position, validation.Min(0)),
+		validation.Field(&r.SelectionEnd, validation.Min(0)),
+	)
+}
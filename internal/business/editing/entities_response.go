@@ -0,0 +1,69 @@
+package editing
+
+// MessageResponse represents a message sent to a client over WebSocket
+type MessageResponse struct {
+	Type      MessageType        `json:"type"`
+	Revision  uint64             `json:"revision,omitempty"`
+	ClientID  string             `json:"client_id,omitempty"`
+	Content   *string            `json:"content,omitempty"`
+	Operation *Operation         `json:"operation,omitempty"`
+	Clients   []PresenceResponse `json:"clients,omitempty"`
+	Presence  *PresenceResponse  `json:"presence,omitempty"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// PresenceResponse represents a client connected to a draft
+type PresenceResponse struct {
+	ClientID string          `json:"client_id"`
+	Name     string          `json:"name,omitempty"`
+	Cursor   *CursorResponse `json:"cursor,omitempty"`
+	Left     bool            `json:"left,omitempty"`
+}
+
+// CursorResponse represents a cursor of a client
+type CursorResponse struct {
+	Position     int `json:"position"`
+	SelectionEnd int `json:"selection_end"`
+}
+
+func convertToMessageResponse(message Message) MessageResponse {
+	response := MessageResponse{
+		Type:     message.Type,
+		Revision: message.Revision,
+		ClientID: message.ClientID,
+		Error:    message.Error,
+	}
+
+	switch message.Type {
+	case MessageInit:
+		response.Content = &message.Content
+		response.Clients = make([]PresenceResponse, len(message.Clients))
+		for i, presence := range message.Clients {
+			response.Clients[i] = convertToPresenceResponse(presence)
+		}
+	case MessageEdit:
+		response.Operation = &message.Operation
+	case MessagePresence:
+		presence := convertToPresenceResponse(message.Presence)
+		response.Presence = &presence
+	}
+
+	return response
+}
+
+func convertToPresenceResponse(presence Presence) PresenceResponse {
+	response := PresenceResponse{
+		ClientID: presence.ClientID,
+		Name:     presence.Name,
+		Left:     presence.Left,
+	}
+
+	if presence.Cursor != nil {
+		response.Cursor = &CursorResponse{
+			Position:     presence.Cursor.Position,
+			SelectionEnd: presence.Cursor.SelectionEnd,
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,284 @@
+package editing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrLengthMismatch error is returned when an operation is applied to a text of another length
+	ErrLengthMismatch = errors.New("operation doesn't match the length of the text")
+	// ErrInvalidOperation error is returned when an operation can't be decoded
+	ErrInvalidOperation = errors.New("invalid operation")
+)
+
+// Operation is a text operation: a sequence of components applied to a text from its beginning.
+// A component retains, deletes or inserts characters. Lengths are counted in Unicode code points.
+//
+// Operations are encoded in the ot.js format: a JSON array, where a positive number retains that many characters,
+// a negative number deletes them, and a string is inserted, e.g. [5, "text", -3, 10].
+type Operation struct {
+	components []component
+
+	// baseLength is a length of texts the operation applies to
+	baseLength int
+	// targetLength is a length of the text produced by the operation
+	targetLength int
+}
+
+type component struct {
+	retain int
+	delete int
+	insert string
+}
+
+// Retain skips n characters
+func (o *Operation) Retain(n int) *Operation {
+	if n <= 0 {
+		return o
+	}
+
+	o.baseLength += n
+	o.targetLength += n
+
+	if last := o.last(); last != nil && last.retain > 0 {
+		last.retain += n
+		return o
+	}
+
+	o.components = append(o.components, component{retain: n})
+	return o
+}
+
+// Insert inserts s at the current position
+func (o *Operation) Insert(s string) *Operation {
+	if s == "" {
+		return o
+	}
+
+	o.targetLength += utf8.RuneCountInString(s)
+
+	last := o.last()
+	switch {
+	case last != nil && last.insert != "":
+		last.insert += s
+	case last != nil && last.delete > 0:
+		// Insertions are kept before deletions, so equal operations have the same components
+		if prev := o.prev(); prev != nil && prev.insert != "" {
+			prev.insert += s
+			return o
+		}
+
+		o.components = append(o.components, *last)
+		o.components[len(o.components)-2] = component{insert: s}
+	default:
+		o.components = append(o.components, component{insert: s})
+	}
+
+	return o
+}
+
+// Delete deletes n characters
+func (o *Operation) Delete(n int) *Operation {
+	if n <= 0 {
+		return o
+	}
+
+	o.baseLength += n
+
+	if last := o.last(); last != nil && last.delete > 0 {
+		last.delete += n
+		return o
+	}
+
+	o.components = append(o.components, component{delete: n})
+	return o
+}
+
+// BaseLength returns a length of texts the operation applies to
+func (o Operation) BaseLength() int {
+	return o.baseLength
+}
+
+// TargetLength returns a length of the text produced by the operation
+func (o Operation) TargetLength() int {
+	return o.targetLength
+}
+
+// IsNoop reports whether the operation keeps the text as is
+func (o Operation) IsNoop() bool {
+	return len(o.components) == 0 || (len(o.components) == 1 && o.components[0].retain > 0)
+}
+
+// Apply applies the operation to text
+func (o Operation) Apply(text string) (string, error) {
+	runes := []rune(text)
+	if len(runes) != o.baseLength {
+		return "", fmt.Errorf("%w: expected %d characters, got %d", ErrLengthMismatch, o.baseLength, len(runes))
+	}
+
+	var (
+		result strings.Builder
+		pos    int
+	)
+
+	for _, c := range o.components {
+		switch {
+		case c.retain > 0:
+			result.WriteString(string(runes[pos : pos+c.retain]))
+			pos += c.retain
+		case c.delete > 0:
+			pos += c.delete
+		default:
+			result.WriteString(c.insert)
+		}
+	}
+
+	return result.String(), nil
+}
+
+// Transform transforms concurrent operations a and b, made on the same text, so that a' applies after b
+// and b' applies after a with the same result. Inserts of a at the same position go first.
+func Transform(a, b Operation) (Operation, Operation, error) {
+	if a.baseLength != b.baseLength {
+		return Operation{}, Operation{}, fmt.Errorf(
+			"%w: concurrent operations apply to %d and %d characters",
+			ErrLengthMismatch,
+			a.baseLength,
+			b.baseLength,
+		)
+	}
+
+	var aPrime, bPrime Operation
+
+	ops1, ops2 := a.components, b.components
+	op1, op2 := next(&ops1), next(&ops2)
+
+	for op1 != nil || op2 != nil {
+		switch {
+		case op1 != nil && op1.insert != "":
+			aPrime.Insert(op1.insert)
+			bPrime.Retain(utf8.RuneCountInString(op1.insert))
+			op1 = next(&ops1)
+		case op2 != nil && op2.insert != "":
+			aPrime.Retain(utf8.RuneCountInString(op2.insert))
+			bPrime.Insert(op2.insert)
+			op2 = next(&ops2)
+		case op1 == nil || op2 == nil:
+			// Unreachable for operations of equal base lengths
+			return Operation{}, Operation{}, fmt.Errorf("%w: operations end at different positions", ErrLengthMismatch)
+		default:
+			n := min(op1.retain+op1.delete, op2.retain+op2.delete)
+			switch {
+			case op1.retain > 0 && op2.retain > 0:
+				aPrime.Retain(n)
+				bPrime.Retain(n)
+			case op1.delete > 0 && op2.retain > 0:
+				aPrime.Delete(n)
+			case op1.retain > 0 && op2.delete > 0:
+				bPrime.Delete(n)
+			}
+			// Characters deleted by both operations are just skipped
+
+			op1 = consume(op1, n, &ops1)
+			op2 = consume(op2, n, &ops2)
+		}
+	}
+
+	return aPrime, bPrime, nil
+}
+
+// next pops the first component of ops, it returns nil once ops are over
+func next(ops *[]component) *component {
+	if len(*ops) == 0 {
+		return nil
+	}
+
+	c := (*ops)[0]
+	*ops = (*ops)[1:]
+	return &c
+}
+
+// consume shortens a retain or delete component by n characters and moves to the next component once it's done
+func consume(c *component, n int, ops *[]component) *component {
+	if c.retain > 0 {
+		c.retain -= n
+		if c.retain > 0 {
+			return c
+		}
+	} else {
+		c.delete -= n
+		if c.delete > 0 {
+			return c
+		}
+	}
+
+	return next(ops)
+}
+
+// MarshalJSON implements json.Marshaler
+func (o Operation) MarshalJSON() ([]byte, error) {
+	encoded := make([]any, len(o.components))
+	for i, c := range o.components {
+		switch {
+		case c.retain > 0:
+			encoded[i] = c.retain
+		case c.delete > 0:
+			encoded[i] = -c.delete
+		default:
+			encoded[i] = c.insert
+		}
+	}
+
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidOperation, err)
+	}
+
+	var decoded Operation
+	for _, item := range raw {
+		var n int
+		if err := json.Unmarshal(item, &n); err == nil {
+			switch {
+			case n > 0:
+				decoded.Retain(n)
+			case n < 0:
+				decoded.Delete(-n)
+			default:
+				return fmt.Errorf("%w: zero-length component", ErrInvalidOperation)
+			}
+			continue
+		}
+
+		var s string
+		if err := json.Unmarshal(item, &s); err != nil || s == "" {
+			return fmt.Errorf("%w: component must be a non-zero integer or a non-empty string", ErrInvalidOperation)
+		}
+		decoded.Insert(s)
+	}
+
+	*o = decoded
+	return nil
+}
+
+func (o *Operation) last() *component {
+	if len(o.components) == 0 {
+		return nil
+	}
+	return &o.components[len(o.components)-1]
+}
+
+func (o *Operation) prev() *component {
+	if len(o.components) < 2 {
+		return nil
+	}
+	return &o.components[len(o.components)-2]
+}
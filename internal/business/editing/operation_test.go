@@ -0,0 +1,162 @@
+package editing_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/editing"
+)
+
+func TestOperation_Apply(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		operation *editing.Operation
+		text      string
+		want      string
+		wantErr   error
+	}{
+		{
+			name:      "Insert, retain and delete",
+			operation: new(editing.Operation).Insert("Hello, ").Retain(5).Delete(6),
+			text:      "world, bye!",
+			want:      "Hello, world",
+		},
+		{
+			name:      "Lengths are counted in code points",
+			operation: new(editing.Operation).Retain(3).Insert("ä").Retain(1),
+			text:      "приö",
+			want:      "приäö",
+		},
+		{
+			name:      "Length mismatch",
+			operation: new(editing.Operation).Retain(3),
+			text:      "text",
+			wantErr:   editing.ErrLengthMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tt.operation.Apply(tt.text)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTransform(t *testing.T) {
+	t.Parallel()
+
+	text := "the quick brown fox"
+
+	tests := []struct {
+		name string
+		a    *editing.Operation
+		b    *editing.Operation
+		want string
+	}{
+		{
+			name: "Inserts at different positions",
+			a:    new(editing.Operation).Retain(4).Insert("very ").Retain(15),
+			b:    new(editing.Operation).Retain(19).Insert(" jumps"),
+			want: "the very quick brown fox jumps",
+		},
+		{
+			name: "Inserts at the same position",
+			a:    new(editing.Operation).Retain(19).Insert("!"),
+			b:    new(editing.Operation).Retain(19).Insert("?"),
+			want: "the quick brown fox!?",
+		},
+		{
+			name: "Overlapping deletes",
+			a:    new(editing.Operation).Retain(4).Delete(6).Retain(9),
+			b:    new(editing.Operation).Retain(8).Delete(8).Retain(3),
+			want: "the fox",
+		},
+		{
+			name: "Insert inside a deleted range",
+			a:    new(editing.Operation).Retain(10).Insert("red ").Retain(9),
+			b:    new(editing.Operation).Retain(4).Delete(12).Retain(3),
+			want: "the red fox",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			aPrime, bPrime, err := editing.Transform(*tt.a, *tt.b)
+			require.NoError(t, err)
+
+			afterA, err := tt.a.Apply(text)
+			require.NoError(t, err)
+			afterB, err := tt.b.Apply(text)
+			require.NoError(t, err)
+
+			gotAB, err := bPrime.Apply(afterA)
+			require.NoError(t, err)
+			gotBA, err := aPrime.Apply(afterB)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, gotAB)
+			assert.Equal(t, tt.want, gotBA)
+		})
+	}
+
+	t.Run("Base length mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, err := editing.Transform(*new(editing.Operation).Retain(3), *new(editing.Operation).Retain(4))
+		assert.ErrorIs(t, err, editing.ErrLengthMismatch)
+	})
+}
+
+func TestOperation_JSON(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Round trip", func(t *testing.T) {
+		t.Parallel()
+
+		var operation editing.Operation
+		require.NoError(t, json.Unmarshal([]byte(`[5, "text", -3, 10]`), &operation))
+
+		assert.Equal(t, 18, operation.BaseLength())
+		assert.Equal(t, 19, operation.TargetLength())
+
+		encoded, err := json.Marshal(operation)
+		require.NoError(t, err)
+		assert.JSONEq(t, `[5, "text", -3, 10]`, string(encoded))
+	})
+
+	t.Run("Inserts are kept before deletes", func(t *testing.T) {
+		t.Parallel()
+
+		var operation editing.Operation
+		require.NoError(t, json.Unmarshal([]byte(`[-3, "abc"]`), &operation))
+
+		encoded, err := json.Marshal(operation)
+		require.NoError(t, err)
+		assert.JSONEq(t, `["abc", -3]`, string(encoded))
+	})
+
+	for _, data := range []string{`{}`, `[0]`, `[""]`, `[true]`, `[1.5]`} {
+		t.Run("Invalid "+data, func(t *testing.T) {
+			t.Parallel()
+
+			var operation editing.Operation
+			assert.ErrorIs(t, json.Unmarshal([]byte(data), &operation), editing.ErrInvalidOperation)
+		})
+	}
+}
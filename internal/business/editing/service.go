@@ -0,0 +1,404 @@
+package editing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+//go:generate go run go.uber.org/mock/mockgen -typed -source=service.go -destination ./service_mock_test.go -package editing_test -mock_names Storage=MockStorage,Snippets=MockSnippets
+
+var (
+	// ErrProtected error is returned when a protected snippet is opened for live editing
+	ErrProtected = errors.New("protected snippets can't be edited live")
+	// ErrContentTooLong error is returned when an operation makes the draft longer than snippets.MaxContentLength
+	ErrContentTooLong = errors.New("content is too long")
+	// ErrEncryptedAtRest error is returned when a snippet is opened for live editing while content is encrypted at rest
+	ErrEncryptedAtRest = errors.New("live editing is disabled, since snippets content is encrypted at rest")
+	// ErrShuttingDown error is returned when a client joins while the server is shutting down
+	ErrShuttingDown = errors.New("server is shutting down")
+)
+
+// Settings of presence of clients
+const (
+	// presenceInterval is how often replicas announce their clients
+	presenceInterval = 30 * time.Second
+	// presenceTimeout is how long a client is considered present since its last announcement
+	presenceTimeout = 3 * presenceInterval
+)
+
+// shutdownTimeout limits the time drafts are saved on shutdown
+const shutdownTimeout = 10 * time.Second
+
+// Storage is used to manipulate drafts in DB
+type Storage interface {
+	Open(ctx context.Context, snippetID uint, content string) (Draft, error)
+	Append(ctx context.Context, snippetID uint, base uint64, clientID string, rebase RebaseFunc) (Edit, error)
+	Since(ctx context.Context, snippetID uint, revision uint64) ([]Edit, error)
+	Persist(ctx context.Context, snippetID uint, save func(content string) error) (bool, error)
+	AnnouncePresence(ctx context.Context, presence Presence) error
+	Listen(ctx context.Context, logger *slog.Logger, handle func(context.Context, Notification)) error
+}
+
+// Snippets is used to read edited snippets and to save their drafts
+type Snippets interface {
+	Get(ctx context.Context, id uint) (snippets.Snippet, *service.Error)
+	UpdateContent(ctx context.Context, id uint, content string) (snippets.Snippet, *service.Error)
+}
+
+// EditingService represents service struct. It holds storage, snippets service, logger
+// and sessions connected to this replica.
+type EditingService struct {
+	storage  Storage
+	snippets Snippets
+	logger   *slog.Logger
+
+	now func() time.Time
+
+	// disabled is set if drafts would be the only snippets content stored in clear
+	disabled bool
+
+	mu     sync.Mutex
+	rooms  map[uint]*room
+	closed bool
+}
+
+// ServiceOption configures optional EditingService parameters
+type ServiceOption func(*EditingService)
+
+// WithContentEncryptedAtRest disables live editing: drafts and edits are stored unencrypted,
+// they must not leak content that is encrypted at rest everywhere else.
+func WithContentEncryptedAtRest() ServiceOption {
+	return func(s *EditingService) {
+		s.disabled = true
+	}
+}
+
+// NewService returns new instance of EditingService
+func NewService(
+	storage Storage,
+	snippets Snippets,
+	logger *slog.Logger,
+	nowFunc func() time.Time,
+	opts ...ServiceOption,
+) *EditingService {
+	s := &EditingService{
+		storage:  storage,
+		snippets: snippets,
+		logger:   logger,
+
+		now: nowFunc,
+
+		rooms: make(map[uint]*room),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Join connects a client to the draft of a snippet. Protected snippets can't be edited live,
+// since drafts are stored unencrypted. For the same reason, nothing can be edited live
+// if content is encrypted at rest, see WithContentEncryptedAtRest.
+func (s *EditingService) Join(ctx context.Context, snippetID uint, name string) (*Session, *service.Error) {
+	if s.disabled {
+		return nil, &service.Error{
+			Type: service.Forbidden,
+			Base: ErrEncryptedAtRest,
+		}
+	}
+
+	snippet, svcErr := s.snippets.Get(ctx, snippetID)
+	switch {
+	case svcErr != nil && errors.Is(svcErr.Base, snippets.ErrPassphraseRequired), svcErr == nil && snippet.Protected():
+		return nil, &service.Error{
+			Type: service.Forbidden,
+			Base: ErrProtected,
+		}
+	case svcErr != nil:
+		return nil, svcErr
+	case !snippet.ExpiresAt.After(s.now()):
+		return nil, &service.Error{
+			Type: service.NotFound,
+			Base: snippets.ErrNotFound,
+		}
+	}
+
+	draft, err := s.storage.Open(ctx, snippetID, snippet.Content)
+	if err != nil {
+		s.logger.Error("failed to open draft", slog.Any("err", err))
+		return nil, wrapErr("failed to open draft", err)
+	}
+
+	clientID, err := newClientID()
+	if err != nil {
+		return nil, wrapErr("failed to join session", err)
+	}
+
+	session := &Session{
+		ID:        clientID,
+		Name:      name,
+		SnippetID: snippetID,
+
+		service:  s,
+		messages: make(chan Message, sessionBufferSize),
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil, &service.Error{
+			Type: service.InternalError,
+			Base: ErrShuttingDown,
+		}
+	}
+
+	r, ok := s.rooms[snippetID]
+	if !ok {
+		r = newRoom(snippetID)
+		s.rooms[snippetID] = r
+	}
+	r.add(session, draft)
+	s.mu.Unlock()
+
+	// Edits made since the draft was opened may have been delivered to the room already
+	if err = r.sync(ctx, s.storage); err != nil {
+		s.logger.Error("failed to sync draft edits", slog.Any("err", err))
+	}
+
+	r.mu.Lock()
+	presence := session.presence()
+	r.mu.Unlock()
+
+	// The client can edit without the presence being announced, so the error is only logged
+	_ = s.announce(ctx, presence)
+
+	return session, nil
+}
+
+// Run receives edits and presence changes of all replicas, saves drafts every persistInterval
+// and announces clients of this replica. Once ctx is done, drafts are saved and all sessions are closed.
+func (s *EditingService) Run(ctx context.Context, persistInterval time.Duration) error {
+	gr, grCtx := errgroup.WithContext(ctx)
+
+	gr.Go(func() error {
+		return s.storage.Listen(grCtx, s.logger, s.handle)
+	})
+
+	gr.Go(func() error {
+		persistTicker := time.NewTicker(persistInterval)
+		defer persistTicker.Stop()
+
+		presenceTicker := time.NewTicker(presenceInterval)
+		defer presenceTicker.Stop()
+
+		for {
+			select {
+			case <-grCtx.Done():
+				return nil
+			case <-persistTicker.C:
+				for _, r := range s.activeRooms() {
+					s.persist(grCtx, r.snippetID)
+				}
+			case <-presenceTicker.C:
+				s.refreshPresence(grCtx)
+			}
+		}
+	})
+
+	err := gr.Wait()
+	s.shutdown()
+
+	return err
+}
+
+// submit appends an operation of a session and delivers it to the clients of this replica.
+// Other replicas deliver it once they are notified.
+func (s *EditingService) submit(ctx context.Context, session *Session, base uint64, operation Operation) *service.Error {
+	_, err := s.storage.Append(ctx, session.SnippetID, base, session.ID, rebase(operation))
+	switch {
+	case errors.Is(err, ErrStaleRevision),
+		errors.Is(err, ErrUnknownRevision),
+		errors.Is(err, ErrLengthMismatch),
+		errors.Is(err, ErrContentTooLong):
+		return &service.Error{
+			Type: service.BadRequest,
+			Base: err,
+		}
+	case errors.Is(err, ErrNotFound):
+		return &service.Error{
+			Type: service.NotFound,
+			Base: ErrNotFound,
+		}
+	case err != nil:
+		s.logger.Error("failed to append edit", slog.Any("err", err))
+		return wrapErr("failed to append edit", err)
+	}
+
+	if err = session.room.sync(ctx, s.storage); err != nil {
+		s.logger.Error("failed to sync draft edits", slog.Any("err", err))
+		return wrapErr("failed to sync draft edits", err)
+	}
+
+	return nil
+}
+
+// rebase transforms an operation against concurrent edits and applies it to the draft
+func rebase(operation Operation) RebaseFunc {
+	return func(draft Draft, concurrent []Edit) (Operation, string, error) {
+		var err error
+		for _, edit := range concurrent {
+			if operation, _, err = Transform(operation, edit.Operation); err != nil {
+				return Operation{}, "", err
+			}
+		}
+
+		if operation.TargetLength() > snippets.MaxContentLength {
+			return Operation{}, "", fmt.Errorf("%w: max %d characters", ErrContentTooLong, snippets.MaxContentLength)
+		}
+
+		content, err := operation.Apply(draft.Content)
+		if err != nil {
+			return Operation{}, "", err
+		}
+
+		return operation, content, nil
+	}
+}
+
+// leave disconnects a session, the draft is saved if it was the last session of the replica
+func (s *EditingService) leave(ctx context.Context, session *Session) {
+	s.mu.Lock()
+	r := session.room
+	r.mu.Lock()
+	presence := session.presence()
+	r.mu.Unlock()
+
+	empty := r.remove(session)
+	if empty && s.rooms[session.SnippetID] == r {
+		delete(s.rooms, session.SnippetID)
+	}
+	s.mu.Unlock()
+
+	presence.Cursor = nil
+	presence.Left = true
+	_ = s.announce(ctx, presence)
+
+	if empty {
+		s.persist(ctx, session.SnippetID)
+	}
+}
+
+// handle passes a notification of any replica to the clients of this replica
+func (s *EditingService) handle(ctx context.Context, notification Notification) {
+	s.mu.Lock()
+	r, ok := s.rooms[notification.SnippetID]
+	s.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	if notification.Presence != nil {
+		r.updatePresence(*notification.Presence, s.now())
+		return
+	}
+
+	if err := r.sync(ctx, s.storage); err != nil {
+		s.logger.Error("failed to sync draft edits", slog.Any("err", err))
+	}
+}
+
+// persist saves a draft to its snippet. If the snippet is deleted, its sessions are closed.
+func (s *EditingService) persist(ctx context.Context, snippetID uint) {
+	var svcErr *service.Error
+	_, err := s.storage.Persist(ctx, snippetID, func(content string) error {
+		if _, svcErr = s.snippets.UpdateContent(ctx, snippetID, content); svcErr != nil {
+			return svcErr
+		}
+		return nil
+	})
+
+	switch {
+	case svcErr != nil && svcErr.Type == service.NotFound:
+		s.mu.Lock()
+		r, ok := s.rooms[snippetID]
+		delete(s.rooms, snippetID)
+		s.mu.Unlock()
+
+		if ok {
+			r.shutdown("snippet is deleted")
+		}
+	case err != nil:
+		s.logger.Error("failed to save draft", slog.Uint64("snippet_id", uint64(snippetID)), slog.Any("err", err))
+	}
+}
+
+// refreshPresence announces clients of this replica and forgets clients of replicas that are gone
+func (s *EditingService) refreshPresence(ctx context.Context) {
+	seenBefore := s.now().Add(-presenceTimeout)
+
+	for _, r := range s.activeRooms() {
+		for _, presence := range r.presenceOfSessions() {
+			_ = s.announce(ctx, presence)
+		}
+
+		r.expirePresence(seenBefore)
+	}
+}
+
+// announce notifies all replicas about a changed presence of a client
+func (s *EditingService) announce(ctx context.Context, presence Presence) *service.Error {
+	if err := s.storage.AnnouncePresence(ctx, presence); err != nil {
+		s.logger.Error("failed to announce presence", slog.Any("err", err))
+		return wrapErr("failed to announce presence", err)
+	}
+
+	return nil
+}
+
+// shutdown saves drafts and closes all sessions
+func (s *EditingService) shutdown() {
+	s.mu.Lock()
+	s.closed = true
+	rooms := s.rooms
+	s.rooms = make(map[uint]*room)
+	s.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	for _, r := range rooms {
+		s.persist(ctx, r.snippetID)
+		r.shutdown(ErrShuttingDown.Error())
+	}
+}
+
+func (s *EditingService) activeRooms() []*room {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rooms := make([]*room, 0, len(s.rooms))
+	for _, r := range s.rooms {
+		rooms = append(rooms, r)
+	}
+
+	return rooms
+}
+
+func wrapErr(operation string, err error) *service.Error {
+	return &service.Error{
+		Type: service.InternalError,
+		Base: fmt.Errorf("%s: %w", operation, err),
+	}
+}
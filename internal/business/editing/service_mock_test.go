@@ -0,0 +1,379 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service.go
+//
+// Generated by this command:
+//
+//	mockgen -typed -source=service.go -destination ./service_mock_test.go -package editing_test -mock_names Storage=MockStorage,Snippets=MockSnippets
+//
+
+// Package editing_test is a generated GoMock package.
+package editing_test
+
+import (
+	context "context"
+	slog "log/slog"
+	reflect "reflect"
+
+	editing "github.com/titusjaka/go-sample/v2/internal/business/editing"
+	snippets "github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	service "github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStorage is a mock of Storage interface.
+type MockStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockStorageMockRecorder
+	isgomock struct{}
+}
+
+// MockStorageMockRecorder is the mock recorder for MockStorage.
+type MockStorageMockRecorder struct {
+	mock *MockStorage
+}
+
+// NewMockStorage creates a new mock instance.
+func NewMockStorage(ctrl *gomock.Controller) *MockStorage {
+	mock := &MockStorage{ctrl: ctrl}
+	mock.recorder = &MockStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
+	return m.recorder
+}
+
+// AnnouncePresence mocks base method.
+func (m *MockStorage) AnnouncePresence(ctx context.Context, presence editing.Presence) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnnouncePresence", ctx, presence)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AnnouncePresence indicates an expected call of AnnouncePresence.
+func (mr *MockStorageMockRecorder) AnnouncePresence(ctx, presence any) *MockStorageAnnouncePresenceCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnnouncePresence", reflect.TypeOf((*MockStorage)(nil).AnnouncePresence), ctx, presence)
+	return &MockStorageAnnouncePresenceCall{Call: call}
+}
+
+// MockStorageAnnouncePresenceCall wrap *gomock.Call
+type MockStorageAnnouncePresenceCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageAnnouncePresenceCall) Return(arg0 error) *MockStorageAnnouncePresenceCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageAnnouncePresenceCall) Do(f func(context.Context, editing.Presence) error) *MockStorageAnnouncePresenceCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageAnnouncePresenceCall) DoAndReturn(f func(context.Context, editing.Presence) error) *MockStorageAnnouncePresenceCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Append mocks base method.
+func (m *MockStorage) Append(ctx context.Context, snippetID uint, base uint64, clientID string, rebase editing.RebaseFunc) (editing.Edit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Append", ctx, snippetID, base, clientID, rebase)
+	ret0, _ := ret[0].(editing.Edit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Append indicates an expected call of Append.
+func (mr *MockStorageMockRecorder) Append(ctx, snippetID, base, clientID, rebase any) *MockStorageAppendCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Append", reflect.TypeOf((*MockStorage)(nil).Append), ctx, snippetID, base, clientID, rebase)
+	return &MockStorageAppendCall{Call: call}
+}
+
+// MockStorageAppendCall wrap *gomock.Call
+type MockStorageAppendCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageAppendCall) Return(arg0 editing.Edit, arg1 error) *MockStorageAppendCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageAppendCall) Do(f func(context.Context, uint, uint64, string, editing.RebaseFunc) (editing.Edit, error)) *MockStorageAppendCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageAppendCall) DoAndReturn(f func(context.Context, uint, uint64, string, editing.RebaseFunc) (editing.Edit, error)) *MockStorageAppendCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Listen mocks base method.
+func (m *MockStorage) Listen(ctx context.Context, logger *slog.Logger, handle func(context.Context, editing.Notification)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Listen", ctx, logger, handle)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Listen indicates an expected call of Listen.
+func (mr *MockStorageMockRecorder) Listen(ctx, logger, handle any) *MockStorageListenCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Listen", reflect.TypeOf((*MockStorage)(nil).Listen), ctx, logger, handle)
+	return &MockStorageListenCall{Call: call}
+}
+
+// MockStorageListenCall wrap *gomock.Call
+type MockStorageListenCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageListenCall) Return(arg0 error) *MockStorageListenCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageListenCall) Do(f func(context.Context, *slog.Logger, func(context.Context, editing.Notification)) error) *MockStorageListenCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageListenCall) DoAndReturn(f func(context.Context, *slog.Logger, func(context.Context, editing.Notification)) error) *MockStorageListenCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Open mocks base method.
+func (m *MockStorage) Open(ctx context.Context, snippetID uint, content string) (editing.Draft, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Open", ctx, snippetID, content)
+	ret0, _ := ret[0].(editing.Draft)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Open indicates an expected call of Open.
+func (mr *MockStorageMockRecorder) Open(ctx, snippetID, content any) *MockStorageOpenCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockStorage)(nil).Open), ctx, snippetID, content)
+	return &MockStorageOpenCall{Call: call}
+}
+
+// MockStorageOpenCall wrap *gomock.Call
+type MockStorageOpenCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageOpenCall) Return(arg0 editing.Draft, arg1 error) *MockStorageOpenCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageOpenCall) Do(f func(context.Context, uint, string) (editing.Draft, error)) *MockStorageOpenCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageOpenCall) DoAndReturn(f func(context.Context, uint, string) (editing.Draft, error)) *MockStorageOpenCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Persist mocks base method.
+func (m *MockStorage) Persist(ctx context.Context, snippetID uint, save func(string) error) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Persist", ctx, snippetID, save)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Persist indicates an expected call of Persist.
+func (mr *MockStorageMockRecorder) Persist(ctx, snippetID, save any) *MockStoragePersistCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Persist", reflect.TypeOf((*MockStorage)(nil).Persist), ctx, snippetID, save)
+	return &MockStoragePersistCall{Call: call}
+}
+
+// MockStoragePersistCall wrap *gomock.Call
+type MockStoragePersistCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStoragePersistCall) Return(arg0 bool, arg1 error) *MockStoragePersistCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStoragePersistCall) Do(f func(context.Context, uint, func(string) error) (bool, error)) *MockStoragePersistCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStoragePersistCall) DoAndReturn(f func(context.Context, uint, func(string) error) (bool, error)) *MockStoragePersistCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Since mocks base method.
+func (m *MockStorage) Since(ctx context.Context, snippetID uint, revision uint64) ([]editing.Edit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Since", ctx, snippetID, revision)
+	ret0, _ := ret[0].([]editing.Edit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Since indicates an expected call of Since.
+func (mr *MockStorageMockRecorder) Since(ctx, snippetID, revision any) *MockStorageSinceCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Since", reflect.TypeOf((*MockStorage)(nil).Since), ctx, snippetID, revision)
+	return &MockStorageSinceCall{Call: call}
+}
+
+// MockStorageSinceCall wrap *gomock.Call
+type MockStorageSinceCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageSinceCall) Return(arg0 []editing.Edit, arg1 error) *MockStorageSinceCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageSinceCall) Do(f func(context.Context, uint, uint64) ([]editing.Edit, error)) *MockStorageSinceCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageSinceCall) DoAndReturn(f func(context.Context, uint, uint64) ([]editing.Edit, error)) *MockStorageSinceCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockSnippets is a mock of Snippets interface.
+type MockSnippets struct {
+	ctrl     *gomock.Controller
+	recorder *MockSnippetsMockRecorder
+	isgomock struct{}
+}
+
+// MockSnippetsMockRecorder is the mock recorder for MockSnippets.
+type MockSnippetsMockRecorder struct {
+	mock *MockSnippets
+}
+
+// NewMockSnippets creates a new mock instance.
+func NewMockSnippets(ctrl *gomock.Controller) *MockSnippets {
+	mock := &MockSnippets{ctrl: ctrl}
+	mock.recorder = &MockSnippetsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSnippets) EXPECT() *MockSnippetsMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockSnippets) Get(ctx context.Context, id uint) (snippets.Snippet, *service.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(snippets.Snippet)
+	ret1, _ := ret[1].(*service.Error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockSnippetsMockRecorder) Get(ctx, id any) *MockSnippetsGetCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockSnippets)(nil).Get), ctx, id)
+	return &MockSnippetsGetCall{Call: call}
+}
+
+// MockSnippetsGetCall wrap *gomock.Call
+type MockSnippetsGetCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSnippetsGetCall) Return(arg0 snippets.Snippet, arg1 *service.Error) *MockSnippetsGetCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSnippetsGetCall) Do(f func(context.Context, uint) (snippets.Snippet, *service.Error)) *MockSnippetsGetCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSnippetsGetCall) DoAndReturn(f func(context.Context, uint) (snippets.Snippet, *service.Error)) *MockSnippetsGetCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// UpdateContent mocks base method.
+func (m *MockSnippets) UpdateContent(ctx context.Context, id uint, content string) (snippets.Snippet, *service.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateContent", ctx, id, content)
+	ret0, _ := ret[0].(snippets.Snippet)
+	ret1, _ := ret[1].(*service.Error)
+	return ret0, ret1
+}
+
+// UpdateContent indicates an expected call of UpdateContent.
+func (mr *MockSnippetsMockRecorder) UpdateContent(ctx, id, content any) *MockSnippetsUpdateContentCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateContent", reflect.TypeOf((*MockSnippets)(nil).UpdateContent), ctx, id, content)
+	return &MockSnippetsUpdateContentCall{Call: call}
+}
+
+// MockSnippetsUpdateContentCall wrap *gomock.Call
+type MockSnippetsUpdateContentCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockSnippetsUpdateContentCall) Return(arg0 snippets.Snippet, arg1 *service.Error) *MockSnippetsUpdateContentCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockSnippetsUpdateContentCall) Do(f func(context.Context, uint, string) (snippets.Snippet, *service.Error)) *MockSnippetsUpdateContentCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockSnippetsUpdateContentCall) DoAndReturn(f func(context.Context, uint, string) (snippets.Snippet, *service.Error)) *MockSnippetsUpdateContentCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -0,0 +1,318 @@
+package editing_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/editing"
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+func TestEditingService_Join(t *testing.T) {
+	t.Parallel()
+
+	fakeNow := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Receive the draft first", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+		mockSnippets := NewMockSnippets(ctrl)
+
+		editingService := editing.NewService(mockStorage, mockSnippets, nopslog.NewNoplogger(), func() time.Time { return fakeNow })
+
+		// ===============================================
+		// Init test data
+		snippet := snippets.Snippet{ID: 5, Content: "saved content", ExpiresAt: fakeNow.Add(time.Hour)}
+		draft := editing.Draft{SnippetID: 5, Content: "edited content", Revision: 3}
+
+		// ===============================================
+		// Describe Mock Calls
+		mockSnippets.EXPECT().Get(ctx, uint(5)).Return(snippet, nil)
+		mockStorage.EXPECT().Open(ctx, uint(5), "saved content").Return(draft, nil)
+		mockStorage.EXPECT().Since(ctx, uint(5), uint64(3)).Return(nil, nil)
+		mockStorage.EXPECT().AnnouncePresence(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, presence editing.Presence) error {
+				assert.Equal(t, uint(5), presence.SnippetID)
+				assert.Equal(t, "Alice", presence.Name)
+				assert.False(t, presence.Left)
+				return nil
+			},
+		)
+
+		// ===============================================
+		// Run Test
+		session, svcErr := editingService.Join(ctx, 5, "Alice")
+		require.Nil(t, svcErr)
+
+		message := <-session.Messages()
+		assert.Equal(t, editing.MessageInit, message.Type)
+		assert.Equal(t, uint64(3), message.Revision)
+		assert.Equal(t, session.ID, message.ClientID)
+		assert.Equal(t, "edited content", message.Content)
+	})
+
+	t.Run("Protected snippets can't be edited", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+		mockSnippets := NewMockSnippets(ctrl)
+
+		editingService := editing.NewService(mockStorage, mockSnippets, nopslog.NewNoplogger(), func() time.Time { return fakeNow })
+
+		// ===============================================
+		// Describe Mock Calls
+		mockSnippets.EXPECT().Get(ctx, uint(5)).Return(snippets.Snippet{}, &service.Error{
+			Type: service.Forbidden,
+			Base: snippets.ErrPassphraseRequired,
+		})
+
+		// ===============================================
+		// Run Test
+		_, svcErr := editingService.Join(ctx, 5, "Alice")
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.Forbidden, svcErr.Type)
+		assert.ErrorIs(t, svcErr, editing.ErrProtected)
+	})
+
+	t.Run("Nothing can be edited if content is encrypted at rest", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+		mockSnippets := NewMockSnippets(ctrl)
+
+		editingService := editing.NewService(
+			mockStorage,
+			mockSnippets,
+			nopslog.NewNoplogger(),
+			func() time.Time { return fakeNow },
+			editing.WithContentEncryptedAtRest(),
+		)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := editingService.Join(ctx, 5, "Alice")
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.Forbidden, svcErr.Type)
+		assert.ErrorIs(t, svcErr, editing.ErrEncryptedAtRest)
+	})
+
+	t.Run("Expired snippets can't be edited", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+		mockSnippets := NewMockSnippets(ctrl)
+
+		editingService := editing.NewService(mockStorage, mockSnippets, nopslog.NewNoplogger(), func() time.Time { return fakeNow })
+
+		// ===============================================
+		// Describe Mock Calls
+		mockSnippets.EXPECT().Get(ctx, uint(5)).Return(snippets.Snippet{ID: 5, ExpiresAt: fakeNow.Add(-time.Hour)}, nil)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := editingService.Join(ctx, 5, "Alice")
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.NotFound, svcErr.Type)
+	})
+}
+
+func TestSession_Submit(t *testing.T) {
+	t.Parallel()
+
+	fakeNow := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Rebase the operation and acknowledge it", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+		mockSnippets := NewMockSnippets(ctrl)
+
+		editingService := editing.NewService(mockStorage, mockSnippets, nopslog.NewNoplogger(), func() time.Time { return fakeNow })
+
+		// ===============================================
+		// Init test data
+		snippet := snippets.Snippet{ID: 5, Content: "fox", ExpiresAt: fakeNow.Add(time.Hour)}
+
+		// Another client has prepended "the " in revision 1
+		concurrent := editing.Edit{
+			SnippetID: 5,
+			Revision:  1,
+			ClientID:  "other",
+			Operation: *new(editing.Operation).Insert("the ").Retain(3),
+		}
+
+		var appended editing.Edit
+
+		// ===============================================
+		// Describe Mock Calls
+		mockSnippets.EXPECT().Get(ctx, uint(5)).Return(snippet, nil)
+		mockStorage.EXPECT().Open(ctx, uint(5), "fox").Return(editing.Draft{SnippetID: 5, Content: "fox"}, nil)
+		mockStorage.EXPECT().AnnouncePresence(ctx, gomock.Any()).Return(nil)
+
+		gomock.InOrder(
+			mockStorage.EXPECT().Since(ctx, uint(5), uint64(0)).Return(nil, nil),
+			mockStorage.EXPECT().Append(ctx, uint(5), uint64(0), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ uint, _ uint64, clientID string, rebase editing.RebaseFunc) (editing.Edit, error) {
+					operation, content, err := rebase(editing.Draft{SnippetID: 5, Content: "the fox", Revision: 1}, []editing.Edit{concurrent})
+					require.NoError(t, err)
+					assert.Equal(t, "the fox!", content)
+
+					appended = editing.Edit{SnippetID: 5, Revision: 2, ClientID: clientID, Operation: operation}
+					return appended, nil
+				},
+			),
+			mockStorage.EXPECT().Since(ctx, uint(5), uint64(0)).DoAndReturn(
+				func(context.Context, uint, uint64) ([]editing.Edit, error) {
+					return []editing.Edit{concurrent, appended}, nil
+				},
+			),
+		)
+
+		// ===============================================
+		// Run Test
+		session, svcErr := editingService.Join(ctx, 5, "Alice")
+		require.Nil(t, svcErr)
+		assert.Equal(t, editing.MessageInit, (<-session.Messages()).Type)
+
+		svcErr = session.Submit(ctx, 0, *new(editing.Operation).Retain(3).Insert("!"))
+		require.Nil(t, svcErr)
+
+		edit := <-session.Messages()
+		assert.Equal(t, editing.MessageEdit, edit.Type)
+		assert.Equal(t, uint64(1), edit.Revision)
+		assert.Equal(t, "other", edit.ClientID)
+
+		ack := <-session.Messages()
+		assert.Equal(t, editing.MessageAck, ack.Type)
+		assert.Equal(t, uint64(2), ack.Revision)
+	})
+
+	t.Run("Content is too long", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+		mockSnippets := NewMockSnippets(ctrl)
+
+		editingService := editing.NewService(mockStorage, mockSnippets, nopslog.NewNoplogger(), func() time.Time { return fakeNow })
+
+		// ===============================================
+		// Describe Mock Calls
+		mockSnippets.EXPECT().Get(ctx, uint(5)).Return(snippets.Snippet{ID: 5, ExpiresAt: fakeNow.Add(time.Hour)}, nil)
+		mockStorage.EXPECT().Open(ctx, uint(5), "").Return(editing.Draft{SnippetID: 5}, nil)
+		mockStorage.EXPECT().AnnouncePresence(ctx, gomock.Any()).Return(nil)
+		mockStorage.EXPECT().Since(ctx, uint(5), uint64(0)).Return(nil, nil)
+		mockStorage.EXPECT().Append(ctx, uint(5), uint64(0), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ uint, _ uint64, _ string, rebase editing.RebaseFunc) (editing.Edit, error) {
+				_, _, err := rebase(editing.Draft{SnippetID: 5}, nil)
+				return editing.Edit{}, err
+			},
+		)
+
+		// ===============================================
+		// Run Test
+		session, svcErr := editingService.Join(ctx, 5, "Alice")
+		require.Nil(t, svcErr)
+
+		long := make([]byte, snippets.MaxContentLength+1)
+		for i := range long {
+			long[i] = 'a'
+		}
+
+		svcErr = session.Submit(ctx, 0, *new(editing.Operation).Insert(string(long)))
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.BadRequest, svcErr.Type)
+		assert.ErrorIs(t, svcErr, editing.ErrContentTooLong)
+	})
+}
+
+func TestSession_Leave(t *testing.T) {
+	t.Parallel()
+
+	fakeNow := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Save the draft once the last client leaves", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+		mockSnippets := NewMockSnippets(ctrl)
+
+		editingService := editing.NewService(mockStorage, mockSnippets, nopslog.NewNoplogger(), func() time.Time { return fakeNow })
+
+		// ===============================================
+		// Describe Mock Calls
+		mockSnippets.EXPECT().Get(ctx, uint(5)).Return(snippets.Snippet{ID: 5, Content: "fox", ExpiresAt: fakeNow.Add(time.Hour)}, nil)
+		mockStorage.EXPECT().Open(ctx, uint(5), "fox").Return(editing.Draft{SnippetID: 5, Content: "the fox", Revision: 1}, nil)
+		mockStorage.EXPECT().Since(ctx, uint(5), uint64(1)).Return(nil, nil)
+
+		gomock.InOrder(
+			mockStorage.EXPECT().AnnouncePresence(ctx, gomock.Any()).Return(nil),
+			mockStorage.EXPECT().AnnouncePresence(ctx, gomock.Any()).DoAndReturn(
+				func(_ context.Context, presence editing.Presence) error {
+					assert.True(t, presence.Left)
+					return nil
+				},
+			),
+			mockStorage.EXPECT().Persist(ctx, uint(5), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ uint, save func(string) error) (bool, error) {
+					return true, save("the fox")
+				},
+			),
+		)
+		mockSnippets.EXPECT().UpdateContent(ctx, uint(5), "the fox").Return(snippets.Snippet{}, nil)
+
+		// ===============================================
+		// Run Test
+		session, svcErr := editingService.Join(ctx, 5, "Alice")
+		require.Nil(t, svcErr)
+
+		session.Leave(ctx)
+
+		// The session is closed after the queued messages
+		assert.Equal(t, editing.MessageInit, (<-session.Messages()).Type)
+		_, ok := <-session.Messages()
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,315 @@
+package editing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+// MessageType is a type of messages sent to clients of live editing sessions
+type MessageType string
+
+// Messages sent to clients
+const (
+	// MessageInit is the first message of a session, it holds the draft and clients connected to it
+	MessageInit MessageType = "init"
+	// MessageAck confirms an operation of the client, it's sent instead of MessageEdit
+	MessageAck MessageType = "ack"
+	// MessageEdit holds an operation of another client
+	MessageEdit MessageType = "edit"
+	// MessagePresence holds a changed presence of another client
+	MessagePresence MessageType = "presence"
+	// MessageError is sent before the session is closed by the server
+	MessageError MessageType = "error"
+)
+
+// sessionBufferSize is the number of messages queued for a client, a client that falls further behind is disconnected
+const sessionBufferSize = 256
+
+// Message is sent to a client of a live editing session
+type Message struct {
+	Type      MessageType
+	Revision  uint64
+	ClientID  string
+	Content   string
+	Operation Operation
+	Clients   []Presence
+	Presence  Presence
+	Error     string
+}
+
+// Session is a client connected to the draft of a snippet
+type Session struct {
+	ID        string
+	Name      string
+	SnippetID uint
+
+	service  *EditingService
+	room     *room
+	messages chan Message
+
+	// Fields below are guarded by room.mu
+	revision uint64
+	cursor   *Cursor
+	closed   bool
+}
+
+// Messages returns the channel of messages for the client. The channel is closed when the session is closed
+// by the server: on shutdown, when the snippet is deleted, or when the client falls behind.
+func (s *Session) Messages() <-chan Message {
+	return s.messages
+}
+
+// Submit applies an operation the client made on the base revision of the draft.
+// The operation is acknowledged with MessageAck, after all edits ordered before it.
+func (s *Session) Submit(ctx context.Context, base uint64, operation Operation) *service.Error {
+	return s.service.submit(ctx, s, base, operation)
+}
+
+// MoveCursor changes the cursor of the client, nil means the client has no cursor in the draft
+func (s *Session) MoveCursor(ctx context.Context, cursor *Cursor) *service.Error {
+	s.room.mu.Lock()
+	s.cursor = cursor
+	presence := s.presence()
+	s.room.mu.Unlock()
+
+	return s.service.announce(ctx, presence)
+}
+
+// Leave disconnects the client. The draft is saved once the last client of the replica leaves.
+func (s *Session) Leave(ctx context.Context) {
+	s.service.leave(ctx, s)
+}
+
+// presence returns the presence of the client, room.mu must be held
+func (s *Session) presence() Presence {
+	return Presence{
+		SnippetID: s.SnippetID,
+		ClientID:  s.ID,
+		Name:      s.Name,
+		Cursor:    s.cursor,
+	}
+}
+
+// room holds sessions of a single draft connected to this replica
+type room struct {
+	snippetID uint
+
+	// syncMu orders deliveries of edits
+	syncMu sync.Mutex
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+	// presence holds clients of all replicas
+	presence map[string]presenceEntry
+}
+
+type presenceEntry struct {
+	Presence
+	seenAt time.Time
+}
+
+func newRoom(snippetID uint) *room {
+	return &room{
+		snippetID: snippetID,
+		sessions:  make(map[string]*Session),
+		presence:  make(map[string]presenceEntry),
+	}
+}
+
+// add connects a session to the room, the session receives the draft first
+func (r *room) add(session *Session, draft Draft) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	clients := make([]Presence, 0, len(r.presence))
+	for _, id := range slices.Sorted(maps.Keys(r.presence)) {
+		clients = append(clients, r.presence[id].Presence)
+	}
+
+	session.room = r
+	session.revision = draft.Revision
+	r.sessions[session.ID] = session
+
+	r.deliver(session, Message{
+		Type:     MessageInit,
+		Revision: draft.Revision,
+		ClientID: session.ID,
+		Content:  draft.Content,
+		Clients:  clients,
+	})
+}
+
+// remove disconnects a session and reports whether the room is empty
+func (r *room) remove(session *Session) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.close(session)
+	return len(r.sessions) == 0
+}
+
+// sync delivers edits to sessions that haven't received them yet
+func (r *room) sync(ctx context.Context, storage Storage) error {
+	r.syncMu.Lock()
+	defer r.syncMu.Unlock()
+
+	r.mu.Lock()
+	if len(r.sessions) == 0 {
+		r.mu.Unlock()
+		return nil
+	}
+
+	from := uint64(math.MaxUint64)
+	for _, session := range r.sessions {
+		from = min(from, session.revision)
+	}
+	r.mu.Unlock()
+
+	edits, err := storage.Since(ctx, r.snippetID, from)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, edit := range edits {
+		for _, session := range r.sessions {
+			if edit.Revision <= session.revision {
+				continue
+			}
+
+			if edit.Revision > session.revision+1 {
+				// Edits the client misses are already purged, it has to reload the draft
+				r.fail(session, ErrStaleRevision.Error())
+				continue
+			}
+
+			session.revision = edit.Revision
+			if edit.ClientID == session.ID {
+				r.deliver(session, Message{Type: MessageAck, Revision: edit.Revision})
+				continue
+			}
+
+			r.deliver(session, Message{
+				Type:      MessageEdit,
+				Revision:  edit.Revision,
+				ClientID:  edit.ClientID,
+				Operation: edit.Operation,
+			})
+		}
+	}
+
+	return nil
+}
+
+// updatePresence records a presence of a client and passes it to other clients
+func (r *room) updatePresence(presence Presence, seenAt time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if presence.Left {
+		delete(r.presence, presence.ClientID)
+	} else {
+		r.presence[presence.ClientID] = presenceEntry{Presence: presence, seenAt: seenAt}
+	}
+
+	r.broadcastPresence(presence)
+}
+
+// expirePresence forgets clients that weren't announced since the given time, their replicas are gone
+func (r *room) expirePresence(seenBefore time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, entry := range r.presence {
+		if entry.seenAt.Before(seenBefore) {
+			delete(r.presence, id)
+
+			left := entry.Presence
+			left.Cursor = nil
+			left.Left = true
+			r.broadcastPresence(left)
+		}
+	}
+}
+
+// presenceOfSessions returns presences of sessions connected to this replica
+func (r *room) presenceOfSessions() []Presence {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	presence := make([]Presence, 0, len(r.sessions))
+	for _, session := range r.sessions {
+		presence = append(presence, session.presence())
+	}
+
+	return presence
+}
+
+// shutdown closes all sessions with an error message
+func (r *room) shutdown(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, session := range r.sessions {
+		r.fail(session, reason)
+	}
+}
+
+// broadcastPresence passes a presence to all clients but the described one, r.mu must be held
+func (r *room) broadcastPresence(presence Presence) {
+	for _, session := range r.sessions {
+		if session.ID != presence.ClientID {
+			r.deliver(session, Message{Type: MessagePresence, Presence: presence})
+		}
+	}
+}
+
+// deliver queues a message for a client, a client that falls behind is disconnected. r.mu must be held.
+func (r *room) deliver(session *Session, message Message) {
+	if session.closed {
+		return
+	}
+
+	select {
+	case session.messages <- message:
+	default:
+		r.close(session)
+	}
+}
+
+// fail sends an error message to a client and closes its session, r.mu must be held
+func (r *room) fail(session *Session, reason string) {
+	r.deliver(session, Message{Type: MessageError, Error: reason})
+	r.close(session)
+}
+
+// close closes a session, r.mu must be held
+func (r *room) close(session *Session) {
+	if session.closed {
+		return
+	}
+
+	session.closed = true
+	delete(r.sessions, session.ID)
+	close(session.messages)
+}
+
+func newClientID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate client ID: %w", err)
+	}
+
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,373 @@
+package editing
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres"
+)
+
+// notificationChannel is a channel that carries Notification of all replicas
+const notificationChannel = "snippet_editing"
+
+// editsRetained is the number of the latest edits kept after a draft is saved, so clients a bit behind
+// can still rebase their operations. A client that is further behind has to reload the draft.
+const editsRetained = 1000
+
+var (
+	// ErrNotFound error used to signal higher level about sql.ErrNoRows error
+	ErrNotFound = errors.New("not found")
+	// ErrStaleRevision error is returned when edits made since the base revision of an operation are already purged
+	ErrStaleRevision = errors.New("revision is too old, reload the draft")
+	// ErrUnknownRevision error is returned when the base revision of an operation is ahead of the draft
+	ErrUnknownRevision = errors.New("revision is ahead of the draft")
+)
+
+// RebaseFunc transforms an operation made on an older revision of a draft against edits made since then.
+// It returns the transformed operation and the draft content it produces.
+type RebaseFunc func(draft Draft, concurrent []Edit) (Operation, string, error)
+
+// PGStorage implements storage interface and provides methods to manipulate drafts in PostgreSQL storage.
+//
+// Edits of a draft are ordered by a lock of the draft row, so the order is the same for all replicas.
+// Every edit and presence change is announced to all replicas with NOTIFY.
+type PGStorage struct {
+	conn *sql.DB
+}
+
+// NewPGStorage returns a new instance of PGStorage
+func NewPGStorage(conn *sql.DB) *PGStorage {
+	return &PGStorage{
+		conn: conn,
+	}
+}
+
+// Open returns the draft of a snippet. A new draft is created from content, if the snippet isn't edited yet.
+func (pg *PGStorage) Open(ctx context.Context, snippetID uint, content string) (Draft, error) {
+	query := `
+		WITH inserted AS (
+			INSERT INTO snippet_drafts
+			(
+				snippet_id,
+				content
+			)
+			VALUES
+			(
+				$1,
+				$2
+			)
+			ON CONFLICT (snippet_id) DO NOTHING
+			RETURNING content, revision
+		)
+		SELECT content, revision FROM inserted
+		UNION ALL
+		SELECT content, revision FROM snippet_drafts WHERE snippet_id = $1
+		LIMIT 1
+	`
+
+	draft := Draft{SnippetID: snippetID}
+	if err := postgres.Conn(ctx, pg.conn).QueryRowContext(ctx, query, snippetID, content).Scan(
+		&draft.Content,
+		&draft.Revision,
+	); err != nil {
+		return Draft{}, fmt.Errorf("failed to open draft: %w", err)
+	}
+
+	return draft, nil
+}
+
+// Append applies an operation made on the base revision to the draft of a snippet and returns the edit.
+// rebase is called while the draft is locked, with edits made since the base revision.
+// Errors returned by rebase are passed as is.
+func (pg *PGStorage) Append(
+	ctx context.Context,
+	snippetID uint,
+	base uint64,
+	clientID string,
+	rebase RebaseFunc,
+) (Edit, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("failed to append edit: %w", err)
+	}
+
+	tx, err := postgres.BeginTx(ctx, pg.conn)
+	if err != nil {
+		return Edit{}, wrapErr(err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	query := `
+		SELECT content, revision
+		FROM snippet_drafts
+		WHERE snippet_id = $1
+		FOR UPDATE
+	`
+
+	draft := Draft{SnippetID: snippetID}
+	switch err = tx.QueryRowContext(ctx, query, snippetID).Scan(&draft.Content, &draft.Revision); {
+	case errors.Is(err, sql.ErrNoRows):
+		return Edit{}, ErrNotFound
+	case err != nil:
+		return Edit{}, wrapErr(err)
+	case base > draft.Revision:
+		return Edit{}, ErrUnknownRevision
+	}
+
+	concurrent, err := since(ctx, tx, snippetID, base)
+	if err != nil {
+		return Edit{}, wrapErr(err)
+	}
+
+	if uint64(len(concurrent)) != draft.Revision-base {
+		return Edit{}, ErrStaleRevision
+	}
+
+	operation, content, err := rebase(draft, concurrent)
+	if err != nil {
+		return Edit{}, err
+	}
+
+	edit := Edit{
+		SnippetID: snippetID,
+		Revision:  draft.Revision + 1,
+		ClientID:  clientID,
+		Operation: operation,
+	}
+
+	if err = pg.saveEdit(ctx, tx, &edit, content); err != nil {
+		return Edit{}, wrapErr(err)
+	}
+
+	if err = notify(ctx, tx, Notification{SnippetID: snippetID}); err != nil {
+		return Edit{}, wrapErr(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return Edit{}, wrapErr(err)
+	}
+
+	return edit, nil
+}
+
+// saveEdit records an edit and updates the draft content
+func (pg *PGStorage) saveEdit(ctx context.Context, tx postgres.Querier, edit *Edit, content string) error {
+	operation, err := json.Marshal(edit.Operation)
+	if err != nil {
+		return fmt.Errorf("failed to encode operation: %w", err)
+	}
+
+	query := `
+		UPDATE snippet_drafts
+		SET
+			content = $2,
+			revision = $3,
+			updated_at = NOW()
+		WHERE snippet_id = $1
+	`
+
+	if _, err = tx.ExecContext(ctx, query, edit.SnippetID, content, edit.Revision); err != nil {
+		return fmt.Errorf("failed to update draft: %w", err)
+	}
+
+	query = `
+		INSERT INTO snippet_edits
+		(
+			snippet_id,
+			revision,
+			client_id,
+			operation
+		)
+		VALUES
+		(
+			$1,
+			$2,
+			$3,
+			$4
+		)
+		RETURNING created_at
+	`
+
+	if err = tx.QueryRowContext(
+		ctx,
+		query,
+		edit.SnippetID,
+		edit.Revision,
+		edit.ClientID,
+		string(operation),
+	).Scan(&edit.CreatedAt); err != nil {
+		return fmt.Errorf("failed to insert edit: %w", err)
+	}
+
+	return nil
+}
+
+// Since returns edits of the draft of a snippet made after the given revision, in order
+func (pg *PGStorage) Since(ctx context.Context, snippetID uint, revision uint64) ([]Edit, error) {
+	edits, err := since(ctx, pg.conn, snippetID, revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list edits: %w", err)
+	}
+
+	return edits, nil
+}
+
+// Persist saves the draft of a snippet with save, if the draft has unsaved edits, and reports whether it was saved.
+// The draft is locked while it's saved, so it isn't edited or saved by other replicas at the same time.
+// Errors returned by save are passed as is.
+func (pg *PGStorage) Persist(ctx context.Context, snippetID uint, save func(content string) error) (bool, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("failed to persist draft: %w", err)
+	}
+
+	tx, err := postgres.BeginTx(ctx, pg.conn)
+	if err != nil {
+		return false, wrapErr(err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	query := `
+		SELECT content, revision
+		FROM snippet_drafts
+		WHERE
+			snippet_id = $1
+			AND persisted_revision < revision
+		FOR UPDATE SKIP LOCKED
+	`
+
+	var (
+		content  string
+		revision uint64
+	)
+	switch err = tx.QueryRowContext(ctx, query, snippetID).Scan(&content, &revision); {
+	case errors.Is(err, sql.ErrNoRows):
+		// Nothing to save, or the draft is being edited or saved right now
+		return false, nil
+	case err != nil:
+		return false, wrapErr(err)
+	}
+
+	if err = save(content); err != nil {
+		return false, err
+	}
+
+	query = `
+		UPDATE snippet_drafts
+		SET persisted_revision = $2
+		WHERE snippet_id = $1
+	`
+
+	if _, err = tx.ExecContext(ctx, query, snippetID, revision); err != nil {
+		return false, wrapErr(err)
+	}
+
+	query = `
+		DELETE FROM snippet_edits
+		WHERE
+			snippet_id = $1
+			AND revision <= $2
+	`
+
+	if revision > editsRetained {
+		if _, err = tx.ExecContext(ctx, query, snippetID, revision-editsRetained); err != nil {
+			return false, wrapErr(err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, wrapErr(err)
+	}
+
+	return true, nil
+}
+
+// AnnouncePresence notifies all replicas about a changed presence of a client
+func (pg *PGStorage) AnnouncePresence(ctx context.Context, presence Presence) error {
+	if err := notify(ctx, pg.conn, Notification{SnippetID: presence.SnippetID, Presence: &presence}); err != nil {
+		return fmt.Errorf("failed to announce presence: %w", err)
+	}
+
+	return nil
+}
+
+// Listen calls handle for every notification of all replicas until ctx is done
+func (pg *PGStorage) Listen(ctx context.Context, logger *slog.Logger, handle func(context.Context, Notification)) error {
+	return postgres.Listen(ctx, pg.conn, logger, notificationChannel, func(ctx context.Context, payload string) {
+		var notification Notification
+		if err := json.Unmarshal([]byte(payload), &notification); err != nil {
+			logger.Error("failed to decode notification", slog.String("payload", payload), slog.Any("err", err))
+			return
+		}
+
+		handle(ctx, notification)
+	})
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func since(ctx context.Context, q querier, snippetID uint, revision uint64) ([]Edit, error) {
+	query := `
+		SELECT
+			revision,
+			client_id,
+			operation,
+			created_at
+		FROM snippet_edits
+		WHERE
+			snippet_id = $1
+			AND revision > $2
+		ORDER BY revision
+	`
+
+	rows, err := q.QueryContext(ctx, query, snippetID, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var edits []Edit
+	for rows.Next() {
+		var (
+			edit      = Edit{SnippetID: snippetID}
+			operation []byte
+		)
+		if err = rows.Scan(&edit.Revision, &edit.ClientID, &operation, &edit.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		if err = json.Unmarshal(operation, &edit.Operation); err != nil {
+			return nil, err
+		}
+
+		edits = append(edits, edit)
+	}
+
+	return edits, rows.Err()
+}
+
+func notify(ctx context.Context, e postgres.Execer, notification Notification) error {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	if _, err = e.ExecContext(ctx, `SELECT PG_NOTIFY($1, $2)`, notificationChannel, string(payload)); err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+
+	return nil
+}
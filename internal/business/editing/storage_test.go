@@ -0,0 +1,145 @@
+package editing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/editing"
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres/pgtest"
+)
+
+const envFile = "../../../.env"
+
+func TestPGStorage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip integration test due to 'short' flag")
+	}
+	t.Parallel()
+
+	pgConn := pgtest.InitTestDatabase(
+		t,
+		pgtest.WithConfigFiles(envFile),
+	)
+
+	ctx := context.Background()
+	snippetStorage := snippets.NewPGStorage(pgConn)
+	pgStorage := editing.NewPGStorage(pgConn)
+
+	fakeTime := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	snippetID, err := snippetStorage.Create(ctx, snippets.Snippet{
+		Title:     "Snippet title",
+		Content:   "fox",
+		CreatedAt: fakeTime,
+		UpdatedAt: fakeTime,
+		ExpiresAt: time.Date(2050, 1, 1, 1, 1, 1, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	insert := func(revision uint64, text string) editing.RebaseFunc {
+		return func(draft editing.Draft, concurrent []editing.Edit) (editing.Operation, string, error) {
+			assert.Len(t, concurrent, int(draft.Revision-revision))
+
+			operation := *new(editing.Operation).Insert(text).Retain(len([]rune(draft.Content)))
+			content, applyErr := operation.Apply(draft.Content)
+			return operation, content, applyErr
+		}
+	}
+
+	t.Run("Open a draft once", func(t *testing.T) {
+		draft, err := pgStorage.Open(ctx, snippetID, "fox")
+		require.NoError(t, err)
+		assert.Equal(t, editing.Draft{SnippetID: snippetID, Content: "fox"}, draft)
+
+		draft, err = pgStorage.Open(ctx, snippetID, "ignored")
+		require.NoError(t, err)
+		assert.Equal(t, "fox", draft.Content)
+	})
+
+	t.Run("Append edits in order", func(t *testing.T) {
+		edit, err := pgStorage.Append(ctx, snippetID, 0, "alice", insert(0, "quick "))
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, edit.Revision)
+
+		edit, err = pgStorage.Append(ctx, snippetID, 0, "bob", insert(0, "the "))
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, edit.Revision)
+
+		_, err = pgStorage.Append(ctx, snippetID, 5, "bob", insert(5, "!"))
+		assert.ErrorIs(t, err, editing.ErrUnknownRevision)
+
+		_, err = pgStorage.Append(ctx, snippetID+100, 0, "bob", insert(0, "!"))
+		assert.ErrorIs(t, err, editing.ErrNotFound)
+
+		edits, err := pgStorage.Since(ctx, snippetID, 1)
+		require.NoError(t, err)
+		require.Len(t, edits, 1)
+		assert.Equal(t, "bob", edits[0].ClientID)
+		assert.Equal(t, 13, edits[0].Operation.TargetLength())
+
+		draft, err := pgStorage.Open(ctx, snippetID, "")
+		require.NoError(t, err)
+		assert.Equal(t, editing.Draft{SnippetID: snippetID, Content: "the quick fox", Revision: 2}, draft)
+	})
+
+	t.Run("Errors of rebase are passed as is", func(t *testing.T) {
+		rebaseErr := errors.New("rebase failed")
+		_, err := pgStorage.Append(ctx, snippetID, 2, "alice", func(editing.Draft, []editing.Edit) (editing.Operation, string, error) {
+			return editing.Operation{}, "", rebaseErr
+		})
+		assert.Equal(t, rebaseErr, err)
+	})
+
+	t.Run("Persist unsaved edits once", func(t *testing.T) {
+		var saved string
+		ok, err := pgStorage.Persist(ctx, snippetID, func(content string) error {
+			saved = content
+			return nil
+		})
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "the quick fox", saved)
+
+		ok, err = pgStorage.Persist(ctx, snippetID, func(string) error {
+			t.Error("the draft is already saved")
+			return nil
+		})
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("Notify about edits and presence", func(t *testing.T) {
+		listenCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		notifications := make(chan editing.Notification, 10)
+		go func() {
+			_ = pgStorage.Listen(listenCtx, nopslog.NewNoplogger(), func(_ context.Context, n editing.Notification) {
+				notifications <- n
+			})
+		}()
+
+		// LISTEN is issued asynchronously, so presence is announced until it's received
+		presence := editing.Presence{SnippetID: snippetID, ClientID: "alice", Cursor: &editing.Cursor{Position: 2}}
+		deadline := time.After(10 * time.Second)
+		for {
+			require.NoError(t, pgStorage.AnnouncePresence(ctx, presence))
+
+			select {
+			case n := <-notifications:
+				assert.Equal(t, editing.Notification{SnippetID: snippetID, Presence: &presence}, n)
+				return
+			case <-time.After(100 * time.Millisecond):
+			case <-deadline:
+				t.Fatal("no notification received")
+			}
+		}
+	})
+}
@@ -0,0 +1,239 @@
+package editing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/gorilla/schema"
+	"github.com/gorilla/websocket"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/api"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+// Settings of WebSocket connections
+const (
+	// maxClientMessageSize limits the size of a client message, an operation may insert a whole snippet
+	maxClientMessageSize = 4 << 20
+	// writeTimeout limits the time of sending a message to a client
+	writeTimeout = 10 * time.Second
+	// pongTimeout is how long a client may stay silent before it's disconnected
+	pongTimeout = 60 * time.Second
+	// pingInterval is how often clients are pinged, it must be less than pongTimeout
+	pingInterval = pongTimeout * 9 / 10
+)
+
+// Service is used to join live editing sessions
+type Service interface {
+	Join(ctx context.Context, snippetID uint, name string) (*Session, *service.Error)
+}
+
+// Transport is a struct that holds the live editing endpoint
+type Transport struct {
+	logger   *slog.Logger
+	service  Service
+	upgrader websocket.Upgrader
+}
+
+// NewTransport creates a new Transport instance
+func NewTransport(s Service, l *slog.Logger) *Transport {
+	return &Transport{
+		logger:  l,
+		service: s,
+		upgrader: websocket.Upgrader{
+			// The API is authenticated with tokens rather than cookies and allows any origin with CORS,
+			// so cross-origin connections get nothing a cross-origin request wouldn't
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+	}
+}
+
+// Routes initialize the endpoint for route /snippets/{snippet_id}/live.
+// The router must be mounted under a route with {snippet_id} URL param.
+func (t *Transport) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", t.joinSession)
+
+	return r
+}
+
+// joinSession is an endpoint for GET /snippets/{snippet_id}/live?name=<x> method.
+// The client joins before the connection is upgraded, so errors are rendered as usual.
+func (t *Transport) joinSession(w http.ResponseWriter, r *http.Request) {
+	snippetID, svcErr := parseID(r, "snippet_id")
+	if svcErr != nil {
+		t.logger.Error("failed to parse snippet id", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	var joinReq JoinRequest
+	if err := schema.NewDecoder().Decode(&joinReq, r.URL.Query()); err != nil {
+		t.logger.Error("failed to decode request params", slog.Any("err", err))
+		_ = render.Render(w, r, api.ErrBadRequest(err))
+		return
+	}
+
+	if validationErr := joinReq.Validate(); validationErr != nil {
+		t.logger.Info("request is not valid", slog.Any("validation_err", validationErr))
+		_ = render.Render(w, r, api.ErrBadRequest(validationErr))
+		return
+	}
+
+	session, svcErr := t.service.Join(r.Context(), snippetID, joinReq.Name)
+	if svcErr != nil {
+		t.logger.Error("failed to join session", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	// The session outlives the request context, since the connection is hijacked
+	leaveCtx := context.WithoutCancel(r.Context())
+	defer session.Leave(leaveCtx)
+
+	ctx, cancel := context.WithCancel(leaveCtx)
+	defer cancel()
+
+	conn, err := t.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied to the client
+		t.logger.Info("failed to upgrade connection", slog.Any("err", err))
+		return
+	}
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	replies := make(chan MessageResponse, 1)
+	readerDone := make(chan struct{})
+
+	go func() {
+		defer close(readerDone)
+		t.readMessages(ctx, conn, session, replies)
+	}()
+
+	t.writeMessages(conn, session, replies, readerDone)
+}
+
+// readMessages passes client messages to the session until the connection is closed.
+// Errors of client messages are sent to replies.
+func (t *Transport) readMessages(ctx context.Context, conn *websocket.Conn, session *Session, replies chan<- MessageResponse) {
+	conn.SetReadLimit(maxClientMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+
+	for {
+		var msgReq ClientMessageRequest
+		if err := conn.ReadJSON(&msgReq); err != nil {
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) {
+				t.logger.Info("failed to read client message", slog.Any("err", err))
+			}
+			return
+		}
+
+		if svcErr := handleClientMessage(ctx, session, msgReq); svcErr != nil {
+			t.logger.Info("client message is rejected", slog.Any("svc_err", svcErr))
+			select {
+			case replies <- MessageResponse{Type: MessageError, Error: svcErr.Error()}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// writeMessages sends session messages and replies to the client until the session or the connection is closed
+func (t *Transport) writeMessages(
+	conn *websocket.Conn,
+	session *Session,
+	replies <-chan MessageResponse,
+	readerDone <-chan struct{},
+) {
+	pingTicker := time.NewTicker(pingInterval)
+	defer pingTicker.Stop()
+
+	for {
+		var err error
+
+		select {
+		case <-readerDone:
+			return
+		case message, ok := <-session.Messages():
+			if !ok {
+				// The session is closed by the server, the reason is already sent
+				_ = conn.WriteControl(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+					time.Now().Add(writeTimeout),
+				)
+				return
+			}
+
+			_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			err = conn.WriteJSON(convertToMessageResponse(message))
+		case reply := <-replies:
+			_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			err = conn.WriteJSON(reply)
+		case <-pingTicker.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout))
+		}
+
+		if err != nil {
+			t.logger.Info("failed to write message", slog.Any("err", err))
+			return
+		}
+	}
+}
+
+// handleClientMessage validates a client message and passes it to the session
+func handleClientMessage(ctx context.Context, session *Session, msgReq ClientMessageRequest) *service.Error {
+	if validationErr := msgReq.Validate(); validationErr != nil {
+		return &service.Error{
+			Type: service.BadRequest,
+			Base: validationErr,
+		}
+	}
+
+	if msgReq.Type == ClientMessageEdit {
+		return session.Submit(ctx, msgReq.Revision, *msgReq.Operation)
+	}
+
+	var cursor *Cursor
+	if msgReq.Cursor != nil {
+		cursor = &Cursor{
+			Position:     msgReq.Cursor.Position,
+			SelectionEnd: msgReq.Cursor.SelectionEnd,
+		}
+	}
+
+	return session.MoveCursor(ctx, cursor)
+}
+
+func parseID(r *http.Request, param string) (uint, *service.Error) {
+	id, err := strconv.Atoi(chi.URLParam(r, param))
+	switch {
+	case err != nil:
+		return 0, &service.Error{
+			Type: service.BadRequest,
+			Base: err,
+		}
+	case id <= 0:
+		return 0, &service.Error{
+			Type: service.BadRequest,
+			Base: fmt.Errorf("invalid %s param: %d", param, id),
+		}
+	default:
+		return uint(id), nil
+	}
+}
@@ -0,0 +1,166 @@
+package editing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/editing"
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+)
+
+func TestTransport_joinSession(t *testing.T) {
+	t.Parallel()
+
+	fakeNow := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Edit over WebSocket", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockStorage := NewMockStorage(ctrl)
+		mockSnippets := NewMockSnippets(ctrl)
+
+		editingService := editing.NewService(mockStorage, mockSnippets, nopslog.NewNoplogger(), func() time.Time { return fakeNow })
+		transport := editing.NewTransport(editingService, nopslog.NewNoplogger())
+		handler := chi.NewRouter()
+		handler.Mount("/snippets/{snippet_id}/live", transport.Routes())
+
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+
+		// ================================================
+		// Describe mock calls
+		left := make(chan struct{})
+
+		mockSnippets.EXPECT().Get(gomock.Any(), uint(5)).Return(snippets.Snippet{ID: 5, Content: "fox", ExpiresAt: fakeNow.Add(time.Hour)}, nil)
+		mockStorage.EXPECT().Open(gomock.Any(), uint(5), "fox").Return(editing.Draft{SnippetID: 5, Content: "fox"}, nil)
+		mockStorage.EXPECT().Since(gomock.Any(), uint(5), uint64(0)).Return(nil, nil)
+
+		gomock.InOrder(
+			mockStorage.EXPECT().AnnouncePresence(gomock.Any(), gomock.Any()).Return(nil),
+			mockStorage.EXPECT().AnnouncePresence(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, presence editing.Presence) error {
+					assert.Equal(t, &editing.Cursor{Position: 1, SelectionEnd: 3}, presence.Cursor)
+					return nil
+				},
+			),
+			mockStorage.EXPECT().AnnouncePresence(gomock.Any(), gomock.Any()).Return(nil),
+			mockStorage.EXPECT().Persist(gomock.Any(), uint(5), gomock.Any()).DoAndReturn(
+				func(context.Context, uint, func(string) error) (bool, error) {
+					close(left)
+					return false, nil
+				},
+			),
+		)
+
+		// ================================================
+		// Run test
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/snippets/5/live?name=Alice"
+		conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		var init map[string]any
+		require.NoError(t, conn.ReadJSON(&init))
+		assert.Equal(t, "init", init["type"])
+		assert.Equal(t, "fox", init["content"])
+		assert.NotEmpty(t, init["client_id"])
+
+		require.NoError(t, conn.WriteJSON(map[string]any{"type": "edit"}))
+
+		var reply map[string]any
+		require.NoError(t, conn.ReadJSON(&reply))
+		assert.Equal(t, "error", reply["type"])
+		assert.Contains(t, reply["error"], "operation")
+
+		require.NoError(t, conn.WriteJSON(map[string]any{
+			"type":   "cursor",
+			"cursor": map[string]any{"position": 1, "selection_end": 3},
+		}))
+
+		require.NoError(t, conn.WriteMessage(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		))
+		_ = conn.Close()
+
+		select {
+		case <-left:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the session wasn't left")
+		}
+	})
+
+	t.Run("Protected snippet", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockSnippets := NewMockSnippets(ctrl)
+
+		editingService := editing.NewService(NewMockStorage(ctrl), mockSnippets, nopslog.NewNoplogger(), time.Now)
+		transport := editing.NewTransport(editingService, nopslog.NewNoplogger())
+		handler := chi.NewRouter()
+		handler.Mount("/snippets/{snippet_id}/live", transport.Routes())
+
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockSnippets.EXPECT().Get(gomock.Any(), uint(5)).Return(snippets.Snippet{
+			ID:         5,
+			ExpiresAt:  time.Now().Add(time.Hour),
+			Encryption: snippets.EncryptionPassphrase,
+		}, nil)
+
+		// ================================================
+		// Run test
+		expect.GET("/snippets/5/live").
+			Expect().
+			Status(http.StatusForbidden)
+	})
+
+	t.Run("Bad snippet id", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+
+		editingService := editing.NewService(NewMockStorage(ctrl), NewMockSnippets(ctrl), nopslog.NewNoplogger(), time.Now)
+		transport := editing.NewTransport(editingService, nopslog.NewNoplogger())
+		handler := chi.NewRouter()
+		handler.Mount("/snippets/{snippet_id}/live", transport.Routes())
+
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		expect.GET("/snippets/abc/live").
+			Expect().
+			Status(http.StatusBadRequest)
+	})
+}
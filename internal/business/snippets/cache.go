@@ -0,0 +1,245 @@
+package snippets
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/lru"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres"
+)
+
+// ChangeListener reports snippets updated or deleted by any replica, it's implemented by PGStorage.
+// listening is called every time changes are (re)subscribed to, changes made before may have been missed.
+type ChangeListener interface {
+	ListenChanged(ctx context.Context, logger *slog.Logger, listening func(), handle func(ids []uint)) error
+}
+
+// CacheStats represents counters of CachingStorage since the start
+type CacheStats struct {
+	Hits          uint64
+	Misses        uint64
+	Invalidations uint64
+	Evictions     uint64
+	Size          int
+}
+
+// CachingStorage is a Storage that keeps snippets returned by Get in an in-process LRU cache.
+// Concurrent misses of the same snippet are collapsed into a single read of the underlying storage.
+//
+// Snippets are dropped from the cache once they're created, updated or deleted through this storage,
+// or by any replica if there's a ChangeListener (see Run). Snippets are cached until they expire,
+// but no longer than the TTL, which also bounds staleness if a notification is missed.
+type CachingStorage struct {
+	Storage
+
+	cache    *lru.Cache[uint, Snippet]
+	ttl      time.Duration
+	now      func() time.Time
+	listener ChangeListener
+	logger   *slog.Logger
+
+	group singleflight.Group
+	// generation is incremented by every invalidation, a snippet read before it isn't cached
+	generation atomic.Uint64
+
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	invalidations atomic.Uint64
+}
+
+// CachingOption configures optional CachingStorage parameters
+type CachingOption func(*CachingStorage)
+
+// WithChangeListener makes the cache drop snippets changed by other replicas
+func WithChangeListener(listener ChangeListener) CachingOption {
+	return func(c *CachingStorage) {
+		c.listener = listener
+	}
+}
+
+// NewCachingStorage returns a new instance of CachingStorage caching up to size snippets for ttl
+func NewCachingStorage(
+	storage Storage,
+	size int,
+	ttl time.Duration,
+	logger *slog.Logger,
+	nowFunc func() time.Time,
+	opts ...CachingOption,
+) *CachingStorage {
+	c := &CachingStorage{
+		Storage: storage,
+		cache:   lru.New[uint, Snippet](size, nowFunc),
+		ttl:     ttl,
+		now:     nowFunc,
+		logger:  logger,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Get returns a cached snippet, or reads it from the underlying storage and caches it
+func (c *CachingStorage) Get(ctx context.Context, id uint) (Snippet, error) {
+	// A transaction sees its own uncommitted changes, they must not get into the cache
+	if postgres.InTx(ctx) {
+		return c.Storage.Get(ctx, id)
+	}
+
+	if snippet, ok := c.cache.Get(id); ok {
+		c.hits.Add(1)
+		return snippet, nil
+	}
+
+	c.misses.Add(1)
+
+	result := c.group.DoChan(cacheKey(id), func() (any, error) {
+		generation := c.generation.Load()
+
+		// The read isn't canceled with ctx, since other callers may wait for it.
+		// It goes to the primary, a lagging replica would keep a stale snippet in the cache until the TTL.
+		snippet, err := c.Storage.Get(postgres.ReadFromPrimary(context.WithoutCancel(ctx)), id)
+		if err == nil && c.generation.Load() == generation {
+			c.cache.Set(id, snippet, min(c.ttl, snippet.ExpiresAt.Sub(c.now())))
+		}
+
+		return snippet, err
+	})
+
+	select {
+	case <-ctx.Done():
+		return Snippet{}, ctx.Err()
+	case r := <-result:
+		if r.Err != nil {
+			return Snippet{}, r.Err
+		}
+		return r.Val.(Snippet), nil
+	}
+}
+
+// Create saves a snippet and drops its ID from the cache
+func (c *CachingStorage) Create(ctx context.Context, snippet Snippet) (uint, error) {
+	id, err := c.Storage.Create(ctx, snippet)
+	if err == nil {
+		c.Invalidate(id)
+	}
+
+	return id, err
+}
+
+// UpdateContent updates a snippet and drops it from the cache
+func (c *CachingStorage) UpdateContent(ctx context.Context, snippet Snippet) error {
+	err := c.Storage.UpdateContent(ctx, snippet)
+	c.Invalidate(snippet.ID)
+
+	return err
+}
+
+// SoftDelete deletes a snippet and drops it from the cache
+func (c *CachingStorage) SoftDelete(ctx context.Context, id uint) error {
+	err := c.Storage.SoftDelete(ctx, id)
+	c.Invalidate(id)
+
+	return err
+}
+
+// CreateBatch saves snippets and drops their IDs from the cache
+func (c *CachingStorage) CreateBatch(ctx context.Context, batch []Snippet) ([]uint, error) {
+	ids, err := c.Storage.CreateBatch(ctx, batch)
+	c.Invalidate(ids...)
+
+	return ids, err
+}
+
+// SoftDeleteBatch deletes snippets and drops them from the cache
+func (c *CachingStorage) SoftDeleteBatch(ctx context.Context, ids []uint) ([]uint, error) {
+	deleted, err := c.Storage.SoftDeleteBatch(ctx, ids)
+	c.Invalidate(ids...)
+
+	return deleted, err
+}
+
+// Invalidate drops snippets from the cache. Reads of them that are in progress aren't cached.
+func (c *CachingStorage) Invalidate(ids ...uint) {
+	if len(ids) == 0 {
+		return
+	}
+
+	c.generation.Add(1)
+
+	for _, id := range ids {
+		c.group.Forget(cacheKey(id))
+		c.cache.Delete(id)
+	}
+
+	c.invalidations.Add(uint64(len(ids)))
+}
+
+// Purge drops all snippets from the cache. Reads of them that are in progress aren't cached.
+func (c *CachingStorage) Purge() {
+	c.generation.Add(1)
+	c.cache.Purge()
+}
+
+// Stats returns counters of the cache
+func (c *CachingStorage) Stats() CacheStats {
+	return CacheStats{
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Invalidations: c.invalidations.Load(),
+		Evictions:     c.cache.Evictions(),
+		Size:          c.cache.Len(),
+	}
+}
+
+// Run drops snippets changed by other replicas from the cache, if there's a ChangeListener,
+// and logs cache statistics every statsInterval until ctx is done. The whole cache is purged every time
+// the listener (re)subscribes, since changes made while it was disconnected are missed.
+func (c *CachingStorage) Run(ctx context.Context, statsInterval time.Duration) error {
+	gr, ctx := errgroup.WithContext(ctx)
+
+	if c.listener != nil {
+		gr.Go(func() error {
+			return c.listener.ListenChanged(ctx, c.logger, c.Purge, func(ids []uint) {
+				c.Invalidate(ids...)
+			})
+		})
+	}
+
+	gr.Go(func() error {
+		ticker := time.NewTicker(statsInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+
+			stats := c.Stats()
+			c.logger.Info(
+				"📊 ➡ snippets cache statistics",
+				slog.Uint64("hits", stats.Hits),
+				slog.Uint64("misses", stats.Misses),
+				slog.Uint64("invalidations", stats.Invalidations),
+				slog.Uint64("evictions", stats.Evictions),
+				slog.Int("size", stats.Size),
+			)
+		}
+	})
+
+	return gr.Wait()
+}
+
+func cacheKey(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
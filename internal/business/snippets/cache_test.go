@@ -0,0 +1,291 @@
+package snippets_test
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+)
+
+func TestCachingStorage_Conformance(t *testing.T) {
+	t.Parallel()
+
+	testStorageConformance(t, func(*testing.T) conformanceStorage {
+		storage := snippets.NewMemoryStorage(time.Now)
+
+		return cachingConformanceStorage{
+			CachingStorage: snippets.NewCachingStorage(storage, 100, time.Minute, nopslog.NewNoplogger(), time.Now),
+			ViewStorage:    storage,
+		}
+	})
+}
+
+type cachingConformanceStorage struct {
+	*snippets.CachingStorage
+	snippets.ViewStorage
+}
+
+func TestCachingStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	snippet := snippets.Snippet{
+		ID:        1,
+		Title:     "Snippet title",
+		Content:   "Snippet content",
+		CreatedAt: now,
+		UpdatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+
+	newCache := func(t *testing.T) (*snippets.CachingStorage, *MockStorage, *time.Time) {
+		t.Helper()
+
+		mockStorage := NewMockStorage(gomock.NewController(t))
+		clock := now
+
+		cache := snippets.NewCachingStorage(
+			mockStorage,
+			10,
+			time.Minute,
+			nopslog.NewNoplogger(),
+			func() time.Time { return clock },
+		)
+
+		return cache, mockStorage, &clock
+	}
+
+	t.Run("Snippets are read once", func(t *testing.T) {
+		t.Parallel()
+
+		cache, mockStorage, _ := newCache(t)
+		mockStorage.EXPECT().Get(gomock.Any(), uint(1)).Return(snippet, nil).Times(1)
+
+		for range 3 {
+			got, err := cache.Get(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, snippet, got)
+		}
+
+		assert.Equal(t, snippets.CacheStats{Hits: 2, Misses: 1, Size: 1}, cache.Stats())
+	})
+
+	t.Run("Errors aren't cached", func(t *testing.T) {
+		t.Parallel()
+
+		cache, mockStorage, _ := newCache(t)
+		mockStorage.EXPECT().Get(gomock.Any(), uint(1)).Return(snippets.Snippet{}, snippets.ErrNotFound).Times(2)
+
+		for range 2 {
+			_, err := cache.Get(ctx, 1)
+			require.ErrorIs(t, err, snippets.ErrNotFound)
+		}
+	})
+
+	t.Run("Snippets are cached for the TTL, but not after they expire", func(t *testing.T) {
+		t.Parallel()
+
+		cache, mockStorage, clock := newCache(t)
+
+		expiring := snippet
+		expiring.ID = 2
+		expiring.ExpiresAt = now.Add(10 * time.Second)
+
+		gomock.InOrder(
+			mockStorage.EXPECT().Get(gomock.Any(), uint(1)).Return(snippet, nil),
+			mockStorage.EXPECT().Get(gomock.Any(), uint(2)).Return(expiring, nil),
+			mockStorage.EXPECT().Get(gomock.Any(), uint(2)).Return(snippets.Snippet{}, snippets.ErrNotFound),
+			mockStorage.EXPECT().Get(gomock.Any(), uint(1)).Return(snippet, nil),
+		)
+
+		_, err := cache.Get(ctx, 1)
+		require.NoError(t, err)
+		_, err = cache.Get(ctx, 2)
+		require.NoError(t, err)
+
+		*clock = now.Add(10 * time.Second)
+
+		_, err = cache.Get(ctx, 2)
+		require.ErrorIs(t, err, snippets.ErrNotFound)
+		_, err = cache.Get(ctx, 1)
+		require.NoError(t, err, "cached until the TTL")
+
+		*clock = now.Add(time.Minute)
+
+		_, err = cache.Get(ctx, 1)
+		require.NoError(t, err)
+	})
+
+	t.Run("Writes invalidate snippets", func(t *testing.T) {
+		t.Parallel()
+
+		cache, mockStorage, _ := newCache(t)
+
+		mockStorage.EXPECT().Get(gomock.Any(), uint(1)).Return(snippet, nil).Times(4)
+		mockStorage.EXPECT().UpdateContent(gomock.Any(), snippet).Return(nil)
+		mockStorage.EXPECT().SoftDelete(gomock.Any(), uint(1)).Return(nil)
+		mockStorage.EXPECT().SoftDeleteBatch(gomock.Any(), []uint{1, 2}).Return([]uint{1}, nil)
+
+		writes := []func() error{
+			func() error { return cache.UpdateContent(ctx, snippet) },
+			func() error { return cache.SoftDelete(ctx, 1) },
+			func() error {
+				_, err := cache.SoftDeleteBatch(ctx, []uint{1, 2})
+				return err
+			},
+		}
+
+		_, err := cache.Get(ctx, 1)
+		require.NoError(t, err)
+
+		for _, write := range writes {
+			require.NoError(t, write())
+
+			_, err = cache.Get(ctx, 1)
+			require.NoError(t, err)
+		}
+
+		assert.EqualValues(t, 4, cache.Stats().Invalidations)
+	})
+
+	t.Run("Concurrent misses are collapsed", func(t *testing.T) {
+		t.Parallel()
+
+		cache, mockStorage, _ := newCache(t)
+
+		release := make(chan struct{})
+		mockStorage.EXPECT().Get(gomock.Any(), uint(1)).DoAndReturn(func(context.Context, uint) (snippets.Snippet, error) {
+			<-release
+			return snippet, nil
+		}).Times(1)
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				got, err := cache.Get(ctx, 1)
+				assert.NoError(t, err)
+				assert.Equal(t, snippet, got)
+			}()
+		}
+
+		assert.Eventually(t, func() bool {
+			return cache.Stats().Misses == 10
+		}, time.Second, time.Millisecond)
+
+		close(release)
+		wg.Wait()
+	})
+
+	t.Run("Snippets invalidated while they're read aren't cached", func(t *testing.T) {
+		t.Parallel()
+
+		cache, mockStorage, _ := newCache(t)
+
+		gomock.InOrder(
+			mockStorage.EXPECT().Get(gomock.Any(), uint(1)).DoAndReturn(func(context.Context, uint) (snippets.Snippet, error) {
+				// Another replica changes the snippet after it's read
+				cache.Invalidate(1)
+				return snippet, nil
+			}),
+			mockStorage.EXPECT().Get(gomock.Any(), uint(1)).Return(snippet, nil),
+		)
+
+		for range 2 {
+			_, err := cache.Get(ctx, 1)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("Waiting for a read is canceled with the context", func(t *testing.T) {
+		t.Parallel()
+
+		cache, mockStorage, _ := newCache(t)
+
+		release := make(chan struct{})
+		t.Cleanup(func() { close(release) })
+
+		mockStorage.EXPECT().Get(gomock.Any(), uint(1)).DoAndReturn(func(context.Context, uint) (snippets.Snippet, error) {
+			<-release
+			return snippet, nil
+		}).AnyTimes()
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		t.Cleanup(cancel)
+
+		_, err := cache.Get(ctx, 1)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestCachingStorage_Run(t *testing.T) {
+	t.Parallel()
+
+	snippet := snippets.Snippet{ID: 1, Title: "Snippet title", ExpiresAt: time.Now().Add(time.Hour)}
+
+	mockStorage := NewMockStorage(gomock.NewController(t))
+	mockStorage.EXPECT().Get(gomock.Any(), uint(1)).Return(snippet, nil).Times(3)
+
+	listener := &fakeChangeListener{subscribed: make(chan fakeSubscription)}
+	cache := snippets.NewCachingStorage(
+		mockStorage,
+		10,
+		time.Minute,
+		nopslog.NewNoplogger(),
+		time.Now,
+		snippets.WithChangeListener(listener),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- cache.Run(ctx, time.Hour)
+	}()
+
+	subscription := <-listener.subscribed
+	subscription.listening()
+
+	_, err := cache.Get(ctx, 1)
+	require.NoError(t, err)
+
+	subscription.handle([]uint{1})
+
+	_, err = cache.Get(ctx, 1)
+	require.NoError(t, err, "the snippet changed by another replica is read again")
+
+	// Changes made while the listener reconnects are missed
+	subscription.listening()
+
+	_, err = cache.Get(ctx, 1)
+	require.NoError(t, err, "the cache is purged once the listener reconnects")
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+type fakeSubscription struct {
+	listening func()
+	handle    func([]uint)
+}
+
+type fakeChangeListener struct {
+	subscribed chan fakeSubscription
+}
+
+func (l *fakeChangeListener) ListenChanged(ctx context.Context, _ *slog.Logger, listening func(), handle func([]uint)) error {
+	l.subscribed <- fakeSubscription{listening: listening, handle: handle}
+	<-ctx.Done()
+	return nil
+}
@@ -0,0 +1,102 @@
+package snippets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+// maxSnippetCacheAge limits how long a snippet may be cached,
+// so soft-deleted snippets disappear from caches in a reasonable time.
+const maxSnippetCacheAge = time.Hour
+
+// snippetETag returns a strong validator of a snippet representation.
+func snippetETag(snippet Snippet) string {
+	h := sha256.New()
+	writeSnippetValidator(h, snippet)
+	return formatETag(h)
+}
+
+// listETag returns a strong validator of a snippets page.
+func listETag(snippets []Snippet, pagination service.Pagination) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%d:%d:%d:%d;", pagination.Total, pagination.Limit, pagination.Offset, len(snippets))
+
+	for i := range snippets {
+		writeSnippetValidator(h, snippets[i])
+	}
+
+	return formatETag(h)
+}
+
+// listLastModified returns the latest modification time of snippets on a page.
+func listLastModified(snippets []Snippet) time.Time {
+	var lastModified time.Time
+	for i := range snippets {
+		if snippets[i].UpdatedAt.After(lastModified) {
+			lastModified = snippets[i].UpdatedAt
+		}
+	}
+	return lastModified
+}
+
+func writeSnippetValidator(h hash.Hash, snippet Snippet) {
+	_, _ = fmt.Fprintf(h, "%d:%d;", snippet.ID, snippet.UpdatedAt.UnixNano())
+}
+
+func formatETag(h hash.Hash) string {
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// snippetCacheControl returns Cache-Control header value for a snippet.
+// Protected snippets are never stored by caches, the others are cached until they expire.
+// Requests are authorized, so only private caches of clients may store snippets.
+func snippetCacheControl(snippet Snippet, now time.Time) string {
+	if snippet.Protected() {
+		return "no-store"
+	}
+
+	maxAge := min(snippet.ExpiresAt.Sub(now), maxSnippetCacheAge)
+	if maxAge <= 0 {
+		return "no-cache"
+	}
+
+	return fmt.Sprintf("private, max-age=%d", int(maxAge.Seconds()))
+}
+
+// writeValidators sets ETag and Last-Modified headers
+// and reports whether the client already has the actual representation (see RFC 9110, section 13.2.2).
+func writeValidators(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag)
+	}
+
+	ifModifiedSince, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || lastModified.IsZero() {
+		return false
+	}
+
+	return !lastModified.Truncate(time.Second).After(ifModifiedSince)
+}
+
+// etagMatches implements the weak comparison used by If-None-Match.
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+package snippets_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"go.uber.org/mock/gomock"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+func TestTransport_getSnippet_ConditionalRequests(t *testing.T) {
+	t.Parallel()
+
+	fakeTimeCreated := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+	fakeTimeUpdated := time.Date(2020, 10, 8, 12, 0, 0, 0, time.UTC)
+	fakeTimeExpires := time.Date(2050, 1, 1, 1, 1, 1, 0, time.UTC)
+	fakeNow := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	snippet := snippets.Snippet{
+		ID:        100,
+		Title:     "Snippet #100",
+		Content:   "Very important text",
+		CreatedAt: fakeTimeCreated,
+		UpdatedAt: fakeTimeUpdated,
+		ExpiresAt: fakeTimeExpires,
+	}
+
+	newExpect := func(t *testing.T, snippet snippets.Snippet, times int) *httpexpect.Expect {
+		t.Helper()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Get(
+			gomock.Any(),
+			snippet.ID,
+		).Return(snippet, nil).Times(times)
+		mockService.EXPECT().Now().Return(fakeNow).AnyTimes()
+
+		// ================================================
+		// Create httpexpect instance
+		return httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+	}
+
+	t.Run("Validators and Cache-Control are set", func(t *testing.T) {
+		t.Parallel()
+
+		expect := newExpect(t, snippet, 1)
+
+		response := expect.GET("/{id}", snippet.ID).
+			Expect().
+			Status(http.StatusOK)
+
+		response.Header("ETag").NotEmpty()
+		response.Header("Last-Modified").IsEqual(fakeTimeUpdated.Format(http.TimeFormat))
+		response.Header("Cache-Control").IsEqual("private, max-age=3600")
+		response.Header("Vary").IsEqual("Authorization")
+	})
+
+	t.Run("Not modified: If-None-Match", func(t *testing.T) {
+		t.Parallel()
+
+		expect := newExpect(t, snippet, 3)
+
+		etag := expect.GET("/{id}", snippet.ID).
+			Expect().
+			Status(http.StatusOK).
+			Header("ETag").Raw()
+
+		expect.GET("/{id}", snippet.ID).
+			WithHeader("If-None-Match", `"other", `+etag).
+			Expect().
+			Status(http.StatusNotModified).
+			NoContent()
+
+		// Validators of a modified snippet don't match
+		expect.GET("/{id}", snippet.ID).
+			WithHeader("If-None-Match", `"other"`).
+			Expect().
+			Status(http.StatusOK)
+	})
+
+	t.Run("Not modified: If-Modified-Since", func(t *testing.T) {
+		t.Parallel()
+
+		expect := newExpect(t, snippet, 2)
+
+		expect.GET("/{id}", snippet.ID).
+			WithHeader("If-Modified-Since", fakeTimeUpdated.Format(http.TimeFormat)).
+			Expect().
+			Status(http.StatusNotModified)
+
+		expect.GET("/{id}", snippet.ID).
+			WithHeader("If-Modified-Since", fakeTimeCreated.Format(http.TimeFormat)).
+			Expect().
+			Status(http.StatusOK)
+	})
+
+	t.Run("If-None-Match takes precedence over If-Modified-Since", func(t *testing.T) {
+		t.Parallel()
+
+		expect := newExpect(t, snippet, 1)
+
+		expect.GET("/{id}", snippet.ID).
+			WithHeader("If-None-Match", `"other"`).
+			WithHeader("If-Modified-Since", fakeTimeUpdated.Format(http.TimeFormat)).
+			Expect().
+			Status(http.StatusOK)
+	})
+
+	t.Run("Cache-Control: snippet expires soon", func(t *testing.T) {
+		t.Parallel()
+
+		expiresSoon := snippet
+		expiresSoon.ExpiresAt = fakeNow.Add(time.Minute)
+
+		expect := newExpect(t, expiresSoon, 1)
+
+		expect.GET("/{id}", snippet.ID).
+			Expect().
+			Status(http.StatusOK).
+			Header("Cache-Control").IsEqual("private, max-age=60")
+	})
+
+	t.Run("Cache-Control: protected snippet", func(t *testing.T) {
+		t.Parallel()
+
+		protected := snippet
+		protected.Encryption = snippets.EncryptionPassphrase
+
+		expect := newExpect(t, protected, 1)
+
+		expect.GET("/{id}", snippet.ID).
+			Expect().
+			Status(http.StatusOK).
+			Header("Cache-Control").IsEqual("no-store")
+	})
+}
+
+func TestTransport_listSnippets_ConditionalRequests(t *testing.T) {
+	t.Parallel()
+
+	fakeTimeCreated := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+	fakeTimeUpdated := time.Date(2020, 10, 8, 12, 0, 0, 0, time.UTC)
+	fakeTimeExpires := time.Date(2050, 1, 1, 1, 1, 1, 0, time.UTC)
+
+	list := []snippets.Snippet{
+		{ID: 1, Title: "Snippet #1", CreatedAt: fakeTimeCreated, UpdatedAt: fakeTimeCreated, ExpiresAt: fakeTimeExpires},
+		{ID: 2, Title: "Snippet #2", CreatedAt: fakeTimeCreated, UpdatedAt: fakeTimeUpdated, ExpiresAt: fakeTimeExpires},
+	}
+	pagination := service.Pagination{Limit: 10, Total: 2, TotalPages: 1, CurrentPage: 1}
+
+	// ================================================
+	// Init mocks and service
+	ctrl := gomock.NewController(t)
+
+	mockService := NewMockService(ctrl)
+	transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+	handler := transport.Routes()
+
+	// ================================================
+	// Describe mock calls
+	gomock.InOrder(
+		mockService.EXPECT().List(gomock.Any(), uint(10), uint(0)).Return(list, pagination, nil).Times(2),
+		mockService.EXPECT().List(gomock.Any(), uint(10), uint(0)).Return(list[:1], pagination, nil),
+	)
+
+	// ================================================
+	// Create httpexpect instance
+	expect := httpexpect.WithConfig(httpexpect.Config{
+		Client: &http.Client{
+			Transport: httpexpect.NewBinder(handler),
+		},
+		Reporter: httpexpect.NewAssertReporter(t),
+	})
+
+	// ================================================
+	// Run test
+	response := expect.GET("/").
+		WithQuery("limit", 10).
+		Expect().
+		Status(http.StatusOK)
+
+	response.Header("Cache-Control").IsEqual("no-cache")
+	response.Header("Last-Modified").IsEqual(fakeTimeUpdated.Format(http.TimeFormat))
+
+	etag := response.Header("ETag").NotEmpty().Raw()
+
+	expect.GET("/").
+		WithQuery("limit", 10).
+		WithHeader("If-None-Match", etag).
+		Expect().
+		Status(http.StatusNotModified)
+
+	// The page has changed
+	expect.GET("/").
+		WithQuery("limit", 10).
+		WithHeader("If-None-Match", etag).
+		Expect().
+		Status(http.StatusOK).
+		Header("ETag").NotEqual(etag)
+}
@@ -0,0 +1,134 @@
+package snippets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is an algorithm used to compress snippet content in storage
+type Compression uint8
+
+const (
+	// CompressionNone means content is stored as is
+	CompressionNone Compression = iota
+	// CompressionGzip means content is compressed with gzip
+	CompressionGzip
+	// CompressionZstd means content is compressed with zstd
+	CompressionZstd
+)
+
+// DefaultCompressionThreshold is the minimal content size (in bytes) worth compressing
+const DefaultCompressionThreshold = 1024
+
+// ParseCompression converts a compression name into Compression
+func ParseCompression(name string) (Compression, error) {
+	switch name {
+	case "none":
+		return CompressionNone, nil
+	case "gzip":
+		return CompressionGzip, nil
+	case "zstd":
+		return CompressionZstd, nil
+	default:
+		return CompressionNone, fmt.Errorf("unknown compression: %q", name)
+	}
+}
+
+// ContentCodec compresses and decompresses snippets content
+type ContentCodec struct {
+	compression Compression
+	threshold   int
+
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+}
+
+// NewContentCodec returns a new ContentCodec compressing contents larger than threshold (in bytes)
+func NewContentCodec(compression Compression, threshold int) *ContentCodec {
+	// Both zstd constructors can only fail on invalid options
+	encoder, _ := zstd.NewWriter(nil)
+	decoder, _ := zstd.NewReader(nil)
+
+	return &ContentCodec{
+		compression: compression,
+		threshold:   threshold,
+		zstdEncoder: encoder,
+		zstdDecoder: decoder,
+	}
+}
+
+// ContentHash returns SHA-256 of the content, which is used as a content address
+func ContentHash(content string) []byte {
+	hash := sha256.Sum256([]byte(content))
+	return hash[:]
+}
+
+// Encode compresses content if it's larger than the threshold.
+// Compressed data is only used if it's actually smaller than the original content.
+func (c *ContentCodec) Encode(content string) ([]byte, Compression, error) {
+	data := []byte(content)
+
+	if c.compression == CompressionNone || len(data) < c.threshold {
+		return data, CompressionNone, nil
+	}
+
+	var compressed []byte
+
+	switch c.compression {
+	case CompressionZstd:
+		compressed = c.zstdEncoder.EncodeAll(data, nil)
+	case CompressionGzip:
+		var buf bytes.Buffer
+
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(data); err != nil {
+			return nil, CompressionNone, fmt.Errorf("gzip content: %w", err)
+		}
+
+		if err := writer.Close(); err != nil {
+			return nil, CompressionNone, fmt.Errorf("gzip content: %w", err)
+		}
+
+		compressed = buf.Bytes()
+	default:
+		return nil, CompressionNone, fmt.Errorf("unknown compression: %d", c.compression)
+	}
+
+	if len(compressed) >= len(data) {
+		return data, CompressionNone, nil
+	}
+
+	return compressed, c.compression, nil
+}
+
+// Decode decompresses content stored in storage
+func (c *ContentCodec) Decode(data []byte, compression Compression) (string, error) {
+	switch compression {
+	case CompressionNone:
+		return string(data), nil
+	case CompressionZstd:
+		decoded, err := c.zstdDecoder.DecodeAll(data, nil)
+		if err != nil {
+			return "", fmt.Errorf("zstd decode content: %w", err)
+		}
+		return string(decoded), nil
+	case CompressionGzip:
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", fmt.Errorf("gzip decode content: %w", err)
+		}
+
+		decoded, err := io.ReadAll(reader)
+		if err != nil {
+			return "", fmt.Errorf("gzip decode content: %w", err)
+		}
+		return string(decoded), nil
+	default:
+		return "", fmt.Errorf("unknown compression: %d", compression)
+	}
+}
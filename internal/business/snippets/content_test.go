@@ -0,0 +1,93 @@
+package snippets_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+)
+
+func TestContentCodec(t *testing.T) {
+	t.Parallel()
+
+	largeContent := strings.Repeat("server {\n\tlisten 80;\n}\n", 100)
+
+	tests := []struct {
+		name                string
+		compression         snippets.Compression
+		content             string
+		expectedCompression snippets.Compression
+	}{
+		{
+			name:                "Small content is not compressed",
+			compression:         snippets.CompressionZstd,
+			content:             "Some text here…",
+			expectedCompression: snippets.CompressionNone,
+		},
+		{
+			name:                "Compression is disabled",
+			compression:         snippets.CompressionNone,
+			content:             largeContent,
+			expectedCompression: snippets.CompressionNone,
+		},
+		{
+			name:                "Large content is compressed with zstd",
+			compression:         snippets.CompressionZstd,
+			content:             largeContent,
+			expectedCompression: snippets.CompressionZstd,
+		},
+		{
+			name:                "Large content is compressed with gzip",
+			compression:         snippets.CompressionGzip,
+			content:             largeContent,
+			expectedCompression: snippets.CompressionGzip,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			codec := snippets.NewContentCodec(tt.compression, snippets.DefaultCompressionThreshold)
+
+			data, compression, err := codec.Encode(tt.content)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCompression, compression)
+
+			if compression != snippets.CompressionNone {
+				assert.Less(t, len(data), len(tt.content))
+			}
+
+			decoded, err := codec.Decode(data, compression)
+			require.NoError(t, err)
+			assert.Equal(t, tt.content, decoded)
+		})
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, snippets.ContentHash("same content"), snippets.ContentHash("same content"))
+	assert.NotEqual(t, snippets.ContentHash("same content"), snippets.ContentHash("another content"))
+	assert.Len(t, snippets.ContentHash(""), 32)
+}
+
+func TestParseCompression(t *testing.T) {
+	t.Parallel()
+
+	for name, expected := range map[string]snippets.Compression{
+		"none": snippets.CompressionNone,
+		"gzip": snippets.CompressionGzip,
+		"zstd": snippets.CompressionZstd,
+	} {
+		actual, err := snippets.ParseCompression(name)
+		require.NoError(t, err)
+		assert.Equal(t, expected, actual)
+	}
+
+	_, err := snippets.ParseCompression("brotli")
+	require.Error(t, err)
+}
@@ -12,11 +12,74 @@ type ListSnippetsRequest struct {
 	Offset uint `schema:"offset"`
 }
 
+// ExportSnippetsRequest represents a request struct for GET /snippets/export?format=<x> method
+type ExportSnippetsRequest struct {
+	Format ExportFormat `schema:"format" json:"format"`
+}
+
+// Validate implements ozzo-validation.Validatable interface and used to check user request
+func (r *ExportSnippetsRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(
+			&r.Format,
+			validation.In(ExportFormatNDJSON, ExportFormatJSON, ExportFormatCSV),
+		),
+	)
+}
+
+// Default and max values of SnippetStatsRequest and PopularSnippetsRequest
+const (
+	defaultStatsDays    = 30
+	defaultPopularDays  = 7
+	defaultPopularLimit = 10
+	maxStatsDays        = 365
+	maxPopularLimit     = 100
+)
+
+// SnippetStatsRequest represents a request struct for GET /snippets/{snippet_id}/stats?days=<x> method
+type SnippetStatsRequest struct {
+	Days uint `schema:"days" json:"days"`
+}
+
+// Validate implements ozzo-validation.Validatable interface and used to check user request
+func (r *SnippetStatsRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Days, validation.Max(uint(maxStatsDays))),
+	)
+}
+
+// PopularSnippetsRequest represents a request struct for GET /snippets/popular?limit=<x>&days=<y> method
+type PopularSnippetsRequest struct {
+	Limit uint `schema:"limit" json:"limit"`
+	Days  uint `schema:"days" json:"days"`
+}
+
+// Validate implements ozzo-validation.Validatable interface and used to check user request
+func (r *PopularSnippetsRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Limit, validation.Max(uint(maxPopularLimit))),
+		validation.Field(&r.Days, validation.Max(uint(maxStatsDays))),
+	)
+}
+
+// MaxContentLength is the maximum length of a snippet content in characters
+const MaxContentLength = 10000
+
 // CreateSnippetRequest represents a request struct for POST /snippets method
 type CreateSnippetRequest struct {
 	Title     string    `json:"title"`
 	Content   string    `json:"content"`
 	ExpiresAt time.Time `json:"expires_at"`
+
+	// Template is optional, it marks the snippet as a template with declared variables
+	Template *Template `json:"template,omitempty"`
+
+	// Passphrase is an optional passphrase used to protect the snippet content.
+	// It's passed via PassphraseHeader and never appears in the request body.
+	Passphrase string `json:"-"`
 }
 
 // Validate implements ozzo-validation.Validatable interface and used to check user request
@@ -26,14 +89,126 @@ func (r *CreateSnippetRequest) Validate() error {
 	yearAfter := now.Add(366 * 24 * time.Hour)
 	rules := []*validation.FieldRules{
 		validation.Field(&r.Title, validation.Required, validation.Length(1, 100)),
-		validation.Field(&r.Content, validation.Required, validation.Length(1, 10000)),
+		validation.Field(&r.Content, validation.Required, validation.Length(1, MaxContentLength)),
 		validation.Field(
 			&r.ExpiresAt,
 			validation.Required,
 			validation.Min(now).Error("must be a valid RFC3339 date >= now"),
 			validation.Max(yearAfter).Error("must be a valid RFC3339 date <= now + 1 year"),
 		),
+		validation.Field(&r.Passphrase, validation.Length(8, 1024)),
+		validation.Field(&r.Template, validation.By(func(any) error {
+			if r.Template == nil {
+				return nil
+			}
+			return r.Template.Validate(r.Content)
+		})),
 	}
 
 	return validation.ValidateStruct(r, rules...)
 }
+
+// ForkSnippetRequest represents a request struct for POST /snippets/{snippet_id}/fork method.
+// All fields are optional: the values of the original snippet are used by default.
+type ForkSnippetRequest struct {
+	Title     string    `json:"title"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// Passphrase is used to read a protected snippet and to protect the fork.
+	// It's passed via PassphraseHeader and never appears in the request body.
+	Passphrase string `json:"-"`
+}
+
+// Validate implements ozzo-validation.Validatable interface and used to check user request
+func (r *ForkSnippetRequest) Validate() error {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	yearAfter := now.Add(366 * 24 * time.Hour)
+	rules := []*validation.FieldRules{
+		validation.Field(&r.Title, validation.Length(1, 100)),
+		validation.Field(
+			&r.ExpiresAt,
+			validation.Min(now).Error("must be a valid RFC3339 date >= now"),
+			validation.Max(yearAfter).Error("must be a valid RFC3339 date <= now + 1 year"),
+		),
+		validation.Field(&r.Passphrase, validation.Length(8, 1024)),
+	}
+
+	return validation.ValidateStruct(r, rules...)
+}
+
+// InstantiateSnippetRequest represents a request struct for POST /snippets/{snippet_id}/instantiate method
+type InstantiateSnippetRequest struct {
+	Values map[string]string `json:"values"`
+
+	// Save stores the rendered content as a new snippet.
+	// Title and ExpiresAt are optional: the values of the template are used by default.
+	Save      bool      `json:"save"`
+	Title     string    `json:"title"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// Passphrase is used to read a protected template and to protect the new snippet.
+	// It's passed via PassphraseHeader and never appears in the request body.
+	Passphrase string `json:"-"`
+}
+
+// Validate implements ozzo-validation.Validatable interface and used to check user request
+func (r *InstantiateSnippetRequest) Validate() error {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	yearAfter := now.Add(366 * 24 * time.Hour)
+	rules := []*validation.FieldRules{
+		validation.Field(
+			&r.Values,
+			validation.Length(0, maxTemplateVariables),
+			validation.Each(validation.RuneLength(0, MaxContentLength)),
+		),
+		validation.Field(&r.Title, validation.Length(1, 100)),
+		validation.Field(
+			&r.ExpiresAt,
+			validation.Min(now).Error("must be a valid RFC3339 date >= now"),
+			validation.Max(yearAfter).Error("must be a valid RFC3339 date <= now + 1 year"),
+		),
+		validation.Field(&r.Passphrase, validation.Length(8, 1024)),
+	}
+
+	return validation.ValidateStruct(r, rules...)
+}
+
+// maxBatchSize is the maximum number of items in a single batch request
+const maxBatchSize = 1000
+
+// BatchCreateSnippetsRequest represents a request struct for POST /snippets:batchCreate method
+type BatchCreateSnippetsRequest struct {
+	Snippets []CreateSnippetRequest `json:"snippets"`
+
+	// BestEffort disables atomic mode: valid snippets are created even if some other snippets are invalid or failed
+	BestEffort bool `json:"best_effort"`
+}
+
+// Validate implements ozzo-validation.Validatable interface and used to check user request.
+// It only checks the batch size, every snippet is validated separately to report per-item errors.
+func (r *BatchCreateSnippetsRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.Snippets, validation.Required, validation.Length(1, maxBatchSize)),
+	)
+}
+
+// BatchDeleteSnippetsRequest represents a request struct for POST /snippets:batchDelete method
+type BatchDeleteSnippetsRequest struct {
+	IDs []uint `json:"ids"`
+}
+
+// Validate implements ozzo-validation.Validatable interface and used to check user request
+func (r *BatchDeleteSnippetsRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(
+			&r.IDs,
+			validation.Required,
+			validation.Length(1, maxBatchSize),
+			validation.Each(validation.Required),
+		),
+	)
+}
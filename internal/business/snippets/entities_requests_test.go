@@ -32,6 +32,30 @@ func TestCreateSnippetRequest_Validate(t *testing.T) {
 			},
 			wantErr: "",
 		},
+		{
+			name: "Valid template",
+			request: snippets.CreateSnippetRequest{
+				Title:     "Valid title",
+				Content:   "I want to break {{what}}!",
+				ExpiresAt: monthAfter,
+				Template: &snippets.Template{Variables: []snippets.TemplateVariable{
+					{Name: "what", Default: "free"},
+				}},
+			},
+			wantErr: "",
+		},
+		{
+			name: "Invalid: template variable is not declared",
+			request: snippets.CreateSnippetRequest{
+				Title:     "Valid title",
+				Content:   "I want to break {{what}}!",
+				ExpiresAt: monthAfter,
+				Template: &snippets.Template{Variables: []snippets.TemplateVariable{
+					{Name: "who"},
+				}},
+			},
+			wantErr: "template: (what: is used in the content, but not declared.).",
+		},
 		{
 			name: "Invalid: expires_at is too big",
 			request: snippets.CreateSnippetRequest{
@@ -86,6 +110,252 @@ func TestCreateSnippetRequest_Validate(t *testing.T) {
 			},
 			wantErr: "content: the length must be between 1 and 10000.",
 		},
+		{
+			name: "Valid: with passphrase",
+			request: snippets.CreateSnippetRequest{
+				Title:      "Valid title",
+				Content:    "Valid content",
+				ExpiresAt:  monthAfter,
+				Passphrase: "correct horse battery staple",
+			},
+			wantErr: "",
+		},
+		{
+			name: "Invalid: passphrase is too short",
+			request: snippets.CreateSnippetRequest{
+				Title:      "Valid title",
+				Content:    "Valid content",
+				ExpiresAt:  monthAfter,
+				Passphrase: "short",
+			},
+			wantErr: "Passphrase: the length must be between 8 and 1024.",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.request.Validate()
+			testutils.AssertError(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestBatchCreateSnippetsRequest_Validate(t *testing.T) {
+	t.Parallel()
+
+	snippet := snippets.CreateSnippetRequest{
+		Title:     "Valid title",
+		Content:   "I want to break free!",
+		ExpiresAt: time.Now().UTC().Add(time.Hour * 24 * 30),
+	}
+
+	tests := []struct {
+		name    string
+		request snippets.BatchCreateSnippetsRequest
+		wantErr string
+	}{
+		{
+			name: "Valid BatchCreateSnippetsRequest",
+			request: snippets.BatchCreateSnippetsRequest{
+				Snippets: []snippets.CreateSnippetRequest{snippet, snippet},
+			},
+			wantErr: "",
+		},
+		{
+			name:    "Invalid: empty batch",
+			request: snippets.BatchCreateSnippetsRequest{},
+			wantErr: "snippets: cannot be blank.",
+		},
+		{
+			name: "Invalid: batch is too big",
+			request: snippets.BatchCreateSnippetsRequest{
+				Snippets: make([]snippets.CreateSnippetRequest, 1001),
+			},
+			wantErr: "snippets: the length must be between 1 and 1000.",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.request.Validate()
+			testutils.AssertError(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestBatchDeleteSnippetsRequest_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		request snippets.BatchDeleteSnippetsRequest
+		wantErr string
+	}{
+		{
+			name:    "Valid BatchDeleteSnippetsRequest",
+			request: snippets.BatchDeleteSnippetsRequest{IDs: []uint{1, 2, 3}},
+			wantErr: "",
+		},
+		{
+			name:    "Invalid: empty ids",
+			request: snippets.BatchDeleteSnippetsRequest{},
+			wantErr: "ids: cannot be blank.",
+		},
+		{
+			name:    "Invalid: zero id",
+			request: snippets.BatchDeleteSnippetsRequest{IDs: []uint{1, 0}},
+			wantErr: "ids: (1: cannot be blank.).",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.request.Validate()
+			testutils.AssertError(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestForkSnippetRequest_Validate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name    string
+		request snippets.ForkSnippetRequest
+		wantErr string
+	}{
+		{
+			name:    "Valid: empty request",
+			request: snippets.ForkSnippetRequest{},
+			wantErr: "",
+		},
+		{
+			name: "Valid ForkSnippetRequest",
+			request: snippets.ForkSnippetRequest{
+				Title:     "Valid title",
+				ExpiresAt: now.Add(time.Hour * 24 * 30),
+			},
+			wantErr: "",
+		},
+		{
+			name: "Invalid: expires_at is too small",
+			request: snippets.ForkSnippetRequest{
+				ExpiresAt: now.Add(-time.Hour),
+			},
+			wantErr: "expires_at: must be a valid RFC3339 date >= now.",
+		},
+		{
+			name: "Invalid: title is too long",
+			request: snippets.ForkSnippetRequest{
+				Title: strings.Repeat("a", 101),
+			},
+			wantErr: "title: the length must be between 1 and 100.",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.request.Validate()
+			testutils.AssertError(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestPopularSnippetsRequest_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		request snippets.PopularSnippetsRequest
+		wantErr string
+	}{
+		{
+			name:    "Valid: empty request",
+			request: snippets.PopularSnippetsRequest{},
+			wantErr: "",
+		},
+		{
+			name: "Valid PopularSnippetsRequest",
+			request: snippets.PopularSnippetsRequest{
+				Limit: 100,
+				Days:  365,
+			},
+			wantErr: "",
+		},
+		{
+			name: "Invalid: limit and days are too big",
+			request: snippets.PopularSnippetsRequest{
+				Limit: 101,
+				Days:  366,
+			},
+			wantErr: "days: must be no greater than 365; limit: must be no greater than 100.",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.request.Validate()
+			testutils.AssertError(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestInstantiateSnippetRequest_Validate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name    string
+		request snippets.InstantiateSnippetRequest
+		wantErr string
+	}{
+		{
+			name:    "Valid: empty request",
+			request: snippets.InstantiateSnippetRequest{},
+			wantErr: "",
+		},
+		{
+			name: "Valid InstantiateSnippetRequest",
+			request: snippets.InstantiateSnippetRequest{
+				Values:    map[string]string{"name": "Gopher"},
+				Save:      true,
+				Title:     "Valid title",
+				ExpiresAt: now.Add(time.Hour * 24 * 30),
+			},
+			wantErr: "",
+		},
+		{
+			name: "Invalid: title is too long",
+			request: snippets.InstantiateSnippetRequest{
+				Title: strings.Repeat("a", 101),
+			},
+			wantErr: "title: the length must be between 1 and 100.",
+		},
+		{
+			name: "Invalid: value is too long",
+			request: snippets.InstantiateSnippetRequest{
+				Values: map[string]string{"name": strings.Repeat("a", snippets.MaxContentLength+1)},
+			},
+			wantErr: "values: (name: the length must be no more than 10000.).",
+		},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -13,6 +13,10 @@ type SnippetResponse struct {
 	Content   string    `json:"content"`
 	CreatedAt time.Time `json:"created_at"`
 	ExpiresAt time.Time `json:"expires_at"`
+	Protected bool      `json:"protected"`
+	ParentID  uint      `json:"parent_id,omitempty"`
+	Lineage   []uint    `json:"lineage,omitempty"`
+	Template  *Template `json:"template,omitempty"`
 }
 
 // ListSnippetsResponse represents a response struct for GET /snippets?limit=<x>&offset=<y> method
@@ -21,6 +25,68 @@ type ListSnippetsResponse struct {
 	Pagination service.Pagination `json:"pagination"`
 }
 
+// BatchItemResponse represents a result of a single item of a batch request
+type BatchItemResponse struct {
+	Index   int              `json:"index"`
+	Status  int              `json:"status"`
+	ID      uint             `json:"id,omitempty"`
+	Snippet *SnippetResponse `json:"snippet,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// BatchResponse represents a response struct for POST /snippets:batchCreate and POST /snippets:batchDelete methods
+type BatchResponse struct {
+	Results []BatchItemResponse `json:"results"`
+}
+
+// InstantiateSnippetResponse represents a response struct for POST /snippets/{snippet_id}/instantiate method
+type InstantiateSnippetResponse struct {
+	Content string `json:"content"`
+	// Snippet is only set if the rendered content is saved as a new snippet
+	Snippet *SnippetResponse `json:"snippet,omitempty"`
+}
+
+// DailyViewsResponse represents views of a snippet during a day
+type DailyViewsResponse struct {
+	Date  string `json:"date"`
+	Views uint64 `json:"views"`
+}
+
+// SnippetStatsResponse represents a response struct for GET /snippets/{snippet_id}/stats method
+type SnippetStatsResponse struct {
+	SnippetID  uint                 `json:"snippet_id"`
+	TotalViews uint64               `json:"total_views"`
+	Daily      []DailyViewsResponse `json:"daily"`
+}
+
+// PopularSnippetResponse represents a snippet in a response for GET /snippets/popular method
+type PopularSnippetResponse struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+	Views uint64 `json:"views"`
+}
+
+// PopularSnippetsResponse represents a response struct for GET /snippets/popular method
+type PopularSnippetsResponse struct {
+	Snippets []PopularSnippetResponse `json:"snippets"`
+}
+
+// UsageResponse represents a response struct for GET /usage method
+type UsageResponse struct {
+	Caller         string        `json:"caller"`
+	Snippets       uint          `json:"snippets"`
+	ContentBytes   uint64        `json:"content_bytes"`
+	CreatedLastDay uint          `json:"created_last_day"`
+	Quota          QuotaResponse `json:"quota"`
+}
+
+// QuotaResponse represents a quota in a response for GET /usage method, zero values mean no limit
+type QuotaResponse struct {
+	MaxSnippets     uint   `json:"max_snippets"`
+	MaxContentBytes uint64 `json:"max_content_bytes"`
+	DailyCreations  uint   `json:"daily_creations"`
+}
+
 // convertToListSnippetsResponse is used to map []Snippet -> []SnippetResponse
 func convertToListSnippetsResponse(snippets []Snippet) []SnippetResponse {
 	response := make([]SnippetResponse, len(snippets))
@@ -39,5 +105,55 @@ func convertToSnippetResponse(snippet Snippet) SnippetResponse {
 		Content:   snippet.Content,
 		CreatedAt: snippet.CreatedAt,
 		ExpiresAt: snippet.ExpiresAt,
+		Protected: snippet.Protected(),
+		ParentID:  snippet.ParentID,
+		Lineage:   snippet.Lineage,
+		Template:  snippet.Template,
+	}
+}
+
+// convertToSnippetStatsResponse is used to map SnippetStats -> SnippetStatsResponse
+func convertToSnippetStatsResponse(stats SnippetStats) SnippetStatsResponse {
+	daily := make([]DailyViewsResponse, len(stats.Daily))
+	for i, v := range stats.Daily {
+		daily[i] = DailyViewsResponse{
+			Date:  v.Day.Format(time.DateOnly),
+			Views: v.Views,
+		}
+	}
+
+	return SnippetStatsResponse{
+		SnippetID:  stats.SnippetID,
+		TotalViews: stats.TotalViews,
+		Daily:      daily,
+	}
+}
+
+// convertToPopularSnippetsResponse is used to map []PopularSnippet -> PopularSnippetsResponse
+func convertToPopularSnippetsResponse(popular []PopularSnippet) PopularSnippetsResponse {
+	snippets := make([]PopularSnippetResponse, len(popular))
+	for i, p := range popular {
+		snippets[i] = PopularSnippetResponse{
+			ID:    p.ID,
+			Title: p.Title,
+			Views: p.Views,
+		}
+	}
+
+	return PopularSnippetsResponse{Snippets: snippets}
+}
+
+// convertToUsageResponse is used to map Usage -> UsageResponse
+func convertToUsageResponse(usage Usage) UsageResponse {
+	return UsageResponse{
+		Caller:         usage.Caller,
+		Snippets:       usage.Snippets,
+		ContentBytes:   usage.ContentBytes,
+		CreatedLastDay: usage.CreatedLastDay,
+		Quota: QuotaResponse{
+			MaxSnippets:     usage.Quota.MaxSnippets,
+			MaxContentBytes: usage.Quota.MaxContentBytes,
+			DailyCreations:  usage.Quota.DailyCreations,
+		},
 	}
 }
@@ -0,0 +1,122 @@
+package snippets
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+// Snippets lifecycle events
+const (
+	EventCreated events.Type = "snippet.created"
+	// EventUpdated is published when the content of a snippet is replaced
+	EventUpdated events.Type = "snippet.updated"
+	EventDeleted events.Type = "snippet.deleted"
+	EventExpired events.Type = "snippet.expired"
+)
+
+// expiredBatchSize is the maximum number of expired snippets announced or purged at once
+const expiredBatchSize = 100
+
+// EventTypes returns all types of snippets events
+func EventTypes() []events.Type {
+	return []events.Type{EventCreated, EventUpdated, EventDeleted, EventExpired}
+}
+
+// EventData is a payload of snippets events. The snippet content is never included.
+// Deleted snippets are described with ID only.
+type EventData struct {
+	ID        uint       `json:"id"`
+	Title     string     `json:"title,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Protected bool       `json:"protected,omitempty"`
+	ParentID  uint       `json:"parent_id,omitempty"`
+}
+
+func newEventData(snippet Snippet) EventData {
+	return EventData{
+		ID:        snippet.ID,
+		Title:     snippet.Title,
+		CreatedAt: &snippet.CreatedAt,
+		ExpiresAt: &snippet.ExpiresAt,
+		Protected: snippet.Protected(),
+		ParentID:  snippet.ParentID,
+	}
+}
+
+// NotifyExpired records EventExpired for snippets that have expired since the last call.
+// Deleted snippets don't expire.
+func (s *SnippetService) NotifyExpired(ctx context.Context) *service.Error {
+	for {
+		announced, err := s.storage.AnnounceExpired(ctx, expiredBatchSize)
+		if err != nil {
+			s.logger.Error("failed to announce expired snippets", slog.Any("err", err))
+			return &service.Error{
+				Type: service.InternalError,
+				Base: fmt.Errorf("failed to announce expired snippets: %w", err),
+			}
+		}
+
+		if announced < expiredBatchSize {
+			return nil
+		}
+	}
+}
+
+// RunExpiryNotifier calls NotifyExpired every interval until ctx is done
+func (s *SnippetService) RunExpiryNotifier(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			// Errors are already logged by NotifyExpired, the next tick retries.
+			_ = s.NotifyExpired(ctx)
+		}
+	}
+}
+
+// PurgeExpired deletes snippets that expired or were deleted more than retention ago, so their unshared contents
+// are released. Snippets are purged only after they're announced, an expired one waits for NotifyExpired.
+func (s *SnippetService) PurgeExpired(ctx context.Context, retention time.Duration) *service.Error {
+	before := s.now().Add(-retention)
+
+	for {
+		purged, err := s.storage.PurgeExpired(ctx, before, expiredBatchSize)
+		if err != nil {
+			s.logger.Error("failed to purge expired snippets", slog.Any("err", err))
+			return &service.Error{
+				Type: service.InternalError,
+				Base: fmt.Errorf("failed to purge expired snippets: %w", err),
+			}
+		}
+
+		if purged < expiredBatchSize {
+			return nil
+		}
+	}
+}
+
+// RunExpiredPurge calls PurgeExpired every interval until ctx is done
+func (s *SnippetService) RunExpiredPurge(ctx context.Context, interval, retention time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			// Errors are already logged by PurgeExpired, the next tick retries.
+			_ = s.PurgeExpired(ctx, retention)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package snippets_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+func TestSnippetService_NotifyExpired(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Announce expired snippets batch by batch", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(mockStorage, nopslog.NewNoplogger(), time.Now)
+
+		// ===============================================
+		// Describe Mock Calls
+		gomock.InOrder(
+			mockStorage.EXPECT().AnnounceExpired(ctx, uint(100)).Return(uint(100), nil),
+			mockStorage.EXPECT().AnnounceExpired(ctx, uint(100)).Return(uint(3), nil),
+		)
+
+		// ===============================================
+		// Run Test
+		assert.Nil(t, snippetService.NotifyExpired(ctx))
+	})
+
+	t.Run("Storage error", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(mockStorage, nopslog.NewNoplogger(), time.Now)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().AnnounceExpired(ctx, gomock.Any()).Return(uint(0), errors.New("connection refused"))
+
+		// ===============================================
+		// Run Test
+		svcErr := snippetService.NotifyExpired(ctx)
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.InternalError, svcErr.Type)
+	})
+}
+
+func TestSnippetService_PurgeExpired(t *testing.T) {
+	t.Parallel()
+
+	fakeNow := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Purge expired snippets batch by batch", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(mockStorage, nopslog.NewNoplogger(), func() time.Time { return fakeNow })
+
+		// ===============================================
+		// Describe Mock Calls
+		before := fakeNow.Add(-24 * time.Hour)
+		gomock.InOrder(
+			mockStorage.EXPECT().PurgeExpired(ctx, before, uint(100)).Return(uint(100), nil),
+			mockStorage.EXPECT().PurgeExpired(ctx, before, uint(100)).Return(uint(3), nil),
+		)
+
+		// ===============================================
+		// Run Test
+		assert.Nil(t, snippetService.PurgeExpired(ctx, 24*time.Hour))
+	})
+
+	t.Run("Storage error", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(mockStorage, nopslog.NewNoplogger(), func() time.Time { return fakeNow })
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().PurgeExpired(ctx, gomock.Any(), gomock.Any()).Return(uint(0), errors.New("connection refused"))
+
+		// ===============================================
+		// Run Test
+		svcErr := snippetService.PurgeExpired(ctx, 24*time.Hour)
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.InternalError, svcErr.Type)
+	})
+}
@@ -0,0 +1,313 @@
+package snippets
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ExportFormat is a file format used to export and import snippets
+type ExportFormat string
+
+const (
+	// ExportFormatNDJSON is newline-delimited JSON: one snippet object per line
+	ExportFormatNDJSON ExportFormat = "ndjson"
+	// ExportFormatJSON is a JSON array of snippet objects
+	ExportFormatJSON ExportFormat = "json"
+	// ExportFormatCSV is CSV with a header row
+	ExportFormatCSV ExportFormat = "csv"
+)
+
+// maxNDJSONLineSize limits the size of a single NDJSON line on import
+const maxNDJSONLineSize = 1 << 20
+
+// ContentType returns the MIME type of the format
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportFormatJSON:
+		return "application/json"
+	case ExportFormatCSV:
+		return "text/csv"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// exporter streams snippets to an HTTP response in the given format.
+// Headers are sent with the first snippet, so an error can still be rendered until then.
+type exporter struct {
+	w       http.ResponseWriter
+	format  ExportFormat
+	started bool
+	count   int
+
+	encoder   *json.Encoder
+	csvWriter *csv.Writer
+}
+
+func newExporter(w http.ResponseWriter, format ExportFormat) *exporter {
+	return &exporter{
+		w:      w,
+		format: format,
+	}
+}
+
+// Write writes a single snippet
+func (e *exporter) Write(snippet SnippetResponse) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	e.count++
+
+	switch e.format {
+	case ExportFormatCSV:
+		return e.csvWriter.Write([]string{
+			strconv.FormatUint(uint64(snippet.ID), 10),
+			snippet.Title,
+			snippet.Content,
+			snippet.CreatedAt.Format(time.RFC3339),
+			snippet.ExpiresAt.Format(time.RFC3339),
+		})
+	case ExportFormatJSON:
+		if e.count > 1 {
+			if _, err := io.WriteString(e.w, ","); err != nil {
+				return err
+			}
+		}
+		return e.encoder.Encode(snippet)
+	default:
+		return e.encoder.Encode(snippet)
+	}
+}
+
+// Close finishes the document
+func (e *exporter) Close() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+
+	switch e.format {
+	case ExportFormatCSV:
+		e.csvWriter.Flush()
+		return e.csvWriter.Error()
+	case ExportFormatJSON:
+		_, err := io.WriteString(e.w, "]\n")
+		return err
+	default:
+		return nil
+	}
+}
+
+// Started reports whether the response has been started
+func (e *exporter) Started() bool {
+	return e.started
+}
+
+func (e *exporter) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+
+	e.w.Header().Set("Content-Type", e.format.ContentType())
+	e.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="snippets.%s"`, e.format))
+	e.w.WriteHeader(http.StatusOK)
+
+	switch e.format {
+	case ExportFormatCSV:
+		e.csvWriter = csv.NewWriter(e.w)
+		return e.csvWriter.Write([]string{"id", "title", "content", "created_at", "expires_at"})
+	case ExportFormatJSON:
+		e.encoder = json.NewEncoder(e.w)
+		_, err := io.WriteString(e.w, "[")
+		return err
+	default:
+		e.encoder = json.NewEncoder(e.w)
+		return nil
+	}
+}
+
+// ReadSnippets reads snippets from r in the given format and calls fn for every record.
+// The record number is a line number for NDJSON and CSV and a position in the array for JSON.
+// Records that can't be parsed are passed to fn with a non-nil error, so the caller can report them and continue.
+// Reading stops when fn returns an error.
+func ReadSnippets(r io.Reader, format ExportFormat, fn func(record int, req CreateSnippetRequest, err error) error) error {
+	switch format {
+	case ExportFormatNDJSON:
+		return readNDJSON(r, fn)
+	case ExportFormatJSON:
+		return readJSON(r, fn)
+	case ExportFormatCSV:
+		return readCSV(r, fn)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// ImportSnippets reads snippets from r in the given format, validates them with CreateSnippetRequest.Validate
+// and calls create for every valid one. Records that can't be parsed, are invalid or fail to be created
+// are passed to report and skipped. An error is returned only if r can't be read any further.
+func ImportSnippets(
+	ctx context.Context,
+	r io.Reader,
+	format ExportFormat,
+	create func(ctx context.Context, snippet Snippet) error,
+	report func(record int, err error),
+) (imported int, failed int, err error) {
+	err = ReadSnippets(r, format, func(record int, req CreateSnippetRequest, err error) error {
+		if err == nil {
+			err = req.Validate()
+		}
+
+		if err == nil {
+			err = create(ctx, Snippet{
+				Title:     req.Title,
+				Content:   req.Content,
+				ExpiresAt: req.ExpiresAt,
+				Template:  req.Template,
+			})
+		}
+
+		if err != nil {
+			failed++
+			report(record, err)
+			return nil
+		}
+
+		imported++
+		return nil
+	})
+
+	return imported, failed, err
+}
+
+func readNDJSON(r io.Reader, fn func(record int, req CreateSnippetRequest, err error) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxNDJSONLineSize)
+
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var req CreateSnippetRequest
+		err := json.Unmarshal(data, &req)
+
+		if err = fn(line, req, err); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read NDJSON: %w", err)
+	}
+
+	return nil
+}
+
+func readJSON(r io.Reader, fn func(record int, req CreateSnippetRequest, err error) error) error {
+	decoder := json.NewDecoder(r)
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return errors.New("read JSON: expected an array of snippets")
+	}
+
+	for record := 1; decoder.More(); record++ {
+		var req CreateSnippetRequest
+
+		err := decoder.Decode(&req)
+
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+			// The decoder can't recover from malformed JSON
+			return fmt.Errorf("read JSON: record %d: %w", record, err)
+		}
+
+		if err = fn(record, req, err); err != nil {
+			return err
+		}
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("read JSON: %w", err)
+	}
+
+	return nil
+}
+
+func readCSV(r io.Reader, fn func(record int, req CreateSnippetRequest, err error) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("read CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+
+	for _, name := range []string{"title", "content", "expires_at"} {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("read CSV header: column %q is missing", name)
+		}
+	}
+
+	for {
+		row, err := reader.Read()
+
+		var (
+			line     int
+			req      CreateSnippetRequest
+			parseErr *csv.ParseError
+		)
+
+		switch {
+		case err == nil:
+			line, _ = reader.FieldPos(0)
+			req, err = parseCSVRow(row, columns)
+		case errors.Is(err, io.EOF):
+			return nil
+		case errors.As(err, &parseErr):
+			line = parseErr.StartLine
+		default:
+			return fmt.Errorf("read CSV: %w", err)
+		}
+
+		if err = fn(line, req, err); err != nil {
+			return err
+		}
+	}
+}
+
+func parseCSVRow(row []string, columns map[string]int) (CreateSnippetRequest, error) {
+	field := func(name string) string {
+		if i := columns[name]; i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, field("expires_at"))
+	if err != nil {
+		return CreateSnippetRequest{}, fmt.Errorf("expires_at: %w", err)
+	}
+
+	return CreateSnippetRequest{
+		Title:     field("title"),
+		Content:   field("content"),
+		ExpiresAt: expiresAt,
+	}, nil
+}
@@ -0,0 +1,254 @@
+package snippets_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+)
+
+func TestReadSnippets(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Date(2050, 1, 1, 1, 1, 1, 0, time.UTC)
+
+	type record struct {
+		Record  int
+		Request snippets.CreateSnippetRequest
+		Err     bool
+	}
+
+	tests := []struct {
+		name    string
+		format  snippets.ExportFormat
+		input   string
+		want    []record
+		wantErr string
+	}{
+		{
+			name:   "NDJSON",
+			format: snippets.ExportFormatNDJSON,
+			input: `{"id":1,"title":"First","content":"Line 1\nLine 2","expires_at":"2050-01-01T01:01:01Z"}
+
+{"title":"Second"
+{"title":"Third","content":"Content","expires_at":"2050-01-01T01:01:01Z"}
+`,
+			want: []record{
+				{Record: 1, Request: snippets.CreateSnippetRequest{Title: "First", Content: "Line 1\nLine 2", ExpiresAt: expiresAt}},
+				{Record: 3, Err: true},
+				{Record: 4, Request: snippets.CreateSnippetRequest{Title: "Third", Content: "Content", ExpiresAt: expiresAt}},
+			},
+		},
+		{
+			name:   "JSON",
+			format: snippets.ExportFormatJSON,
+			input: `[
+				{"id":1,"title":"First","content":"Content","expires_at":"2050-01-01T01:01:01Z"},
+				{"title":42},
+				{"title":"Third","content":"Content","expires_at":"2050-01-01T01:01:01Z"}
+			]`,
+			want: []record{
+				{Record: 1, Request: snippets.CreateSnippetRequest{Title: "First", Content: "Content", ExpiresAt: expiresAt}},
+				{Record: 2, Err: true},
+				{Record: 3, Request: snippets.CreateSnippetRequest{Title: "Third", Content: "Content", ExpiresAt: expiresAt}},
+			},
+		},
+		{
+			name:    "JSON: not an array",
+			format:  snippets.ExportFormatJSON,
+			input:   `{"title":"First"}`,
+			wantErr: "read JSON: expected an array of snippets",
+		},
+		{
+			name:   "CSV",
+			format: snippets.ExportFormatCSV,
+			input: `id,title,content,created_at,expires_at
+1,First,"Line 1
+Line 2",2020-10-07T12:00:00Z,2050-01-01T01:01:01Z
+2,Second,Content,2020-10-07T12:00:00Z,tomorrow
+3,Third,Content,2020-10-07T12:00:00Z,2050-01-01T01:01:01Z
+`,
+			want: []record{
+				{Record: 2, Request: snippets.CreateSnippetRequest{Title: "First", Content: "Line 1\nLine 2", ExpiresAt: expiresAt}},
+				{Record: 4, Err: true},
+				{Record: 5, Request: snippets.CreateSnippetRequest{Title: "Third", Content: "Content", ExpiresAt: expiresAt}},
+			},
+		},
+		{
+			name:    "CSV: missing column",
+			format:  snippets.ExportFormatCSV,
+			input:   "title,content\nFirst,Content\n",
+			wantErr: `read CSV header: column "expires_at" is missing`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []record
+
+			err := snippets.ReadSnippets(
+				strings.NewReader(tt.input),
+				tt.format,
+				func(n int, req snippets.CreateSnippetRequest, err error) error {
+					if err != nil {
+						got = append(got, record{Record: n, Err: true})
+						return nil
+					}
+					got = append(got, record{Record: n, Request: req})
+					return nil
+				},
+			)
+
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestImportSnippets(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+
+	t.Run("Exported snippets are imported back", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+
+		source := snippets.NewService(snippets.NewMemoryStorage(time.Now), nopslog.NewNoplogger(), time.Now)
+		for _, snippet := range []snippets.Snippet{
+			{Title: "Snippet #1", Content: "Content #1", ExpiresAt: expiresAt},
+			{Title: "Snippet, #2", Content: "Content\n\"#2\"", ExpiresAt: expiresAt.Add(time.Hour)},
+		} {
+			_, svcErr := source.Create(ctx, snippet)
+			require.Nil(t, svcErr)
+		}
+
+		handler := snippets.NewTransport(source, nopslog.NewNoplogger()).Routes()
+		want := exportedSnippets(t, source)
+		require.Len(t, want, 2)
+
+		for _, format := range []snippets.ExportFormat{
+			snippets.ExportFormatNDJSON,
+			snippets.ExportFormatJSON,
+			snippets.ExportFormatCSV,
+		} {
+			t.Run(string(format), func(t *testing.T) {
+				t.Parallel()
+
+				recorder := httptest.NewRecorder()
+				handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/export?format="+string(format), nil))
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				target := snippets.NewService(snippets.NewMemoryStorage(time.Now), nopslog.NewNoplogger(), time.Now)
+				create := func(ctx context.Context, snippet snippets.Snippet) error {
+					if _, svcErr := target.Create(ctx, snippet); svcErr != nil {
+						return svcErr
+					}
+					return nil
+				}
+				report := func(record int, err error) {
+					t.Errorf("record %d is not imported: %v", record, err)
+				}
+
+				imported, failed, err := snippets.ImportSnippets(ctx, recorder.Body, format, create, report)
+				require.NoError(t, err)
+				assert.Equal(t, 2, imported)
+				assert.Zero(t, failed)
+				assert.Equal(t, want, exportedSnippets(t, target))
+			})
+		}
+	})
+
+	t.Run("Malformed and invalid NDJSON lines are reported and skipped", func(t *testing.T) {
+		t.Parallel()
+
+		input := `{"title":"First","content":"Content","expires_at":"` + expiresAt.Format(time.RFC3339) + `"}
+{"title":"Second","content":
+{"title":"","content":"Content","expires_at":"` + expiresAt.Format(time.RFC3339) + `"}
+{"title":"Fourth","content":"Content","expires_at":"` + expiresAt.Format(time.RFC3339) + `"}
+`
+
+		var (
+			created  []string
+			reported []int
+		)
+
+		imported, failed, err := snippets.ImportSnippets(
+			context.Background(),
+			strings.NewReader(input),
+			snippets.ExportFormatNDJSON,
+			func(_ context.Context, snippet snippets.Snippet) error {
+				created = append(created, snippet.Title)
+				return nil
+			},
+			func(record int, err error) {
+				assert.Error(t, err)
+				reported = append(reported, record)
+			},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, 2, imported)
+		assert.Equal(t, 2, failed)
+		assert.Equal(t, []string{"First", "Fourth"}, created)
+		assert.Equal(t, []int{2, 3}, reported)
+	})
+
+	t.Run("Failed creations are reported", func(t *testing.T) {
+		t.Parallel()
+
+		errFailed := errors.New("failed")
+		input := `{"title":"First","content":"Content","expires_at":"` + expiresAt.Format(time.RFC3339) + `"}
+`
+
+		var reported []error
+
+		imported, failed, err := snippets.ImportSnippets(
+			context.Background(),
+			strings.NewReader(input),
+			snippets.ExportFormatNDJSON,
+			func(context.Context, snippets.Snippet) error { return errFailed },
+			func(_ int, err error) { reported = append(reported, err) },
+		)
+		require.NoError(t, err)
+		assert.Zero(t, imported)
+		assert.Equal(t, 1, failed)
+		assert.Equal(t, []error{errFailed}, reported)
+	})
+}
+
+// exportedSnippets returns titles, contents and expiration times of all snippets of the service
+func exportedSnippets(t *testing.T, snippetService *snippets.SnippetService) []snippets.Snippet {
+	t.Helper()
+
+	var exported []snippets.Snippet
+
+	svcErr := snippetService.Export(context.Background(), func(snippet snippets.Snippet) error {
+		exported = append(exported, snippets.Snippet{
+			Title:     snippet.Title,
+			Content:   snippet.Content,
+			ExpiresAt: snippet.ExpiresAt,
+		})
+		return nil
+	})
+	require.Nil(t, svcErr)
+
+	return exported
+}
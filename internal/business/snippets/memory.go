@@ -0,0 +1,425 @@
+package snippets
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+// MemoryStorage implements storage interface and keeps snippets in memory.
+// It's meant for local development and tests, and has the same semantics as PGStorage:
+// expired and deleted snippets are filtered out the same way, and the results are ordered the same way.
+//
+// Events are not recorded, since there's no outbox to relay them from.
+type MemoryStorage struct {
+	now func() time.Time
+
+	mu       sync.RWMutex
+	lastID   uint
+	snippets map[uint]*memorySnippet
+	views    map[viewKey]uint64
+}
+
+type memorySnippet struct {
+	Snippet
+	expiryNotified bool
+}
+
+// NewMemoryStorage returns a new instance of MemoryStorage
+func NewMemoryStorage(nowFunc func() time.Time) *MemoryStorage {
+	return &MemoryStorage{
+		now:      nowFunc,
+		snippets: make(map[uint]*memorySnippet),
+		views:    make(map[viewKey]uint64),
+	}
+}
+
+// Get returns a single snippet from storage
+func (m *MemoryStorage) Get(_ context.Context, id uint) (Snippet, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored, ok := m.snippets[id]
+	if !ok {
+		return Snippet{}, ErrNotFound
+	}
+
+	return stored.read(), nil
+}
+
+// Create saves a single snippet to storage
+func (m *MemoryStorage) Create(_ context.Context, snippet Snippet) (uint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.insert(snippet), nil
+}
+
+// UpdateContent replaces the content of a snippet along with its encryption parameters.
+// Deleted and expired snippets are not updated, ErrNotFound is returned for them.
+func (m *MemoryStorage) UpdateContent(_ context.Context, snippet Snippet) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.snippets[snippet.ID]
+	if !ok || !stored.active(m.now()) {
+		return ErrNotFound
+	}
+
+	stored.Content = snippet.Content
+	stored.Encryption = snippet.Encryption
+	stored.WrappedKey = slices.Clone(snippet.WrappedKey)
+	stored.UpdatedAt = snippet.UpdatedAt
+	stored.ContentSize = snippet.ContentSize
+
+	return nil
+}
+
+// List returns a list of snippets from storage
+func (m *MemoryStorage) List(_ context.Context, pagination service.Pagination) ([]Snippet, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	active := m.activeSnippets()
+	slices.SortStableFunc(active, func(a, b *memorySnippet) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+
+	start := min(int(pagination.Offset), len(active))
+	end := len(active)
+	if pagination.Limit != 0 {
+		end = min(start+int(pagination.Limit), end)
+	}
+
+	var results []Snippet
+	for _, stored := range active[start:end] {
+		results = append(results, stored.read())
+	}
+
+	return results, nil
+}
+
+// Iterate calls fn for every active snippet ordered by id.
+// Snippets are copied before the first call, so fn may use the storage.
+func (m *MemoryStorage) Iterate(_ context.Context, fn func(Snippet) error) error {
+	m.mu.RLock()
+	active := m.activeSnippets()
+	batch := make([]Snippet, len(active))
+	for i, stored := range active {
+		batch[i] = stored.read()
+	}
+	m.mu.RUnlock()
+
+	for _, snippet := range batch {
+		if err := fn(snippet); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Lineage returns IDs of the snippet ancestors, starting from its parent up to the original snippet
+func (m *MemoryStorage) Lineage(_ context.Context, id uint) ([]uint, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var lineage []uint
+	for stored, ok := m.snippets[id]; ok && stored.ParentID != 0; stored, ok = m.snippets[stored.ParentID] {
+		lineage = append(lineage, stored.ParentID)
+	}
+
+	return lineage, nil
+}
+
+// SoftDelete set `expires_at` to `now()`, so snippet is considered deleted
+func (m *MemoryStorage) SoftDelete(ctx context.Context, id uint) error {
+	deleted, err := m.SoftDeleteBatch(ctx, []uint{id})
+	switch {
+	case err != nil:
+		return err
+	case len(deleted) == 0:
+		return ErrNotFound
+	default:
+		return nil
+	}
+}
+
+// Total counts a total number of snippets
+func (m *MemoryStorage) Total(_ context.Context) (uint, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return uint(len(m.snippets)), nil
+}
+
+// Usage returns the consumption of an API caller: active snippets, the total size of their contents
+// and the number of snippets created since the given time
+func (m *MemoryStorage) Usage(_ context.Context, caller string, since time.Time) (Usage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := m.now()
+
+	var usage Usage
+	for _, stored := range m.snippets {
+		if stored.Caller != caller {
+			continue
+		}
+
+		if stored.active(now) {
+			usage.Snippets++
+			usage.ContentBytes += uint64(stored.ContentSize)
+		}
+
+		if stored.CreatedAt.After(since) {
+			usage.CreatedLastDay++
+		}
+	}
+
+	return usage, nil
+}
+
+// LockQuota does nothing, SnippetService serializes quota checks within the process
+func (m *MemoryStorage) LockQuota(_ context.Context, _ string) error {
+	return nil
+}
+
+// CreateBatch saves several snippets to storage. IDs are returned in the order of passed snippets.
+// As for PGStorage, parent IDs of the batch are not stored.
+func (m *MemoryStorage) CreateBatch(_ context.Context, batch []Snippet) ([]uint, error) {
+	if len(batch) == 0 {
+		return nil, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]uint, len(batch))
+	for i, snippet := range batch {
+		snippet.ParentID = 0
+		ids[i] = m.insert(snippet)
+	}
+
+	return ids, nil
+}
+
+// SoftDeleteBatch marks several live snippets as deleted and returns their IDs, already deleted ones are skipped
+func (m *MemoryStorage) SoftDeleteBatch(_ context.Context, ids []uint) ([]uint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	var deleted []uint
+	for _, id := range ids {
+		stored := m.snippets[id]
+		if !stored.active(now) {
+			continue
+		}
+
+		stored.UpdatedAt = now
+		stored.ExpiresAt = now
+		stored.expiryNotified = true
+		deleted = append(deleted, id)
+	}
+
+	return deleted, nil
+}
+
+// AddViews adds views counters to storage
+func (m *MemoryStorage) AddViews(_ context.Context, views []DailyViews) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, v := range views {
+		if _, ok := m.snippets[v.SnippetID]; !ok {
+			continue
+		}
+
+		m.views[viewKey{snippetID: v.SnippetID, day: truncateToDay(v.Day)}] += v.Views
+	}
+
+	return nil
+}
+
+// Views returns the total amount of views of a snippet and its daily views starting from the since day
+func (m *MemoryStorage) Views(_ context.Context, id uint, since time.Time) (uint64, []DailyViews, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	since = truncateToDay(since)
+
+	var (
+		total uint64
+		daily []DailyViews
+	)
+	for key, views := range m.views {
+		if key.snippetID != id {
+			continue
+		}
+
+		total += views
+		if !key.day.Before(since) {
+			daily = append(daily, DailyViews{SnippetID: id, Day: key.day, Views: views})
+		}
+	}
+
+	slices.SortFunc(daily, func(a, b DailyViews) int {
+		return a.Day.Compare(b.Day)
+	})
+
+	return total, daily, nil
+}
+
+// Popular returns active snippets with the most views starting from the since day
+func (m *MemoryStorage) Popular(_ context.Context, since time.Time, limit uint) ([]PopularSnippet, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	since = truncateToDay(since)
+	now := m.now()
+
+	totals := make(map[uint]uint64)
+	for key, views := range m.views {
+		if !key.day.Before(since) && m.snippets[key.snippetID].active(now) {
+			totals[key.snippetID] += views
+		}
+	}
+
+	results := make([]PopularSnippet, 0, len(totals))
+	for id, views := range totals {
+		results = append(results, PopularSnippet{ID: id, Title: m.snippets[id].Title, Views: views})
+	}
+
+	slices.SortFunc(results, func(a, b PopularSnippet) int {
+		return cmp.Or(cmp.Compare(b.Views, a.Views), cmp.Compare(a.ID, b.ID))
+	})
+
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	return results[:min(int(limit), len(results))], nil
+}
+
+// PurgeExpired deletes up to limit snippets that expired before the time and are already announced or deleted,
+// and returns the number of deleted snippets with their views. Forks of deleted snippets lose their parent.
+func (m *MemoryStorage) PurgeExpired(_ context.Context, before time.Time, limit uint) (uint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var expired []*memorySnippet
+	for _, stored := range m.snippets {
+		if stored.expiryNotified && !stored.ExpiresAt.After(before) {
+			expired = append(expired, stored)
+		}
+	}
+
+	slices.SortFunc(expired, func(a, b *memorySnippet) int {
+		return cmp.Or(a.ExpiresAt.Compare(b.ExpiresAt), cmp.Compare(a.ID, b.ID))
+	})
+
+	expired = expired[:min(int(limit), len(expired))]
+	for _, stored := range expired {
+		delete(m.snippets, stored.ID)
+	}
+
+	for _, stored := range m.snippets {
+		if _, ok := m.snippets[stored.ParentID]; !ok {
+			stored.ParentID = 0
+		}
+	}
+
+	for key := range m.views {
+		if _, ok := m.snippets[key.snippetID]; !ok {
+			delete(m.views, key)
+		}
+	}
+
+	return uint(len(expired)), nil
+}
+
+// AnnounceExpired marks up to limit snippets that have expired, but haven't been announced yet,
+// and returns the number of announced snippets. Deleted snippets are never announced.
+func (m *MemoryStorage) AnnounceExpired(_ context.Context, limit uint) (uint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	var expired []*memorySnippet
+	for _, stored := range m.snippets {
+		if !stored.expiryNotified && !stored.active(now) {
+			expired = append(expired, stored)
+		}
+	}
+
+	slices.SortFunc(expired, func(a, b *memorySnippet) int {
+		return cmp.Or(a.ExpiresAt.Compare(b.ExpiresAt), cmp.Compare(a.ID, b.ID))
+	})
+
+	expired = expired[:min(int(limit), len(expired))]
+	for _, stored := range expired {
+		stored.expiryNotified = true
+	}
+
+	return uint(len(expired)), nil
+}
+
+// insert stores a copy of a snippet with the next ID, m.mu must be held
+func (m *MemoryStorage) insert(snippet Snippet) uint {
+	m.lastID++
+
+	stored := &memorySnippet{Snippet: cloneSnippet(snippet)}
+	stored.ID = m.lastID
+	stored.Lineage = nil
+	m.snippets[stored.ID] = stored
+
+	return stored.ID
+}
+
+// activeSnippets returns snippets that are neither expired nor deleted ordered by id, m.mu must be held
+func (m *MemoryStorage) activeSnippets() []*memorySnippet {
+	now := m.now()
+
+	var active []*memorySnippet
+	for _, id := range slices.Sorted(maps.Keys(m.snippets)) {
+		if stored := m.snippets[id]; stored.active(now) {
+			active = append(active, stored)
+		}
+	}
+
+	return active
+}
+
+func (s *memorySnippet) active(now time.Time) bool {
+	return s != nil && s.ExpiresAt.After(now)
+}
+
+// read returns a copy of a stored snippet. Caller and ContentSize are not read back, as for PGStorage.
+func (s *memorySnippet) read() Snippet {
+	snippet := cloneSnippet(s.Snippet)
+	snippet.Caller = ""
+	snippet.ContentSize = 0
+
+	return snippet
+}
+
+// cloneSnippet copies a snippet, so the stored one isn't changed by callers
+func cloneSnippet(snippet Snippet) Snippet {
+	snippet.PassphraseSalt = slices.Clone(snippet.PassphraseSalt)
+	snippet.WrappedKey = slices.Clone(snippet.WrappedKey)
+	snippet.Lineage = slices.Clone(snippet.Lineage)
+
+	if snippet.Template != nil {
+		snippet.Template = &Template{Variables: slices.Clone(snippet.Template.Variables)}
+	}
+
+	return snippet
+}
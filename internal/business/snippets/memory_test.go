@@ -0,0 +1,91 @@
+package snippets_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	t.Parallel()
+
+	testStorageConformance(t, func(*testing.T) conformanceStorage {
+		return snippets.NewMemoryStorage(time.Now)
+	})
+}
+
+func TestMemoryStorage_Copies(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	storage := snippets.NewMemoryStorage(time.Now)
+
+	snippet := snippets.Snippet{
+		Title:          "Snippet title",
+		Content:        "Snippet content",
+		ExpiresAt:      time.Now().Add(time.Hour),
+		PassphraseSalt: []byte("salt"),
+		Template: &snippets.Template{
+			Variables: []snippets.TemplateVariable{{Name: "name"}},
+		},
+	}
+
+	id, err := storage.Create(ctx, snippet)
+	require.NoError(t, err)
+
+	// Neither the created snippet nor the read one share memory with the stored one
+	snippet.PassphraseSalt[0] = 'S'
+	snippet.Template.Variables[0].Name = "changed"
+
+	got, err := storage.Get(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("salt"), got.PassphraseSalt)
+	assert.Equal(t, "name", got.Template.Variables[0].Name)
+
+	got.Template.Variables[0].Name = "changed"
+
+	got, err = storage.Get(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "name", got.Template.Variables[0].Name)
+}
+
+func TestMemoryStorage_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	storage := snippets.NewMemoryStorage(time.Now)
+
+	const writers = 10
+
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			id, err := storage.Create(ctx, snippets.Snippet{
+				Title:     fmt.Sprintf("Snippet title #%d", i),
+				ExpiresAt: time.Now().Add(time.Hour),
+			})
+			assert.NoError(t, err)
+
+			_, err = storage.List(ctx, service.Pagination{Limit: 5})
+			assert.NoError(t, err)
+
+			assert.NoError(t, storage.AddViews(ctx, []snippets.DailyViews{{SnippetID: id, Day: time.Now(), Views: 1}}))
+		}()
+	}
+	wg.Wait()
+
+	total, err := storage.Total(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, writers, total)
+}
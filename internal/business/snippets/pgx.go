@@ -0,0 +1,818 @@
+package snippets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+// PGXStorage is a variant of PGStorage on top of a native pgx pool instead of database/sql.
+// It uses the same tables and passes the same conformance suite, but skips the database/sql layer:
+// statements are cached by pgx, and batches are sent in a single round trip.
+//
+// It exists to benchmark both paths and isn't a drop-in replacement of PGStorage: it doesn't join transactions
+// of the context (see postgres.TxManager), doesn't read from replicas and doesn't notify other replicas
+// of changed snippets, so their caches keep stale snippets until the TTL.
+type PGXStorage struct {
+	pool  *pgxpool.Pool
+	codec *ContentCodec
+}
+
+// PGXStorageOption configures optional PGXStorage parameters
+type PGXStorageOption func(*PGXStorage)
+
+// WithPGXCompression sets an algorithm used to compress contents larger than threshold (in bytes)
+func WithPGXCompression(compression Compression, threshold int) PGXStorageOption {
+	return func(pg *PGXStorage) {
+		pg.codec = NewContentCodec(compression, threshold)
+	}
+}
+
+// NewPGXStorage returns a new instance of PGXStorage
+func NewPGXStorage(pool *pgxpool.Pool, opts ...PGXStorageOption) *PGXStorage {
+	pg := &PGXStorage{
+		pool:  pool,
+		codec: NewContentCodec(CompressionZstd, DefaultCompressionThreshold),
+	}
+
+	for _, opt := range opts {
+		opt(pg)
+	}
+
+	return pg
+}
+
+// pgxSnippetColumns are columns read by scanSnippet
+const pgxSnippetColumns = `
+	s.id,
+	s.title,
+	c.data,
+	c.compression,
+	s.created_at,
+	s.updated_at,
+	s.expires_at,
+	s.encryption,
+	s.passphrase_salt,
+	s.wrapped_key,
+	COALESCE(s.parent_id, 0),
+	s.template
+`
+
+// Get returns a single snippet from storage
+func (pg *PGXStorage) Get(ctx context.Context, id uint) (Snippet, error) {
+	query := `
+		SELECT ` + pgxSnippetColumns + `
+		FROM
+			snippets s
+			JOIN snippet_contents c ON c.hash = s.content_hash
+		WHERE s.id = $1
+	`
+
+	snippet, err := pg.scanSnippet(pg.pool.QueryRow(ctx, query, id))
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return Snippet{}, ErrNotFound
+	case err != nil:
+		return Snippet{}, err
+	}
+
+	return snippet, nil
+}
+
+// Create saves a single snippet to storage
+func (pg *PGXStorage) Create(ctx context.Context, snippet Snippet) (uint, error) {
+	ids, err := pg.insert(ctx, []Snippet{snippet})
+	if err != nil {
+		return 0, fmt.Errorf("failed to add snippet: %w", err)
+	}
+
+	return ids[0], nil
+}
+
+// UpdateContent replaces the content of a snippet along with its encryption parameters.
+// Deleted and expired snippets are not updated, ErrNotFound is returned for them.
+func (pg *PGXStorage) UpdateContent(ctx context.Context, snippet Snippet) error {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("failed to update snippet content: %w", err)
+	}
+
+	data, compression, err := pg.codec.Encode(snippet.Content)
+	if err != nil {
+		return wrapErr(fmt.Errorf("failed to encode content: %w", err))
+	}
+
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		return wrapErr(err)
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	hash := ContentHash(snippet.Content)
+
+	batch := &pgx.Batch{}
+	batch.Queue(pgxSaveContentQuery, hash, data, compression, len(snippet.Content))
+	batch.Queue(
+		`
+		UPDATE snippets
+		SET
+			content_hash = $2,
+			encryption = $3,
+			wrapped_key = $4,
+			updated_at = $5,
+			content_size = $6
+		WHERE
+			id = $1
+			AND expires_at > NOW()
+		`,
+		snippet.ID,
+		hash,
+		snippet.Encryption,
+		snippet.WrappedKey,
+		snippet.UpdatedAt,
+		snippet.ContentSize,
+	)
+
+	results := tx.SendBatch(ctx, batch)
+	if _, err = results.Exec(); err != nil {
+		_ = results.Close()
+		return wrapErr(fmt.Errorf("failed to save content: %w", err))
+	}
+
+	tag, err := results.Exec()
+	if err != nil {
+		_ = results.Close()
+		return wrapErr(err)
+	}
+
+	if err = results.Close(); err != nil {
+		return wrapErr(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	if err = writeEvents(ctx, postgres.PgxExecer(tx), EventUpdated, []Snippet{snippet}); err != nil {
+		return wrapErr(err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return wrapErr(err)
+	}
+
+	return nil
+}
+
+// List returns a list of snippets from storage
+func (pg *PGXStorage) List(ctx context.Context, pagination service.Pagination) ([]Snippet, error) {
+	query := `
+		SELECT ` + pgxSnippetColumns + `
+		FROM snippets s
+			JOIN snippet_contents c ON c.hash = s.content_hash
+		WHERE
+			s.expires_at > NOW()
+		ORDER BY s.created_at DESC
+		%s
+	`
+
+	rows, err := pg.pool.Query(ctx, fmt.Sprintf(query, ConvertPaginationToSQLExpression(pagination)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snippets: %w", err)
+	}
+
+	defer rows.Close()
+
+	var results []Snippet
+	for rows.Next() {
+		snippet, err := pg.scanSnippet(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, snippet)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error from iterating snippets rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// Iterate calls fn for every active snippet ordered by id.
+// Rows are streamed from the database, so the whole result set is never held in memory.
+func (pg *PGXStorage) Iterate(ctx context.Context, fn func(Snippet) error) error {
+	query := `
+		SELECT ` + pgxSnippetColumns + `
+		FROM snippets s
+			JOIN snippet_contents c ON c.hash = s.content_hash
+		WHERE
+			s.expires_at > NOW()
+		ORDER BY s.id
+	`
+
+	rows, err := pg.pool.Query(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to iterate snippets: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		snippet, err := pg.scanSnippet(rows)
+		if err != nil {
+			return err
+		}
+
+		if err = fn(snippet); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error from iterating snippets rows: %w", err)
+	}
+
+	return nil
+}
+
+// scanSnippet scans a snippet row joined with its content. pgx.ErrNoRows is returned as is.
+func (pg *PGXStorage) scanSnippet(row pgx.Row) (Snippet, error) {
+	var (
+		snippet     Snippet
+		data        []byte
+		compression Compression
+		template    []byte
+	)
+
+	err := row.Scan(
+		&snippet.ID,
+		&snippet.Title,
+		&data,
+		&compression,
+		&snippet.CreatedAt,
+		&snippet.UpdatedAt,
+		&snippet.ExpiresAt,
+		&snippet.Encryption,
+		&snippet.PassphraseSalt,
+		&snippet.WrappedKey,
+		&snippet.ParentID,
+		&template,
+	)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return Snippet{}, err
+	case err != nil:
+		return Snippet{}, fmt.Errorf("failed to scan snippet row: %w", err)
+	}
+
+	if snippet.Content, err = pg.codec.Decode(data, compression); err != nil {
+		return Snippet{}, fmt.Errorf("failed to decode snippet content: %w", err)
+	}
+
+	if snippet.Template, err = unmarshalTemplate(template); err != nil {
+		return Snippet{}, err
+	}
+
+	return snippet, nil
+}
+
+// Lineage returns IDs of the snippet ancestors, starting from its parent up to the original snippet
+func (pg *PGXStorage) Lineage(ctx context.Context, id uint) ([]uint, error) {
+	query := `
+		WITH RECURSIVE lineage AS (
+			SELECT parent_id, 1 AS depth
+			FROM snippets
+			WHERE id = $1
+			UNION ALL
+			SELECT s.parent_id, l.depth + 1
+			FROM snippets s
+				JOIN lineage l ON s.id = l.parent_id
+		)
+		SELECT parent_id
+		FROM lineage
+		WHERE parent_id IS NOT NULL
+		ORDER BY depth
+	`
+
+	rows, err := pg.pool.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query snippet lineage: %w", err)
+	}
+
+	// Rows are appended to nil, so a snippet without ancestors has nil lineage as with PGStorage
+	var lineage []uint
+	lineage, err = pgx.AppendRows(lineage, rows, pgx.RowTo[uint])
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan snippet lineage: %w", err)
+	}
+
+	return lineage, nil
+}
+
+// SoftDelete set `expires_at` to `now()`, so snippet is considered deleted
+func (pg *PGXStorage) SoftDelete(ctx context.Context, id uint) error {
+	deleted, err := pg.SoftDeleteBatch(ctx, []uint{id})
+	switch {
+	case err != nil:
+		return fmt.Errorf("failed to soft delete snippet from DB (ID: %d): %w", id, err)
+	case len(deleted) == 0:
+		return ErrNotFound
+	default:
+		return nil
+	}
+}
+
+// Total counts a total number of snippets
+func (pg *PGXStorage) Total(ctx context.Context) (uint, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM snippets
+	`
+
+	var count uint
+	err := pg.pool.QueryRow(ctx, query).Scan(&count)
+	return count, err
+}
+
+// Usage returns the consumption of an API caller: active snippets, the total size of their contents
+// and the number of snippets created since the given time
+func (pg *PGXStorage) Usage(ctx context.Context, caller string, since time.Time) (Usage, error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE expires_at > NOW()),
+			COALESCE(SUM(content_size) FILTER (WHERE expires_at > NOW()), 0),
+			COUNT(*) FILTER (WHERE created_at > $2)
+		FROM snippets
+		WHERE caller = $1
+	`
+
+	var usage Usage
+	if err := pg.pool.QueryRow(ctx, query, caller, since).Scan(
+		&usage.Snippets,
+		&usage.ContentBytes,
+		&usage.CreatedLastDay,
+	); err != nil {
+		return Usage{}, fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// LockQuota does nothing: transactions of the context aren't joined, so an advisory lock would be released
+// right away. Quota checks are serialized within the process only.
+func (pg *PGXStorage) LockQuota(_ context.Context, _ string) error {
+	return nil
+}
+
+// CreateBatch saves several snippets to storage within a single transaction.
+// All statements are sent in a single round trip, IDs are returned in the order of passed snippets.
+// As for PGStorage, parent IDs of the batch are not stored.
+func (pg *PGXStorage) CreateBatch(ctx context.Context, batch []Snippet) ([]uint, error) {
+	if len(batch) == 0 {
+		return nil, nil
+	}
+
+	originals := make([]Snippet, len(batch))
+	for i, snippet := range batch {
+		snippet.ParentID = 0
+		originals[i] = snippet
+	}
+
+	ids, err := pg.insert(ctx, originals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add batch of snippets: %w", err)
+	}
+
+	return ids, nil
+}
+
+// SoftDeleteBatch marks several live snippets as deleted and returns their IDs, already deleted ones are skipped
+func (pg *PGXStorage) SoftDeleteBatch(ctx context.Context, ids []uint) ([]uint, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("failed to soft delete batch of snippets from DB: %w", err)
+	}
+
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	query := `
+		UPDATE snippets
+		SET
+			updated_at = NOW(),
+			expires_at = NOW(),
+			expiry_notified = TRUE
+		WHERE
+			id = ANY($1)
+			AND expires_at > NOW()
+		RETURNING id, updated_at
+	`
+
+	params := make([]int64, len(ids))
+	for i, id := range ids {
+		params[i] = int64(id)
+	}
+
+	rows, err := tx.Query(ctx, query, params)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+
+	var (
+		deleted []uint
+		batch   []events.Event
+	)
+	for rows.Next() {
+		var (
+			id        uint
+			deletedAt time.Time
+		)
+		if err = rows.Scan(&id, &deletedAt); err != nil {
+			rows.Close()
+			return nil, wrapErr(err)
+		}
+
+		event, err := events.New(EventDeleted, deletedAt, EventData{ID: id})
+		if err != nil {
+			rows.Close()
+			return nil, wrapErr(err)
+		}
+
+		deleted = append(deleted, id)
+		batch = append(batch, event)
+	}
+
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, wrapErr(err)
+	}
+
+	if err = postgres.WriteOutbox(ctx, postgres.PgxExecer(tx), batch...); err != nil {
+		return nil, wrapErr(err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, wrapErr(err)
+	}
+
+	return deleted, nil
+}
+
+// pgxSaveContentQuery saves content to the content-addressed table.
+// If the same content already exists, only its reference counter is incremented.
+const pgxSaveContentQuery = `
+	INSERT INTO snippet_contents
+	(
+		hash,
+		data,
+		compression,
+		size,
+		ref_count
+	)
+	VALUES
+	(
+		$1,
+		$2,
+		$3,
+		$4,
+		1
+	)
+	ON CONFLICT (hash) DO UPDATE
+	SET ref_count = snippet_contents.ref_count + 1
+`
+
+// insert saves snippets with their contents within a single transaction and returns their IDs.
+// Every content and snippet is a separate statement of a single batch, so content shared by several snippets
+// has its reference counter incremented once per snippet, as with PGStorage.
+func (pg *PGXStorage) insert(ctx context.Context, snippets []Snippet) ([]uint, error) {
+	query := `
+		INSERT INTO snippets
+		(
+			title,
+			content_hash,
+			created_at,
+			updated_at,
+			expires_at,
+			encryption,
+			passphrase_salt,
+			wrapped_key,
+			parent_id,
+			template,
+			caller,
+			content_size
+		)
+		VALUES
+		(
+			$1,
+			$2,
+			$3,
+			$4,
+			$5,
+			$6,
+			$7,
+			$8,
+			NULLIF($9, 0),
+			$10,
+			$11,
+			$12
+		)
+		RETURNING id
+	`
+
+	batch := &pgx.Batch{}
+	for _, snippet := range snippets {
+		data, compression, err := pg.codec.Encode(snippet.Content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode content: %w", err)
+		}
+
+		template, err := marshalTemplate(snippet.Template)
+		if err != nil {
+			return nil, err
+		}
+
+		hash := ContentHash(snippet.Content)
+
+		batch.Queue(pgxSaveContentQuery, hash, data, compression, len(snippet.Content))
+		batch.Queue(
+			query,
+			snippet.Title,
+			hash,
+			snippet.CreatedAt,
+			snippet.UpdatedAt,
+			snippet.ExpiresAt,
+			snippet.Encryption,
+			snippet.PassphraseSalt,
+			snippet.WrappedKey,
+			int64(snippet.ParentID),
+			template,
+			snippet.Caller,
+			snippet.ContentSize,
+		)
+	}
+
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	results := tx.SendBatch(ctx, batch)
+
+	ids := make([]uint, len(snippets))
+	for i := range snippets {
+		if _, err = results.Exec(); err != nil {
+			_ = results.Close()
+			return nil, fmt.Errorf("failed to save content: %w", err)
+		}
+
+		if err = results.QueryRow().Scan(&ids[i]); err != nil {
+			_ = results.Close()
+			return nil, err
+		}
+	}
+
+	if err = results.Close(); err != nil {
+		return nil, err
+	}
+
+	created := make([]Snippet, len(snippets))
+	for i := range snippets {
+		created[i] = snippets[i]
+		created[i].ID = ids[i]
+	}
+
+	if err = writeEvents(ctx, postgres.PgxExecer(tx), EventCreated, created); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// AddViews adds views counters to storage
+func (pg *PGXStorage) AddViews(ctx context.Context, views []DailyViews) error {
+	query := `
+		INSERT INTO snippet_views
+		(
+			snippet_id,
+			day,
+			views
+		)
+		SELECT t.snippet_id, t.day, t.views
+		FROM UNNEST($1::integer[], $2::date[], $3::bigint[]) AS t(snippet_id, day, views)
+			JOIN snippets s ON s.id = t.snippet_id
+		ON CONFLICT (snippet_id, day) DO UPDATE
+		SET views = snippet_views.views + EXCLUDED.views
+	`
+
+	ids := make([]int64, len(views))
+	days := make([]time.Time, len(views))
+	counts := make([]int64, len(views))
+	for i, v := range views {
+		ids[i] = int64(v.SnippetID)
+		days[i] = v.Day
+		counts[i] = int64(v.Views)
+	}
+
+	if _, err := pg.pool.Exec(ctx, query, ids, days, counts); err != nil {
+		return fmt.Errorf("failed to add snippet views: %w", err)
+	}
+
+	return nil
+}
+
+// Views returns the total amount of views of a snippet and its daily views starting from the since day
+func (pg *PGXStorage) Views(ctx context.Context, id uint, since time.Time) (uint64, []DailyViews, error) {
+	var total uint64
+	if err := pg.pool.QueryRow(
+		ctx,
+		`SELECT COALESCE(SUM(views), 0) FROM snippet_views WHERE snippet_id = $1`,
+		id,
+	).Scan(&total); err != nil {
+		return 0, nil, fmt.Errorf("failed to count snippet views: %w", err)
+	}
+
+	query := `
+		SELECT
+			day,
+			views
+		FROM snippet_views
+		WHERE
+			snippet_id = $1
+			AND day >= $2::date
+		ORDER BY day
+	`
+
+	rows, err := pg.pool.Query(ctx, query, id, since)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to query snippet views: %w", err)
+	}
+
+	var daily []DailyViews
+	daily, err = pgx.AppendRows(daily, rows, func(row pgx.CollectableRow) (DailyViews, error) {
+		v := DailyViews{SnippetID: id}
+		err := row.Scan(&v.Day, &v.Views)
+		return v, err
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to scan snippet views row: %w", err)
+	}
+
+	return total, daily, nil
+}
+
+// Popular returns active snippets with the most views starting from the since day
+func (pg *PGXStorage) Popular(ctx context.Context, since time.Time, limit uint) ([]PopularSnippet, error) {
+	query := `
+		SELECT
+			s.id,
+			s.title,
+			SUM(v.views) AS total
+		FROM snippet_views v
+			JOIN snippets s ON s.id = v.snippet_id
+		WHERE
+			v.day >= $1::date
+			AND s.expires_at > NOW()
+		GROUP BY s.id, s.title
+		ORDER BY total DESC, s.id
+		LIMIT $2
+	`
+
+	rows, err := pg.pool.Query(ctx, query, since, int64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query popular snippets: %w", err)
+	}
+
+	var results []PopularSnippet
+	results, err = pgx.AppendRows(results, rows, func(row pgx.CollectableRow) (PopularSnippet, error) {
+		var popular PopularSnippet
+		err := row.Scan(&popular.ID, &popular.Title, &popular.Views)
+		return popular, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan popular snippet row: %w", err)
+	}
+
+	return results, nil
+}
+
+// PurgeExpired deletes up to limit snippets that expired before the time and are already announced or deleted,
+// and returns the number of deleted snippets. Their contents are released by a trigger.
+func (pg *PGXStorage) PurgeExpired(ctx context.Context, before time.Time, limit uint) (uint, error) {
+	query := `
+		DELETE FROM snippets
+		WHERE id IN (
+			SELECT id
+			FROM snippets
+			WHERE
+				expires_at <= $1
+				AND expiry_notified
+			ORDER BY expires_at, id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+	`
+
+	tag, err := pg.pool.Exec(ctx, query, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge expired snippets: %w", err)
+	}
+
+	return uint(tag.RowsAffected()), nil
+}
+
+// AnnounceExpired records EventExpired for up to limit snippets that have expired, but haven't been announced yet,
+// and returns the number of announced snippets. Deleted snippets are never announced.
+func (pg *PGXStorage) AnnounceExpired(ctx context.Context, limit uint) (uint, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("failed to announce expired snippets: %w", err)
+	}
+
+	tx, err := pg.pool.Begin(ctx)
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	query := `
+		UPDATE snippets
+		SET expiry_notified = TRUE
+		WHERE id IN (
+			SELECT id
+			FROM snippets
+			WHERE
+				expires_at <= NOW()
+				AND NOT expiry_notified
+			ORDER BY expires_at, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING
+			id,
+			title,
+			created_at,
+			updated_at,
+			expires_at,
+			encryption,
+			COALESCE(parent_id, 0)
+	`
+
+	rows, err := tx.Query(ctx, query, int64(limit))
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+
+	expired, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (Snippet, error) {
+		var snippet Snippet
+		err := row.Scan(
+			&snippet.ID,
+			&snippet.Title,
+			&snippet.CreatedAt,
+			&snippet.UpdatedAt,
+			&snippet.ExpiresAt,
+			&snippet.Encryption,
+			&snippet.ParentID,
+		)
+		return snippet, err
+	})
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+
+	if err = writeEvents(ctx, postgres.PgxExecer(tx), EventExpired, expired); err != nil {
+		return 0, wrapErr(err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, wrapErr(err)
+	}
+
+	return uint(len(expired)), nil
+}
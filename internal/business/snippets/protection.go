@@ -0,0 +1,181 @@
+package snippets
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/encryption"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+// PassphraseHeader is an HTTP header used to pass a passphrase of a protected snippet
+const PassphraseHeader = "X-Snippet-Passphrase"
+
+var (
+	// ErrPassphraseRequired is returned when a protected snippet is requested without a passphrase
+	ErrPassphraseRequired = errors.New("snippet is protected, passphrase is required")
+	// ErrWrongPassphrase is returned when a protected snippet can't be decrypted with a given passphrase
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+	// ErrProtectedUpdate is returned when the content of a protected snippet is updated
+	ErrProtectedUpdate = errors.New("protected snippets can't be updated")
+)
+
+type passphraseKey struct{}
+
+// ContextWithPassphrase returns a copy of ctx holding a snippet passphrase
+func ContextWithPassphrase(ctx context.Context, passphrase string) context.Context {
+	if passphrase == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, passphraseKey{}, passphrase)
+}
+
+// PassphraseFromContext returns a snippet passphrase stored in ctx
+func PassphraseFromContext(ctx context.Context) (string, bool) {
+	passphrase, ok := ctx.Value(passphraseKey{}).(string)
+	return passphrase, ok && passphrase != ""
+}
+
+type derivedKeyKey struct{}
+
+// derivedKey is a key derived from the passphrase of the context with its salt
+type derivedKey struct {
+	key  []byte
+	salt []byte
+}
+
+// contextWithDerivedKey derives a key from the passphrase of ctx once, so content of several snippets
+// is sealed with the same key and salt: PBKDF2 is too slow to be run for every snippet of a batch.
+func contextWithDerivedKey(ctx context.Context) (context.Context, error) {
+	passphrase, ok := PassphraseFromContext(ctx)
+	if !ok {
+		return ctx, nil
+	}
+
+	salt, err := encryption.NewSalt()
+	if err != nil {
+		return nil, err
+	}
+
+	return context.WithValue(ctx, derivedKeyKey{}, derivedKey{
+		key:  encryption.DeriveKey(passphrase, salt),
+		salt: salt,
+	}), nil
+}
+
+// sealContent encrypts snippet content before it's saved to storage.
+// A passphrase from the context takes precedence over the server key.
+func (s *SnippetService) sealContent(ctx context.Context, snippet *Snippet) error {
+	if passphrase, ok := PassphraseFromContext(ctx); ok {
+		derived, ok := ctx.Value(derivedKeyKey{}).(derivedKey)
+		if !ok {
+			salt, err := encryption.NewSalt()
+			if err != nil {
+				return err
+			}
+			derived = derivedKey{key: encryption.DeriveKey(passphrase, salt), salt: salt}
+		}
+
+		sealed, err := encryption.Seal(derived.key, []byte(snippet.Content))
+		if err != nil {
+			return err
+		}
+
+		snippet.Content = base64.StdEncoding.EncodeToString(sealed)
+		snippet.Encryption = EncryptionPassphrase
+		snippet.PassphraseSalt = derived.salt
+		return nil
+	}
+
+	if s.envelope == nil {
+		snippet.Encryption = EncryptionNone
+		return nil
+	}
+
+	ciphertext, wrappedKey, err := s.envelope.Seal([]byte(snippet.Content))
+	if err != nil {
+		return err
+	}
+
+	snippet.Content = base64.StdEncoding.EncodeToString(ciphertext)
+	snippet.Encryption = EncryptionEnvelope
+	snippet.WrappedKey = wrappedKey
+	return nil
+}
+
+// openContent decrypts content of a snippet fetched from storage.
+// If skipProtected is set, content of protected snippets is cleared instead of being decrypted.
+func (s *SnippetService) openContent(ctx context.Context, snippet *Snippet, skipProtected bool) *service.Error {
+	switch snippet.Encryption {
+	case EncryptionNone:
+		return nil
+	case EncryptionPassphrase:
+		if skipProtected {
+			snippet.Content = ""
+			return nil
+		}
+		return s.openProtectedContent(ctx, snippet)
+	case EncryptionEnvelope:
+		if s.envelope == nil {
+			return &service.Error{
+				Type: service.InternalError,
+				Base: fmt.Errorf("snippet %d is encrypted, but server key is not configured", snippet.ID),
+			}
+		}
+
+		ciphertext, err := base64.StdEncoding.DecodeString(snippet.Content)
+		if err != nil {
+			return &service.Error{
+				Type: service.InternalError,
+				Base: fmt.Errorf("failed to decode snippet %d content: %w", snippet.ID, err),
+			}
+		}
+
+		plaintext, err := s.envelope.Open(ciphertext, snippet.WrappedKey)
+		if err != nil {
+			return &service.Error{
+				Type: service.InternalError,
+				Base: fmt.Errorf("failed to decrypt snippet %d content: %w", snippet.ID, err),
+			}
+		}
+
+		snippet.Content = string(plaintext)
+		return nil
+	default:
+		return &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("unknown encryption of snippet %d: %d", snippet.ID, snippet.Encryption),
+		}
+	}
+}
+
+func (s *SnippetService) openProtectedContent(ctx context.Context, snippet *Snippet) *service.Error {
+	passphrase, ok := PassphraseFromContext(ctx)
+	if !ok {
+		return &service.Error{
+			Type: service.Unauthorized,
+			Base: ErrPassphraseRequired,
+		}
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(snippet.Content)
+	if err != nil {
+		return &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to decode snippet %d content: %w", snippet.ID, err),
+		}
+	}
+
+	plaintext, err := encryption.Open(encryption.DeriveKey(passphrase, snippet.PassphraseSalt), sealed)
+	if err != nil {
+		return &service.Error{
+			Type: service.Forbidden,
+			Base: ErrWrongPassphrase,
+		}
+	}
+
+	snippet.Content = string(plaintext)
+	return nil
+}
@@ -0,0 +1,237 @@
+package snippets_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/encryption"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+func TestSnippetService_PassphraseProtection(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	// ===============================================
+	// Init Mocks and Service
+	mockStorage := NewMockStorage(ctrl)
+
+	fakeNow := time.Now().UTC()
+
+	snippetService := snippets.NewService(
+		mockStorage,
+		nopslog.NewNoplogger(),
+		func() time.Time { return fakeNow },
+	)
+
+	// ===============================================
+	// Init test data
+	passphrase := "correct horse battery staple"
+
+	snippetToCreate := snippets.Snippet{
+		Title:     "Best snippet ever",
+		Content:   "Some secret text here…",
+		ExpiresAt: fakeNow.Add(time.Hour * 24),
+	}
+
+	var stored snippets.Snippet
+
+	// ===============================================
+	// Describe Mock Calls
+	mockStorage.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, snippet snippets.Snippet) (uint, error) {
+			stored = snippet
+			stored.ID = 200
+			return stored.ID, nil
+		},
+	)
+	mockStorage.EXPECT().Get(gomock.Any(), uint(200)).DoAndReturn(
+		func(context.Context, uint) (snippets.Snippet, error) {
+			return stored, nil
+		},
+	).Times(3)
+
+	// ===============================================
+	// Run Test
+	created, svcErr := snippetService.Create(snippets.ContextWithPassphrase(ctx, passphrase), snippetToCreate)
+	require.Nil(t, svcErr)
+	assert.Equal(t, snippetToCreate.Content, created.Content)
+	assert.True(t, created.Protected())
+
+	assert.Equal(t, snippets.EncryptionPassphrase, stored.Encryption)
+	assert.Len(t, stored.PassphraseSalt, encryption.SaltSize)
+	assert.NotContains(t, stored.Content, snippetToCreate.Content)
+
+	t.Run("Passphrase is required", func(t *testing.T) {
+		_, svcErr := snippetService.Get(ctx, 200)
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.Unauthorized, svcErr.Type)
+		assert.ErrorIs(t, svcErr, snippets.ErrPassphraseRequired)
+	})
+
+	t.Run("Wrong passphrase", func(t *testing.T) {
+		_, svcErr := snippetService.Get(snippets.ContextWithPassphrase(ctx, "wrong passphrase"), 200)
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.Forbidden, svcErr.Type)
+		assert.ErrorIs(t, svcErr, snippets.ErrWrongPassphrase)
+	})
+
+	t.Run("Correct passphrase", func(t *testing.T) {
+		actual, svcErr := snippetService.Get(snippets.ContextWithPassphrase(ctx, passphrase), 200)
+		require.Nil(t, svcErr)
+		assert.Equal(t, snippetToCreate.Content, actual.Content)
+	})
+}
+
+func TestSnippetService_PassphraseProtectionBatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	// ===============================================
+	// Init Mocks and Service
+	mockStorage := NewMockStorage(ctrl)
+
+	fakeNow := time.Now().UTC()
+
+	snippetService := snippets.NewService(
+		mockStorage,
+		nopslog.NewNoplogger(),
+		func() time.Time { return fakeNow },
+	)
+
+	// ===============================================
+	// Init test data
+	passphrase := "correct horse battery staple"
+
+	batch := []snippets.Snippet{
+		{Title: "Snippet #1", Content: "First secret", ExpiresAt: fakeNow.Add(time.Hour)},
+		{Title: "Snippet #2", Content: "Second secret", ExpiresAt: fakeNow.Add(time.Hour)},
+	}
+
+	var stored []snippets.Snippet
+
+	// ===============================================
+	// Describe Mock Calls
+	mockStorage.EXPECT().CreateBatch(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, batch []snippets.Snippet) ([]uint, error) {
+			stored = batch
+			return []uint{1, 2}, nil
+		},
+	)
+	for i := range batch {
+		mockStorage.EXPECT().Get(gomock.Any(), uint(i+1)).DoAndReturn(
+			func(context.Context, uint) (snippets.Snippet, error) {
+				return stored[i], nil
+			},
+		)
+	}
+
+	// ===============================================
+	// Run Test
+	ctx = snippets.ContextWithPassphrase(ctx, passphrase)
+
+	results, svcErr := snippetService.CreateBatch(ctx, batch, true)
+	require.Nil(t, svcErr)
+	require.Len(t, stored, 2)
+
+	// The key is derived once per batch, so all snippets share the salt
+	assert.Len(t, stored[0].PassphraseSalt, encryption.SaltSize)
+	assert.Equal(t, stored[0].PassphraseSalt, stored[1].PassphraseSalt)
+
+	for i, result := range results {
+		assert.Equal(t, batch[i].Content, result.Snippet.Content)
+		assert.NotContains(t, stored[i].Content, batch[i].Content)
+
+		actual, svcErr := snippetService.Get(ctx, uint(i+1))
+		require.Nil(t, svcErr)
+		assert.Equal(t, batch[i].Content, actual.Content)
+	}
+}
+
+func TestSnippetService_EnvelopeEncryption(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	// ===============================================
+	// Init Mocks and Service
+	mockStorage := NewMockStorage(ctrl)
+
+	kek, err := encryption.NewKey()
+	require.NoError(t, err)
+
+	envelope, err := encryption.NewEnvelope(kek)
+	require.NoError(t, err)
+
+	fakeNow := time.Now().UTC()
+
+	snippetService := snippets.NewService(
+		mockStorage,
+		nopslog.NewNoplogger(),
+		func() time.Time { return fakeNow },
+		snippets.WithEnvelope(envelope),
+	)
+
+	// ===============================================
+	// Init test data
+	snippetToCreate := snippets.Snippet{
+		Title:     "Best snippet ever",
+		Content:   "Some secret text here…",
+		ExpiresAt: fakeNow.Add(time.Hour * 24),
+	}
+
+	var stored snippets.Snippet
+
+	// ===============================================
+	// Describe Mock Calls
+	mockStorage.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, snippet snippets.Snippet) (uint, error) {
+			stored = snippet
+			stored.ID = 200
+			return stored.ID, nil
+		},
+	)
+	mockStorage.EXPECT().Get(ctx, uint(200)).DoAndReturn(
+		func(context.Context, uint) (snippets.Snippet, error) {
+			return stored, nil
+		},
+	)
+	mockStorage.EXPECT().Total(ctx).Return(uint(1), nil)
+	mockStorage.EXPECT().List(ctx, gomock.Any()).DoAndReturn(
+		func(context.Context, service.Pagination) ([]snippets.Snippet, error) {
+			return []snippets.Snippet{stored}, nil
+		},
+	)
+
+	// ===============================================
+	// Run Test
+	created, svcErr := snippetService.Create(ctx, snippetToCreate)
+	require.Nil(t, svcErr)
+	assert.Equal(t, snippetToCreate.Content, created.Content)
+	assert.False(t, created.Protected())
+
+	assert.Equal(t, snippets.EncryptionEnvelope, stored.Encryption)
+	assert.NotEmpty(t, stored.WrappedKey)
+	assert.NotContains(t, stored.Content, snippetToCreate.Content)
+
+	actual, svcErr := snippetService.Get(ctx, 200)
+	require.Nil(t, svcErr)
+	assert.Equal(t, snippetToCreate.Content, actual.Content)
+
+	list, _, svcErr := snippetService.List(ctx, 0, 0)
+	require.Nil(t, svcErr)
+	require.Len(t, list, 1)
+	assert.Equal(t, snippetToCreate.Content, list[0].Content)
+}
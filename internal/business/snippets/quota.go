@@ -0,0 +1,219 @@
+package snippets
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+// quotaWindow is the period daily creations are counted for
+const quotaWindow = 24 * time.Hour
+
+var (
+	// ErrSnippetsQuota error is returned when a caller has too many active snippets
+	ErrSnippetsQuota = errors.New("snippets quota exceeded")
+	// ErrContentQuota error is returned when active snippets of a caller are too large in total
+	ErrContentQuota = errors.New("content quota exceeded")
+	// ErrDailyCreationsQuota error is returned when a caller has created too many snippets in the last 24 hours
+	ErrDailyCreationsQuota = errors.New("daily creations quota exceeded")
+)
+
+// Quota limits snippets of every API caller, zero fields mean no limit.
+// Snippets created by CLI commands are not limited.
+type Quota struct {
+	// MaxSnippets limits the number of active (not expired and not deleted) snippets
+	MaxSnippets uint
+	// MaxContentBytes limits the total size of contents of active snippets
+	MaxContentBytes uint64
+	// DailyCreations limits the number of snippets created in the last 24 hours, deleted ones included
+	DailyCreations uint
+}
+
+// Usage describes the consumption of an API caller
+type Usage struct {
+	Caller string
+	// Snippets is the number of active snippets
+	Snippets uint
+	// ContentBytes is the total size of contents of active snippets
+	ContentBytes uint64
+	// CreatedLastDay is the number of snippets created in the last 24 hours
+	CreatedLastDay uint
+
+	Quota Quota
+}
+
+// WithQuota enables quotas on snippets of API callers
+func WithQuota(quota Quota) ServiceOption {
+	return func(s *SnippetService) {
+		s.quota = quota
+	}
+}
+
+// Usage returns the consumption of the API caller of ctx along with its quota
+func (s *SnippetService) Usage(ctx context.Context) (Usage, *service.Error) {
+	caller, _ := service.CallerFromContext(ctx)
+
+	usage, svcErr := s.usage(ctx, caller)
+	if svcErr != nil {
+		return Usage{}, svcErr
+	}
+
+	usage.Quota = s.quota
+	return usage, nil
+}
+
+func (s *SnippetService) usage(ctx context.Context, caller string) (Usage, *service.Error) {
+	usage, err := s.storage.Usage(ctx, caller, s.now().Add(-quotaWindow))
+	if err != nil {
+		s.logger.Error("failed to get usage", slog.String("caller", caller), slog.Any("err", err))
+		return Usage{}, &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to get usage: %w", err),
+		}
+	}
+
+	usage.Caller = caller
+	return usage, nil
+}
+
+// createWithinQuota checks whether the API caller of ctx may create count snippets of the given total content size
+// and calls create if so. Snippets created without an API caller aren't limited.
+func (s *SnippetService) createWithinQuota(
+	ctx context.Context,
+	count uint,
+	contentBytes uint64,
+	create func(ctx context.Context) *service.Error,
+) *service.Error {
+	caller, ok := service.CallerFromContext(ctx)
+	if !ok {
+		return create(ctx)
+	}
+
+	return s.withinQuota(ctx, caller, func(ctx context.Context) *service.Error {
+		if svcErr := s.checkQuota(ctx, caller, count, contentBytes); svcErr != nil {
+			return svcErr
+		}
+
+		return create(ctx)
+	})
+}
+
+// withinQuota runs fn, which checks the quota of the caller and writes snippets, so that concurrent calls
+// of the same caller can't pass the check before the writes of each other are done. They wait for each other
+// within the process and, if the storage and the Transactor are backed by PostgreSQL, on all replicas:
+// fn runs in a transaction that holds the quota lock of the caller, see Storage.LockQuota.
+func (s *SnippetService) withinQuota(ctx context.Context, caller string, fn func(ctx context.Context) *service.Error) *service.Error {
+	if s.quota == (Quota{}) {
+		return fn(ctx)
+	}
+
+	unlock := s.quotaLocks.lock(caller)
+	defer unlock()
+
+	// Every statement of a read committed transaction sees the snippets committed before it,
+	// including ones committed while the lock was awaited. A repeatable read snapshot would be taken before that.
+	err := s.transactor.RunInTx(ctx, sql.LevelReadCommitted, func(ctx context.Context) error {
+		if err := s.storage.LockQuota(ctx, caller); err != nil {
+			return fmt.Errorf("failed to lock quota: %w", err)
+		}
+
+		if svcErr := fn(ctx); svcErr != nil {
+			return svcErr
+		}
+
+		return nil
+	})
+
+	var svcErr *service.Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &svcErr):
+		return svcErr
+	default:
+		s.logger.Error("failed to write snippets within quota", slog.String("caller", caller), slog.Any("err", err))
+		return &service.Error{
+			Type: service.InternalError,
+			Base: err,
+		}
+	}
+}
+
+// checkQuota checks whether the caller may add count snippets and contentBytes of content to its active snippets.
+// It must be called by withinQuota, otherwise concurrent requests may exceed the quota.
+func (s *SnippetService) checkQuota(ctx context.Context, caller string, count uint, contentBytes uint64) *service.Error {
+	if s.quota == (Quota{}) {
+		return nil
+	}
+
+	usage, svcErr := s.usage(ctx, caller)
+	if svcErr != nil {
+		return svcErr
+	}
+
+	switch {
+	case count > 0 && s.quota.DailyCreations > 0 && usage.CreatedLastDay+count > s.quota.DailyCreations:
+		return &service.Error{
+			Type: service.TooManyRequests,
+			Base: fmt.Errorf("%w: max %d snippets in 24 hours", ErrDailyCreationsQuota, s.quota.DailyCreations),
+		}
+	case count > 0 && s.quota.MaxSnippets > 0 && usage.Snippets+count > s.quota.MaxSnippets:
+		return &service.Error{
+			Type: service.QuotaExceeded,
+			Base: fmt.Errorf("%w: max %d active snippets", ErrSnippetsQuota, s.quota.MaxSnippets),
+		}
+	case s.quota.MaxContentBytes > 0 && usage.ContentBytes+contentBytes > s.quota.MaxContentBytes:
+		return &service.Error{
+			Type: service.QuotaExceeded,
+			Base: fmt.Errorf("%w: max %d bytes of active snippets", ErrContentQuota, s.quota.MaxContentBytes),
+		}
+	}
+
+	return nil
+}
+
+// callerLocks serializes quota checks of every caller within the process
+type callerLocks struct {
+	mu    sync.Mutex
+	locks map[string]*callerLock
+}
+
+type callerLock struct {
+	mu sync.Mutex
+	// waiters is the number of holders of the lock and goroutines waiting for it, the lock is dropped once it's 0
+	waiters int
+}
+
+// lock locks the caller and returns the function that unlocks it
+func (l *callerLocks) lock(caller string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*callerLock)
+	}
+
+	cl, ok := l.locks[caller]
+	if !ok {
+		cl = &callerLock{}
+		l.locks[caller] = cl
+	}
+	cl.waiters++
+	l.mu.Unlock()
+
+	cl.mu.Lock()
+
+	return func() {
+		cl.mu.Unlock()
+
+		l.mu.Lock()
+		if cl.waiters--; cl.waiters == 0 {
+			delete(l.locks, caller)
+		}
+		l.mu.Unlock()
+	}
+}
@@ -0,0 +1,372 @@
+package snippets_test
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+func TestSnippetService_Quota(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+	expiresAt := time.Date(2050, 1, 1, 1, 1, 1, 0, time.UTC)
+
+	quota := snippets.Quota{
+		MaxSnippets:     10,
+		MaxContentBytes: 100,
+		DailyCreations:  5,
+	}
+
+	t.Run("Snippets of CLI commands are not limited", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+			snippets.WithQuota(quota),
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Create(ctx, gomock.Any()).Return(uint(1), nil)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := snippetService.Create(ctx, snippets.Snippet{Title: "Title", Content: "Content", ExpiresAt: expiresAt})
+		require.Nil(t, svcErr)
+	})
+
+	t.Run("Create a snippet within the quota", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := service.ContextWithCaller(context.Background(), "ci")
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+			snippets.WithQuota(quota),
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		gomock.InOrder(
+			mockStorage.EXPECT().LockQuota(ctx, "ci").Return(nil),
+			mockStorage.EXPECT().Usage(ctx, "ci", now.Add(-24*time.Hour)).Return(snippets.Usage{
+				Snippets:       9,
+				ContentBytes:   93,
+				CreatedLastDay: 4,
+			}, nil),
+			mockStorage.EXPECT().Create(ctx, snippets.Snippet{
+				Title:       "Title",
+				Content:     "Content",
+				ContentSize: 7,
+				Caller:      "ci",
+				CreatedAt:   now,
+				UpdatedAt:   now,
+				ExpiresAt:   expiresAt,
+			}).Return(uint(1), nil),
+		)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := snippetService.Create(ctx, snippets.Snippet{Title: "Title", Content: "Content", ExpiresAt: expiresAt})
+		require.Nil(t, svcErr)
+	})
+
+	t.Run("Quota is exceeded", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name     string
+			usage    snippets.Usage
+			wantType service.ErrorType
+			wantErr  error
+		}{
+			{
+				name:     "Daily creations",
+				usage:    snippets.Usage{CreatedLastDay: 4},
+				wantType: service.TooManyRequests,
+				wantErr:  snippets.ErrDailyCreationsQuota,
+			},
+			{
+				name:     "Active snippets",
+				usage:    snippets.Usage{Snippets: 9},
+				wantType: service.QuotaExceeded,
+				wantErr:  snippets.ErrSnippetsQuota,
+			},
+			{
+				name:     "Content bytes",
+				usage:    snippets.Usage{ContentBytes: 90},
+				wantType: service.QuotaExceeded,
+				wantErr:  snippets.ErrContentQuota,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				ctx := service.ContextWithCaller(context.Background(), "ci")
+				ctrl := gomock.NewController(t)
+
+				// ===============================================
+				// Init Mocks and Service
+				mockStorage := NewMockStorage(ctrl)
+
+				snippetService := snippets.NewService(
+					mockStorage,
+					nopslog.NewNoplogger(),
+					func() time.Time { return now },
+					snippets.WithQuota(quota),
+				)
+
+				// ===============================================
+				// Describe Mock Calls
+				gomock.InOrder(
+					mockStorage.EXPECT().LockQuota(ctx, "ci").Return(nil),
+					mockStorage.EXPECT().Usage(ctx, "ci", gomock.Any()).Return(tt.usage, nil),
+				)
+
+				// ===============================================
+				// Run Test
+				_, svcErr := snippetService.CreateBatch(ctx, []snippets.Snippet{
+					{Title: "Title #1", Content: "Content #1", ExpiresAt: expiresAt},
+					{Title: "Title #2", Content: "Content #2", ExpiresAt: expiresAt},
+				}, false)
+				require.NotNil(t, svcErr)
+				assert.Equal(t, tt.wantType, svcErr.Type)
+				assert.ErrorIs(t, svcErr, tt.wantErr)
+			})
+		}
+	})
+}
+
+func TestSnippetService_QuotaConcurrency(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Now().Add(time.Hour)
+	quota := snippets.Quota{MaxSnippets: 3}
+
+	t.Run("Concurrent creations of a caller don't exceed the quota", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := service.ContextWithCaller(context.Background(), "ci")
+
+		snippetService := snippets.NewService(
+			snippets.NewMemoryStorage(time.Now),
+			nopslog.NewNoplogger(),
+			time.Now,
+			snippets.WithQuota(quota),
+		)
+
+		var (
+			wg      sync.WaitGroup
+			created atomic.Int32
+		)
+
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				_, svcErr := snippetService.CreateBatch(ctx, []snippets.Snippet{
+					{Title: "Title #1", Content: "Content #1", ExpiresAt: expiresAt},
+					{Title: "Title #2", Content: "Content #2", ExpiresAt: expiresAt},
+				}, true)
+				if svcErr == nil {
+					created.Add(1)
+					return
+				}
+				assert.ErrorIs(t, svcErr, snippets.ErrSnippetsQuota)
+			}()
+		}
+
+		wg.Wait()
+		assert.EqualValues(t, 1, created.Load())
+	})
+
+	t.Run("The quota is checked and snippets are created in one transaction", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := service.ContextWithCaller(context.Background(), "ci")
+		txCtx := context.WithValue(ctx, txKey{}, true)
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+		mockTransactor := NewMockTransactor(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			time.Now,
+			snippets.WithQuota(quota),
+			snippets.WithTransactor(mockTransactor),
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockTransactor.EXPECT().RunInTx(ctx, sql.LevelReadCommitted, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ sql.IsolationLevel, fn func(ctx context.Context) error) error {
+				return fn(txCtx)
+			},
+		)
+		gomock.InOrder(
+			mockStorage.EXPECT().LockQuota(txCtx, "ci").Return(nil),
+			mockStorage.EXPECT().Usage(txCtx, "ci", gomock.Any()).Return(snippets.Usage{}, nil),
+			mockStorage.EXPECT().Create(txCtx, gomock.Any()).Return(uint(1), nil),
+		)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := snippetService.Create(ctx, snippets.Snippet{Title: "Title", Content: "Content", ExpiresAt: expiresAt})
+		require.Nil(t, svcErr)
+	})
+}
+
+type txKey struct{}
+
+func TestSnippetService_UpdateContentQuota(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+	quota := snippets.Quota{MaxContentBytes: 100}
+
+	snippet := snippets.Snippet{
+		ID:          1,
+		Title:       "Title",
+		Content:     "Short",
+		ContentSize: 5,
+		Caller:      "ci",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+	}
+
+	t.Run("Grown content counts towards the quota of the owner", func(t *testing.T) {
+		t.Parallel()
+
+		// Live editing persists drafts without an API caller
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+			snippets.WithQuota(quota),
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		gomock.InOrder(
+			mockStorage.EXPECT().Get(ctx, uint(1)).Return(snippet, nil),
+			mockStorage.EXPECT().LockQuota(ctx, "ci").Return(nil),
+			mockStorage.EXPECT().Usage(ctx, "ci", gomock.Any()).Return(snippets.Usage{ContentBytes: 95}, nil),
+		)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := snippetService.UpdateContent(ctx, 1, "Much longer")
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.QuotaExceeded, svcErr.Type)
+		assert.ErrorIs(t, svcErr, snippets.ErrContentQuota)
+	})
+
+	t.Run("Shrunk content isn't checked", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+			snippets.WithQuota(quota),
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		gomock.InOrder(
+			mockStorage.EXPECT().Get(ctx, uint(1)).Return(snippet, nil),
+			mockStorage.EXPECT().UpdateContent(ctx, gomock.Any()).Return(nil),
+		)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := snippetService.UpdateContent(ctx, 1, "Tiny")
+		require.Nil(t, svcErr)
+	})
+}
+
+func TestSnippetService_Usage(t *testing.T) {
+	t.Parallel()
+
+	ctx := service.ContextWithCaller(context.Background(), "ci")
+	ctrl := gomock.NewController(t)
+
+	now := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+	quota := snippets.Quota{MaxSnippets: 10}
+
+	// ===============================================
+	// Init Mocks and Service
+	mockStorage := NewMockStorage(ctrl)
+
+	snippetService := snippets.NewService(
+		mockStorage,
+		nopslog.NewNoplogger(),
+		func() time.Time { return now },
+		snippets.WithQuota(quota),
+	)
+
+	// ===============================================
+	// Describe Mock Calls
+	mockStorage.EXPECT().Usage(ctx, "ci", now.Add(-24*time.Hour)).Return(snippets.Usage{Snippets: 3, ContentBytes: 42}, nil)
+
+	// ===============================================
+	// Run Test
+	usage, svcErr := snippetService.Usage(ctx)
+	require.Nil(t, svcErr)
+	assert.Equal(t, snippets.Usage{
+		Caller:       "ci",
+		Snippets:     3,
+		ContentBytes: 42,
+		Quota:        quota,
+	}, usage)
+}
@@ -2,31 +2,101 @@ package snippets
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log/slog"
 	"time"
+	"unicode/utf8"
 
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/encryption"
 	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
 )
 
 //go:generate go run go.uber.org/mock/mockgen -typed -source=service.go -destination ./service_mock_test.go -package snippets_test -mock_names Storage=MockStorage
 
-// Storage is used to manipulate data in DB
+// Storage is used to manipulate data in DB.
+// Methods that create, update, delete or expire snippets record the matching events in the same transaction.
 type Storage interface {
 	Get(ctx context.Context, id uint) (Snippet, error)
 	Create(ctx context.Context, snippet Snippet) (uint, error)
+	UpdateContent(ctx context.Context, snippet Snippet) error
 	List(ctx context.Context, pagination service.Pagination) ([]Snippet, error)
 	SoftDelete(ctx context.Context, id uint) error
 	Total(ctx context.Context) (uint, error)
+	CreateBatch(ctx context.Context, batch []Snippet) ([]uint, error)
+	SoftDeleteBatch(ctx context.Context, ids []uint) ([]uint, error)
+	Iterate(ctx context.Context, fn func(Snippet) error) error
+	Lineage(ctx context.Context, id uint) ([]uint, error)
+	Views(ctx context.Context, id uint, since time.Time) (uint64, []DailyViews, error)
+	Popular(ctx context.Context, since time.Time, limit uint) ([]PopularSnippet, error)
+	AnnounceExpired(ctx context.Context, limit uint) (uint, error)
+	// PurgeExpired deletes up to limit snippets that expired before the time and are already announced or deleted
+	PurgeExpired(ctx context.Context, before time.Time, limit uint) (uint, error)
+	Usage(ctx context.Context, caller string, since time.Time) (Usage, error)
+	// LockQuota serializes quota checks and creations of snippets of the caller until the transaction of ctx ends
+	LockQuota(ctx context.Context, caller string) error
+}
+
+// Transactor runs fn in a transaction, storage calls made with the context of fn are atomic
+type Transactor interface {
+	RunInTx(ctx context.Context, isolation sql.IsolationLevel, fn func(ctx context.Context) error) error
+}
+
+// noTransactor runs fn without a transaction, storage calls are independent
+type noTransactor struct{}
+
+// RunInTx implements Transactor
+func (noTransactor) RunInTx(ctx context.Context, _ sql.IsolationLevel, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// BatchResult represents a result of a single item of a batch operation
+type BatchResult struct {
+	Snippet Snippet
+	Err     *service.Error
 }
 
 // SnippetService represents service struct. It holds storage and logger.
 type SnippetService struct {
-	storage Storage
-	logger  *slog.Logger
+	storage    Storage
+	transactor Transactor
+	logger     *slog.Logger
+
+	withoutListSnapshot bool
 
 	now func() time.Time
+
+	envelope   *encryption.Envelope
+	quota      Quota
+	quotaLocks callerLocks
+}
+
+// ServiceOption configures optional SnippetService dependencies
+type ServiceOption func(*SnippetService)
+
+// WithEnvelope enables server-side encryption of snippets content at rest
+func WithEnvelope(envelope *encryption.Envelope) ServiceOption {
+	return func(s *SnippetService) {
+		s.envelope = envelope
+	}
+}
+
+// WithTransactor makes storage calls of a service call run in one transaction, e.g. List counts and lists snippets
+// of the same snapshot, and quota checks hold the quota lock until the checked snippets are created.
+// Without it, every storage call is independent.
+func WithTransactor(transactor Transactor) ServiceOption {
+	return func(s *SnippetService) {
+		s.transactor = transactor
+	}
+}
+
+// WithoutListSnapshot makes List count and list snippets with independent storage calls even if there's a Transactor,
+// so they can be read from replicas: a transaction would pin them to the primary.
+func WithoutListSnapshot() ServiceOption {
+	return func(s *SnippetService) {
+		s.withoutListSnapshot = true
+	}
 }
 
 // NewService returns new instance of SnippetService
@@ -34,13 +104,26 @@ func NewService(
 	storage Storage,
 	logger *slog.Logger,
 	nowFunc func() time.Time,
+	opts ...ServiceOption,
 ) *SnippetService {
-	return &SnippetService{
-		storage: storage,
-		logger:  logger,
+	s := &SnippetService{
+		storage:    storage,
+		transactor: noTransactor{},
+		logger:     logger,
 
 		now: nowFunc,
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Now returns the current time of the service clock
+func (s *SnippetService) Now() time.Time {
+	return s.now()
 }
 
 // Get returns a single snippet
@@ -48,6 +131,18 @@ func (s *SnippetService) Get(ctx context.Context, id uint) (Snippet, *service.Er
 	snippet, err := s.storage.Get(ctx, id)
 	switch {
 	case err == nil:
+		if svcErr := s.openContent(ctx, &snippet, false); svcErr != nil {
+			return Snippet{}, svcErr
+		}
+		if snippet.ParentID != 0 {
+			if snippet.Lineage, err = s.storage.Lineage(ctx, snippet.ID); err != nil {
+				s.logger.Error("failed to get snippet lineage", slog.Any("err", err))
+				return Snippet{}, &service.Error{
+					Type: service.InternalError,
+					Base: fmt.Errorf("failed to get snippet lineage: %w", err),
+				}
+			}
+		}
 		return snippet, nil
 	case errors.Is(err, ErrNotFound):
 		return Snippet{}, &service.Error{
@@ -63,51 +158,464 @@ func (s *SnippetService) Get(ctx context.Context, id uint) (Snippet, *service.Er
 	}
 }
 
-// Create creates a single snippet
+// Create creates a single snippet.
+// If the context holds a passphrase (see ContextWithPassphrase), the snippet content is encrypted with it.
+// If the context holds an API caller (see service.ContextWithCaller), the snippet is counted towards its quota.
 func (s *SnippetService) Create(ctx context.Context, snippet Snippet) (Snippet, *service.Error) {
+	stored, svcErr := s.prepare(ctx, snippet, s.now())
+	if svcErr != nil {
+		return Snippet{}, svcErr
+	}
+
+	svcErr = s.createWithinQuota(ctx, 1, uint64(len(snippet.Content)), func(ctx context.Context) *service.Error {
+		id, err := s.storage.Create(ctx, stored)
+		if err != nil {
+			s.logger.Error("failed to create snippet", slog.Any("err", err))
+			return &service.Error{
+				Type: service.InternalError,
+				Base: fmt.Errorf("failed to create snippet: %w", err),
+			}
+		}
+
+		stored.ID = id
+		return nil
+	})
+	if svcErr != nil {
+		return Snippet{}, svcErr
+	}
+
+	stored.Content = snippet.Content
+	return stored, nil
+}
+
+// UpdateContent replaces the content of a snippet. Protected snippets can't be updated,
+// since their passphrases are never stored on the server.
+func (s *SnippetService) UpdateContent(ctx context.Context, id uint, content string) (Snippet, *service.Error) {
+	snippet, err := s.storage.Get(ctx, id)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return Snippet{}, &service.Error{
+			Type: service.NotFound,
+			Base: ErrNotFound,
+		}
+	case err != nil:
+		s.logger.Error("failed to get a snippet", slog.Any("err", err))
+		return Snippet{}, &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to get snippet: %w", err),
+		}
+	}
+
+	now := s.now()
+	if !snippet.ExpiresAt.After(now) {
+		return Snippet{}, &service.Error{
+			Type: service.NotFound,
+			Base: ErrNotFound,
+		}
+	}
+
+	if snippet.Protected() {
+		return Snippet{}, &service.Error{
+			Type: service.Forbidden,
+			Base: ErrProtectedUpdate,
+		}
+	}
+
+	if length := utf8.RuneCountInString(content); length > MaxContentLength {
+		return Snippet{}, &service.Error{
+			Type: service.BadRequest,
+			Base: fmt.Errorf("content is too long: %d characters, max %d", length, MaxContentLength),
+		}
+	}
+
+	grown := len(content) - snippet.ContentSize
+
+	snippet.Content = content
+	snippet.ContentSize = len(content)
+	snippet.UpdatedAt = now
+	stored := snippet
+	stored.WrappedKey = nil
+	// The snippet isn't protected, so a passphrase in ctx must not be used to encrypt the new content
+	if err = s.sealContent(context.WithValue(ctx, passphraseKey{}, ""), &stored); err != nil {
+		s.logger.Error("failed to encrypt snippet content", slog.Any("err", err))
+		return Snippet{}, &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to encrypt snippet content: %w", err),
+		}
+	}
+
+	update := func(ctx context.Context) *service.Error {
+		switch err := s.storage.UpdateContent(ctx, stored); {
+		case errors.Is(err, ErrNotFound):
+			return &service.Error{
+				Type: service.NotFound,
+				Base: ErrNotFound,
+			}
+		case err != nil:
+			s.logger.Error("failed to update snippet content", slog.Uint64("id", uint64(id)), slog.Any("err", err))
+			return &service.Error{
+				Type: service.InternalError,
+				Base: fmt.Errorf("failed to update snippet content: %w", err),
+			}
+		}
+
+		return nil
+	}
+
+	// Grown content counts towards the quota of the API caller that created the snippet, whoever edits it
+	var svcErr *service.Error
+	if snippet.Caller != "" && grown > 0 {
+		svcErr = s.withinQuota(ctx, snippet.Caller, func(ctx context.Context) *service.Error {
+			if svcErr := s.checkQuota(ctx, snippet.Caller, 0, uint64(grown)); svcErr != nil {
+				return svcErr
+			}
+
+			return update(ctx)
+		})
+	} else {
+		svcErr = update(ctx)
+	}
+
+	if svcErr != nil {
+		return Snippet{}, svcErr
+	}
+
+	return snippet, nil
+}
+
+// Fork copies a snippet and records the original snippet as its parent.
+// Title and ExpiresAt of the fork are taken from the original snippet, unless they're set.
+// A protected snippet can only be forked with its passphrase, the fork is protected with the same passphrase.
+func (s *SnippetService) Fork(ctx context.Context, id uint, fork Snippet) (Snippet, *service.Error) {
+	parent, svcErr := s.Get(ctx, id)
+	if svcErr != nil {
+		return Snippet{}, svcErr
+	}
+
+	if !parent.ExpiresAt.After(s.now()) {
+		return Snippet{}, &service.Error{
+			Type: service.NotFound,
+			Base: ErrNotFound,
+		}
+	}
+
+	if fork.Title == "" {
+		fork.Title = parent.Title
+	}
+
+	if fork.ExpiresAt.IsZero() {
+		fork.ExpiresAt = parent.ExpiresAt
+	}
+
+	fork.Content = parent.Content
+	fork.ParentID = parent.ID
+	fork.Template = parent.Template
+
+	created, svcErr := s.Create(ctx, fork)
+	if svcErr != nil {
+		return Snippet{}, svcErr
+	}
+
+	created.Lineage = append([]uint{parent.ID}, parent.Lineage...)
+	return created, nil
+}
+
+// Instantiation describes how a template snippet is instantiated
+type Instantiation struct {
+	// Values of the template variables, defaults are used for missing ones
+	Values map[string]string
+	// Save stores the rendered content as a new snippet.
+	// Title and ExpiresAt of the new snippet are taken from the template, unless they're set.
+	Save      bool
+	Title     string
+	ExpiresAt time.Time
+}
+
+// Instantiate renders a template snippet with the supplied values.
+// The rendered snippet is only stored if Instantiation.Save is set, otherwise its ID is 0.
+// A protected template can only be instantiated with its passphrase, the new snippet is protected with the same passphrase.
+func (s *SnippetService) Instantiate(ctx context.Context, id uint, instantiation Instantiation) (Snippet, *service.Error) {
+	template, svcErr := s.Get(ctx, id)
+	if svcErr != nil {
+		return Snippet{}, svcErr
+	}
+
+	if !template.ExpiresAt.After(s.now()) {
+		return Snippet{}, &service.Error{
+			Type: service.NotFound,
+			Base: ErrNotFound,
+		}
+	}
+
+	if template.Template == nil {
+		return Snippet{}, &service.Error{
+			Type: service.BadRequest,
+			Base: ErrNotTemplate,
+		}
+	}
+
+	content, err := template.Template.Render(template.Content, instantiation.Values)
+	switch {
+	case errors.Is(err, ErrRenderedTooLong):
+		return Snippet{}, &service.Error{
+			Type: service.BadRequest,
+			Base: err,
+		}
+	case err != nil:
+		return Snippet{}, &service.Error{
+			Type: service.BadRequest,
+			Base: fmt.Errorf("values: %w", err),
+		}
+	}
+
+	rendered := Snippet{
+		Title:     instantiation.Title,
+		Content:   content,
+		ExpiresAt: instantiation.ExpiresAt,
+	}
+
+	if rendered.Title == "" {
+		rendered.Title = template.Title
+	}
+
+	if rendered.ExpiresAt.IsZero() {
+		rendered.ExpiresAt = template.ExpiresAt
+	}
+
+	if !instantiation.Save {
+		return rendered, nil
+	}
+
+	return s.Create(ctx, rendered)
+}
+
+// CreateBatch creates several snippets at once.
+// In atomic mode either all snippets are created or none of them. Otherwise, every snippet is created
+// independently, and a failure is reported in the corresponding BatchResult.
+// Protected snippets of a batch share the salt, the key is derived from the passphrase once.
+func (s *SnippetService) CreateBatch(ctx context.Context, batch []Snippet, atomic bool) ([]BatchResult, *service.Error) {
+	var contentBytes uint64
+	for i := range batch {
+		contentBytes += uint64(len(batch[i].Content))
+	}
+
+	ctx, err := contextWithDerivedKey(ctx)
+	if err != nil {
+		s.logger.Error("failed to derive passphrase key", slog.Any("err", err))
+		return nil, &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to encrypt snippet content: %w", err),
+		}
+	}
+
 	createdAt := s.now()
+
+	stored := make([]Snippet, len(batch))
+	for i := range batch {
+		var svcErr *service.Error
+		if stored[i], svcErr = s.prepare(ctx, batch[i], createdAt); svcErr != nil {
+			return nil, svcErr
+		}
+	}
+
+	var results []BatchResult
+
+	// The whole batch is rejected if it doesn't fit the quota, even in non-atomic mode
+	svcErr := s.createWithinQuota(ctx, uint(len(batch)), contentBytes, func(ctx context.Context) *service.Error {
+		results = make([]BatchResult, len(batch))
+
+		if !atomic {
+			for i := range stored {
+				id, err := s.storage.Create(ctx, stored[i])
+				if err != nil {
+					s.logger.Error("failed to create snippet", slog.Int("index", i), slog.Any("err", err))
+					results[i].Err = &service.Error{
+						Type: service.InternalError,
+						Base: fmt.Errorf("failed to create snippet: %w", err),
+					}
+					continue
+				}
+
+				results[i].Snippet = stored[i]
+				results[i].Snippet.ID = id
+				results[i].Snippet.Content = batch[i].Content
+			}
+
+			return nil
+		}
+
+		ids, err := s.storage.CreateBatch(ctx, stored)
+		if err != nil {
+			s.logger.Error("failed to create batch of snippets", slog.Any("err", err))
+			return &service.Error{
+				Type: service.InternalError,
+				Base: fmt.Errorf("failed to create snippets: %w", err),
+			}
+		}
+
+		for i := range stored {
+			results[i].Snippet = stored[i]
+			results[i].Snippet.ID = ids[i]
+			results[i].Snippet.Content = batch[i].Content
+		}
+
+		return nil
+	})
+	if svcErr != nil {
+		return nil, svcErr
+	}
+
+	return results, nil
+}
+
+// prepare fills service fields of a new snippet and encrypts its content
+func (s *SnippetService) prepare(ctx context.Context, snippet Snippet, createdAt time.Time) (Snippet, *service.Error) {
 	snippet.CreatedAt = createdAt
 	snippet.UpdatedAt = createdAt
 	snippet.ExpiresAt = snippet.ExpiresAt.UTC()
+	snippet.Caller, _ = service.CallerFromContext(ctx)
+	snippet.ContentSize = len(snippet.Content)
 
-	id, err := s.storage.Create(ctx, snippet)
-	if err != nil {
-		s.logger.Error("failed to create snippet", slog.Any("err", err))
+	if err := s.sealContent(ctx, &snippet); err != nil {
+		s.logger.Error("failed to encrypt snippet content", slog.Any("err", err))
 		return Snippet{}, &service.Error{
 			Type: service.InternalError,
-			Base: fmt.Errorf("failed to create snippet: %w", err),
+			Base: fmt.Errorf("failed to encrypt snippet content: %w", err),
 		}
 	}
 
-	snippet.ID = id
 	return snippet, nil
 }
 
 // List returns a list of snippets and a pagination struct
 func (s *SnippetService) List(ctx context.Context, limit uint, offset uint) ([]Snippet, service.Pagination, *service.Error) {
-	snippetsCount, err := s.storage.Total(ctx)
-	if err != nil {
-		s.logger.Error("failed to query total amount of snippets", slog.Any("err", err))
-		return nil, service.Pagination{}, &service.Error{
-			Type: service.InternalError,
-			Base: fmt.Errorf("failed to query total amount of snippets: %w", err),
-		}
+	var (
+		snippets   []Snippet
+		pagination service.Pagination
+	)
+
+	transactor := s.transactor
+	if s.withoutListSnapshot {
+		transactor = noTransactor{}
 	}
 
-	pagination := NewPagination(limit, offset, snippetsCount)
+	// The total and the page are read from the same snapshot, so the pagination matches the page
+	err := transactor.RunInTx(ctx, sql.LevelRepeatableRead, func(ctx context.Context) error {
+		snippetsCount, err := s.storage.Total(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to query total amount of snippets: %w", err)
+		}
+
+		pagination = NewPagination(limit, offset, snippetsCount)
 
-	snippets, err := s.storage.List(ctx, pagination)
+		if snippets, err = s.storage.List(ctx, pagination); err != nil {
+			return fmt.Errorf("failed to list snippets: %w", err)
+		}
+
+		return nil
+	})
 	if err != nil {
 		s.logger.Error("failed to list snippets", slog.Any("err", err))
 		return nil, pagination, &service.Error{
 			Type: service.InternalError,
-			Base: fmt.Errorf("failed to list snippets: %w", err),
+			Base: err,
+		}
+	}
+
+	for i := range snippets {
+		if svcErr := s.openContent(ctx, &snippets[i], true); svcErr != nil {
+			s.logger.Error("failed to decrypt snippet", slog.Any("err", svcErr))
+			return nil, pagination, svcErr
 		}
 	}
 
 	return snippets, pagination, nil
 }
 
+// Export calls fn for every active snippet ordered by id.
+// Protected snippets are skipped, since their content can't be decrypted without a passphrase.
+func (s *SnippetService) Export(ctx context.Context, fn func(Snippet) error) *service.Error {
+	err := s.storage.Iterate(ctx, func(snippet Snippet) error {
+		if snippet.Protected() {
+			return nil
+		}
+
+		if svcErr := s.openContent(ctx, &snippet, true); svcErr != nil {
+			return svcErr
+		}
+
+		return fn(snippet)
+	})
+
+	var svcErr *service.Error
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &svcErr):
+		return svcErr
+	default:
+		s.logger.Error("failed to export snippets", slog.Any("err", err))
+		return &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to export snippets: %w", err),
+		}
+	}
+}
+
+// Stats returns reads statistics of a snippet for the last days, today included.
+// Views are flushed to storage in batches, so the most recent reads may be missing.
+func (s *SnippetService) Stats(ctx context.Context, id uint, days uint) (SnippetStats, *service.Error) {
+	if _, err := s.storage.Get(ctx, id); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return SnippetStats{}, &service.Error{
+				Type: service.NotFound,
+				Base: ErrNotFound,
+			}
+		}
+
+		s.logger.Error("failed to get a snippet", slog.Any("err", err))
+		return SnippetStats{}, &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to get snippet: %w", err),
+		}
+	}
+
+	today := truncateToDay(s.now())
+	since := today.AddDate(0, 0, 1-int(days))
+
+	total, daily, err := s.storage.Views(ctx, id, since)
+	if err != nil {
+		s.logger.Error("failed to get snippet views", slog.Any("err", err))
+		return SnippetStats{}, &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to get snippet views: %w", err),
+		}
+	}
+
+	return SnippetStats{
+		SnippetID:  id,
+		TotalViews: total,
+		Daily:      histogram(id, since, today, daily),
+	}, nil
+}
+
+// Popular returns up to limit active snippets with the most reads during the last days, today included
+func (s *SnippetService) Popular(ctx context.Context, limit uint, days uint) ([]PopularSnippet, *service.Error) {
+	since := truncateToDay(s.now()).AddDate(0, 0, 1-int(days))
+
+	popular, err := s.storage.Popular(ctx, since, limit)
+	if err != nil {
+		s.logger.Error("failed to get popular snippets", slog.Any("err", err))
+		return nil, &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to get popular snippets: %w", err),
+		}
+	}
+
+	return popular, nil
+}
+
 // SoftDelete mark a single snippet as deleted
 func (s *SnippetService) SoftDelete(ctx context.Context, id uint) *service.Error {
 	switch err := s.storage.SoftDelete(ctx, id); {
@@ -130,3 +638,33 @@ func (s *SnippetService) SoftDelete(ctx context.Context, id uint) *service.Error
 		}
 	}
 }
+
+// SoftDeleteBatch marks several snippets as deleted.
+// It returns a slice of errors for every passed ID: nil if the snippet was deleted, or a NotFound error.
+func (s *SnippetService) SoftDeleteBatch(ctx context.Context, ids []uint) ([]*service.Error, *service.Error) {
+	deletedIDs, err := s.storage.SoftDeleteBatch(ctx, ids)
+	if err != nil {
+		s.logger.Error("failed to soft delete batch of snippets", slog.Any("err", err))
+		return nil, &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to delete snippets: %w", err),
+		}
+	}
+
+	deleted := make(map[uint]struct{}, len(deletedIDs))
+	for _, id := range deletedIDs {
+		deleted[id] = struct{}{}
+	}
+
+	results := make([]*service.Error, len(ids))
+	for i, id := range ids {
+		if _, ok := deleted[id]; !ok {
+			results[i] = &service.Error{
+				Type: service.NotFound,
+				Base: ErrNotFound,
+			}
+		}
+	}
+
+	return results, nil
+}
@@ -11,7 +11,9 @@ package snippets_test
 
 import (
 	context "context"
+	sql "database/sql"
 	reflect "reflect"
+	time "time"
 
 	snippets "github.com/titusjaka/go-sample/v2/internal/business/snippets"
 	service "github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
@@ -22,6 +24,7 @@ import (
 type MockStorage struct {
 	ctrl     *gomock.Controller
 	recorder *MockStorageMockRecorder
+	isgomock struct{}
 }
 
 // MockStorageMockRecorder is the mock recorder for MockStorage.
@@ -41,6 +44,45 @@ func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
 	return m.recorder
 }
 
+// AnnounceExpired mocks base method.
+func (m *MockStorage) AnnounceExpired(ctx context.Context, limit uint) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnnounceExpired", ctx, limit)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AnnounceExpired indicates an expected call of AnnounceExpired.
+func (mr *MockStorageMockRecorder) AnnounceExpired(ctx, limit any) *MockStorageAnnounceExpiredCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnnounceExpired", reflect.TypeOf((*MockStorage)(nil).AnnounceExpired), ctx, limit)
+	return &MockStorageAnnounceExpiredCall{Call: call}
+}
+
+// MockStorageAnnounceExpiredCall wrap *gomock.Call
+type MockStorageAnnounceExpiredCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageAnnounceExpiredCall) Return(arg0 uint, arg1 error) *MockStorageAnnounceExpiredCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageAnnounceExpiredCall) Do(f func(context.Context, uint) (uint, error)) *MockStorageAnnounceExpiredCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageAnnounceExpiredCall) DoAndReturn(f func(context.Context, uint) (uint, error)) *MockStorageAnnounceExpiredCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Create mocks base method.
 func (m *MockStorage) Create(ctx context.Context, snippet snippets.Snippet) (uint, error) {
 	m.ctrl.T.Helper()
@@ -80,6 +122,45 @@ func (c *MockStorageCreateCall) DoAndReturn(f func(context.Context, snippets.Sni
 	return c
 }
 
+// CreateBatch mocks base method.
+func (m *MockStorage) CreateBatch(ctx context.Context, batch []snippets.Snippet) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, batch)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockStorageMockRecorder) CreateBatch(ctx, batch any) *MockStorageCreateBatchCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockStorage)(nil).CreateBatch), ctx, batch)
+	return &MockStorageCreateBatchCall{Call: call}
+}
+
+// MockStorageCreateBatchCall wrap *gomock.Call
+type MockStorageCreateBatchCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageCreateBatchCall) Return(arg0 []uint, arg1 error) *MockStorageCreateBatchCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageCreateBatchCall) Do(f func(context.Context, []snippets.Snippet) ([]uint, error)) *MockStorageCreateBatchCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageCreateBatchCall) DoAndReturn(f func(context.Context, []snippets.Snippet) ([]uint, error)) *MockStorageCreateBatchCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Get mocks base method.
 func (m *MockStorage) Get(ctx context.Context, id uint) (snippets.Snippet, error) {
 	m.ctrl.T.Helper()
@@ -119,6 +200,83 @@ func (c *MockStorageGetCall) DoAndReturn(f func(context.Context, uint) (snippets
 	return c
 }
 
+// Iterate mocks base method.
+func (m *MockStorage) Iterate(ctx context.Context, fn func(snippets.Snippet) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Iterate", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Iterate indicates an expected call of Iterate.
+func (mr *MockStorageMockRecorder) Iterate(ctx, fn any) *MockStorageIterateCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Iterate", reflect.TypeOf((*MockStorage)(nil).Iterate), ctx, fn)
+	return &MockStorageIterateCall{Call: call}
+}
+
+// MockStorageIterateCall wrap *gomock.Call
+type MockStorageIterateCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageIterateCall) Return(arg0 error) *MockStorageIterateCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageIterateCall) Do(f func(context.Context, func(snippets.Snippet) error) error) *MockStorageIterateCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageIterateCall) DoAndReturn(f func(context.Context, func(snippets.Snippet) error) error) *MockStorageIterateCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Lineage mocks base method.
+func (m *MockStorage) Lineage(ctx context.Context, id uint) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lineage", ctx, id)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lineage indicates an expected call of Lineage.
+func (mr *MockStorageMockRecorder) Lineage(ctx, id any) *MockStorageLineageCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lineage", reflect.TypeOf((*MockStorage)(nil).Lineage), ctx, id)
+	return &MockStorageLineageCall{Call: call}
+}
+
+// MockStorageLineageCall wrap *gomock.Call
+type MockStorageLineageCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageLineageCall) Return(arg0 []uint, arg1 error) *MockStorageLineageCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageLineageCall) Do(f func(context.Context, uint) ([]uint, error)) *MockStorageLineageCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageLineageCall) DoAndReturn(f func(context.Context, uint) ([]uint, error)) *MockStorageLineageCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // List mocks base method.
 func (m *MockStorage) List(ctx context.Context, pagination service.Pagination) ([]snippets.Snippet, error) {
 	m.ctrl.T.Helper()
@@ -158,6 +316,122 @@ func (c *MockStorageListCall) DoAndReturn(f func(context.Context, service.Pagina
 	return c
 }
 
+// LockQuota mocks base method.
+func (m *MockStorage) LockQuota(ctx context.Context, caller string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockQuota", ctx, caller)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockQuota indicates an expected call of LockQuota.
+func (mr *MockStorageMockRecorder) LockQuota(ctx, caller any) *MockStorageLockQuotaCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockQuota", reflect.TypeOf((*MockStorage)(nil).LockQuota), ctx, caller)
+	return &MockStorageLockQuotaCall{Call: call}
+}
+
+// MockStorageLockQuotaCall wrap *gomock.Call
+type MockStorageLockQuotaCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageLockQuotaCall) Return(arg0 error) *MockStorageLockQuotaCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageLockQuotaCall) Do(f func(context.Context, string) error) *MockStorageLockQuotaCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageLockQuotaCall) DoAndReturn(f func(context.Context, string) error) *MockStorageLockQuotaCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Popular mocks base method.
+func (m *MockStorage) Popular(ctx context.Context, since time.Time, limit uint) ([]snippets.PopularSnippet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Popular", ctx, since, limit)
+	ret0, _ := ret[0].([]snippets.PopularSnippet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Popular indicates an expected call of Popular.
+func (mr *MockStorageMockRecorder) Popular(ctx, since, limit any) *MockStoragePopularCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Popular", reflect.TypeOf((*MockStorage)(nil).Popular), ctx, since, limit)
+	return &MockStoragePopularCall{Call: call}
+}
+
+// MockStoragePopularCall wrap *gomock.Call
+type MockStoragePopularCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStoragePopularCall) Return(arg0 []snippets.PopularSnippet, arg1 error) *MockStoragePopularCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStoragePopularCall) Do(f func(context.Context, time.Time, uint) ([]snippets.PopularSnippet, error)) *MockStoragePopularCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStoragePopularCall) DoAndReturn(f func(context.Context, time.Time, uint) ([]snippets.PopularSnippet, error)) *MockStoragePopularCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// PurgeExpired mocks base method.
+func (m *MockStorage) PurgeExpired(ctx context.Context, before time.Time, limit uint) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeExpired", ctx, before, limit)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeExpired indicates an expected call of PurgeExpired.
+func (mr *MockStorageMockRecorder) PurgeExpired(ctx, before, limit any) *MockStoragePurgeExpiredCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeExpired", reflect.TypeOf((*MockStorage)(nil).PurgeExpired), ctx, before, limit)
+	return &MockStoragePurgeExpiredCall{Call: call}
+}
+
+// MockStoragePurgeExpiredCall wrap *gomock.Call
+type MockStoragePurgeExpiredCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStoragePurgeExpiredCall) Return(arg0 uint, arg1 error) *MockStoragePurgeExpiredCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStoragePurgeExpiredCall) Do(f func(context.Context, time.Time, uint) (uint, error)) *MockStoragePurgeExpiredCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStoragePurgeExpiredCall) DoAndReturn(f func(context.Context, time.Time, uint) (uint, error)) *MockStoragePurgeExpiredCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // SoftDelete mocks base method.
 func (m *MockStorage) SoftDelete(ctx context.Context, id uint) error {
 	m.ctrl.T.Helper()
@@ -196,6 +470,45 @@ func (c *MockStorageSoftDeleteCall) DoAndReturn(f func(context.Context, uint) er
 	return c
 }
 
+// SoftDeleteBatch mocks base method.
+func (m *MockStorage) SoftDeleteBatch(ctx context.Context, ids []uint) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDeleteBatch", ctx, ids)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SoftDeleteBatch indicates an expected call of SoftDeleteBatch.
+func (mr *MockStorageMockRecorder) SoftDeleteBatch(ctx, ids any) *MockStorageSoftDeleteBatchCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDeleteBatch", reflect.TypeOf((*MockStorage)(nil).SoftDeleteBatch), ctx, ids)
+	return &MockStorageSoftDeleteBatchCall{Call: call}
+}
+
+// MockStorageSoftDeleteBatchCall wrap *gomock.Call
+type MockStorageSoftDeleteBatchCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageSoftDeleteBatchCall) Return(arg0 []uint, arg1 error) *MockStorageSoftDeleteBatchCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageSoftDeleteBatchCall) Do(f func(context.Context, []uint) ([]uint, error)) *MockStorageSoftDeleteBatchCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageSoftDeleteBatchCall) DoAndReturn(f func(context.Context, []uint) ([]uint, error)) *MockStorageSoftDeleteBatchCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Total mocks base method.
 func (m *MockStorage) Total(ctx context.Context) (uint, error) {
 	m.ctrl.T.Helper()
@@ -234,3 +547,182 @@ func (c *MockStorageTotalCall) DoAndReturn(f func(context.Context) (uint, error)
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
+
+// UpdateContent mocks base method.
+func (m *MockStorage) UpdateContent(ctx context.Context, snippet snippets.Snippet) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateContent", ctx, snippet)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateContent indicates an expected call of UpdateContent.
+func (mr *MockStorageMockRecorder) UpdateContent(ctx, snippet any) *MockStorageUpdateContentCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateContent", reflect.TypeOf((*MockStorage)(nil).UpdateContent), ctx, snippet)
+	return &MockStorageUpdateContentCall{Call: call}
+}
+
+// MockStorageUpdateContentCall wrap *gomock.Call
+type MockStorageUpdateContentCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageUpdateContentCall) Return(arg0 error) *MockStorageUpdateContentCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageUpdateContentCall) Do(f func(context.Context, snippets.Snippet) error) *MockStorageUpdateContentCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageUpdateContentCall) DoAndReturn(f func(context.Context, snippets.Snippet) error) *MockStorageUpdateContentCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Usage mocks base method.
+func (m *MockStorage) Usage(ctx context.Context, caller string, since time.Time) (snippets.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Usage", ctx, caller, since)
+	ret0, _ := ret[0].(snippets.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Usage indicates an expected call of Usage.
+func (mr *MockStorageMockRecorder) Usage(ctx, caller, since any) *MockStorageUsageCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Usage", reflect.TypeOf((*MockStorage)(nil).Usage), ctx, caller, since)
+	return &MockStorageUsageCall{Call: call}
+}
+
+// MockStorageUsageCall wrap *gomock.Call
+type MockStorageUsageCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageUsageCall) Return(arg0 snippets.Usage, arg1 error) *MockStorageUsageCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageUsageCall) Do(f func(context.Context, string, time.Time) (snippets.Usage, error)) *MockStorageUsageCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageUsageCall) DoAndReturn(f func(context.Context, string, time.Time) (snippets.Usage, error)) *MockStorageUsageCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Views mocks base method.
+func (m *MockStorage) Views(ctx context.Context, id uint, since time.Time) (uint64, []snippets.DailyViews, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Views", ctx, id, since)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].([]snippets.DailyViews)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Views indicates an expected call of Views.
+func (mr *MockStorageMockRecorder) Views(ctx, id, since any) *MockStorageViewsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Views", reflect.TypeOf((*MockStorage)(nil).Views), ctx, id, since)
+	return &MockStorageViewsCall{Call: call}
+}
+
+// MockStorageViewsCall wrap *gomock.Call
+type MockStorageViewsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageViewsCall) Return(arg0 uint64, arg1 []snippets.DailyViews, arg2 error) *MockStorageViewsCall {
+	c.Call = c.Call.Return(arg0, arg1, arg2)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageViewsCall) Do(f func(context.Context, uint, time.Time) (uint64, []snippets.DailyViews, error)) *MockStorageViewsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageViewsCall) DoAndReturn(f func(context.Context, uint, time.Time) (uint64, []snippets.DailyViews, error)) *MockStorageViewsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MockTransactor is a mock of Transactor interface.
+type MockTransactor struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransactorMockRecorder
+	isgomock struct{}
+}
+
+// MockTransactorMockRecorder is the mock recorder for MockTransactor.
+type MockTransactorMockRecorder struct {
+	mock *MockTransactor
+}
+
+// NewMockTransactor creates a new mock instance.
+func NewMockTransactor(ctrl *gomock.Controller) *MockTransactor {
+	mock := &MockTransactor{ctrl: ctrl}
+	mock.recorder = &MockTransactorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransactor) EXPECT() *MockTransactorMockRecorder {
+	return m.recorder
+}
+
+// RunInTx mocks base method.
+func (m *MockTransactor) RunInTx(ctx context.Context, isolation sql.IsolationLevel, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunInTx", ctx, isolation, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunInTx indicates an expected call of RunInTx.
+func (mr *MockTransactorMockRecorder) RunInTx(ctx, isolation, fn any) *MockTransactorRunInTxCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunInTx", reflect.TypeOf((*MockTransactor)(nil).RunInTx), ctx, isolation, fn)
+	return &MockTransactorRunInTxCall{Call: call}
+}
+
+// MockTransactorRunInTxCall wrap *gomock.Call
+type MockTransactorRunInTxCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockTransactorRunInTxCall) Return(arg0 error) *MockTransactorRunInTxCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockTransactorRunInTxCall) Do(f func(context.Context, sql.IsolationLevel, func(context.Context) error) error) *MockTransactorRunInTxCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockTransactorRunInTxCall) DoAndReturn(f func(context.Context, sql.IsolationLevel, func(context.Context) error) error) *MockTransactorRunInTxCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
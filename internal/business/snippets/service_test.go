@@ -3,6 +3,7 @@ package snippets_test
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -50,11 +51,12 @@ func TestSnippetService_Create(t *testing.T) {
 			}
 
 			snippetPassedToStorage := snippets.Snippet{
-				Title:     snippetToCreate.Title,
-				Content:   snippetToCreate.Content,
-				CreatedAt: fakeNow,
-				UpdatedAt: fakeNow,
-				ExpiresAt: expiresAt,
+				Title:       snippetToCreate.Title,
+				Content:     snippetToCreate.Content,
+				ContentSize: len(snippetToCreate.Content),
+				CreatedAt:   fakeNow,
+				UpdatedAt:   fakeNow,
+				ExpiresAt:   expiresAt,
 			}
 
 			snippetID := uint(200)
@@ -103,11 +105,12 @@ func TestSnippetService_Create(t *testing.T) {
 			}
 
 			snippetPassedToStorage := snippets.Snippet{
-				Title:     snippetToCreate.Title,
-				Content:   snippetToCreate.Content,
-				CreatedAt: fakeNow,
-				UpdatedAt: fakeNow,
-				ExpiresAt: expiresAt.UTC(),
+				Title:       snippetToCreate.Title,
+				Content:     snippetToCreate.Content,
+				ContentSize: len(snippetToCreate.Content),
+				CreatedAt:   fakeNow,
+				UpdatedAt:   fakeNow,
+				ExpiresAt:   expiresAt.UTC(),
 			}
 
 			snippetID := uint(200)
@@ -592,3 +595,864 @@ func TestSnippetService_SoftDelete(t *testing.T) {
 		})
 	})
 }
+
+func TestSnippetService_CreateBatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Atomic mode", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		fakeNow := time.Now().UTC()
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return fakeNow },
+		)
+
+		// ===============================================
+		// Init test data
+		expiresAt := fakeNow.Add(time.Hour * 24)
+
+		batch := []snippets.Snippet{
+			{Title: "Snippet #1", Content: "Some text here…", ExpiresAt: expiresAt},
+			{Title: "Snippet #2", Content: "Another text here…", ExpiresAt: expiresAt},
+		}
+
+		snippetsPassedToStorage := []snippets.Snippet{
+			{Title: "Snippet #1", Content: "Some text here…", ContentSize: 17, CreatedAt: fakeNow, UpdatedAt: fakeNow, ExpiresAt: expiresAt},
+			{Title: "Snippet #2", Content: "Another text here…", ContentSize: 20, CreatedAt: fakeNow, UpdatedAt: fakeNow, ExpiresAt: expiresAt},
+		}
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().CreateBatch(ctx, snippetsPassedToStorage).Return([]uint{10, 11}, nil)
+
+		// ===============================================
+		// Run Test
+		results, svcErr := snippetService.CreateBatch(ctx, batch, true)
+		require.Nil(t, svcErr)
+		require.Len(t, results, 2)
+
+		for i, result := range results {
+			expected := snippetsPassedToStorage[i]
+			expected.ID = uint(10 + i)
+
+			assert.Nil(t, result.Err)
+			assert.Equal(t, expected, result.Snippet)
+		}
+	})
+
+	t.Run("Atomic mode: storage error", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return time.Now().UTC() },
+		)
+
+		// ===============================================
+		// Init test data
+		expectedErr := errors.New("something wrong happen 😱")
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().CreateBatch(ctx, gomock.Any()).Return(nil, expectedErr)
+
+		// ===============================================
+		// Run Test
+		results, svcErr := snippetService.CreateBatch(ctx, []snippets.Snippet{{}, {}}, true)
+		require.NotNil(t, svcErr)
+		assert.Nil(t, results)
+		assert.Equal(t, service.InternalError, svcErr.Type)
+		assert.ErrorIs(t, svcErr, expectedErr)
+	})
+
+	t.Run("Best-effort mode", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		fakeNow := time.Now().UTC()
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return fakeNow },
+		)
+
+		// ===============================================
+		// Init test data
+		batch := []snippets.Snippet{
+			{Title: "Snippet #1", Content: "Some text here…"},
+			{Title: "Snippet #2", Content: "Another text here…"},
+		}
+
+		expectedErr := errors.New("something wrong happen 😱")
+
+		// ===============================================
+		// Describe Mock Calls
+		gomock.InOrder(
+			mockStorage.EXPECT().Create(ctx, gomock.Any()).Return(uint(0), expectedErr),
+			mockStorage.EXPECT().Create(ctx, gomock.Any()).Return(uint(11), nil),
+		)
+
+		// ===============================================
+		// Run Test
+		results, svcErr := snippetService.CreateBatch(ctx, batch, false)
+		require.Nil(t, svcErr)
+		require.Len(t, results, 2)
+
+		require.NotNil(t, results[0].Err)
+		assert.ErrorIs(t, results[0].Err, expectedErr)
+
+		assert.Nil(t, results[1].Err)
+		assert.Equal(t, uint(11), results[1].Snippet.ID)
+		assert.Equal(t, "Snippet #2", results[1].Snippet.Title)
+	})
+}
+
+func TestSnippetService_SoftDeleteBatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Successfully delete snippets", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return time.Now().UTC() },
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().SoftDeleteBatch(ctx, []uint{1, 2, 3}).Return([]uint{3, 1}, nil)
+
+		// ===============================================
+		// Run Test
+		results, svcErr := snippetService.SoftDeleteBatch(ctx, []uint{1, 2, 3})
+		require.Nil(t, svcErr)
+		require.Len(t, results, 3)
+
+		assert.Nil(t, results[0])
+		require.NotNil(t, results[1])
+		assert.Equal(t, service.NotFound, results[1].Type)
+		assert.Nil(t, results[2])
+	})
+
+	t.Run("Storage error", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return time.Now().UTC() },
+		)
+
+		// ===============================================
+		// Init test data
+		expectedErr := errors.New("something wrong happen 😱")
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().SoftDeleteBatch(ctx, []uint{1}).Return(nil, expectedErr)
+
+		// ===============================================
+		// Run Test
+		results, svcErr := snippetService.SoftDeleteBatch(ctx, []uint{1})
+		require.NotNil(t, svcErr)
+		assert.Nil(t, results)
+		assert.Equal(t, service.InternalError, svcErr.Type)
+		assert.ErrorIs(t, svcErr, expectedErr)
+	})
+}
+
+func TestSnippetService_Export(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Protected snippets are skipped", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return time.Now().UTC() },
+		)
+
+		// ===============================================
+		// Init test data
+		stored := []snippets.Snippet{
+			{ID: 1, Title: "Snippet #1", Content: "Plain content"},
+			{ID: 2, Title: "Snippet #2", Content: "c2VjcmV0", Encryption: snippets.EncryptionPassphrase},
+			{ID: 3, Title: "Snippet #3", Content: "Another content"},
+		}
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Iterate(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, fn func(snippets.Snippet) error) error {
+				for _, snippet := range stored {
+					if err := fn(snippet); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		)
+
+		// ===============================================
+		// Run Test
+		var exported []snippets.Snippet
+
+		svcErr := snippetService.Export(ctx, func(snippet snippets.Snippet) error {
+			exported = append(exported, snippet)
+			return nil
+		})
+		require.Nil(t, svcErr)
+		assert.Equal(t, []snippets.Snippet{stored[0], stored[2]}, exported)
+	})
+
+	t.Run("Storage error", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return time.Now().UTC() },
+		)
+
+		// ===============================================
+		// Init test data
+		expectedErr := errors.New("something wrong happen 😱")
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Iterate(ctx, gomock.Any()).Return(expectedErr)
+
+		// ===============================================
+		// Run Test
+		svcErr := snippetService.Export(ctx, func(snippets.Snippet) error { return nil })
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.InternalError, svcErr.Type)
+		assert.ErrorIs(t, svcErr, expectedErr)
+	})
+}
+
+func TestSnippetService_Fork(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+	fakeTimeCreated := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	fakeTimeExpires := time.Date(2050, 1, 1, 1, 1, 1, 0, time.UTC)
+
+	parent := snippets.Snippet{
+		ID:        2,
+		Title:     "Snippet #2",
+		Content:   "Very important text",
+		CreatedAt: fakeTimeCreated,
+		UpdatedAt: fakeTimeCreated,
+		ExpiresAt: fakeTimeExpires,
+		ParentID:  1,
+	}
+
+	t.Run("Successfully fork a snippet", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Get(ctx, parent.ID).Return(parent, nil)
+		mockStorage.EXPECT().Lineage(ctx, parent.ID).Return([]uint{1}, nil)
+		mockStorage.EXPECT().Create(ctx, snippets.Snippet{
+			Title:       "My fork",
+			Content:     parent.Content,
+			ContentSize: len(parent.Content),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			ExpiresAt:   parent.ExpiresAt,
+			ParentID:    parent.ID,
+		}).Return(uint(3), nil)
+
+		// ===============================================
+		// Run Test
+		fork, svcErr := snippetService.Fork(ctx, parent.ID, snippets.Snippet{Title: "My fork"})
+		require.Nil(t, svcErr)
+
+		assert.Equal(t, snippets.Snippet{
+			ID:          3,
+			Title:       "My fork",
+			Content:     parent.Content,
+			ContentSize: len(parent.Content),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			ExpiresAt:   parent.ExpiresAt,
+			ParentID:    parent.ID,
+			Lineage:     []uint{2, 1},
+		}, fork)
+	})
+
+	t.Run("Deleted snippet can't be forked", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+		)
+
+		// ===============================================
+		// Init test data
+		deleted := parent
+		deleted.ParentID = 0
+		deleted.ExpiresAt = now.Add(-time.Minute)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Get(ctx, parent.ID).Return(deleted, nil)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := snippetService.Fork(ctx, parent.ID, snippets.Snippet{})
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.NotFound, svcErr.Type)
+	})
+
+	t.Run("Snippet not found", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Get(ctx, uint(42)).Return(snippets.Snippet{}, snippets.ErrNotFound)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := snippetService.Fork(ctx, 42, snippets.Snippet{})
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.NotFound, svcErr.Type)
+	})
+}
+
+func TestSnippetService_Stats(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+	today := time.Date(2024, 10, 7, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Days without views are filled with zeros", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Get(ctx, uint(1)).Return(snippets.Snippet{ID: 1}, nil)
+		mockStorage.EXPECT().Views(ctx, uint(1), today.AddDate(0, 0, -2)).Return(uint64(42), []snippets.DailyViews{
+			{SnippetID: 1, Day: today.AddDate(0, 0, -2), Views: 3},
+			{SnippetID: 1, Day: today, Views: 5},
+		}, nil)
+
+		// ===============================================
+		// Run Test
+		stats, svcErr := snippetService.Stats(ctx, 1, 3)
+		require.Nil(t, svcErr)
+
+		assert.Equal(t, snippets.SnippetStats{
+			SnippetID:  1,
+			TotalViews: 42,
+			Daily: []snippets.DailyViews{
+				{SnippetID: 1, Day: today.AddDate(0, 0, -2), Views: 3},
+				{SnippetID: 1, Day: today.AddDate(0, 0, -1), Views: 0},
+				{SnippetID: 1, Day: today, Views: 5},
+			},
+		}, stats)
+	})
+
+	t.Run("Snippet not found", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Get(ctx, uint(1)).Return(snippets.Snippet{}, snippets.ErrNotFound)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := snippetService.Stats(ctx, 1, 30)
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.NotFound, svcErr.Type)
+	})
+}
+
+func TestSnippetService_Popular(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+	today := time.Date(2024, 10, 7, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Successfully get popular snippets", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+		)
+
+		// ===============================================
+		// Init test data
+		popular := []snippets.PopularSnippet{
+			{ID: 2, Title: "Snippet #2", Views: 10},
+			{ID: 1, Title: "Snippet #1", Views: 7},
+		}
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Popular(ctx, today.AddDate(0, 0, -6), uint(5)).Return(popular, nil)
+
+		// ===============================================
+		// Run Test
+		result, svcErr := snippetService.Popular(ctx, 5, 7)
+		require.Nil(t, svcErr)
+		assert.Equal(t, popular, result)
+	})
+
+	t.Run("Storage error", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Popular(ctx, today, uint(10)).Return(nil, errors.New("unexpected error"))
+
+		// ===============================================
+		// Run Test
+		_, svcErr := snippetService.Popular(ctx, 10, 1)
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.InternalError, svcErr.Type)
+	})
+}
+
+func TestSnippetService_Instantiate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+	fakeTimeCreated := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	fakeTimeExpires := time.Date(2050, 1, 1, 1, 1, 1, 0, time.UTC)
+
+	template := snippets.Snippet{
+		ID:        2,
+		Title:     "Greeting",
+		Content:   "Hello, {{name}}!",
+		CreatedAt: fakeTimeCreated,
+		UpdatedAt: fakeTimeCreated,
+		ExpiresAt: fakeTimeExpires,
+		Template: &snippets.Template{Variables: []snippets.TemplateVariable{
+			{Name: "name", Default: "world"},
+		}},
+	}
+
+	t.Run("Render without saving", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Get(ctx, template.ID).Return(template, nil)
+
+		// ===============================================
+		// Run Test
+		rendered, svcErr := snippetService.Instantiate(ctx, template.ID, snippets.Instantiation{})
+		require.Nil(t, svcErr)
+
+		assert.Equal(t, snippets.Snippet{
+			Title:     "Greeting",
+			Content:   "Hello, world!",
+			ExpiresAt: fakeTimeExpires,
+		}, rendered)
+	})
+
+	t.Run("Render and save", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Get(ctx, template.ID).Return(template, nil)
+		mockStorage.EXPECT().Create(ctx, snippets.Snippet{
+			Title:       "Hello, Gopher",
+			Content:     "Hello, Gopher!",
+			ContentSize: 14,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			ExpiresAt:   fakeTimeExpires,
+		}).Return(uint(3), nil)
+
+		// ===============================================
+		// Run Test
+		saved, svcErr := snippetService.Instantiate(ctx, template.ID, snippets.Instantiation{
+			Values: map[string]string{"name": "Gopher"},
+			Save:   true,
+			Title:  "Hello, Gopher",
+		})
+		require.Nil(t, svcErr)
+
+		assert.Equal(t, snippets.Snippet{
+			ID:          3,
+			Title:       "Hello, Gopher",
+			Content:     "Hello, Gopher!",
+			ContentSize: 14,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			ExpiresAt:   fakeTimeExpires,
+		}, saved)
+	})
+
+	t.Run("Invalid values", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Get(ctx, template.ID).Return(template, nil)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := snippetService.Instantiate(ctx, template.ID, snippets.Instantiation{
+			Values: map[string]string{"title": "Mr"},
+		})
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.BadRequest, svcErr.Type)
+		assert.EqualError(t, svcErr.Base, "values: title: is not declared by the template.")
+	})
+
+	t.Run("Rendered content is too long even without saving", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+		)
+
+		repeated := template
+		repeated.Content = strings.Repeat("{{name}}", 10)
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Get(ctx, template.ID).Return(repeated, nil)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := snippetService.Instantiate(ctx, template.ID, snippets.Instantiation{
+			Values: map[string]string{"name": strings.Repeat("a", snippets.MaxContentLength/5)},
+		})
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.BadRequest, svcErr.Type)
+		assert.ErrorIs(t, svcErr.Base, snippets.ErrRenderedTooLong)
+	})
+
+	t.Run("Snippet is not a template", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+		)
+
+		// ===============================================
+		// Init test data
+		regular := template
+		regular.Template = nil
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Get(ctx, template.ID).Return(regular, nil)
+
+		// ===============================================
+		// Run Test
+		_, svcErr := snippetService.Instantiate(ctx, template.ID, snippets.Instantiation{})
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.BadRequest, svcErr.Type)
+		assert.ErrorIs(t, svcErr.Base, snippets.ErrNotTemplate)
+	})
+}
+
+func TestSnippetService_UpdateContent(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+	fakeTimeCreated := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	fakeTimeExpires := time.Date(2050, 1, 1, 1, 1, 1, 0, time.UTC)
+
+	snippet := snippets.Snippet{
+		ID:        2,
+		Title:     "Snippet title",
+		Content:   "Snippet content",
+		CreatedAt: fakeTimeCreated,
+		UpdatedAt: fakeTimeCreated,
+		ExpiresAt: fakeTimeExpires,
+	}
+
+	t.Run("Successfully update content", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+
+		snippetService := snippets.NewService(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return now },
+		)
+
+		// ===============================================
+		// Init test data
+		expected := snippet
+		expected.Content = "Edited content"
+		expected.ContentSize = len("Edited content")
+		expected.UpdatedAt = now
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Get(ctx, snippet.ID).Return(snippet, nil)
+		mockStorage.EXPECT().UpdateContent(ctx, expected).Return(nil)
+
+		// ===============================================
+		// Run Test
+		actual, svcErr := snippetService.UpdateContent(ctx, snippet.ID, "Edited content")
+		require.Nil(t, svcErr)
+		assert.Equal(t, expected, actual)
+	})
+
+	t.Run("Failed to update content", func(t *testing.T) {
+		t.Parallel()
+
+		protected := snippet
+		protected.Encryption = snippets.EncryptionPassphrase
+
+		expired := snippet
+		expired.ExpiresAt = now.Add(-time.Hour)
+
+		tests := []struct {
+			name     string
+			stored   snippets.Snippet
+			content  string
+			wantType service.ErrorType
+		}{
+			{name: "Protected snippet", stored: protected, content: "Edited content", wantType: service.Forbidden},
+			{name: "Expired snippet", stored: expired, content: "Edited content", wantType: service.NotFound},
+			{
+				name:     "Content is too long",
+				stored:   snippet,
+				content:  strings.Repeat("a", snippets.MaxContentLength+1),
+				wantType: service.BadRequest,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				ctx := context.Background()
+				ctrl := gomock.NewController(t)
+
+				// ===============================================
+				// Init Mocks and Service
+				mockStorage := NewMockStorage(ctrl)
+
+				snippetService := snippets.NewService(
+					mockStorage,
+					nopslog.NewNoplogger(),
+					func() time.Time { return now },
+				)
+
+				// ===============================================
+				// Describe Mock Calls
+				mockStorage.EXPECT().Get(ctx, snippet.ID).Return(tt.stored, nil)
+
+				// ===============================================
+				// Run Test
+				_, svcErr := snippetService.UpdateContent(ctx, snippet.ID, tt.content)
+				require.NotNil(t, svcErr)
+				assert.Equal(t, tt.wantType, svcErr.Type)
+			})
+		}
+	})
+}
@@ -12,4 +12,44 @@ type Snippet struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	ExpiresAt time.Time
+
+	// ParentID is an ID of the snippet this one was forked from, 0 for original snippets
+	ParentID uint
+	// Lineage holds IDs of all ancestors of a forked snippet, starting from its parent.
+	// It's not stored and is only filled when a single snippet is requested.
+	Lineage []uint
+
+	// Template holds variables of a template snippet, nil for regular snippets
+	Template *Template
+
+	// Encryption describes how Content is stored at rest.
+	// If the content is encrypted, Content holds base64-encoded ciphertext.
+	Encryption ContentEncryption
+	// PassphraseSalt is a salt used to derive a key from the snippet passphrase
+	PassphraseSalt []byte
+	// WrappedKey is a data key encrypted with the server key
+	WrappedKey []byte
+
+	// Caller is the name of the API caller that created the snippet, empty for snippets created by CLI commands.
+	// It's only stored to enforce quotas.
+	Caller string
+	// ContentSize is the size of the plain content in bytes. It's only stored to enforce quotas.
+	ContentSize int
+}
+
+// ContentEncryption describes how a snippet content is stored at rest
+type ContentEncryption uint8
+
+const (
+	// EncryptionNone means content is stored as is
+	EncryptionNone ContentEncryption = iota
+	// EncryptionPassphrase means content is encrypted with a key derived from the snippet passphrase
+	EncryptionPassphrase
+	// EncryptionEnvelope means content is encrypted with a random data key wrapped by the server key
+	EncryptionEnvelope
+)
+
+// Protected reports whether a passphrase is required to read the snippet content
+func (s Snippet) Protected() bool {
+	return s.Encryption == EncryptionPassphrase
 }
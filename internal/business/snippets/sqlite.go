@@ -0,0 +1,789 @@
+package snippets
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+// SQLiteStorage implements storage interface and provides methods to manipulate data in SQLite storage.
+// It's meant for single-binary deployments without PostgreSQL and has the same semantics as PGStorage.
+//
+// Times are stored as Unix time in microseconds, the precision of PostgreSQL timestamps,
+// and are compared to the time of nowFunc instead of the database clock.
+// Events are not recorded, since there's no outbox to relay them from.
+type SQLiteStorage struct {
+	conn  *sql.DB
+	codec *ContentCodec
+	now   func() time.Time
+}
+
+// SQLiteStorageOption configures optional SQLiteStorage parameters
+type SQLiteStorageOption func(*SQLiteStorage)
+
+// WithSQLiteCompression sets an algorithm used to compress contents larger than threshold (in bytes)
+func WithSQLiteCompression(compression Compression, threshold int) SQLiteStorageOption {
+	return func(s *SQLiteStorage) {
+		s.codec = NewContentCodec(compression, threshold)
+	}
+}
+
+// NewSQLiteStorage returns a new instance of SQLiteStorage
+func NewSQLiteStorage(conn *sql.DB, nowFunc func() time.Time, opts ...SQLiteStorageOption) *SQLiteStorage {
+	s := &SQLiteStorage{
+		conn:  conn,
+		codec: NewContentCodec(CompressionZstd, DefaultCompressionThreshold),
+		now:   nowFunc,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// sqliteSnippetColumns are columns read by scanSnippet
+const sqliteSnippetColumns = `
+	s.id,
+	s.title,
+	c.data,
+	c.compression,
+	s.created_at,
+	s.updated_at,
+	s.expires_at,
+	s.encryption,
+	s.passphrase_salt,
+	s.wrapped_key,
+	COALESCE(s.parent_id, 0),
+	s.template
+`
+
+// Get returns a single snippet from storage
+func (s *SQLiteStorage) Get(ctx context.Context, id uint) (Snippet, error) {
+	query := `
+		SELECT ` + sqliteSnippetColumns + `
+		FROM
+			snippets s
+			JOIN snippet_contents c ON c.hash = s.content_hash
+		WHERE s.id = ?
+	`
+
+	snippet, err := s.scanSnippet(s.conn.QueryRowContext(ctx, query, id))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return Snippet{}, ErrNotFound
+	case err != nil:
+		return Snippet{}, err
+	}
+
+	return snippet, nil
+}
+
+// Create saves a single snippet to storage
+func (s *SQLiteStorage) Create(ctx context.Context, snippet Snippet) (uint, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("failed to add snippet: %w", err)
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	id, err := s.insert(ctx, tx, snippet)
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, wrapErr(err)
+	}
+
+	return id, nil
+}
+
+// UpdateContent replaces the content of a snippet along with its encryption parameters.
+// Deleted and expired snippets are not updated, ErrNotFound is returned for them.
+func (s *SQLiteStorage) UpdateContent(ctx context.Context, snippet Snippet) error {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("failed to update snippet content: %w", err)
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapErr(err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	hash, err := s.saveContent(ctx, tx, snippet.Content)
+	if err != nil {
+		return wrapErr(err)
+	}
+
+	query := `
+		UPDATE snippets
+		SET
+			content_hash = ?,
+			encryption = ?,
+			wrapped_key = ?,
+			updated_at = ?,
+			content_size = ?
+		WHERE
+			id = ?
+			AND expires_at > ?
+	`
+
+	result, err := tx.ExecContext(
+		ctx,
+		query,
+		hash,
+		snippet.Encryption,
+		snippet.WrappedKey,
+		snippet.UpdatedAt.UnixMicro(),
+		snippet.ContentSize,
+		snippet.ID,
+		s.now().UnixMicro(),
+	)
+	if err != nil {
+		return wrapErr(err)
+	}
+
+	switch affected, err := result.RowsAffected(); {
+	case err != nil:
+		return wrapErr(err)
+	case affected == 0:
+		return ErrNotFound
+	}
+
+	if err = tx.Commit(); err != nil {
+		return wrapErr(err)
+	}
+
+	return nil
+}
+
+// List returns a list of snippets from storage
+func (s *SQLiteStorage) List(ctx context.Context, pagination service.Pagination) ([]Snippet, error) {
+	query := `
+		SELECT ` + sqliteSnippetColumns + `
+		FROM snippets s
+			JOIN snippet_contents c ON c.hash = s.content_hash
+		WHERE
+			s.expires_at > ?
+		ORDER BY s.created_at DESC, s.id
+		LIMIT ?
+		OFFSET ?
+	`
+
+	// A negative limit means no limit in SQLite
+	limit := int64(-1)
+	if pagination.Limit != 0 {
+		limit = int64(pagination.Limit)
+	}
+
+	rows, err := s.conn.QueryContext(ctx, query, s.now().UnixMicro(), limit, pagination.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snippets: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var results []Snippet
+	for rows.Next() {
+		snippet, err := s.scanSnippet(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, snippet)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error from iterating snippets rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// Iterate calls fn for every active snippet ordered by id.
+// Rows are streamed from the database, so the whole result set is never held in memory.
+func (s *SQLiteStorage) Iterate(ctx context.Context, fn func(Snippet) error) error {
+	query := `
+		SELECT ` + sqliteSnippetColumns + `
+		FROM snippets s
+			JOIN snippet_contents c ON c.hash = s.content_hash
+		WHERE
+			s.expires_at > ?
+		ORDER BY s.id
+	`
+
+	rows, err := s.conn.QueryContext(ctx, query, s.now().UnixMicro())
+	if err != nil {
+		return fmt.Errorf("failed to iterate snippets: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		snippet, err := s.scanSnippet(rows)
+		if err != nil {
+			return err
+		}
+
+		if err = fn(snippet); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error from iterating snippets rows: %w", err)
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanSnippet scans a snippet row joined with its content. sql.ErrNoRows is returned as is.
+func (s *SQLiteStorage) scanSnippet(row scanner) (Snippet, error) {
+	var (
+		snippet                         Snippet
+		data                            []byte
+		compression                     Compression
+		createdAt, updatedAt, expiresAt int64
+		template                        []byte
+	)
+
+	err := row.Scan(
+		&snippet.ID,
+		&snippet.Title,
+		&data,
+		&compression,
+		&createdAt,
+		&updatedAt,
+		&expiresAt,
+		&snippet.Encryption,
+		&snippet.PassphraseSalt,
+		&snippet.WrappedKey,
+		&snippet.ParentID,
+		&template,
+	)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return Snippet{}, err
+	case err != nil:
+		return Snippet{}, fmt.Errorf("failed to scan snippet row: %w", err)
+	}
+
+	snippet.CreatedAt = fromUnixMicro(createdAt)
+	snippet.UpdatedAt = fromUnixMicro(updatedAt)
+	snippet.ExpiresAt = fromUnixMicro(expiresAt)
+
+	if snippet.Content, err = s.codec.Decode(data, compression); err != nil {
+		return Snippet{}, fmt.Errorf("failed to decode snippet content: %w", err)
+	}
+
+	if snippet.Template, err = unmarshalTemplate(template); err != nil {
+		return Snippet{}, err
+	}
+
+	return snippet, nil
+}
+
+// Lineage returns IDs of the snippet ancestors, starting from its parent up to the original snippet
+func (s *SQLiteStorage) Lineage(ctx context.Context, id uint) ([]uint, error) {
+	query := `
+		WITH RECURSIVE lineage AS (
+			SELECT parent_id, 1 AS depth
+			FROM snippets
+			WHERE id = ?
+			UNION ALL
+			SELECT s.parent_id, l.depth + 1
+			FROM snippets s
+				JOIN lineage l ON s.id = l.parent_id
+		)
+		SELECT parent_id
+		FROM lineage
+		WHERE parent_id IS NOT NULL
+		ORDER BY depth
+	`
+
+	rows, err := s.conn.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query snippet lineage: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var lineage []uint
+	for rows.Next() {
+		var parentID uint
+		if err = rows.Scan(&parentID); err != nil {
+			return nil, fmt.Errorf("failed to scan snippet lineage: %w", err)
+		}
+		lineage = append(lineage, parentID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error from iterating snippet lineage rows: %w", err)
+	}
+
+	return lineage, nil
+}
+
+// SoftDelete set `expires_at` to `now()`, so snippet is considered deleted
+func (s *SQLiteStorage) SoftDelete(ctx context.Context, id uint) error {
+	deleted, err := s.SoftDeleteBatch(ctx, []uint{id})
+	switch {
+	case err != nil:
+		return fmt.Errorf("failed to soft delete snippet from DB (ID: %d): %w", id, err)
+	case len(deleted) == 0:
+		return ErrNotFound
+	default:
+		return nil
+	}
+}
+
+// Total counts a total number of snippets
+func (s *SQLiteStorage) Total(ctx context.Context) (uint, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM snippets
+	`
+
+	var count uint
+	err := s.conn.QueryRowContext(ctx, query).Scan(&count)
+	return count, err
+}
+
+// Usage returns the consumption of an API caller: active snippets, the total size of their contents
+// and the number of snippets created since the given time
+func (s *SQLiteStorage) Usage(ctx context.Context, caller string, since time.Time) (Usage, error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE expires_at > ?),
+			COALESCE(SUM(content_size) FILTER (WHERE expires_at > ?), 0),
+			COUNT(*) FILTER (WHERE created_at > ?)
+		FROM snippets
+		WHERE caller = ?
+	`
+
+	now := s.now().UnixMicro()
+
+	var usage Usage
+	if err := s.conn.QueryRowContext(ctx, query, now, now, since.UnixMicro(), caller).Scan(
+		&usage.Snippets,
+		&usage.ContentBytes,
+		&usage.CreatedLastDay,
+	); err != nil {
+		return Usage{}, fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// LockQuota does nothing: the database is used by a single process, which serializes quota checks itself
+func (s *SQLiteStorage) LockQuota(_ context.Context, _ string) error {
+	return nil
+}
+
+// CreateBatch saves several snippets to storage within a single transaction.
+// IDs are returned in the order of passed snippets. As for PGStorage, parent IDs of the batch are not stored.
+func (s *SQLiteStorage) CreateBatch(ctx context.Context, batch []Snippet) ([]uint, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("failed to add batch of snippets: %w", err)
+	}
+
+	if len(batch) == 0 {
+		return nil, nil
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Rows of SQLite are inserted one by one anyway, so there's nothing to gain from multi-row inserts
+	ids := make([]uint, len(batch))
+	for i, snippet := range batch {
+		snippet.ParentID = 0
+		if ids[i], err = s.insert(ctx, tx, snippet); err != nil {
+			return nil, wrapErr(err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, wrapErr(err)
+	}
+
+	return ids, nil
+}
+
+// SoftDeleteBatch marks several live snippets as deleted and returns their IDs, already deleted ones are skipped
+func (s *SQLiteStorage) SoftDeleteBatch(ctx context.Context, ids []uint) ([]uint, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("failed to soft delete batch of snippets from DB: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		UPDATE snippets
+		SET
+			updated_at = ?,
+			expires_at = ?,
+			expiry_notified = TRUE
+		WHERE
+			id IN (%s)
+			AND expires_at > ?
+		RETURNING id
+	`
+
+	now := s.now().UnixMicro()
+
+	args := make([]any, 0, len(ids)+3)
+	args = append(args, now, now)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	args = append(args, now)
+
+	rows, err := s.conn.QueryContext(ctx, fmt.Sprintf(query, placeholders(len(ids))), args...)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var deleted []uint
+	for rows.Next() {
+		var id uint
+		if err = rows.Scan(&id); err != nil {
+			return nil, wrapErr(err)
+		}
+		deleted = append(deleted, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapErr(err)
+	}
+
+	return deleted, nil
+}
+
+// insert saves a snippet with its content and returns its ID
+func (s *SQLiteStorage) insert(ctx context.Context, tx *sql.Tx, snippet Snippet) (uint, error) {
+	hash, err := s.saveContent(ctx, tx, snippet.Content)
+	if err != nil {
+		return 0, err
+	}
+
+	template, err := marshalTemplate(snippet.Template)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `
+		INSERT INTO snippets
+		(
+			title,
+			content_hash,
+			created_at,
+			updated_at,
+			expires_at,
+			encryption,
+			passphrase_salt,
+			wrapped_key,
+			parent_id,
+			template,
+			caller,
+			content_size
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, 0), ?, ?, ?)
+		RETURNING id
+	`
+
+	var id uint
+	if err = tx.QueryRowContext(
+		ctx,
+		query,
+		snippet.Title,
+		hash,
+		snippet.CreatedAt.UnixMicro(),
+		snippet.UpdatedAt.UnixMicro(),
+		snippet.ExpiresAt.UnixMicro(),
+		snippet.Encryption,
+		snippet.PassphraseSalt,
+		snippet.WrappedKey,
+		snippet.ParentID,
+		template,
+		snippet.Caller,
+		snippet.ContentSize,
+	).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to insert snippet: %w", err)
+	}
+
+	return id, nil
+}
+
+// saveContent saves content to the content-addressed table and returns its hash.
+// If the same content already exists, only its reference counter is incremented.
+func (s *SQLiteStorage) saveContent(ctx context.Context, tx *sql.Tx, content string) ([]byte, error) {
+	data, compression, err := s.codec.Encode(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode content: %w", err)
+	}
+
+	query := `
+		INSERT INTO snippet_contents
+		(
+			hash,
+			data,
+			compression,
+			size,
+			ref_count
+		)
+		VALUES (?, ?, ?, ?, 1)
+		ON CONFLICT (hash) DO UPDATE
+		SET ref_count = snippet_contents.ref_count + 1
+	`
+
+	hash := ContentHash(content)
+	if _, err = tx.ExecContext(ctx, query, hash, data, compression, len(content)); err != nil {
+		return nil, fmt.Errorf("failed to save content: %w", err)
+	}
+
+	return hash, nil
+}
+
+// AddViews adds views counters to storage
+func (s *SQLiteStorage) AddViews(ctx context.Context, views []DailyViews) error {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("failed to add snippet views: %w", err)
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return wrapErr(err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	query := `
+		INSERT INTO snippet_views
+		(
+			snippet_id,
+			day,
+			views
+		)
+		SELECT id, ?, ?
+		FROM snippets
+		WHERE id = ?
+		ON CONFLICT (snippet_id, day) DO UPDATE
+		SET views = snippet_views.views + excluded.views
+	`
+
+	for _, v := range views {
+		if _, err = tx.ExecContext(ctx, query, truncateToDay(v.Day).UnixMicro(), v.Views, v.SnippetID); err != nil {
+			return wrapErr(err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return wrapErr(err)
+	}
+
+	return nil
+}
+
+// Views returns the total amount of views of a snippet and its daily views starting from the since day
+func (s *SQLiteStorage) Views(ctx context.Context, id uint, since time.Time) (uint64, []DailyViews, error) {
+	var total uint64
+	if err := s.conn.QueryRowContext(
+		ctx,
+		`SELECT COALESCE(SUM(views), 0) FROM snippet_views WHERE snippet_id = ?`,
+		id,
+	).Scan(&total); err != nil {
+		return 0, nil, fmt.Errorf("failed to count snippet views: %w", err)
+	}
+
+	query := `
+		SELECT
+			day,
+			views
+		FROM snippet_views
+		WHERE
+			snippet_id = ?
+			AND day >= ?
+		ORDER BY day
+	`
+
+	rows, err := s.conn.QueryContext(ctx, query, id, truncateToDay(since).UnixMicro())
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to query snippet views: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var daily []DailyViews
+	for rows.Next() {
+		var (
+			v   = DailyViews{SnippetID: id}
+			day int64
+		)
+		if err = rows.Scan(&day, &v.Views); err != nil {
+			return 0, nil, fmt.Errorf("failed to scan snippet views row: %w", err)
+		}
+		v.Day = fromUnixMicro(day)
+		daily = append(daily, v)
+	}
+
+	if err = rows.Err(); err != nil {
+		return 0, nil, fmt.Errorf("error from iterating snippet views rows: %w", err)
+	}
+
+	return total, daily, nil
+}
+
+// Popular returns active snippets with the most views starting from the since day
+func (s *SQLiteStorage) Popular(ctx context.Context, since time.Time, limit uint) ([]PopularSnippet, error) {
+	query := `
+		SELECT
+			s.id,
+			s.title,
+			SUM(v.views) AS total
+		FROM snippet_views v
+			JOIN snippets s ON s.id = v.snippet_id
+		WHERE
+			v.day >= ?
+			AND s.expires_at > ?
+		GROUP BY s.id, s.title
+		ORDER BY total DESC, s.id
+		LIMIT ?
+	`
+
+	rows, err := s.conn.QueryContext(ctx, query, truncateToDay(since).UnixMicro(), s.now().UnixMicro(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query popular snippets: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var results []PopularSnippet
+	for rows.Next() {
+		var popular PopularSnippet
+		if err = rows.Scan(&popular.ID, &popular.Title, &popular.Views); err != nil {
+			return nil, fmt.Errorf("failed to scan popular snippet row: %w", err)
+		}
+		results = append(results, popular)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error from iterating popular snippets rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// PurgeExpired deletes up to limit snippets that expired before the time and are already announced or deleted,
+// and returns the number of deleted snippets. Their contents are released by a trigger.
+func (s *SQLiteStorage) PurgeExpired(ctx context.Context, before time.Time, limit uint) (uint, error) {
+	query := `
+		DELETE FROM snippets
+		WHERE id IN (
+			SELECT id
+			FROM snippets
+			WHERE
+				expires_at <= ?
+				AND expiry_notified
+			ORDER BY expires_at, id
+			LIMIT ?
+		)
+	`
+
+	result, err := s.conn.ExecContext(ctx, query, before.UnixMicro(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge expired snippets: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge expired snippets: %w", err)
+	}
+
+	return uint(purged), nil
+}
+
+// AnnounceExpired marks up to limit snippets that have expired, but haven't been announced yet,
+// and returns the number of announced snippets. Deleted snippets are never announced.
+func (s *SQLiteStorage) AnnounceExpired(ctx context.Context, limit uint) (uint, error) {
+	query := `
+		UPDATE snippets
+		SET expiry_notified = TRUE
+		WHERE id IN (
+			SELECT id
+			FROM snippets
+			WHERE
+				expires_at <= ?
+				AND NOT expiry_notified
+			ORDER BY expires_at, id
+			LIMIT ?
+		)
+	`
+
+	result, err := s.conn.ExecContext(ctx, query, s.now().UnixMicro(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to announce expired snippets: %w", err)
+	}
+
+	announced, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to announce expired snippets: %w", err)
+	}
+
+	return uint(announced), nil
+}
+
+// placeholders builds placeholders for a list of n values: ?, ?, …
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func fromUnixMicro(usec int64) time.Time {
+	return time.UnixMicro(usec).UTC()
+}
@@ -0,0 +1,139 @@
+package snippets_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/sqlite"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/sqlite/sqlitemigrator"
+	sqlitemigrations "github.com/titusjaka/go-sample/v2/migrations/sqlite"
+)
+
+func TestSQLiteStorage(t *testing.T) {
+	t.Parallel()
+
+	testStorageConformance(t, func(t *testing.T) conformanceStorage {
+		return snippets.NewSQLiteStorage(openSQLite(t), time.Now)
+	})
+}
+
+func TestSQLiteStorage_UpdateContent(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := openSQLite(t)
+	storage := snippets.NewSQLiteStorage(db, time.Now)
+
+	now := time.Now().UTC()
+	snippet := snippets.Snippet{
+		Title:     "Snippet title",
+		Content:   "Shared content",
+		CreatedAt: now,
+		UpdatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+
+	ids, err := storage.CreateBatch(ctx, []snippets.Snippet{snippet, snippet})
+	require.NoError(t, err)
+
+	snippet.ID = ids[0]
+	for _, content := range []string{"Edited content", "Edited content", "Final content"} {
+		snippet.Content = content
+		require.NoError(t, storage.UpdateContent(ctx, snippet))
+	}
+
+	refCounts := make(map[string]int)
+
+	rows, err := db.QueryContext(ctx, `SELECT data, ref_count FROM snippet_contents`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			data     string
+			refCount int
+		)
+		require.NoError(t, rows.Scan(&data, &refCount))
+		refCounts[data] = refCount
+	}
+	require.NoError(t, rows.Err())
+
+	assert.Equal(t, map[string]int{"Shared content": 1, "Final content": 1}, refCounts, "replaced contents are released")
+}
+
+func TestSQLiteStorage_PurgeExpired(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := openSQLite(t)
+	storage := snippets.NewSQLiteStorage(db, time.Now)
+
+	now := time.Now().UTC()
+	newSnippet := func(content string) snippets.Snippet {
+		return snippets.Snippet{
+			Title:     "Snippet title",
+			Content:   content,
+			CreatedAt: now,
+			UpdatedAt: now,
+			ExpiresAt: now.Add(time.Hour),
+		}
+	}
+
+	ids, err := storage.CreateBatch(ctx, []snippets.Snippet{
+		newSnippet("Shared content"),
+		newSnippet("Shared content"),
+		newSnippet("Unique content"),
+	})
+	require.NoError(t, err)
+
+	deleted, err := storage.SoftDeleteBatch(ctx, ids[1:])
+	require.NoError(t, err)
+	require.Len(t, deleted, 2)
+
+	purged, err := storage.PurgeExpired(ctx, time.Now().UTC().Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, purged)
+
+	refCounts := make(map[string]int)
+
+	rows, err := db.QueryContext(ctx, `SELECT data, ref_count FROM snippet_contents`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			data     string
+			refCount int
+		)
+		require.NoError(t, rows.Scan(&data, &refCount))
+		refCounts[data] = refCount
+	}
+	require.NoError(t, rows.Err())
+
+	assert.Equal(t, map[string]int{"Shared content": 1}, refCounts, "contents of purged snippets are released")
+}
+
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+
+	flags := sqlite.Flags{Path: filepath.Join(t.TempDir(), "snippets.db")}
+
+	db, err := flags.OpenStdSQLDB()
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	_, err = sqlitemigrator.NewMigrator(db, sqlitemigrations.Dir).Up(context.Background())
+	require.NoError(t, err)
+
+	return db
+}
@@ -5,48 +5,121 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres"
 	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
 )
 
 // ErrNotFound error used to signal higher level about sql.ErrNoRows error
 var ErrNotFound = errors.New("not found")
 
-// PGStorage implements storage interface and provides methods to manipulate data in PostgreSQL storage
+const (
+	// changedChannel is a channel notified with IDs of updated or deleted snippets, separated by commas
+	changedChannel = "snippets_changed"
+
+	// maxNotifiedIDs keeps notification payloads under the limit of 8000 bytes
+	maxNotifiedIDs = 500
+)
+
+// PGStorage implements storage interface and provides methods to manipulate data in PostgreSQL storage.
+//
+// Snippets content is stored in a separate content-addressed table (keyed by SHA-256 of the content),
+// so identical contents are stored only once. Large contents are compressed transparently.
 type PGStorage struct {
-	conn *sql.DB
+	conn   *sql.DB
+	codec  *ContentCodec
+	router *postgres.Router
+}
+
+// PGStorageOption configures optional PGStorage parameters
+type PGStorageOption func(*PGStorage)
+
+// WithCompression sets an algorithm used to compress contents larger than threshold (in bytes)
+func WithCompression(compression Compression, threshold int) PGStorageOption {
+	return func(pg *PGStorage) {
+		pg.codec = NewContentCodec(compression, threshold)
+	}
+}
+
+// WithReplicas makes Get, List and Total read from replicas of the router.
+// The connection passed to NewPGStorage must be the primary one, everything else is read from it and written to it.
+func WithReplicas(router *postgres.Router) PGStorageOption {
+	return func(pg *PGStorage) {
+		pg.router = router
+	}
 }
 
 // NewPGStorage returns a new instance of PGStorage
-func NewPGStorage(conn *sql.DB) *PGStorage {
-	return &PGStorage{conn: conn}
+func NewPGStorage(conn *sql.DB, opts ...PGStorageOption) *PGStorage {
+	pg := &PGStorage{
+		conn:  conn,
+		codec: NewContentCodec(CompressionZstd, DefaultCompressionThreshold),
+	}
+
+	for _, opt := range opts {
+		opt(pg)
+	}
+
+	return pg
 }
 
 // Get returns a single snippet from storage
 func (pg *PGStorage) Get(ctx context.Context, id uint) (Snippet, error) {
 	query := `
 		SELECT 
-			id, 
-			title,
-			content,
-			created_at,
-			updated_at,
-			expires_at
+			s.id, 
+			s.title,
+			c.data,
+			c.compression,
+			s.created_at,
+			s.updated_at,
+			s.expires_at,
+			s.encryption,
+			s.passphrase_salt,
+			s.wrapped_key,
+			COALESCE(s.parent_id, 0),
+			s.template
 		FROM 
-			snippets
-		WHERE id = $1
+			snippets s
+			JOIN snippet_contents c ON c.hash = s.content_hash
+		WHERE s.id = $1
 	`
 
-	var snippet Snippet
-	switch err := pg.conn.QueryRowContext(ctx, query, id).Scan(
-		&snippet.ID,
-		&snippet.Title,
-		&snippet.Content,
-		&snippet.CreatedAt,
-		&snippet.UpdatedAt,
-		&snippet.ExpiresAt,
-	); {
+	var (
+		snippet     Snippet
+		data        []byte
+		compression Compression
+		template    []byte
+	)
+	switch err := pg.read(ctx, func(conn postgres.Querier) error {
+		return conn.QueryRowContext(ctx, query, id).Scan(
+			&snippet.ID,
+			&snippet.Title,
+			&data,
+			&compression,
+			&snippet.CreatedAt,
+			&snippet.UpdatedAt,
+			&snippet.ExpiresAt,
+			&snippet.Encryption,
+			&snippet.PassphraseSalt,
+			&snippet.WrappedKey,
+			&snippet.ParentID,
+			&template,
+		)
+	}); {
 	case err == nil:
+		if snippet.Content, err = pg.codec.Decode(data, compression); err != nil {
+			return Snippet{}, fmt.Errorf("failed to decode snippet content: %w", err)
+		}
+		if snippet.Template, err = unmarshalTemplate(template); err != nil {
+			return Snippet{}, err
+		}
 		return snippet, nil
 	case errors.Is(err, sql.ErrNoRows):
 		return Snippet{}, ErrNotFound
@@ -57,14 +130,46 @@ func (pg *PGStorage) Get(ctx context.Context, id uint) (Snippet, error) {
 
 // Create saves a single snippet to storage
 func (pg *PGStorage) Create(ctx context.Context, snippet Snippet) (uint, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("failed to add snippet: %w", err)
+	}
+
+	postgres.MarkWritten(ctx)
+
+	tx, err := postgres.BeginTx(ctx, pg.conn)
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	hash, err := pg.saveContent(ctx, tx, snippet.Content)
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+
+	template, err := marshalTemplate(snippet.Template)
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+
 	query := `
 		INSERT INTO snippets
 		(
 			title,
-			content,
+			content_hash,
 			created_at,
 			updated_at,
-			expires_at
+			expires_at,
+			encryption,
+			passphrase_salt,
+			wrapped_key,
+			parent_id,
+			template,
+			caller,
+			content_size
 		)
 		VALUES
 		(
@@ -72,113 +177,328 @@ func (pg *PGStorage) Create(ctx context.Context, snippet Snippet) (uint, error)
 			$2,
 			$3,
 			$4,
-			$5
+			$5,
+			$6,
+			$7,
+			$8,
+			NULLIF($9, 0),
+			$10,
+			$11,
+			$12
 		)
 		RETURNING id
 	`
 
 	var id uint
-	switch err := pg.conn.QueryRowContext(
+	if err = tx.QueryRowContext(
 		ctx,
 		query,
 		snippet.Title,
-		snippet.Content,
+		hash,
 		snippet.CreatedAt,
 		snippet.UpdatedAt,
 		snippet.ExpiresAt,
-	).Scan(&id); err {
-	case nil:
-		return id, nil
-	default:
-		return 0, fmt.Errorf("failed to add snippet: %w", err)
+		snippet.Encryption,
+		snippet.PassphraseSalt,
+		snippet.WrappedKey,
+		snippet.ParentID,
+		template,
+		snippet.Caller,
+		snippet.ContentSize,
+	).Scan(&id); err != nil {
+		return 0, wrapErr(err)
+	}
+
+	snippet.ID = id
+	if err = writeEvents(ctx, tx, EventCreated, []Snippet{snippet}); err != nil {
+		return 0, wrapErr(err)
 	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, wrapErr(err)
+	}
+
+	return id, nil
+}
+
+// UpdateContent replaces the content of a snippet along with its encryption parameters.
+// Deleted and expired snippets are not updated, ErrNotFound is returned for them.
+func (pg *PGStorage) UpdateContent(ctx context.Context, snippet Snippet) error {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("failed to update snippet content: %w", err)
+	}
+
+	postgres.MarkWritten(ctx)
+
+	tx, err := postgres.BeginTx(ctx, pg.conn)
+	if err != nil {
+		return wrapErr(err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	hash, err := pg.saveContent(ctx, tx, snippet.Content)
+	if err != nil {
+		return wrapErr(err)
+	}
+
+	query := `
+		UPDATE snippets
+		SET
+			content_hash = $2,
+			encryption = $3,
+			wrapped_key = $4,
+			updated_at = $5,
+			content_size = $6
+		WHERE
+			id = $1
+			AND expires_at > NOW()
+	`
+
+	result, err := tx.ExecContext(
+		ctx,
+		query,
+		snippet.ID,
+		hash,
+		snippet.Encryption,
+		snippet.WrappedKey,
+		snippet.UpdatedAt,
+		snippet.ContentSize,
+	)
+	if err != nil {
+		return wrapErr(err)
+	}
+
+	switch affected, err := result.RowsAffected(); {
+	case err != nil:
+		return wrapErr(err)
+	case affected == 0:
+		return ErrNotFound
+	}
+
+	if err = writeEvents(ctx, tx, EventUpdated, []Snippet{snippet}); err != nil {
+		return wrapErr(err)
+	}
+
+	if err = notifyChanged(ctx, tx, []uint{snippet.ID}); err != nil {
+		return wrapErr(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return wrapErr(err)
+	}
+
+	return nil
 }
 
 // List returns a list of snippets from storage
 func (pg *PGStorage) List(ctx context.Context, pagination service.Pagination) ([]Snippet, error) {
 	query := `
 		SELECT
-			id,
-			title,
-			content,
-			created_at,
-			updated_at,
-			expires_at
-		FROM snippets
+			s.id,
+			s.title,
+			c.data,
+			c.compression,
+			s.created_at,
+			s.updated_at,
+			s.expires_at,
+			s.encryption,
+			s.passphrase_salt,
+			s.wrapped_key,
+			COALESCE(s.parent_id, 0),
+			s.template
+		FROM snippets s
+			JOIN snippet_contents c ON c.hash = s.content_hash
 		WHERE
-			expires_at > NOW()
-		ORDER BY created_at DESC
+			s.expires_at > NOW()
+		ORDER BY s.created_at DESC
 		%s
 	`
 
 	paginationExpression := ConvertPaginationToSQLExpression(pagination)
 
-	rows, err := pg.conn.QueryContext(
-		ctx,
-		fmt.Sprintf(query, paginationExpression),
-	)
-	switch {
-	case err == nil:
-		break
-	default:
-		return nil, fmt.Errorf("failed to list snippets: %w", err)
+	var results []Snippet
+	err := pg.read(ctx, func(conn postgres.Querier) error {
+		rows, err := conn.QueryContext(
+			ctx,
+			fmt.Sprintf(query, paginationExpression),
+		)
+		switch {
+		case err == nil:
+			break
+		default:
+			return fmt.Errorf("failed to list snippets: %w", err)
+		}
+
+		defer func() {
+			_ = rows.Close()
+		}()
+
+		// The list is read again from the primary if a replica fails
+		results = nil
+		for rows.Next() {
+			snippet, err := pg.scanSnippet(rows)
+			if err != nil {
+				return err
+			}
+
+			results = append(results, snippet)
+		}
+
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("error from iterating snippets rows: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Iterate calls fn for every active snippet ordered by id.
+// Rows are streamed from the database, so the whole result set is never held in memory.
+func (pg *PGStorage) Iterate(ctx context.Context, fn func(Snippet) error) error {
+	query := `
+		SELECT
+			s.id,
+			s.title,
+			c.data,
+			c.compression,
+			s.created_at,
+			s.updated_at,
+			s.expires_at,
+			s.encryption,
+			s.passphrase_salt,
+			s.wrapped_key,
+			COALESCE(s.parent_id, 0),
+			s.template
+		FROM snippets s
+			JOIN snippet_contents c ON c.hash = s.content_hash
+		WHERE
+			s.expires_at > NOW()
+		ORDER BY s.id
+	`
+
+	rows, err := postgres.Conn(ctx, pg.conn).QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to iterate snippets: %w", err)
 	}
 
 	defer func() {
 		_ = rows.Close()
 	}()
 
-	var results []Snippet
 	for rows.Next() {
-		var snippet Snippet
-		err := rows.Scan(
-			&snippet.ID,
-			&snippet.Title,
-			&snippet.Content,
-			&snippet.CreatedAt,
-			&snippet.UpdatedAt,
-			&snippet.ExpiresAt,
-		)
-
+		snippet, err := pg.scanSnippet(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan snippet row: %w", err)
+			return err
 		}
 
-		results = append(results, snippet)
+		if err = fn(snippet); err != nil {
+			return err
+		}
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error from iterating snippets rows: %w", err)
+		return fmt.Errorf("error from iterating snippets rows: %w", err)
 	}
 
-	return results, nil
+	return nil
 }
 
-// SoftDelete set `expires_at` to `now()`, so snippet is considered deleted
-func (pg *PGStorage) SoftDelete(ctx context.Context, id uint) error {
-	wrapErr := func(err error) error {
-		return fmt.Errorf("failed to soft delete snippet from DB (ID: %d): %w", id, err)
+// scanSnippet scans a snippet row joined with its content
+func (pg *PGStorage) scanSnippet(rows *sql.Rows) (Snippet, error) {
+	var (
+		snippet     Snippet
+		data        []byte
+		compression Compression
+		template    []byte
+	)
+
+	err := rows.Scan(
+		&snippet.ID,
+		&snippet.Title,
+		&data,
+		&compression,
+		&snippet.CreatedAt,
+		&snippet.UpdatedAt,
+		&snippet.ExpiresAt,
+		&snippet.Encryption,
+		&snippet.PassphraseSalt,
+		&snippet.WrappedKey,
+		&snippet.ParentID,
+		&template,
+	)
+	if err != nil {
+		return Snippet{}, fmt.Errorf("failed to scan snippet row: %w", err)
+	}
+
+	if snippet.Content, err = pg.codec.Decode(data, compression); err != nil {
+		return Snippet{}, fmt.Errorf("failed to decode snippet content: %w", err)
+	}
+
+	if snippet.Template, err = unmarshalTemplate(template); err != nil {
+		return Snippet{}, err
 	}
 
+	return snippet, nil
+}
+
+// Lineage returns IDs of the snippet ancestors, starting from its parent up to the original snippet
+func (pg *PGStorage) Lineage(ctx context.Context, id uint) ([]uint, error) {
 	query := `
-		UPDATE snippets
-		SET
-			updated_at = NOW(),
-			expires_at = NOW()
-		WHERE
-			id = $1
+		WITH RECURSIVE lineage AS (
+			SELECT parent_id, 1 AS depth
+			FROM snippets
+			WHERE id = $1
+			UNION ALL
+			SELECT s.parent_id, l.depth + 1
+			FROM snippets s
+				JOIN lineage l ON s.id = l.parent_id
+		)
+		SELECT parent_id
+		FROM lineage
+		WHERE parent_id IS NOT NULL
+		ORDER BY depth
 	`
 
-	result, err := pg.conn.ExecContext(ctx, query, id)
+	rows, err := postgres.Conn(ctx, pg.conn).QueryContext(ctx, query, id)
 	if err != nil {
-		return wrapErr(err)
+		return nil, fmt.Errorf("failed to query snippet lineage: %w", err)
 	}
 
-	affectedRows, err := result.RowsAffected()
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var lineage []uint
+	for rows.Next() {
+		var parentID uint
+		if err = rows.Scan(&parentID); err != nil {
+			return nil, fmt.Errorf("failed to scan snippet lineage: %w", err)
+		}
+		lineage = append(lineage, parentID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error from iterating snippet lineage rows: %w", err)
+	}
+
+	return lineage, nil
+}
+
+// SoftDelete set `expires_at` to `now()`, so snippet is considered deleted
+func (pg *PGStorage) SoftDelete(ctx context.Context, id uint) error {
+	deleted, err := pg.SoftDeleteBatch(ctx, []uint{id})
 	switch {
 	case err != nil:
-		return wrapErr(err)
-	case affectedRows == 0:
+		return fmt.Errorf("failed to soft delete snippet from DB (ID: %d): %w", id, err)
+	case len(deleted) == 0:
 		return ErrNotFound
 	default:
 		return nil
@@ -192,9 +512,666 @@ func (pg *PGStorage) Total(ctx context.Context) (uint, error) {
 		FROM snippets
 	`
 
-	row := pg.conn.QueryRowContext(ctx, query)
-
 	var count uint
-	err := row.Scan(&count)
+	err := pg.read(ctx, func(conn postgres.Querier) error {
+		return conn.QueryRowContext(ctx, query).Scan(&count)
+	})
 	return count, err
 }
+
+// read runs a read-only query with the transaction of ctx if there's one, or with a replica if there's a router,
+// or with the primary otherwise
+func (pg *PGStorage) read(ctx context.Context, fn func(conn postgres.Querier) error) error {
+	conn := postgres.Conn(ctx, pg.conn)
+	if pg.router == nil || conn != postgres.Querier(pg.conn) {
+		return fn(conn)
+	}
+
+	return pg.router.Read(ctx, func(db *sql.DB) error {
+		return fn(db)
+	})
+}
+
+// Usage returns the consumption of an API caller: active snippets, the total size of their contents
+// and the number of snippets created since the given time
+func (pg *PGStorage) Usage(ctx context.Context, caller string, since time.Time) (Usage, error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE expires_at > NOW()),
+			COALESCE(SUM(content_size) FILTER (WHERE expires_at > NOW()), 0),
+			COUNT(*) FILTER (WHERE created_at > $2)
+		FROM snippets
+		WHERE caller = $1
+	`
+
+	var usage Usage
+	if err := postgres.Conn(ctx, pg.conn).QueryRowContext(ctx, query, caller, since).Scan(
+		&usage.Snippets,
+		&usage.ContentBytes,
+		&usage.CreatedLastDay,
+	); err != nil {
+		return Usage{}, fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// LockQuota takes a transaction-level advisory lock of the caller, so quota checks of the caller made by any replica
+// wait until snippets created after the current check are committed. Without a transaction in ctx,
+// the lock is released as soon as it's taken.
+func (pg *PGStorage) LockQuota(ctx context.Context, caller string) error {
+	query := `SELECT PG_ADVISORY_XACT_LOCK(HASHTEXTEXTENDED($1, 0))`
+
+	if _, err := postgres.Conn(ctx, pg.conn).ExecContext(ctx, query, "snippets.quota:"+caller); err != nil {
+		return fmt.Errorf("failed to lock quota: %w", err)
+	}
+
+	return nil
+}
+
+// CreateBatch saves several snippets to storage within a single transaction
+// using multi-row inserts. IDs are returned in the order of passed snippets.
+func (pg *PGStorage) CreateBatch(ctx context.Context, batch []Snippet) ([]uint, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("failed to add batch of snippets: %w", err)
+	}
+
+	if len(batch) == 0 {
+		return nil, nil
+	}
+
+	postgres.MarkWritten(ctx)
+
+	tx, err := postgres.BeginTx(ctx, pg.conn)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	contents := make([]string, len(batch))
+	for i := range batch {
+		contents[i] = batch[i].Content
+	}
+
+	hashes, err := pg.saveContents(ctx, tx, contents)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+
+	query := `
+		INSERT INTO snippets
+		(
+			title,
+			content_hash,
+			created_at,
+			updated_at,
+			expires_at,
+			encryption,
+			passphrase_salt,
+			wrapped_key,
+			template,
+			caller,
+			content_size
+		)
+		VALUES %s
+		RETURNING id
+	`
+
+	const columns = 11
+
+	args := make([]any, 0, len(batch)*columns)
+	for i, snippet := range batch {
+		var template []byte
+		if template, err = marshalTemplate(snippet.Template); err != nil {
+			return nil, wrapErr(err)
+		}
+
+		args = append(
+			args,
+			snippet.Title,
+			hashes[i],
+			snippet.CreatedAt,
+			snippet.UpdatedAt,
+			snippet.ExpiresAt,
+			snippet.Encryption,
+			snippet.PassphraseSalt,
+			snippet.WrappedKey,
+			template,
+			snippet.Caller,
+			snippet.ContentSize,
+		)
+	}
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(query, valuesPlaceholders(len(batch), columns)), args...)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	ids := make([]uint, 0, len(batch))
+	for rows.Next() {
+		var id uint
+		if err = rows.Scan(&id); err != nil {
+			return nil, wrapErr(err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapErr(err)
+	}
+
+	// IDs of a single INSERT are generated in the order of VALUES,
+	// while RETURNING doesn't guarantee any order.
+	slices.Sort(ids)
+
+	created := make([]Snippet, len(batch))
+	for i := range batch {
+		created[i] = batch[i]
+		created[i].ID = ids[i]
+	}
+
+	if err = writeEvents(ctx, tx, EventCreated, created); err != nil {
+		return nil, wrapErr(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, wrapErr(err)
+	}
+
+	return ids, nil
+}
+
+// SoftDeleteBatch marks several live snippets as deleted and returns their IDs, already deleted ones are skipped
+func (pg *PGStorage) SoftDeleteBatch(ctx context.Context, ids []uint) ([]uint, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("failed to soft delete batch of snippets from DB: %w", err)
+	}
+
+	postgres.MarkWritten(ctx)
+
+	tx, err := postgres.BeginTx(ctx, pg.conn)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	query := `
+		UPDATE snippets
+		SET
+			updated_at = NOW(),
+			expires_at = NOW(),
+			expiry_notified = TRUE
+		WHERE
+			id = ANY($1)
+			AND expires_at > NOW()
+		RETURNING id, updated_at
+	`
+
+	params := make([]int64, len(ids))
+	for i, id := range ids {
+		params[i] = int64(id)
+	}
+
+	rows, err := tx.QueryContext(ctx, query, params)
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var (
+		deleted []uint
+		batch   []events.Event
+	)
+	for rows.Next() {
+		var (
+			id        uint
+			deletedAt time.Time
+		)
+		if err = rows.Scan(&id, &deletedAt); err != nil {
+			return nil, wrapErr(err)
+		}
+
+		event, err := events.New(EventDeleted, deletedAt, EventData{ID: id})
+		if err != nil {
+			return nil, wrapErr(err)
+		}
+
+		deleted = append(deleted, id)
+		batch = append(batch, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, wrapErr(err)
+	}
+
+	if err = postgres.WriteOutbox(ctx, tx, batch...); err != nil {
+		return nil, wrapErr(err)
+	}
+
+	if err = notifyChanged(ctx, tx, deleted); err != nil {
+		return nil, wrapErr(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, wrapErr(err)
+	}
+
+	return deleted, nil
+}
+
+// saveContent saves content to the content-addressed table and returns its hash.
+// If the same content already exists, only its reference counter is incremented.
+func (pg *PGStorage) saveContent(ctx context.Context, tx postgres.Querier, content string) ([]byte, error) {
+	data, compression, err := pg.codec.Encode(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode content: %w", err)
+	}
+
+	query := `
+		INSERT INTO snippet_contents
+		(
+			hash,
+			data,
+			compression,
+			size,
+			ref_count
+		)
+		VALUES
+		(
+			$1,
+			$2,
+			$3,
+			$4,
+			1
+		)
+		ON CONFLICT (hash) DO UPDATE
+		SET ref_count = snippet_contents.ref_count + 1
+	`
+
+	hash := ContentHash(content)
+	if _, err = tx.ExecContext(ctx, query, hash, data, compression, len(content)); err != nil {
+		return nil, fmt.Errorf("failed to save content: %w", err)
+	}
+
+	return hash, nil
+}
+
+// saveContents saves several contents using a single multi-row insert and returns their hashes.
+// Duplicates are merged before the insert, since ON CONFLICT can't affect the same row twice.
+func (pg *PGStorage) saveContents(ctx context.Context, tx postgres.Querier, contents []string) ([][]byte, error) {
+	type contentRow struct {
+		hash        []byte
+		data        []byte
+		compression Compression
+		size        int
+		refs        int
+	}
+
+	hashes := make([][]byte, len(contents))
+	rows := make([]*contentRow, 0, len(contents))
+	rowsByHash := make(map[string]*contentRow, len(contents))
+
+	for i, content := range contents {
+		hashes[i] = ContentHash(content)
+
+		if row, ok := rowsByHash[string(hashes[i])]; ok {
+			row.refs++
+			continue
+		}
+
+		data, compression, err := pg.codec.Encode(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode content: %w", err)
+		}
+
+		row := &contentRow{hash: hashes[i], data: data, compression: compression, size: len(content), refs: 1}
+		rows = append(rows, row)
+		rowsByHash[string(hashes[i])] = row
+	}
+
+	query := `
+		INSERT INTO snippet_contents
+		(
+			hash,
+			data,
+			compression,
+			size,
+			ref_count
+		)
+		VALUES %s
+		ON CONFLICT (hash) DO UPDATE
+		SET ref_count = snippet_contents.ref_count + EXCLUDED.ref_count
+	`
+
+	const columns = 5
+
+	args := make([]any, 0, len(rows)*columns)
+	for _, row := range rows {
+		args = append(args, row.hash, row.data, row.compression, row.size, row.refs)
+	}
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(query, valuesPlaceholders(len(rows), columns)), args...); err != nil {
+		return nil, fmt.Errorf("failed to save contents: %w", err)
+	}
+
+	return hashes, nil
+}
+
+// valuesPlaceholders builds placeholders for a multi-row insert: ($1, $2), ($3, $4), …
+func valuesPlaceholders(rows, columns int) string {
+	values := make([]string, rows)
+	placeholders := make([]string, columns)
+
+	for i := range rows {
+		for j := range columns {
+			placeholders[j] = fmt.Sprintf("$%d", i*columns+j+1)
+		}
+		values[i] = "(" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	return strings.Join(values, ", ")
+}
+
+// AddViews adds views counters to storage
+func (pg *PGStorage) AddViews(ctx context.Context, views []DailyViews) error {
+	query := `
+		INSERT INTO snippet_views
+		(
+			snippet_id,
+			day,
+			views
+		)
+		SELECT t.snippet_id, t.day, t.views
+		FROM UNNEST($1::integer[], $2::date[], $3::bigint[]) AS t(snippet_id, day, views)
+			JOIN snippets s ON s.id = t.snippet_id
+		ON CONFLICT (snippet_id, day) DO UPDATE
+		SET views = snippet_views.views + EXCLUDED.views
+	`
+
+	ids := make([]int64, len(views))
+	days := make([]time.Time, len(views))
+	counts := make([]int64, len(views))
+	for i, v := range views {
+		ids[i] = int64(v.SnippetID)
+		days[i] = v.Day
+		counts[i] = int64(v.Views)
+	}
+
+	if _, err := postgres.Conn(ctx, pg.conn).ExecContext(ctx, query, ids, days, counts); err != nil {
+		return fmt.Errorf("failed to add snippet views: %w", err)
+	}
+
+	return nil
+}
+
+// Views returns the total amount of views of a snippet and its daily views starting from the since day
+func (pg *PGStorage) Views(ctx context.Context, id uint, since time.Time) (uint64, []DailyViews, error) {
+	var total uint64
+	if err := postgres.Conn(ctx, pg.conn).QueryRowContext(
+		ctx,
+		`SELECT COALESCE(SUM(views), 0) FROM snippet_views WHERE snippet_id = $1`,
+		id,
+	).Scan(&total); err != nil {
+		return 0, nil, fmt.Errorf("failed to count snippet views: %w", err)
+	}
+
+	query := `
+		SELECT
+			day,
+			views
+		FROM snippet_views
+		WHERE
+			snippet_id = $1
+			AND day >= $2::date
+		ORDER BY day
+	`
+
+	rows, err := postgres.Conn(ctx, pg.conn).QueryContext(ctx, query, id, since)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to query snippet views: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var daily []DailyViews
+	for rows.Next() {
+		v := DailyViews{SnippetID: id}
+		if err = rows.Scan(&v.Day, &v.Views); err != nil {
+			return 0, nil, fmt.Errorf("failed to scan snippet views row: %w", err)
+		}
+		daily = append(daily, v)
+	}
+
+	if err = rows.Err(); err != nil {
+		return 0, nil, fmt.Errorf("error from iterating snippet views rows: %w", err)
+	}
+
+	return total, daily, nil
+}
+
+// Popular returns active snippets with the most views starting from the since day
+func (pg *PGStorage) Popular(ctx context.Context, since time.Time, limit uint) ([]PopularSnippet, error) {
+	query := `
+		SELECT
+			s.id,
+			s.title,
+			SUM(v.views) AS total
+		FROM snippet_views v
+			JOIN snippets s ON s.id = v.snippet_id
+		WHERE
+			v.day >= $1::date
+			AND s.expires_at > NOW()
+		GROUP BY s.id, s.title
+		ORDER BY total DESC, s.id
+		LIMIT $2
+	`
+
+	rows, err := postgres.Conn(ctx, pg.conn).QueryContext(ctx, query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query popular snippets: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var results []PopularSnippet
+	for rows.Next() {
+		var popular PopularSnippet
+		if err = rows.Scan(&popular.ID, &popular.Title, &popular.Views); err != nil {
+			return nil, fmt.Errorf("failed to scan popular snippet row: %w", err)
+		}
+		results = append(results, popular)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error from iterating popular snippets rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// PurgeExpired deletes up to limit snippets that expired before the time and are already announced or deleted,
+// and returns the number of deleted snippets. Their contents are released by a trigger.
+func (pg *PGStorage) PurgeExpired(ctx context.Context, before time.Time, limit uint) (uint, error) {
+	query := `
+		DELETE FROM snippets
+		WHERE id IN (
+			SELECT id
+			FROM snippets
+			WHERE
+				expires_at <= $1
+				AND expiry_notified
+			ORDER BY expires_at, id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+	`
+
+	result, err := postgres.Conn(ctx, pg.conn).ExecContext(ctx, query, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge expired snippets: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge expired snippets: %w", err)
+	}
+
+	return uint(purged), nil
+}
+
+// AnnounceExpired records EventExpired for up to limit snippets that have expired, but haven't been announced yet,
+// and returns the number of announced snippets. Deleted snippets are never announced.
+func (pg *PGStorage) AnnounceExpired(ctx context.Context, limit uint) (uint, error) {
+	wrapErr := func(err error) error {
+		return fmt.Errorf("failed to announce expired snippets: %w", err)
+	}
+
+	tx, err := postgres.BeginTx(ctx, pg.conn)
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	query := `
+		UPDATE snippets
+		SET expiry_notified = TRUE
+		WHERE id IN (
+			SELECT id
+			FROM snippets
+			WHERE
+				expires_at <= NOW()
+				AND NOT expiry_notified
+			ORDER BY expires_at, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING
+			id,
+			title,
+			created_at,
+			updated_at,
+			expires_at,
+			encryption,
+			COALESCE(parent_id, 0)
+	`
+
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return 0, wrapErr(err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var expired []Snippet
+	for rows.Next() {
+		var snippet Snippet
+		if err = rows.Scan(
+			&snippet.ID,
+			&snippet.Title,
+			&snippet.CreatedAt,
+			&snippet.UpdatedAt,
+			&snippet.ExpiresAt,
+			&snippet.Encryption,
+			&snippet.ParentID,
+		); err != nil {
+			return 0, wrapErr(err)
+		}
+		expired = append(expired, snippet)
+	}
+
+	if err = rows.Err(); err != nil {
+		return 0, wrapErr(err)
+	}
+
+	if err = writeEvents(ctx, tx, EventExpired, expired); err != nil {
+		return 0, wrapErr(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, wrapErr(err)
+	}
+
+	return uint(len(expired)), nil
+}
+
+// writeEvents records events of the given type for snippets to the outbox.
+// Snippets are created at CreatedAt, updated at UpdatedAt and expire at ExpiresAt, so these are used as event times.
+func writeEvents(ctx context.Context, tx postgres.Execer, eventType events.Type, snippets []Snippet) error {
+	batch := make([]events.Event, len(snippets))
+	for i, snippet := range snippets {
+		occurredAt := snippet.CreatedAt
+		switch eventType {
+		case EventUpdated:
+			occurredAt = snippet.UpdatedAt
+		case EventExpired:
+			occurredAt = snippet.ExpiresAt
+		}
+
+		var err error
+		if batch[i], err = events.New(eventType, occurredAt, newEventData(snippet)); err != nil {
+			return err
+		}
+	}
+
+	return postgres.WriteOutbox(ctx, tx, batch...)
+}
+
+// notifyChanged notifies changedChannel about updated or deleted snippets, so replicas drop them from their caches.
+// Notifications are delivered once tx is committed.
+func notifyChanged(ctx context.Context, tx postgres.Execer, ids []uint) error {
+	for chunk := range slices.Chunk(ids, maxNotifiedIDs) {
+		payload := make([]string, len(chunk))
+		for i, id := range chunk {
+			payload[i] = strconv.FormatUint(uint64(id), 10)
+		}
+
+		if _, err := tx.ExecContext(ctx, `SELECT PG_NOTIFY($1, $2)`, changedChannel, strings.Join(payload, ",")); err != nil {
+			return fmt.Errorf("notify changed snippets: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ListenChanged calls handle with IDs of snippets updated or deleted by any replica until ctx is done.
+// listening is called every time the notifications are (re)subscribed to, changes made before may have been missed.
+func (pg *PGStorage) ListenChanged(ctx context.Context, logger *slog.Logger, listening func(), handle func(ids []uint)) error {
+	onListen := postgres.OnListen(func(context.Context) { listening() })
+
+	return postgres.Listen(ctx, pg.conn, logger, changedChannel, func(_ context.Context, payload string) {
+		ids := make([]uint, 0, strings.Count(payload, ",")+1)
+		for _, field := range strings.Split(payload, ",") {
+			id, err := strconv.ParseUint(field, 10, 64)
+			if err != nil {
+				logger.Error("failed to decode changed snippets", slog.String("payload", payload), slog.Any("err", err))
+				return
+			}
+
+			ids = append(ids, uint(id))
+		}
+
+		handle(ids)
+	}, onListen)
+}
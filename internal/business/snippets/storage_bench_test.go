@@ -0,0 +1,110 @@
+package snippets_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres/pgtest"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+// benchSnippets is the number of snippets created before Get and List benchmarks
+const benchSnippets = 100
+
+// ===================================================================================================================
+// Benchmarks comparing database/sql and native pgx storages
+
+func BenchmarkStorage_Get(b *testing.B) {
+	benchmarkStorages(b, func(b *testing.B, storage snippets.Storage) {
+		ctx := context.Background()
+		ids := seedStorage(b, storage)
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := storage.Get(ctx, ids[i%len(ids)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkStorage_List(b *testing.B) {
+	benchmarkStorages(b, func(b *testing.B, storage snippets.Storage) {
+		ctx := context.Background()
+		seedStorage(b, storage)
+
+		pagination := service.Pagination{Limit: 20, Offset: 40}
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := storage.List(ctx, pagination); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkStorage_Create(b *testing.B) {
+	benchmarkStorages(b, func(b *testing.B, storage snippets.Storage) {
+		ctx := context.Background()
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := storage.Create(ctx, benchSnippet(i)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// benchmarkStorages runs bench against PGStorage and PGXStorage, each with its own test database
+func benchmarkStorages(b *testing.B, bench func(b *testing.B, storage snippets.Storage)) {
+	if testing.Short() {
+		b.Skip("skip integration benchmark due to 'short' flag")
+	}
+
+	b.Run("database/sql", func(b *testing.B) {
+		bench(b, snippets.NewPGStorage(pgtest.InitTestDatabase(
+			b,
+			pgtest.WithConfigFiles(envFile),
+		)))
+	})
+
+	b.Run("pgx", func(b *testing.B) {
+		bench(b, snippets.NewPGXStorage(pgtest.InitTestPool(
+			b,
+			pgtest.WithConfigFiles(envFile),
+		)))
+	})
+}
+
+func seedStorage(b *testing.B, storage snippets.Storage) []uint {
+	b.Helper()
+
+	batch := make([]snippets.Snippet, benchSnippets)
+	for i := range batch {
+		batch[i] = benchSnippet(i)
+	}
+
+	ids, err := storage.CreateBatch(context.Background(), batch)
+	require.NoError(b, err)
+
+	return ids
+}
+
+func benchSnippet(i int) snippets.Snippet {
+	created := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC).Add(time.Duration(i) * time.Second)
+
+	return snippets.Snippet{
+		Title:     fmt.Sprintf("Snippet title #%d", i),
+		Content:   fmt.Sprintf("Very important content #%d", i),
+		CreatedAt: created,
+		UpdatedAt: created,
+		ExpiresAt: time.Date(2050, 1, 1, 1, 1, 1, 0, time.UTC),
+	}
+}
@@ -0,0 +1,499 @@
+package snippets_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+// conformanceStorage is a storage checked by the conformance suite
+type conformanceStorage interface {
+	snippets.Storage
+	snippets.ViewStorage
+}
+
+// testStorageConformance checks that a storage has the semantics of PGStorage.
+// newStorage must return an empty storage on every call, storages are compared to the real time.
+func testStorageConformance(t *testing.T, newStorage func(t *testing.T) conformanceStorage) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	fakeTimeCreated := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+	fakeTimeExpires := time.Date(2050, 1, 1, 1, 1, 1, 0, time.UTC)
+	fakeTimeExpired := time.Date(2021, 1, 1, 1, 1, 1, 0, time.UTC)
+
+	newSnippet := func(i int, expiresAt time.Time) snippets.Snippet {
+		return snippets.Snippet{
+			Title:     fmt.Sprintf("Snippet title #%d", i),
+			Content:   fmt.Sprintf("Snippet content #%d", i),
+			CreatedAt: fakeTimeCreated.Add(time.Duration(i) * time.Minute),
+			UpdatedAt: fakeTimeCreated.Add(time.Duration(i) * time.Minute),
+			ExpiresAt: expiresAt,
+		}
+	}
+
+	createSnippets := func(t *testing.T, storage conformanceStorage, batch ...snippets.Snippet) {
+		t.Helper()
+
+		for _, snippet := range batch {
+			_, err := storage.Create(ctx, snippet)
+			require.NoError(t, err)
+		}
+	}
+
+	t.Run("Create and get a snippet", func(t *testing.T) {
+		t.Parallel()
+
+		storage := newStorage(t)
+
+		snippet := newSnippet(1, fakeTimeExpires)
+		snippet.Encryption = snippets.EncryptionPassphrase
+		snippet.PassphraseSalt = []byte("salt")
+		snippet.WrappedKey = []byte("key")
+		snippet.Template = &snippets.Template{
+			Variables: []snippets.TemplateVariable{{Name: "name", Default: "World"}},
+		}
+		snippet.Caller = "ci"
+		snippet.ContentSize = len(snippet.Content)
+
+		id, err := storage.Create(ctx, snippet)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, id)
+
+		id, err = storage.Create(ctx, newSnippet(2, fakeTimeExpires))
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, id)
+
+		got, err := storage.Get(ctx, 1)
+		require.NoError(t, err)
+
+		expected := snippet
+		expected.ID = 1
+		// Caller and content size are only used to enforce quotas
+		expected.Caller = ""
+		expected.ContentSize = 0
+		assert.Equal(t, expected, inUTC(got))
+	})
+
+	t.Run("Get a missing snippet", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := newStorage(t).Get(ctx, 42)
+		assert.ErrorIs(t, err, snippets.ErrNotFound)
+	})
+
+	t.Run("Get an expired snippet", func(t *testing.T) {
+		t.Parallel()
+
+		storage := newStorage(t)
+		createSnippets(t, storage, newSnippet(1, fakeTimeExpired))
+
+		got, err := storage.Get(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Snippet title #1", got.Title)
+	})
+
+	t.Run("Update content", func(t *testing.T) {
+		t.Parallel()
+
+		storage := newStorage(t)
+		createSnippets(t, storage, newSnippet(1, fakeTimeExpires), newSnippet(2, fakeTimeExpired))
+
+		updatedAt := fakeTimeCreated.Add(time.Hour)
+		require.NoError(t, storage.UpdateContent(ctx, snippets.Snippet{
+			ID:         1,
+			Content:    "Updated content",
+			UpdatedAt:  updatedAt,
+			Encryption: snippets.EncryptionEnvelope,
+			WrappedKey: []byte("key"),
+		}))
+
+		got, err := storage.Get(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Snippet title #1", got.Title)
+		assert.Equal(t, "Updated content", got.Content)
+		assert.Equal(t, snippets.EncryptionEnvelope, got.Encryption)
+		assert.Equal(t, []byte("key"), got.WrappedKey)
+		assert.True(t, updatedAt.Equal(got.UpdatedAt))
+
+		err = storage.UpdateContent(ctx, snippets.Snippet{ID: 2, Content: "Updated content", UpdatedAt: updatedAt})
+		assert.ErrorIs(t, err, snippets.ErrNotFound, "expired snippets are not updated")
+
+		err = storage.UpdateContent(ctx, snippets.Snippet{ID: 42, Content: "Updated content", UpdatedAt: updatedAt})
+		assert.ErrorIs(t, err, snippets.ErrNotFound)
+	})
+
+	t.Run("List active snippets", func(t *testing.T) {
+		t.Parallel()
+
+		storage := newStorage(t)
+		for i := 1; i <= 5; i++ {
+			expiresAt := fakeTimeExpires
+			if i == 3 {
+				expiresAt = fakeTimeExpired
+			}
+			createSnippets(t, storage, newSnippet(i, expiresAt))
+		}
+
+		tests := []struct {
+			name       string
+			pagination service.Pagination
+			expected   []uint
+		}{
+			{
+				name:     "Newest first",
+				expected: []uint{5, 4, 2, 1},
+			},
+			{
+				name:       "Limit",
+				pagination: service.Pagination{Limit: 2},
+				expected:   []uint{5, 4},
+			},
+			{
+				name:       "Limit and offset",
+				pagination: service.Pagination{Limit: 2, Offset: 2},
+				expected:   []uint{2, 1},
+			},
+			{
+				name:       "Offset after the last snippet",
+				pagination: service.Pagination{Limit: 2, Offset: 10},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				list, err := storage.List(ctx, tt.pagination)
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, snippetIDs(list))
+			})
+		}
+	})
+
+	t.Run("Total counts all snippets", func(t *testing.T) {
+		t.Parallel()
+
+		storage := newStorage(t)
+		createSnippets(t, storage, newSnippet(1, fakeTimeExpires), newSnippet(2, fakeTimeExpired))
+
+		total, err := storage.Total(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, total)
+	})
+
+	t.Run("Soft delete a snippet", func(t *testing.T) {
+		t.Parallel()
+
+		storage := newStorage(t)
+		createSnippets(t, storage, newSnippet(1, fakeTimeExpires), newSnippet(2, fakeTimeExpires))
+
+		require.NoError(t, storage.SoftDelete(ctx, 1))
+		assert.ErrorIs(t, storage.SoftDelete(ctx, 42), snippets.ErrNotFound)
+
+		got, err := storage.Get(ctx, 1)
+		require.NoError(t, err)
+		assert.False(t, got.ExpiresAt.After(time.Now()))
+		assert.True(t, got.ExpiresAt.Equal(got.UpdatedAt))
+
+		list, err := storage.List(ctx, service.Pagination{})
+		require.NoError(t, err)
+		assert.Equal(t, []uint{2}, snippetIDs(list))
+
+		err = storage.UpdateContent(ctx, snippets.Snippet{ID: 1, Content: "Updated content", UpdatedAt: time.Now()})
+		assert.ErrorIs(t, err, snippets.ErrNotFound)
+	})
+
+	t.Run("Create a batch", func(t *testing.T) {
+		t.Parallel()
+
+		storage := newStorage(t)
+		createSnippets(t, storage, newSnippet(1, fakeTimeExpires))
+
+		ids, err := storage.CreateBatch(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, ids)
+
+		ids, err = storage.CreateBatch(ctx, []snippets.Snippet{
+			newSnippet(2, fakeTimeExpires),
+			newSnippet(3, fakeTimeExpires),
+			newSnippet(4, fakeTimeExpires),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []uint{2, 3, 4}, ids)
+
+		for _, id := range ids {
+			got, err := storage.Get(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, fmt.Sprintf("Snippet title #%d", id), got.Title)
+		}
+	})
+
+	t.Run("Soft delete a batch", func(t *testing.T) {
+		t.Parallel()
+
+		storage := newStorage(t)
+		createSnippets(
+			t,
+			storage,
+			newSnippet(1, fakeTimeExpires),
+			newSnippet(2, fakeTimeExpires),
+			newSnippet(3, fakeTimeExpired),
+		)
+
+		deleted, err := storage.SoftDeleteBatch(ctx, []uint{1, 2, 3, 42})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []uint{1, 2}, deleted)
+
+		list, err := storage.List(ctx, service.Pagination{})
+		require.NoError(t, err)
+		assert.Empty(t, list)
+
+		deletedAt, err := storage.Get(ctx, 1)
+		require.NoError(t, err)
+
+		deleted, err = storage.SoftDeleteBatch(ctx, []uint{1, 2})
+		require.NoError(t, err)
+		assert.Empty(t, deleted)
+		assert.ErrorIs(t, storage.SoftDelete(ctx, 1), snippets.ErrNotFound)
+
+		got, err := storage.Get(ctx, 1)
+		require.NoError(t, err)
+		assert.True(t, deletedAt.UpdatedAt.Equal(got.UpdatedAt))
+		assert.True(t, deletedAt.ExpiresAt.Equal(got.ExpiresAt))
+	})
+
+	t.Run("Iterate active snippets", func(t *testing.T) {
+		t.Parallel()
+
+		storage := newStorage(t)
+		createSnippets(
+			t,
+			storage,
+			newSnippet(1, fakeTimeExpires),
+			newSnippet(2, fakeTimeExpired),
+			newSnippet(3, fakeTimeExpires),
+		)
+
+		var ids []uint
+		require.NoError(t, storage.Iterate(ctx, func(snippet snippets.Snippet) error {
+			ids = append(ids, snippet.ID)
+			assert.Equal(t, fmt.Sprintf("Snippet content #%d", snippet.ID), snippet.Content)
+			return nil
+		}))
+		assert.Equal(t, []uint{1, 3}, ids)
+
+		expectedErr := errors.New("stop")
+
+		var calls int
+		err := storage.Iterate(ctx, func(snippets.Snippet) error {
+			calls++
+			return expectedErr
+		})
+		require.ErrorIs(t, err, expectedErr)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Lineage", func(t *testing.T) {
+		t.Parallel()
+
+		storage := newStorage(t)
+
+		var parentID uint
+		for i := 1; i <= 3; i++ {
+			snippet := newSnippet(i, fakeTimeExpires)
+			snippet.ParentID = parentID
+
+			id, err := storage.Create(ctx, snippet)
+			require.NoError(t, err)
+			parentID = id
+		}
+
+		// Deleted ancestors are still a part of the lineage
+		require.NoError(t, storage.SoftDelete(ctx, 2))
+
+		got, err := storage.Get(ctx, 3)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, got.ParentID)
+
+		lineage, err := storage.Lineage(ctx, 3)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{2, 1}, lineage)
+
+		lineage, err = storage.Lineage(ctx, 1)
+		require.NoError(t, err)
+		assert.Empty(t, lineage)
+
+		lineage, err = storage.Lineage(ctx, 42)
+		require.NoError(t, err)
+		assert.Empty(t, lineage)
+	})
+
+	t.Run("Views", func(t *testing.T) {
+		t.Parallel()
+
+		storage := newStorage(t)
+		createSnippets(
+			t,
+			storage,
+			newSnippet(1, fakeTimeExpires),
+			newSnippet(2, fakeTimeExpires),
+			newSnippet(3, fakeTimeExpires),
+			newSnippet(4, fakeTimeExpires),
+		)
+
+		day := time.Date(2024, 10, 7, 0, 0, 0, 0, time.UTC)
+
+		require.NoError(t, storage.AddViews(ctx, []snippets.DailyViews{
+			{SnippetID: 1, Day: day.AddDate(0, 0, -10), Views: 100},
+			{SnippetID: 1, Day: day, Views: 2},
+			{SnippetID: 2, Day: day, Views: 5},
+			{SnippetID: 3, Day: day, Views: 9},
+			{SnippetID: 4, Day: day, Views: 3},
+			// Views of unknown snippets are ignored
+			{SnippetID: 42, Day: day, Views: 1},
+		}))
+		require.NoError(t, storage.AddViews(ctx, []snippets.DailyViews{
+			{SnippetID: 1, Day: day, Views: 1},
+		}))
+		require.NoError(t, storage.SoftDelete(ctx, 3))
+
+		total, daily, err := storage.Views(ctx, 1, day.Add(-time.Hour))
+		require.NoError(t, err)
+		assert.EqualValues(t, 103, total)
+		require.Len(t, daily, 1)
+		assert.True(t, day.Equal(daily[0].Day))
+		assert.EqualValues(t, 3, daily[0].Views)
+
+		total, daily, err = storage.Views(ctx, 42, day)
+		require.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Empty(t, daily)
+
+		// Snippets with equal views are ordered by id, deleted snippets are skipped
+		popular, err := storage.Popular(ctx, day, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []snippets.PopularSnippet{
+			{ID: 2, Title: "Snippet title #2", Views: 5},
+			{ID: 1, Title: "Snippet title #1", Views: 3},
+		}, popular)
+	})
+
+	t.Run("Announce expired snippets", func(t *testing.T) {
+		t.Parallel()
+
+		storage := newStorage(t)
+		createSnippets(
+			t,
+			storage,
+			newSnippet(1, fakeTimeExpired),
+			newSnippet(2, fakeTimeExpired.Add(-time.Hour)),
+			newSnippet(3, fakeTimeExpires),
+			newSnippet(4, fakeTimeExpires),
+		)
+
+		// Deleted snippets are never announced, even after their expiry time
+		require.NoError(t, storage.SoftDelete(ctx, 3))
+
+		announced, err := storage.AnnounceExpired(ctx, 1)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, announced)
+
+		announced, err = storage.AnnounceExpired(ctx, 10)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, announced)
+
+		announced, err = storage.AnnounceExpired(ctx, 10)
+		require.NoError(t, err)
+		assert.Zero(t, announced)
+	})
+
+	t.Run("Purge expired snippets", func(t *testing.T) {
+		t.Parallel()
+
+		storage := newStorage(t)
+		createSnippets(
+			t,
+			storage,
+			newSnippet(1, fakeTimeExpired),
+			newSnippet(2, fakeTimeExpired.Add(time.Hour)),
+			newSnippet(3, fakeTimeExpires),
+			newSnippet(4, fakeTimeExpires),
+		)
+
+		require.NoError(t, storage.SoftDelete(ctx, 4))
+
+		announced, err := storage.AnnounceExpired(ctx, 1)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, announced)
+
+		purged, err := storage.PurgeExpired(ctx, fakeTimeExpired.Add(-time.Hour), 10)
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+
+		// Snippets that are expired, but not announced yet, are kept
+		purged, err = storage.PurgeExpired(ctx, time.Now().UTC().Add(time.Minute), 10)
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, purged)
+
+		for _, id := range []uint{1, 4} {
+			_, err = storage.Get(ctx, id)
+			assert.ErrorIs(t, err, snippets.ErrNotFound)
+		}
+
+		for _, id := range []uint{2, 3} {
+			_, err = storage.Get(ctx, id)
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("Usage", func(t *testing.T) {
+		t.Parallel()
+
+		storage := newStorage(t)
+		now := time.Now().UTC().Truncate(time.Second)
+
+		for i, snippet := range []snippets.Snippet{
+			{Caller: "ci", ContentSize: 10, CreatedAt: now, ExpiresAt: fakeTimeExpires},
+			{Caller: "ci", ContentSize: 20, CreatedAt: now.Add(-48 * time.Hour), ExpiresAt: fakeTimeExpires},
+			{Caller: "ci", ContentSize: 40, CreatedAt: now.Add(-time.Hour), ExpiresAt: fakeTimeExpired},
+			{Caller: "bot", ContentSize: 80, CreatedAt: now, ExpiresAt: fakeTimeExpires},
+		} {
+			snippet.Title = fmt.Sprintf("Snippet title #%d", i+1)
+			snippet.Content = "Snippet content"
+			snippet.UpdatedAt = snippet.CreatedAt
+			createSnippets(t, storage, snippet)
+		}
+
+		usage, err := storage.Usage(ctx, "ci", now.Add(-24*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, snippets.Usage{Snippets: 2, ContentBytes: 30, CreatedLastDay: 2}, usage)
+
+		usage, err = storage.Usage(ctx, "unknown", now.Add(-24*time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, snippets.Usage{}, usage)
+	})
+}
+
+func snippetIDs(list []snippets.Snippet) []uint {
+	var ids []uint
+	for _, snippet := range list {
+		ids = append(ids, snippet.ID)
+	}
+	return ids
+}
+
+// inUTC converts times of a snippet to UTC, so snippets read from different storages can be compared
+func inUTC(snippet snippets.Snippet) snippets.Snippet {
+	snippet.CreatedAt = snippet.CreatedAt.UTC()
+	snippet.UpdatedAt = snippet.UpdatedAt.UTC()
+	snippet.ExpiresAt = snippet.ExpiresAt.UTC()
+	return snippet
+}
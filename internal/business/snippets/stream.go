@@ -0,0 +1,36 @@
+package snippets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+)
+
+// LastEventIDHeader is sent by Server-Sent Events clients to resume a stream after a reconnect
+const LastEventIDHeader = "Last-Event-ID"
+
+// eventsKeepAliveInterval is how often a comment is sent to an idle stream, so proxies don't close it
+const eventsKeepAliveInterval = 15 * time.Second
+
+// EventStream is a source of events for GET /snippets/events
+type EventStream interface {
+	Subscribe(ctx context.Context, lastEventID string) (*events.Subscription, error)
+}
+
+// writeServerSentEvent writes an event in the text/event-stream format
+func writeServerSentEvent(w io.Writer, event events.Event) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "id: %s\nevent: %s\n", event.ID, event.Type)
+	// A new line ends a data field, so multi-line data is split into several fields
+	for _, line := range bytes.Split(event.Data, []byte("\n")) {
+		fmt.Fprintf(&buf, "data: %s\n", line)
+	}
+	buf.WriteByte('\n')
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
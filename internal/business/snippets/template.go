@@ -0,0 +1,220 @@
+package snippets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// maxTemplateVariables is the maximum number of variables a template may declare
+const maxTemplateVariables = 50
+
+var (
+	// ErrNotTemplate error is returned when a snippet that isn't a template is instantiated
+	ErrNotTemplate = errors.New("snippet is not a template")
+	// ErrRenderedTooLong error is returned when the rendered content is longer than MaxContentLength
+	ErrRenderedTooLong = fmt.Errorf("rendered content is too long: max %d characters", MaxContentLength)
+
+	// placeholderRegexp matches placeholders of template variables: {{name}} or {{ name }}
+	placeholderRegexp = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+	// variableNameRegexp matches valid names of template variables
+	variableNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Template marks a snippet as a template. The snippet content references variables as {{name}}.
+// Variables are stored as is even if the snippet content is encrypted.
+type Template struct {
+	Variables []TemplateVariable `json:"variables"`
+}
+
+// TemplateVariable is a variable declared by a template
+type TemplateVariable struct {
+	Name string `json:"name"`
+	// Default is used when no value is supplied for the variable
+	Default string `json:"default,omitempty"`
+	// Required variables must be supplied, the default value is ignored
+	Required bool `json:"required,omitempty"`
+	// Pattern is an optional regular expression every value of the variable must fully match
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// Validate checks the declared variables and that every placeholder of the content is declared
+func (t Template) Validate(content string) error {
+	if err := validation.Validate(
+		t.Variables,
+		validation.Required,
+		validation.Length(1, maxTemplateVariables),
+	); err != nil {
+		return validation.Errors{"variables": err}
+	}
+
+	errs := validation.Errors{}
+	declared := make(map[string]struct{}, len(t.Variables))
+
+	for _, v := range t.Variables {
+		if !variableNameRegexp.MatchString(v.Name) {
+			return validation.Errors{"variables": fmt.Errorf("invalid variable name %q", v.Name)}
+		}
+
+		if _, ok := declared[v.Name]; ok {
+			errs[v.Name] = errors.New("is declared more than once")
+			continue
+		}
+		declared[v.Name] = struct{}{}
+
+		if _, err := v.compile(); err != nil {
+			errs[v.Name] = err
+			continue
+		}
+
+		if v.Default != "" {
+			if err := v.check(v.Default); err != nil {
+				errs[v.Name] = fmt.Errorf("default value %w", err)
+			}
+		}
+	}
+
+	for _, match := range placeholderRegexp.FindAllStringSubmatch(content, -1) {
+		if _, ok := declared[match[1]]; !ok {
+			errs[match[1]] = errors.New("is used in the content, but not declared")
+		}
+	}
+
+	return errs.Filter()
+}
+
+// Render substitutes variables of the content with the supplied values or their defaults.
+// The returned error is validation.Errors keyed by variable names, or ErrRenderedTooLong:
+// rendering stops as soon as the content gets longer than MaxContentLength.
+func (t Template) Render(content string, values map[string]string) (string, error) {
+	errs := validation.Errors{}
+	resolved := make(map[string]string, len(t.Variables))
+
+	for _, v := range t.Variables {
+		value, ok := values[v.Name]
+		switch {
+		case !ok && v.Required:
+			errs[v.Name] = errors.New("is required")
+			continue
+		case !ok:
+			value = v.Default
+		}
+
+		if err := v.check(value); err != nil {
+			errs[v.Name] = err
+			continue
+		}
+
+		resolved[v.Name] = value
+	}
+
+	for name := range values {
+		if _, ok := resolved[name]; !ok && errs[name] == nil {
+			errs[name] = errors.New("is not declared by the template")
+		}
+	}
+
+	if err := errs.Filter(); err != nil {
+		return "", err
+	}
+
+	var (
+		rendered strings.Builder
+		length   int
+		last     int
+	)
+
+	write := func(s string) error {
+		if length += utf8.RuneCountInString(s); length > MaxContentLength {
+			return ErrRenderedTooLong
+		}
+		rendered.WriteString(s)
+		return nil
+	}
+
+	for _, match := range placeholderRegexp.FindAllStringSubmatchIndex(content, -1) {
+		value, ok := resolved[content[match[2]:match[3]]]
+		if !ok {
+			value = content[match[0]:match[1]]
+		}
+
+		if err := write(content[last:match[0]]); err != nil {
+			return "", err
+		}
+		if err := write(value); err != nil {
+			return "", err
+		}
+		last = match[1]
+	}
+
+	if err := write(content[last:]); err != nil {
+		return "", err
+	}
+
+	return rendered.String(), nil
+}
+
+// check validates a value of the variable against its pattern
+func (v TemplateVariable) check(value string) error {
+	pattern, err := v.compile()
+	if err != nil || pattern == nil {
+		return err
+	}
+
+	if !pattern.MatchString(value) {
+		return fmt.Errorf("must match pattern %s", v.Pattern)
+	}
+
+	return nil
+}
+
+// compile returns the variable pattern anchored to the whole value, nil if there's no pattern
+func (v TemplateVariable) compile() (*regexp.Regexp, error) {
+	if v.Pattern == "" {
+		return nil, nil
+	}
+
+	if _, err := regexp.Compile(v.Pattern); err != nil {
+		return nil, fmt.Errorf("has invalid pattern: %w", err)
+	}
+
+	pattern, err := regexp.Compile(`^(?:` + v.Pattern + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("has invalid pattern: %w", err)
+	}
+
+	return pattern, nil
+}
+
+// marshalTemplate encodes a template to be stored in DB, nil means the snippet isn't a template
+func marshalTemplate(t *Template) ([]byte, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode template: %w", err)
+	}
+
+	return data, nil
+}
+
+// unmarshalTemplate decodes a template stored in DB
+func unmarshalTemplate(data []byte) (*Template, error) {
+	if data == nil {
+		return nil, nil
+	}
+
+	var t Template
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("failed to decode template: %w", err)
+	}
+
+	return &t, nil
+}
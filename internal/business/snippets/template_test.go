@@ -0,0 +1,132 @@
+package snippets_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/utils/testutils"
+)
+
+func TestTemplate_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		template snippets.Template
+		content  string
+		wantErr  string
+	}{
+		{
+			name: "Valid template",
+			template: snippets.Template{Variables: []snippets.TemplateVariable{
+				{Name: "name", Required: true},
+				{Name: "port", Default: "8080", Pattern: `\d+`},
+			}},
+			content: "server {{name}} listens on {{ port }}, {{name}}!",
+			wantErr: "",
+		},
+		{
+			name: "Valid: declared variable isn't used",
+			template: snippets.Template{Variables: []snippets.TemplateVariable{
+				{Name: "name"},
+			}},
+			content: "no placeholders",
+			wantErr: "",
+		},
+		{
+			name:     "Invalid: no variables",
+			template: snippets.Template{},
+			content:  "{{name}}",
+			wantErr:  "variables: cannot be blank.",
+		},
+		{
+			name: "Invalid: bad variable name",
+			template: snippets.Template{Variables: []snippets.TemplateVariable{
+				{Name: "first-name"},
+			}},
+			content: "{{name}}",
+			wantErr: `variables: invalid variable name "first-name".`,
+		},
+		{
+			name: "Invalid: undeclared placeholder, duplicate, bad default and bad pattern",
+			template: snippets.Template{Variables: []snippets.TemplateVariable{
+				{Name: "name"},
+				{Name: "name"},
+				{Name: "port", Default: "http", Pattern: `\d+`},
+				{Name: "host", Pattern: `[a-z`},
+			}},
+			content: "{{name}}:{{port}} {{ user }}",
+			wantErr: "host: has invalid pattern: error parsing regexp: missing closing ]: `[a-z`; " +
+				"name: is declared more than once; " +
+				`port: default value must match pattern \d+; ` +
+				"user: is used in the content, but not declared.",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.template.Validate(tt.content)
+			testutils.AssertError(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestTemplate_Render(t *testing.T) {
+	t.Parallel()
+
+	template := snippets.Template{Variables: []snippets.TemplateVariable{
+		{Name: "name", Required: true},
+		{Name: "port", Default: "8080", Pattern: `\d+`},
+	}}
+	content := "server {{name}} listens on {{ port }}, {{name}}!"
+
+	tests := []struct {
+		name    string
+		values  map[string]string
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "Defaults are used for missing values",
+			values: map[string]string{"name": "api"},
+			want:   "server api listens on 8080, api!",
+		},
+		{
+			name:   "Supplied values",
+			values: map[string]string{"name": "{{port}}", "port": "443"},
+			want:   "server {{port}} listens on 443, {{port}}!",
+		},
+		{
+			name:    "Invalid: required value is missing",
+			values:  nil,
+			wantErr: "name: is required.",
+		},
+		{
+			name:    "Invalid: value doesn't match pattern, unknown variable",
+			values:  map[string]string{"name": "api", "port": "80a", "user": "root"},
+			wantErr: `port: must match pattern \d+; user: is not declared by the template.`,
+		},
+		{
+			name:    "Invalid: rendered content is too long",
+			values:  map[string]string{"name": strings.Repeat("a", snippets.MaxContentLength/2)},
+			wantErr: "rendered content is too long: max 10000 characters",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rendered, err := template.Render(content, tt.values)
+			testutils.AssertError(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, rendered)
+		})
+	}
+}
@@ -2,16 +2,21 @@ package snippets
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 	"github.com/gorilla/schema"
 
 	"github.com/titusjaka/go-sample/v2/internal/infrastructure/api"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
 	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
 )
 
@@ -23,20 +28,55 @@ type Service interface {
 	Create(ctx context.Context, snippet Snippet) (Snippet, *service.Error)
 	List(ctx context.Context, limit uint, offset uint) ([]Snippet, service.Pagination, *service.Error)
 	SoftDelete(ctx context.Context, id uint) *service.Error
+	CreateBatch(ctx context.Context, batch []Snippet, atomic bool) ([]BatchResult, *service.Error)
+	SoftDeleteBatch(ctx context.Context, ids []uint) ([]*service.Error, *service.Error)
+	Export(ctx context.Context, fn func(Snippet) error) *service.Error
+	Fork(ctx context.Context, id uint, fork Snippet) (Snippet, *service.Error)
+	Instantiate(ctx context.Context, id uint, instantiation Instantiation) (Snippet, *service.Error)
+	Stats(ctx context.Context, id uint, days uint) (SnippetStats, *service.Error)
+	Popular(ctx context.Context, limit uint, days uint) ([]PopularSnippet, *service.Error)
+	Usage(ctx context.Context) (Usage, *service.Error)
+	Now() time.Time
 }
 
 // Transport is a struct that holds all endpoints for snippets
 type Transport struct {
 	logger  *slog.Logger
 	service Service
+
+	views  ViewRecorder
+	stream EventStream
+}
+
+// TransportOption configures optional Transport dependencies
+type TransportOption func(*Transport)
+
+// WithViewRecorder enables recording of snippets reads made by GET /snippets/{snippet_id}
+func WithViewRecorder(recorder ViewRecorder) TransportOption {
+	return func(t *Transport) {
+		t.views = recorder
+	}
+}
+
+// WithEventStream enables GET /snippets/events
+func WithEventStream(stream EventStream) TransportOption {
+	return func(t *Transport) {
+		t.stream = stream
+	}
 }
 
 // NewTransport creates a new Transport instance
-func NewTransport(s Service, l *slog.Logger) *Transport {
-	return &Transport{
+func NewTransport(s Service, l *slog.Logger, opts ...TransportOption) *Transport {
+	t := &Transport{
 		logger:  l,
 		service: s,
 	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
 }
 
 // Routes initialize all endpoints for route /snippets
@@ -44,14 +84,32 @@ func (t *Transport) Routes() chi.Router {
 	r := chi.NewRouter()
 	r.Get("/", t.listSnippets)
 	r.Post("/", t.createSnippet)
+	r.Get("/export", t.exportSnippets)
+	r.Get("/popular", t.popularSnippets)
+	r.Get("/events", t.snippetEvents)
 	r.Route("/{snippet_id}", func(r chi.Router) {
 		r.Get("/", t.getSnippet)
+		r.Get("/stats", t.snippetStats)
 		r.Delete("/", t.deleteSnippet)
+		r.Post("/fork", t.forkSnippet)
+		r.Post("/instantiate", t.instantiateSnippet)
 	})
 
 	return r
 }
 
+// RegisterBatchRoutes registers batch endpoints POST /snippets:batchCreate and POST /snippets:batchDelete.
+// These endpoints can't be mounted under /snippets/ route, since they share the resource path.
+func (t *Transport) RegisterBatchRoutes(r chi.Router) {
+	r.Post("/snippets:batchCreate", t.batchCreateSnippets)
+	r.Post("/snippets:batchDelete", t.batchDeleteSnippets)
+}
+
+// RegisterUsageRoutes registers GET /usage endpoint, which shows the consumption of the API caller.
+func (t *Transport) RegisterUsageRoutes(r chi.Router) {
+	r.Get("/usage", t.getUsage)
+}
+
 // listSnippets in an endpoint for GET /snippets method
 func (t *Transport) listSnippets(w http.ResponseWriter, r *http.Request) {
 	var listSnippetsRequest ListSnippetsRequest
@@ -68,12 +126,150 @@ func (t *Transport) listSnippets(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Vary", "Authorization")
+	if writeValidators(w, r, listETag(snippets, pagination), listLastModified(snippets)) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	render.JSON(w, r, &ListSnippetsResponse{
 		Snippets:   convertToListSnippetsResponse(snippets),
 		Pagination: pagination,
 	})
 }
 
+// forkSnippet is an endpoint for POST /snippets/{snippet_id}/fork method
+func (t *Transport) forkSnippet(w http.ResponseWriter, r *http.Request) {
+	snippetID, svcErr := parseSnippetID(r)
+	if svcErr != nil {
+		t.logger.Error("failed to parse snippet id", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	// The request body is optional
+	var forkReq ForkSnippetRequest
+	if r.ContentLength != 0 {
+		if err := render.Decode(r, &forkReq); err != nil && !errors.Is(err, io.EOF) {
+			t.logger.Error("failed to decode request params", slog.Any("err", err))
+			_ = render.Render(w, r, api.ErrBadRequest(err))
+			return
+		}
+	}
+
+	forkReq.Passphrase = r.Header.Get(PassphraseHeader)
+
+	if validationErr := forkReq.Validate(); validationErr != nil {
+		t.logger.Info("request is not valid", slog.Any("validation_err", validationErr))
+		_ = render.Render(w, r, api.ErrBadRequest(validationErr))
+		return
+	}
+
+	ctx := ContextWithPassphrase(r.Context(), forkReq.Passphrase)
+
+	snippet, svcErr := t.service.Fork(ctx, snippetID, Snippet{
+		Title:     forkReq.Title,
+		ExpiresAt: forkReq.ExpiresAt,
+	})
+	if svcErr != nil {
+		t.logger.Error("failed to fork snippet", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	render.JSON(w, r, convertToSnippetResponse(snippet))
+}
+
+// instantiateSnippet is an endpoint for POST /snippets/{snippet_id}/instantiate method
+func (t *Transport) instantiateSnippet(w http.ResponseWriter, r *http.Request) {
+	snippetID, svcErr := parseSnippetID(r)
+	if svcErr != nil {
+		t.logger.Error("failed to parse snippet id", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	// The request body is optional: all variables may have defaults
+	var instantiateReq InstantiateSnippetRequest
+	if r.ContentLength != 0 {
+		if err := render.Decode(r, &instantiateReq); err != nil && !errors.Is(err, io.EOF) {
+			t.logger.Error("failed to decode request params", slog.Any("err", err))
+			_ = render.Render(w, r, api.ErrBadRequest(err))
+			return
+		}
+	}
+
+	instantiateReq.Passphrase = r.Header.Get(PassphraseHeader)
+
+	if validationErr := instantiateReq.Validate(); validationErr != nil {
+		t.logger.Info("request is not valid", slog.Any("validation_err", validationErr))
+		_ = render.Render(w, r, api.ErrBadRequest(validationErr))
+		return
+	}
+
+	ctx := ContextWithPassphrase(r.Context(), instantiateReq.Passphrase)
+
+	snippet, svcErr := t.service.Instantiate(ctx, snippetID, Instantiation{
+		Values:    instantiateReq.Values,
+		Save:      instantiateReq.Save,
+		Title:     instantiateReq.Title,
+		ExpiresAt: instantiateReq.ExpiresAt,
+	})
+	if svcErr != nil {
+		t.logger.Error("failed to instantiate snippet", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	response := InstantiateSnippetResponse{Content: snippet.Content}
+	if instantiateReq.Save {
+		saved := convertToSnippetResponse(snippet)
+		response.Snippet = &saved
+	}
+
+	render.JSON(w, r, response)
+}
+
+// exportSnippets is an endpoint for GET /snippets/export?format=<x> method.
+// Snippets are streamed one by one, the whole result set is never held in memory.
+func (t *Transport) exportSnippets(w http.ResponseWriter, r *http.Request) {
+	var exportReq ExportSnippetsRequest
+	if err := schema.NewDecoder().Decode(&exportReq, r.URL.Query()); err != nil {
+		t.logger.Error("failed to decode request params", slog.Any("err", err))
+		_ = render.Render(w, r, api.ErrBadRequest(err))
+		return
+	}
+
+	if validationErr := exportReq.Validate(); validationErr != nil {
+		t.logger.Info("request is not valid", slog.Any("validation_err", validationErr))
+		_ = render.Render(w, r, api.ErrBadRequest(validationErr))
+		return
+	}
+
+	if exportReq.Format == "" {
+		exportReq.Format = ExportFormatNDJSON
+	}
+
+	exporter := newExporter(w, exportReq.Format)
+
+	svcErr := t.service.Export(r.Context(), func(snippet Snippet) error {
+		return exporter.Write(convertToSnippetResponse(snippet))
+	})
+	if svcErr != nil {
+		t.logger.Error("failed to export snippets", slog.Any("svc_err", svcErr))
+		if !exporter.Started() {
+			_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		}
+		// Otherwise the response is already sent and is left unfinished
+		return
+	}
+
+	if err := exporter.Close(); err != nil {
+		t.logger.Error("failed to finish export", slog.Any("err", err))
+	}
+}
+
 // getSnippet is an endpoint for GET /snippets/{snippet_id} method
 func (t *Transport) getSnippet(w http.ResponseWriter, r *http.Request) {
 	snippetID, svcErr := parseSnippetID(r)
@@ -83,16 +279,164 @@ func (t *Transport) getSnippet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	snippet, svcErr := t.service.Get(r.Context(), snippetID)
+	ctx := ContextWithPassphrase(r.Context(), r.Header.Get(PassphraseHeader))
+
+	snippet, svcErr := t.service.Get(ctx, snippetID)
 	if svcErr != nil {
 		t.logger.Error("failed to get snippet", slog.Any("svc_err", svcErr))
 		_ = render.Render(w, r, api.NewErrResponse(svcErr))
 		return
 	}
 
+	if t.views != nil {
+		t.views.RecordView(snippet.ID)
+	}
+
+	w.Header().Set("Cache-Control", snippetCacheControl(snippet, t.service.Now()))
+	w.Header().Set("Vary", "Authorization")
+	if writeValidators(w, r, snippetETag(snippet), snippet.UpdatedAt) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	render.JSON(w, r, convertToSnippetResponse(snippet))
 }
 
+// snippetStats is an endpoint for GET /snippets/{snippet_id}/stats?days=<x> method
+func (t *Transport) snippetStats(w http.ResponseWriter, r *http.Request) {
+	snippetID, svcErr := parseSnippetID(r)
+	if svcErr != nil {
+		t.logger.Error("failed to parse snippet id", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	var statsReq SnippetStatsRequest
+	if err := schema.NewDecoder().Decode(&statsReq, r.URL.Query()); err != nil {
+		t.logger.Error("failed to decode request params", slog.Any("err", err))
+		_ = render.Render(w, r, api.ErrBadRequest(err))
+		return
+	}
+
+	if validationErr := statsReq.Validate(); validationErr != nil {
+		t.logger.Info("request is not valid", slog.Any("validation_err", validationErr))
+		_ = render.Render(w, r, api.ErrBadRequest(validationErr))
+		return
+	}
+
+	if statsReq.Days == 0 {
+		statsReq.Days = defaultStatsDays
+	}
+
+	stats, svcErr := t.service.Stats(r.Context(), snippetID, statsReq.Days)
+	if svcErr != nil {
+		t.logger.Error("failed to get snippet stats", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	render.JSON(w, r, convertToSnippetStatsResponse(stats))
+}
+
+// getUsage is an endpoint for GET /usage method
+func (t *Transport) getUsage(w http.ResponseWriter, r *http.Request) {
+	usage, svcErr := t.service.Usage(r.Context())
+	if svcErr != nil {
+		t.logger.Error("failed to get usage", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	render.JSON(w, r, convertToUsageResponse(usage))
+}
+
+// popularSnippets is an endpoint for GET /snippets/popular?limit=<x>&days=<y> method
+func (t *Transport) popularSnippets(w http.ResponseWriter, r *http.Request) {
+	var popularReq PopularSnippetsRequest
+	if err := schema.NewDecoder().Decode(&popularReq, r.URL.Query()); err != nil {
+		t.logger.Error("failed to decode request params", slog.Any("err", err))
+		_ = render.Render(w, r, api.ErrBadRequest(err))
+		return
+	}
+
+	if validationErr := popularReq.Validate(); validationErr != nil {
+		t.logger.Info("request is not valid", slog.Any("validation_err", validationErr))
+		_ = render.Render(w, r, api.ErrBadRequest(validationErr))
+		return
+	}
+
+	if popularReq.Limit == 0 {
+		popularReq.Limit = defaultPopularLimit
+	}
+	if popularReq.Days == 0 {
+		popularReq.Days = defaultPopularDays
+	}
+
+	popular, svcErr := t.service.Popular(r.Context(), popularReq.Limit, popularReq.Days)
+	if svcErr != nil {
+		t.logger.Error("failed to get popular snippets", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	render.JSON(w, r, convertToPopularSnippetsResponse(popular))
+}
+
+// snippetEvents is an endpoint for GET /snippets/events method. It streams snippets events as Server-Sent Events,
+// the stream is resumed with the Last-Event-ID header.
+func (t *Transport) snippetEvents(w http.ResponseWriter, r *http.Request) {
+	if t.stream == nil {
+		_ = render.Render(w, r, api.ErrNotFound(errors.New("events stream is disabled")))
+		return
+	}
+
+	sub, err := t.stream.Subscribe(r.Context(), r.Header.Get(LastEventIDHeader))
+	if err != nil {
+		t.logger.Error("failed to subscribe to snippets events", slog.Any("err", err))
+		_ = render.Render(w, r, api.ErrInternal(err))
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	ticker := time.NewTicker(eventsKeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		if err = rc.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			_, err = io.WriteString(w, ": keep-alive\n\n")
+		case event, ok := <-sub.Events():
+			if !ok {
+				// The server is shutting down or the client fell behind, it reconnects with Last-Event-ID
+				return
+			}
+
+			if event.Type != events.TypeReset && !slices.Contains(EventTypes(), event.Type) {
+				continue
+			}
+
+			err = writeServerSentEvent(w, event)
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
 // createSnippet in an endpoint for POST /snippets method
 func (t *Transport) createSnippet(w http.ResponseWriter, r *http.Request) {
 	var createSnippetReq CreateSnippetRequest
@@ -102,6 +446,8 @@ func (t *Transport) createSnippet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	createSnippetReq.Passphrase = r.Header.Get(PassphraseHeader)
+
 	if validationErr := createSnippetReq.Validate(); validationErr != nil {
 		t.logger.Info("request is not valid", slog.Any("validation_err", validationErr))
 		_ = render.Render(w, r, api.ErrBadRequest(validationErr))
@@ -112,9 +458,12 @@ func (t *Transport) createSnippet(w http.ResponseWriter, r *http.Request) {
 		Title:     createSnippetReq.Title,
 		Content:   createSnippetReq.Content,
 		ExpiresAt: createSnippetReq.ExpiresAt,
+		Template:  createSnippetReq.Template,
 	}
 
-	snippet, svcErr := t.service.Create(r.Context(), newSnippet)
+	ctx := ContextWithPassphrase(r.Context(), createSnippetReq.Passphrase)
+
+	snippet, svcErr := t.service.Create(ctx, newSnippet)
 	if svcErr != nil {
 		t.logger.Error("failed to create snippet", slog.Any("svc_err", svcErr))
 		_ = render.Render(w, r, api.NewErrResponse(svcErr))
@@ -143,6 +492,128 @@ func (t *Transport) deleteSnippet(w http.ResponseWriter, r *http.Request) {
 	render.NoContent(w, r)
 }
 
+// batchCreateSnippets is an endpoint for POST /snippets:batchCreate method
+func (t *Transport) batchCreateSnippets(w http.ResponseWriter, r *http.Request) {
+	var batchReq BatchCreateSnippetsRequest
+	if err := render.Decode(r, &batchReq); err != nil {
+		t.logger.Error("failed to decode request params", slog.Any("err", err))
+		_ = render.Render(w, r, api.ErrBadRequest(err))
+		return
+	}
+
+	if validationErr := batchReq.Validate(); validationErr != nil {
+		t.logger.Info("request is not valid", slog.Any("validation_err", validationErr))
+		_ = render.Render(w, r, api.ErrBadRequest(validationErr))
+		return
+	}
+
+	passphrase := r.Header.Get(PassphraseHeader)
+
+	results := make([]BatchItemResponse, len(batchReq.Snippets))
+	validSnippets := make([]Snippet, 0, len(batchReq.Snippets))
+	validIndexes := make([]int, 0, len(batchReq.Snippets))
+
+	for i := range batchReq.Snippets {
+		item := batchReq.Snippets[i]
+		item.Passphrase = passphrase
+
+		if validationErr := item.Validate(); validationErr != nil {
+			results[i] = BatchItemResponse{Index: i, Status: http.StatusBadRequest, Error: validationErr.Error()}
+			continue
+		}
+
+		validSnippets = append(validSnippets, Snippet{
+			Title:     item.Title,
+			Content:   item.Content,
+			ExpiresAt: item.ExpiresAt,
+			Template:  item.Template,
+		})
+		validIndexes = append(validIndexes, i)
+	}
+
+	if !batchReq.BestEffort && len(validSnippets) < len(batchReq.Snippets) {
+		t.logger.Info("batch is not valid, nothing is created")
+		for _, i := range validIndexes {
+			results[i] = BatchItemResponse{
+				Index:  i,
+				Status: http.StatusFailedDependency,
+				Error:  "batch is aborted due to invalid snippets",
+			}
+		}
+
+		render.Status(r, http.StatusBadRequest)
+		render.JSON(w, r, &BatchResponse{Results: results})
+		return
+	}
+
+	if len(validSnippets) > 0 {
+		ctx := ContextWithPassphrase(r.Context(), passphrase)
+
+		created, svcErr := t.service.CreateBatch(ctx, validSnippets, !batchReq.BestEffort)
+		if svcErr != nil {
+			t.logger.Error("failed to create batch of snippets", slog.Any("svc_err", svcErr))
+			_ = render.Render(w, r, api.NewErrResponse(svcErr))
+			return
+		}
+
+		for j, result := range created {
+			i := validIndexes[j]
+
+			if result.Err != nil {
+				errResponse := api.NewErrResponse(result.Err)
+				results[i] = BatchItemResponse{Index: i, Status: errResponse.StatusCode(), Error: errResponse.Error}
+				continue
+			}
+
+			snippetResponse := convertToSnippetResponse(result.Snippet)
+			results[i] = BatchItemResponse{
+				Index:   i,
+				Status:  http.StatusCreated,
+				ID:      result.Snippet.ID,
+				Snippet: &snippetResponse,
+			}
+		}
+	}
+
+	render.JSON(w, r, &BatchResponse{Results: results})
+}
+
+// batchDeleteSnippets is an endpoint for POST /snippets:batchDelete method
+func (t *Transport) batchDeleteSnippets(w http.ResponseWriter, r *http.Request) {
+	var batchReq BatchDeleteSnippetsRequest
+	if err := render.Decode(r, &batchReq); err != nil {
+		t.logger.Error("failed to decode request params", slog.Any("err", err))
+		_ = render.Render(w, r, api.ErrBadRequest(err))
+		return
+	}
+
+	if validationErr := batchReq.Validate(); validationErr != nil {
+		t.logger.Info("request is not valid", slog.Any("validation_err", validationErr))
+		_ = render.Render(w, r, api.ErrBadRequest(validationErr))
+		return
+	}
+
+	deleteErrs, svcErr := t.service.SoftDeleteBatch(r.Context(), batchReq.IDs)
+	if svcErr != nil {
+		t.logger.Error("failed to delete batch of snippets", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	results := make([]BatchItemResponse, len(batchReq.IDs))
+	for i, id := range batchReq.IDs {
+		results[i] = BatchItemResponse{Index: i, Status: http.StatusNoContent, ID: id}
+
+		if deleteErrs[i] != nil {
+			errResponse := api.NewErrResponse(deleteErrs[i])
+			results[i].Status = errResponse.StatusCode()
+			results[i].Error = errResponse.Error
+		}
+	}
+
+	render.JSON(w, r, &BatchResponse{Results: results})
+}
+
 // parseSnippetID fetches URLParam from go-chi request Context and check it. In case of error service.Error is returned
 func parseSnippetID(r *http.Request) (uint, *service.Error) {
 	id, err := strconv.Atoi(chi.URLParam(r, "snippet_id"))
@@ -12,6 +12,7 @@ package snippets_test
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	snippets "github.com/titusjaka/go-sample/v2/internal/business/snippets"
 	service "github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
@@ -22,6 +23,7 @@ import (
 type MockService struct {
 	ctrl     *gomock.Controller
 	recorder *MockServiceMockRecorder
+	isgomock struct{}
 }
 
 // MockServiceMockRecorder is the mock recorder for MockService.
@@ -80,6 +82,122 @@ func (c *MockServiceCreateCall) DoAndReturn(f func(context.Context, snippets.Sni
 	return c
 }
 
+// CreateBatch mocks base method.
+func (m *MockService) CreateBatch(ctx context.Context, batch []snippets.Snippet, atomic bool) ([]snippets.BatchResult, *service.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBatch", ctx, batch, atomic)
+	ret0, _ := ret[0].([]snippets.BatchResult)
+	ret1, _ := ret[1].(*service.Error)
+	return ret0, ret1
+}
+
+// CreateBatch indicates an expected call of CreateBatch.
+func (mr *MockServiceMockRecorder) CreateBatch(ctx, batch, atomic any) *MockServiceCreateBatchCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBatch", reflect.TypeOf((*MockService)(nil).CreateBatch), ctx, batch, atomic)
+	return &MockServiceCreateBatchCall{Call: call}
+}
+
+// MockServiceCreateBatchCall wrap *gomock.Call
+type MockServiceCreateBatchCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceCreateBatchCall) Return(arg0 []snippets.BatchResult, arg1 *service.Error) *MockServiceCreateBatchCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceCreateBatchCall) Do(f func(context.Context, []snippets.Snippet, bool) ([]snippets.BatchResult, *service.Error)) *MockServiceCreateBatchCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceCreateBatchCall) DoAndReturn(f func(context.Context, []snippets.Snippet, bool) ([]snippets.BatchResult, *service.Error)) *MockServiceCreateBatchCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Export mocks base method.
+func (m *MockService) Export(ctx context.Context, fn func(snippets.Snippet) error) *service.Error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", ctx, fn)
+	ret0, _ := ret[0].(*service.Error)
+	return ret0
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockServiceMockRecorder) Export(ctx, fn any) *MockServiceExportCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockService)(nil).Export), ctx, fn)
+	return &MockServiceExportCall{Call: call}
+}
+
+// MockServiceExportCall wrap *gomock.Call
+type MockServiceExportCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceExportCall) Return(arg0 *service.Error) *MockServiceExportCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceExportCall) Do(f func(context.Context, func(snippets.Snippet) error) *service.Error) *MockServiceExportCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceExportCall) DoAndReturn(f func(context.Context, func(snippets.Snippet) error) *service.Error) *MockServiceExportCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Fork mocks base method.
+func (m *MockService) Fork(ctx context.Context, id uint, fork snippets.Snippet) (snippets.Snippet, *service.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Fork", ctx, id, fork)
+	ret0, _ := ret[0].(snippets.Snippet)
+	ret1, _ := ret[1].(*service.Error)
+	return ret0, ret1
+}
+
+// Fork indicates an expected call of Fork.
+func (mr *MockServiceMockRecorder) Fork(ctx, id, fork any) *MockServiceForkCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fork", reflect.TypeOf((*MockService)(nil).Fork), ctx, id, fork)
+	return &MockServiceForkCall{Call: call}
+}
+
+// MockServiceForkCall wrap *gomock.Call
+type MockServiceForkCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceForkCall) Return(arg0 snippets.Snippet, arg1 *service.Error) *MockServiceForkCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceForkCall) Do(f func(context.Context, uint, snippets.Snippet) (snippets.Snippet, *service.Error)) *MockServiceForkCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceForkCall) DoAndReturn(f func(context.Context, uint, snippets.Snippet) (snippets.Snippet, *service.Error)) *MockServiceForkCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Get mocks base method.
 func (m *MockService) Get(ctx context.Context, id uint) (snippets.Snippet, *service.Error) {
 	m.ctrl.T.Helper()
@@ -119,6 +237,45 @@ func (c *MockServiceGetCall) DoAndReturn(f func(context.Context, uint) (snippets
 	return c
 }
 
+// Instantiate mocks base method.
+func (m *MockService) Instantiate(ctx context.Context, id uint, instantiation snippets.Instantiation) (snippets.Snippet, *service.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Instantiate", ctx, id, instantiation)
+	ret0, _ := ret[0].(snippets.Snippet)
+	ret1, _ := ret[1].(*service.Error)
+	return ret0, ret1
+}
+
+// Instantiate indicates an expected call of Instantiate.
+func (mr *MockServiceMockRecorder) Instantiate(ctx, id, instantiation any) *MockServiceInstantiateCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Instantiate", reflect.TypeOf((*MockService)(nil).Instantiate), ctx, id, instantiation)
+	return &MockServiceInstantiateCall{Call: call}
+}
+
+// MockServiceInstantiateCall wrap *gomock.Call
+type MockServiceInstantiateCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceInstantiateCall) Return(arg0 snippets.Snippet, arg1 *service.Error) *MockServiceInstantiateCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceInstantiateCall) Do(f func(context.Context, uint, snippets.Instantiation) (snippets.Snippet, *service.Error)) *MockServiceInstantiateCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceInstantiateCall) DoAndReturn(f func(context.Context, uint, snippets.Instantiation) (snippets.Snippet, *service.Error)) *MockServiceInstantiateCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // List mocks base method.
 func (m *MockService) List(ctx context.Context, limit, offset uint) ([]snippets.Snippet, service.Pagination, *service.Error) {
 	m.ctrl.T.Helper()
@@ -159,6 +316,83 @@ func (c *MockServiceListCall) DoAndReturn(f func(context.Context, uint, uint) ([
 	return c
 }
 
+// Now mocks base method.
+func (m *MockService) Now() time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Now")
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// Now indicates an expected call of Now.
+func (mr *MockServiceMockRecorder) Now() *MockServiceNowCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Now", reflect.TypeOf((*MockService)(nil).Now))
+	return &MockServiceNowCall{Call: call}
+}
+
+// MockServiceNowCall wrap *gomock.Call
+type MockServiceNowCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceNowCall) Return(arg0 time.Time) *MockServiceNowCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceNowCall) Do(f func() time.Time) *MockServiceNowCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceNowCall) DoAndReturn(f func() time.Time) *MockServiceNowCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Popular mocks base method.
+func (m *MockService) Popular(ctx context.Context, limit, days uint) ([]snippets.PopularSnippet, *service.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Popular", ctx, limit, days)
+	ret0, _ := ret[0].([]snippets.PopularSnippet)
+	ret1, _ := ret[1].(*service.Error)
+	return ret0, ret1
+}
+
+// Popular indicates an expected call of Popular.
+func (mr *MockServiceMockRecorder) Popular(ctx, limit, days any) *MockServicePopularCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Popular", reflect.TypeOf((*MockService)(nil).Popular), ctx, limit, days)
+	return &MockServicePopularCall{Call: call}
+}
+
+// MockServicePopularCall wrap *gomock.Call
+type MockServicePopularCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServicePopularCall) Return(arg0 []snippets.PopularSnippet, arg1 *service.Error) *MockServicePopularCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServicePopularCall) Do(f func(context.Context, uint, uint) ([]snippets.PopularSnippet, *service.Error)) *MockServicePopularCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServicePopularCall) DoAndReturn(f func(context.Context, uint, uint) ([]snippets.PopularSnippet, *service.Error)) *MockServicePopularCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // SoftDelete mocks base method.
 func (m *MockService) SoftDelete(ctx context.Context, id uint) *service.Error {
 	m.ctrl.T.Helper()
@@ -196,3 +430,120 @@ func (c *MockServiceSoftDeleteCall) DoAndReturn(f func(context.Context, uint) *s
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
+
+// SoftDeleteBatch mocks base method.
+func (m *MockService) SoftDeleteBatch(ctx context.Context, ids []uint) ([]*service.Error, *service.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SoftDeleteBatch", ctx, ids)
+	ret0, _ := ret[0].([]*service.Error)
+	ret1, _ := ret[1].(*service.Error)
+	return ret0, ret1
+}
+
+// SoftDeleteBatch indicates an expected call of SoftDeleteBatch.
+func (mr *MockServiceMockRecorder) SoftDeleteBatch(ctx, ids any) *MockServiceSoftDeleteBatchCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SoftDeleteBatch", reflect.TypeOf((*MockService)(nil).SoftDeleteBatch), ctx, ids)
+	return &MockServiceSoftDeleteBatchCall{Call: call}
+}
+
+// MockServiceSoftDeleteBatchCall wrap *gomock.Call
+type MockServiceSoftDeleteBatchCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceSoftDeleteBatchCall) Return(arg0 []*service.Error, arg1 *service.Error) *MockServiceSoftDeleteBatchCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceSoftDeleteBatchCall) Do(f func(context.Context, []uint) ([]*service.Error, *service.Error)) *MockServiceSoftDeleteBatchCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceSoftDeleteBatchCall) DoAndReturn(f func(context.Context, []uint) ([]*service.Error, *service.Error)) *MockServiceSoftDeleteBatchCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Stats mocks base method.
+func (m *MockService) Stats(ctx context.Context, id, days uint) (snippets.SnippetStats, *service.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats", ctx, id, days)
+	ret0, _ := ret[0].(snippets.SnippetStats)
+	ret1, _ := ret[1].(*service.Error)
+	return ret0, ret1
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockServiceMockRecorder) Stats(ctx, id, days any) *MockServiceStatsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockService)(nil).Stats), ctx, id, days)
+	return &MockServiceStatsCall{Call: call}
+}
+
+// MockServiceStatsCall wrap *gomock.Call
+type MockServiceStatsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceStatsCall) Return(arg0 snippets.SnippetStats, arg1 *service.Error) *MockServiceStatsCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceStatsCall) Do(f func(context.Context, uint, uint) (snippets.SnippetStats, *service.Error)) *MockServiceStatsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceStatsCall) DoAndReturn(f func(context.Context, uint, uint) (snippets.SnippetStats, *service.Error)) *MockServiceStatsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Usage mocks base method.
+func (m *MockService) Usage(ctx context.Context) (snippets.Usage, *service.Error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Usage", ctx)
+	ret0, _ := ret[0].(snippets.Usage)
+	ret1, _ := ret[1].(*service.Error)
+	return ret0, ret1
+}
+
+// Usage indicates an expected call of Usage.
+func (mr *MockServiceMockRecorder) Usage(ctx any) *MockServiceUsageCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Usage", reflect.TypeOf((*MockService)(nil).Usage), ctx)
+	return &MockServiceUsageCall{Call: call}
+}
+
+// MockServiceUsageCall wrap *gomock.Call
+type MockServiceUsageCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockServiceUsageCall) Return(arg0 snippets.Usage, arg1 *service.Error) *MockServiceUsageCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockServiceUsageCall) Do(f func(context.Context) (snippets.Usage, *service.Error)) *MockServiceUsageCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockServiceUsageCall) DoAndReturn(f func(context.Context) (snippets.Usage, *service.Error)) *MockServiceUsageCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -1,15 +1,25 @@
 package snippets_test
 
 import (
+	"bufio"
+	"context"
 	"errors"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/gavv/httpexpect/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 
 	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
 	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
 	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
 )
@@ -302,6 +312,7 @@ func TestTransport_getSnippet(t *testing.T) {
 			gomock.Any(),
 			id,
 		).Return(snippet, nil)
+		mockService.EXPECT().Now().Return(time.Now())
 
 		// ================================================
 		// Run test
@@ -321,6 +332,75 @@ func TestTransport_getSnippet(t *testing.T) {
 			JSON().Object().IsEqual(expectedSnippetResponse)
 	})
 
+	t.Run("Successfully get protected snippet", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Init test data
+		id := uint(100)
+		passphrase := "correct horse battery staple"
+
+		fakeTimeCreated := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+		fakeTimeExpires := time.Date(2050, 1, 1, 1, 1, 1, 0, time.UTC)
+
+		snippet := snippets.Snippet{
+			ID:         id,
+			Title:      "Snippet #100",
+			Content:    "Very important text",
+			CreatedAt:  fakeTimeCreated,
+			UpdatedAt:  fakeTimeCreated,
+			ExpiresAt:  fakeTimeExpires,
+			Encryption: snippets.EncryptionPassphrase,
+		}
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Get(
+			gomock.Cond(func(ctx context.Context) bool {
+				actual, ok := snippets.PassphraseFromContext(ctx)
+				return ok && actual == passphrase
+			}),
+			id,
+		).Return(snippet, nil)
+		mockService.EXPECT().Now().Return(time.Now())
+
+		// ================================================
+		// Run test
+		expectedSnippetResponse := snippets.SnippetResponse{
+			ID:        id,
+			Title:     "Snippet #100",
+			Content:   "Very important text",
+			CreatedAt: fakeTimeCreated,
+			ExpiresAt: fakeTimeExpires,
+			Protected: true,
+		}
+
+		response := expect.GET("/{id}", id).
+			WithHeader(snippets.PassphraseHeader, passphrase).
+			Expect()
+
+		response.
+			Status(http.StatusOK).
+			JSON().Object().IsEqual(expectedSnippetResponse)
+	})
+
 	t.Run("Failed to get snippet", func(t *testing.T) {
 		t.Parallel()
 
@@ -544,6 +624,50 @@ func TestTransport_createSnippet(t *testing.T) {
 				JSON().Object().IsEqual(expected)
 		})
 
+		t.Run("Bad request: passphrase is too short", func(t *testing.T) {
+			t.Parallel()
+
+			// ================================================
+			// Init mocks and service
+			ctrl := gomock.NewController(t)
+
+			mockService := NewMockService(ctrl)
+			transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+			handler := transport.Routes()
+
+			// ================================================
+			// Create httpexpect instance
+			expect := httpexpect.WithConfig(httpexpect.Config{
+				Client: &http.Client{
+					Transport: httpexpect.NewBinder(handler),
+				},
+				Reporter: httpexpect.NewAssertReporter(t),
+			})
+
+			// ================================================
+			// Init test data
+			createSnippetRequest := snippets.CreateSnippetRequest{
+				Title:     "Snippet #100",
+				Content:   "Very important text",
+				ExpiresAt: time.Now().UTC().Add(time.Hour * 24 * 120).Truncate(time.Second),
+			}
+
+			// ================================================
+			// Run test
+			expected := map[string]any{
+				"error": "Passphrase: the length must be between 8 and 1024.",
+			}
+
+			response := expect.POST("/").
+				WithHeader(snippets.PassphraseHeader, "short").
+				WithJSON(createSnippetRequest).
+				Expect()
+
+			response.
+				Status(http.StatusBadRequest).
+				JSON().Object().IsEqual(expected)
+		})
+
 		t.Run("Bad request: decode error", func(t *testing.T) {
 			t.Parallel()
 
@@ -745,3 +869,1250 @@ func TestTransport_deleteSnippet(t *testing.T) {
 		})
 	})
 }
+
+func TestTransport_batchCreateSnippets(t *testing.T) {
+	t.Parallel()
+
+	validRequest := func(title string) snippets.CreateSnippetRequest {
+		return snippets.CreateSnippetRequest{
+			Title:     title,
+			Content:   "Very important text",
+			ExpiresAt: time.Now().UTC().Add(time.Hour * 24 * 120).Truncate(time.Second),
+		}
+	}
+
+	t.Run("Successfully create snippets", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := chi.NewRouter()
+		transport.RegisterBatchRoutes(handler)
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Init test data
+		batchRequest := snippets.BatchCreateSnippetsRequest{
+			Snippets: []snippets.CreateSnippetRequest{
+				validRequest("Snippet #100"),
+				validRequest("Snippet #101"),
+			},
+		}
+
+		createdAt := time.Now().UTC().Truncate(time.Second)
+
+		results := make([]snippets.BatchResult, len(batchRequest.Snippets))
+		for i, item := range batchRequest.Snippets {
+			results[i].Snippet = snippets.Snippet{
+				ID:        uint(100 + i),
+				Title:     item.Title,
+				Content:   item.Content,
+				CreatedAt: createdAt,
+				UpdatedAt: createdAt,
+				ExpiresAt: item.ExpiresAt,
+			}
+		}
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().CreateBatch(
+			gomock.Any(),
+			[]snippets.Snippet{
+				{Title: "Snippet #100", Content: "Very important text", ExpiresAt: batchRequest.Snippets[0].ExpiresAt},
+				{Title: "Snippet #101", Content: "Very important text", ExpiresAt: batchRequest.Snippets[1].ExpiresAt},
+			},
+			true,
+		).Return(results, nil)
+
+		// ================================================
+		// Run test
+		response := expect.POST("/snippets:batchCreate").
+			WithJSON(batchRequest).
+			Expect().
+			Status(http.StatusOK)
+
+		items := response.JSON().Object().Value("results").Array()
+		items.Length().IsEqual(2)
+
+		for i := range results {
+			item := items.Value(i).Object()
+			item.Value("index").Number().IsEqual(i)
+			item.Value("status").Number().IsEqual(http.StatusCreated)
+			item.Value("id").Number().IsEqual(results[i].Snippet.ID)
+			item.Value("snippet").Object().Value("title").String().IsEqual(results[i].Snippet.Title)
+		}
+	})
+
+	t.Run("Atomic mode: invalid snippet aborts the batch", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := chi.NewRouter()
+		transport.RegisterBatchRoutes(handler)
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Init test data
+		batchRequest := snippets.BatchCreateSnippetsRequest{
+			Snippets: []snippets.CreateSnippetRequest{
+				validRequest("Snippet #100"),
+				validRequest(""),
+			},
+		}
+
+		// ================================================
+		// Run test
+		response := expect.POST("/snippets:batchCreate").
+			WithJSON(batchRequest).
+			Expect().
+			Status(http.StatusBadRequest)
+
+		items := response.JSON().Object().Value("results").Array()
+		items.Length().IsEqual(2)
+
+		items.Value(0).Object().Value("status").Number().IsEqual(http.StatusFailedDependency)
+		items.Value(1).Object().Value("status").Number().IsEqual(http.StatusBadRequest)
+		items.Value(1).Object().Value("error").String().IsEqual("title: cannot be blank.")
+	})
+
+	t.Run("Best-effort mode: valid snippets are created", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := chi.NewRouter()
+		transport.RegisterBatchRoutes(handler)
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Init test data
+		batchRequest := snippets.BatchCreateSnippetsRequest{
+			Snippets: []snippets.CreateSnippetRequest{
+				validRequest(""),
+				validRequest("Snippet #101"),
+				validRequest("Snippet #102"),
+			},
+			BestEffort: true,
+		}
+
+		results := []snippets.BatchResult{
+			{Snippet: snippets.Snippet{ID: 101, Title: "Snippet #101"}},
+			{Err: &service.Error{Type: service.InternalError, Base: errors.New("internal error")}},
+		}
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().CreateBatch(
+			gomock.Any(),
+			gomock.Len(2),
+			false,
+		).Return(results, nil)
+
+		// ================================================
+		// Run test
+		response := expect.POST("/snippets:batchCreate").
+			WithJSON(batchRequest).
+			Expect().
+			Status(http.StatusOK)
+
+		items := response.JSON().Object().Value("results").Array()
+		items.Length().IsEqual(3)
+
+		items.Value(0).Object().Value("status").Number().IsEqual(http.StatusBadRequest)
+		items.Value(1).Object().Value("status").Number().IsEqual(http.StatusCreated)
+		items.Value(1).Object().Value("id").Number().IsEqual(101)
+		items.Value(2).Object().Value("status").Number().IsEqual(http.StatusInternalServerError)
+		items.Value(2).Object().Value("error").String().IsEqual("internal error")
+	})
+
+	t.Run("Bad request: empty batch", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := chi.NewRouter()
+		transport.RegisterBatchRoutes(handler)
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Run test
+		expect.POST("/snippets:batchCreate").
+			WithJSON(snippets.BatchCreateSnippetsRequest{}).
+			Expect().
+			Status(http.StatusBadRequest).
+			JSON().Object().IsEqual(map[string]any{
+			"error": "snippets: cannot be blank.",
+		})
+	})
+}
+
+func TestTransport_batchDeleteSnippets(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Successfully delete snippets", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := chi.NewRouter()
+		transport.RegisterBatchRoutes(handler)
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().SoftDeleteBatch(
+			gomock.Any(),
+			[]uint{1, 2},
+		).Return([]*service.Error{nil, {Type: service.NotFound, Base: snippets.ErrNotFound}}, nil)
+
+		// ================================================
+		// Run test
+		expected := snippets.BatchResponse{
+			Results: []snippets.BatchItemResponse{
+				{Index: 0, Status: http.StatusNoContent, ID: 1},
+				{Index: 1, Status: http.StatusNotFound, ID: 2, Error: "not found"},
+			},
+		}
+
+		expect.POST("/snippets:batchDelete").
+			WithJSON(snippets.BatchDeleteSnippetsRequest{IDs: []uint{1, 2}}).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().IsEqual(expected)
+	})
+
+	t.Run("Bad request: invalid id", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := chi.NewRouter()
+		transport.RegisterBatchRoutes(handler)
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Run test
+		expect.POST("/snippets:batchDelete").
+			WithJSON(snippets.BatchDeleteSnippetsRequest{IDs: []uint{1, 0}}).
+			Expect().
+			Status(http.StatusBadRequest)
+	})
+}
+
+func TestTransport_exportSnippets(t *testing.T) {
+	t.Parallel()
+
+	fakeTimeCreated := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+	fakeTimeExpires := time.Date(2050, 1, 1, 1, 1, 1, 0, time.UTC)
+
+	list := []snippets.Snippet{
+		{ID: 1, Title: "Snippet #1", Content: "Content #1", CreatedAt: fakeTimeCreated, ExpiresAt: fakeTimeExpires},
+		{ID: 2, Title: "Snippet, #2", Content: "Content\n#2", CreatedAt: fakeTimeCreated, ExpiresAt: fakeTimeExpires},
+	}
+
+	exportFunc := func(_ context.Context, fn func(snippets.Snippet) error) *service.Error {
+		for _, snippet := range list {
+			if err := fn(snippet); err != nil {
+				return &service.Error{Type: service.InternalError, Base: err}
+			}
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name        string
+		format      string
+		contentType string
+		body        string
+	}{
+		{
+			name:        "Default format is NDJSON",
+			format:      "",
+			contentType: "application/x-ndjson",
+			body: `{"id":1,"title":"Snippet #1","content":"Content #1","created_at":"2020-10-07T12:00:00Z","expires_at":"2050-01-01T01:01:01Z","protected":false}
+{"id":2,"title":"Snippet, #2","content":"Content\n#2","created_at":"2020-10-07T12:00:00Z","expires_at":"2050-01-01T01:01:01Z","protected":false}
+`,
+		},
+		{
+			name:        "JSON",
+			format:      "json",
+			contentType: "application/json",
+			body: `[{"id":1,"title":"Snippet #1","content":"Content #1","created_at":"2020-10-07T12:00:00Z","expires_at":"2050-01-01T01:01:01Z","protected":false}
+,{"id":2,"title":"Snippet, #2","content":"Content\n#2","created_at":"2020-10-07T12:00:00Z","expires_at":"2050-01-01T01:01:01Z","protected":false}
+]
+`,
+		},
+		{
+			name:        "CSV",
+			format:      "csv",
+			contentType: "text/csv",
+			body: `id,title,content,created_at,expires_at
+1,Snippet #1,Content #1,2020-10-07T12:00:00Z,2050-01-01T01:01:01Z
+2,"Snippet, #2","Content
+#2",2020-10-07T12:00:00Z,2050-01-01T01:01:01Z
+`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// ================================================
+			// Init mocks and service
+			ctrl := gomock.NewController(t)
+
+			mockService := NewMockService(ctrl)
+			transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+			handler := transport.Routes()
+
+			// ================================================
+			// Create httpexpect instance
+			expect := httpexpect.WithConfig(httpexpect.Config{
+				Client: &http.Client{
+					Transport: httpexpect.NewBinder(handler),
+				},
+				Reporter: httpexpect.NewAssertReporter(t),
+			})
+
+			// ================================================
+			// Describe mock calls
+			mockService.EXPECT().Export(gomock.Any(), gomock.Any()).DoAndReturn(exportFunc)
+
+			// ================================================
+			// Run test
+			response := expect.GET("/export").
+				WithQuery("format", tt.format).
+				Expect().
+				Status(http.StatusOK)
+
+			response.Header("Content-Type").IsEqual(tt.contentType)
+			response.Header("Content-Disposition").Contains("attachment")
+			response.Body().IsEqual(tt.body)
+		})
+	}
+
+	t.Run("Bad request: unknown format", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Run test
+		expect.GET("/export").
+			WithQuery("format", "xml").
+			Expect().
+			Status(http.StatusBadRequest).
+			JSON().Object().IsEqual(map[string]any{
+			"error": "format: must be a valid value.",
+		})
+	})
+
+	t.Run("Service error before the first snippet", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Export(gomock.Any(), gomock.Any()).Return(&service.Error{
+			Type: service.InternalError,
+			Base: errors.New("internal error"),
+		})
+
+		// ================================================
+		// Run test
+		expect.GET("/export").
+			Expect().
+			Status(http.StatusInternalServerError).
+			JSON().Object().IsEqual(map[string]any{
+			"error": "internal error",
+		})
+	})
+}
+
+func TestTransport_forkSnippet(t *testing.T) {
+	t.Parallel()
+
+	fakeTimeCreated := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+	fakeTimeExpires := time.Date(2050, 1, 1, 1, 1, 1, 0, time.UTC)
+
+	fork := snippets.Snippet{
+		ID:        3,
+		Title:     "Snippet #2",
+		Content:   "Very important text",
+		CreatedAt: fakeTimeCreated,
+		UpdatedAt: fakeTimeCreated,
+		ExpiresAt: fakeTimeExpires,
+		ParentID:  2,
+		Lineage:   []uint{2, 1},
+	}
+
+	t.Run("Successfully fork snippet", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Fork(gomock.Any(), uint(2), snippets.Snippet{}).Return(fork, nil)
+
+		// ================================================
+		// Run test
+		expectedSnippetResponse := snippets.SnippetResponse{
+			ID:        3,
+			Title:     "Snippet #2",
+			Content:   "Very important text",
+			CreatedAt: fakeTimeCreated,
+			ExpiresAt: fakeTimeExpires,
+			ParentID:  2,
+			Lineage:   []uint{2, 1},
+		}
+
+		expect.POST("/{id}/fork", 2).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().IsEqual(expectedSnippetResponse)
+	})
+
+	t.Run("Successfully fork snippet with a new title", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		renamed := fork
+		renamed.Title = "My fork"
+
+		mockService.EXPECT().Fork(gomock.Any(), uint(2), snippets.Snippet{Title: "My fork"}).Return(renamed, nil)
+
+		// ================================================
+		// Run test
+		expect.POST("/{id}/fork", 2).
+			WithJSON(snippets.ForkSnippetRequest{Title: "My fork"}).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("title").String().IsEqual("My fork")
+	})
+
+	t.Run("Failed to fork snippet", func(t *testing.T) {
+		t.Parallel()
+
+		t.Run("Service error", func(t *testing.T) {
+			t.Parallel()
+
+			// ================================================
+			// Init mocks and service
+			ctrl := gomock.NewController(t)
+
+			mockService := NewMockService(ctrl)
+			transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+			handler := transport.Routes()
+
+			// ================================================
+			// Create httpexpect instance
+			expect := httpexpect.WithConfig(httpexpect.Config{
+				Client: &http.Client{
+					Transport: httpexpect.NewBinder(handler),
+				},
+				Reporter: httpexpect.NewAssertReporter(t),
+			})
+
+			// ================================================
+			// Describe mock calls
+			mockService.EXPECT().Fork(gomock.Any(), uint(42), snippets.Snippet{}).Return(
+				snippets.Snippet{},
+				&service.Error{Type: service.NotFound, Base: snippets.ErrNotFound},
+			)
+
+			// ================================================
+			// Run test
+			expect.POST("/{id}/fork", 42).
+				Expect().
+				Status(http.StatusNotFound).
+				JSON().Object().IsEqual(map[string]any{
+				"error": "not found",
+			})
+		})
+
+		t.Run("Bad request: validation error", func(t *testing.T) {
+			t.Parallel()
+
+			// ================================================
+			// Init mocks and service
+			ctrl := gomock.NewController(t)
+
+			mockService := NewMockService(ctrl)
+			transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+			handler := transport.Routes()
+
+			// ================================================
+			// Create httpexpect instance
+			expect := httpexpect.WithConfig(httpexpect.Config{
+				Client: &http.Client{
+					Transport: httpexpect.NewBinder(handler),
+				},
+				Reporter: httpexpect.NewAssertReporter(t),
+			})
+
+			// ================================================
+			// Run test
+			expect.POST("/{id}/fork", 2).
+				WithJSON(snippets.ForkSnippetRequest{Title: strings.Repeat("a", 101)}).
+				Expect().
+				Status(http.StatusBadRequest).
+				JSON().Object().IsEqual(map[string]any{
+				"error": "title: the length must be between 1 and 100.",
+			})
+		})
+	})
+}
+
+// viewsRecorder collects recorded views in tests
+type viewsRecorder struct {
+	mu  sync.Mutex
+	ids []uint
+}
+
+func (r *viewsRecorder) RecordView(id uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ids = append(r.ids, id)
+}
+
+func TestTransport_getSnippet_recordsViews(t *testing.T) {
+	t.Parallel()
+
+	// ================================================
+	// Init mocks and service
+	ctrl := gomock.NewController(t)
+
+	mockService := NewMockService(ctrl)
+	recorder := &viewsRecorder{}
+	transport := snippets.NewTransport(mockService, nopslog.NewNoplogger(), snippets.WithViewRecorder(recorder))
+	handler := transport.Routes()
+
+	// ================================================
+	// Create httpexpect instance
+	expect := httpexpect.WithConfig(httpexpect.Config{
+		Client: &http.Client{
+			Transport: httpexpect.NewBinder(handler),
+		},
+		Reporter: httpexpect.NewAssertReporter(t),
+	})
+
+	// ================================================
+	// Describe mock calls
+	mockService.EXPECT().Get(gomock.Any(), uint(1)).Return(snippets.Snippet{
+		ID:        1,
+		Title:     "Snippet #1",
+		Content:   "Very important text",
+		ExpiresAt: time.Date(2050, 1, 1, 1, 1, 1, 0, time.UTC),
+	}, nil)
+	mockService.EXPECT().Now().Return(time.Now())
+	mockService.EXPECT().Get(gomock.Any(), uint(2)).Return(snippets.Snippet{}, &service.Error{
+		Type: service.NotFound,
+		Base: snippets.ErrNotFound,
+	})
+
+	// ================================================
+	// Run test
+	expect.GET("/{id}", 1).Expect().Status(http.StatusOK)
+	expect.GET("/{id}", 2).Expect().Status(http.StatusNotFound)
+
+	assert.Equal(t, []uint{1}, recorder.ids)
+}
+
+func TestTransport_snippetStats(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Successfully get stats with default days", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Stats(gomock.Any(), uint(1), uint(30)).Return(snippets.SnippetStats{
+			SnippetID:  1,
+			TotalViews: 12,
+			Daily: []snippets.DailyViews{
+				{SnippetID: 1, Day: time.Date(2024, 10, 6, 0, 0, 0, 0, time.UTC), Views: 0},
+				{SnippetID: 1, Day: time.Date(2024, 10, 7, 0, 0, 0, 0, time.UTC), Views: 4},
+			},
+		}, nil)
+
+		// ================================================
+		// Run test
+		expect.GET("/{id}/stats", 1).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().IsEqual(snippets.SnippetStatsResponse{
+			SnippetID:  1,
+			TotalViews: 12,
+			Daily: []snippets.DailyViewsResponse{
+				{Date: "2024-10-06", Views: 0},
+				{Date: "2024-10-07", Views: 4},
+			},
+		})
+	})
+
+	t.Run("Too many days", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Run test
+		expect.GET("/{id}/stats", 1).
+			WithQuery("days", 366).
+			Expect().
+			Status(http.StatusBadRequest)
+	})
+
+	t.Run("Snippet not found", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Stats(gomock.Any(), uint(1), uint(7)).Return(snippets.SnippetStats{}, &service.Error{
+			Type: service.NotFound,
+			Base: snippets.ErrNotFound,
+		})
+
+		// ================================================
+		// Run test
+		expect.GET("/{id}/stats", 1).
+			WithQuery("days", 7).
+			Expect().
+			Status(http.StatusNotFound)
+	})
+}
+
+func TestTransport_popularSnippets(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Successfully get popular snippets with default params", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Popular(gomock.Any(), uint(10), uint(7)).Return([]snippets.PopularSnippet{
+			{ID: 2, Title: "Snippet #2", Views: 10},
+		}, nil)
+
+		// ================================================
+		// Run test
+		expect.GET("/popular").
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().IsEqual(snippets.PopularSnippetsResponse{
+			Snippets: []snippets.PopularSnippetResponse{
+				{ID: 2, Title: "Snippet #2", Views: 10},
+			},
+		})
+	})
+
+	t.Run("Empty list", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Popular(gomock.Any(), uint(3), uint(1)).Return(nil, nil)
+
+		// ================================================
+		// Run test
+		expect.GET("/popular").
+			WithQuery("limit", 3).
+			WithQuery("days", 1).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().Value("snippets").Array().IsEmpty()
+	})
+
+	t.Run("Limit is too big", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Run test
+		expect.GET("/popular").
+			WithQuery("limit", 101).
+			Expect().
+			Status(http.StatusBadRequest)
+	})
+}
+
+func TestTransport_instantiateSnippet(t *testing.T) {
+	t.Parallel()
+
+	fakeTimeCreated := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+	fakeTimeExpires := time.Date(2050, 1, 1, 1, 1, 1, 0, time.UTC)
+
+	t.Run("Render with defaults", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Instantiate(gomock.Any(), uint(2), snippets.Instantiation{}).Return(snippets.Snippet{
+			Title:     "Greeting",
+			Content:   "Hello, world!",
+			ExpiresAt: fakeTimeExpires,
+		}, nil)
+
+		// ================================================
+		// Run test
+		expect.POST("/{id}/instantiate", 2).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().IsEqual(snippets.InstantiateSnippetResponse{
+			Content: "Hello, world!",
+		})
+	})
+
+	t.Run("Render and save", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Instantiate(gomock.Any(), uint(2), snippets.Instantiation{
+			Values: map[string]string{"name": "Gopher"},
+			Save:   true,
+		}).Return(snippets.Snippet{
+			ID:        3,
+			Title:     "Greeting",
+			Content:   "Hello, Gopher!",
+			CreatedAt: fakeTimeCreated,
+			UpdatedAt: fakeTimeCreated,
+			ExpiresAt: fakeTimeExpires,
+		}, nil)
+
+		// ================================================
+		// Run test
+		expect.POST("/{id}/instantiate", 2).
+			WithJSON(snippets.InstantiateSnippetRequest{
+				Values: map[string]string{"name": "Gopher"},
+				Save:   true,
+			}).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().IsEqual(snippets.InstantiateSnippetResponse{
+			Content: "Hello, Gopher!",
+			Snippet: &snippets.SnippetResponse{
+				ID:        3,
+				Title:     "Greeting",
+				Content:   "Hello, Gopher!",
+				CreatedAt: fakeTimeCreated,
+				ExpiresAt: fakeTimeExpires,
+			},
+		})
+	})
+
+	t.Run("Bad request: invalid values", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Instantiate(gomock.Any(), uint(2), snippets.Instantiation{
+			Values: map[string]string{"name": ""},
+		}).Return(snippets.Snippet{}, &service.Error{
+			Type: service.BadRequest,
+			Base: errors.New("values: name: is required."),
+		})
+
+		// ================================================
+		// Run test
+		expect.POST("/{id}/instantiate", 2).
+			WithJSON(snippets.InstantiateSnippetRequest{
+				Values: map[string]string{"name": ""},
+			}).
+			Expect().
+			Status(http.StatusBadRequest)
+	})
+}
+
+func TestTransport_snippetEvents(t *testing.T) {
+	t.Parallel()
+
+	occurredAt := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	newEvent := func(t *testing.T, eventType events.Type, id uint) events.Event {
+		t.Helper()
+
+		event, err := events.New(eventType, occurredAt, snippets.EventData{ID: id})
+		require.NoError(t, err)
+		return event
+	}
+
+	t.Run("Resume the stream and receive new events", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init test data
+		seen := newEvent(t, snippets.EventCreated, 1)
+		missed := newEvent(t, snippets.EventDeleted, 1)
+		live := newEvent(t, snippets.EventCreated, 2)
+
+		history := historyFunc(func(_ context.Context, id string, _ uint) ([]events.Event, error) {
+			assert.Equal(t, seen.ID, id)
+			return []events.Event{missed}, nil
+		})
+
+		// ================================================
+		// Init service
+		broadcaster := events.NewBroadcaster(history)
+		transport := snippets.NewTransport(
+			NewMockService(gomock.NewController(t)),
+			nopslog.NewNoplogger(),
+			snippets.WithEventStream(broadcaster),
+		)
+
+		server := httptest.NewServer(transport.Routes())
+		t.Cleanup(server.Close)
+
+		// ================================================
+		// Run test
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL+"/events", nil)
+		require.NoError(t, err)
+		req.Header.Set(snippets.LastEventIDHeader, seen.ID)
+
+		resp, err := server.Client().Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		body := bufio.NewReader(resp.Body)
+		readEvent := func() string {
+			var lines []string
+			for {
+				line, readErr := body.ReadString('\n')
+				require.NoError(t, readErr)
+				if line == "\n" {
+					return strings.Join(lines, "")
+				}
+				lines = append(lines, line)
+			}
+		}
+
+		assert.Equal(t, "id: "+missed.ID+"\nevent: snippet.deleted\ndata: {\"id\":1}\n", readEvent())
+
+		// Events of the backlog are not repeated
+		require.NoError(t, broadcaster.Publish(context.Background(), missed, live))
+		assert.Equal(t, "id: "+live.ID+"\nevent: snippet.created\ndata: {\"id\":2}\n", readEvent())
+
+		// The stream ends when the broadcaster is closed
+		broadcaster.Close()
+		rest, err := io.ReadAll(body)
+		require.NoError(t, err)
+		assert.Empty(t, rest)
+	})
+
+	t.Run("Stream is disabled", func(t *testing.T) {
+		t.Parallel()
+
+		transport := snippets.NewTransport(NewMockService(gomock.NewController(t)), nopslog.NewNoplogger())
+
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(transport.Routes()),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		expect.GET("/events").
+			Expect().
+			Status(http.StatusNotFound)
+	})
+}
+
+// historyFunc is a function that implements events.History
+type historyFunc func(ctx context.Context, id string, limit uint) ([]events.Event, error)
+
+func (f historyFunc) After(ctx context.Context, id string, limit uint) ([]events.Event, error) {
+	return f(ctx, id, limit)
+}
+
+func TestTransport_getUsage(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Successfully get usage", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := chi.NewRouter()
+		transport.RegisterUsageRoutes(handler)
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Usage(gomock.Any()).Return(snippets.Usage{
+			Caller:         "ci",
+			Snippets:       3,
+			ContentBytes:   42,
+			CreatedLastDay: 1,
+			Quota:          snippets.Quota{MaxSnippets: 10, DailyCreations: 5},
+		}, nil)
+
+		// ================================================
+		// Run test
+		expect.GET("/usage").
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().IsEqual(snippets.UsageResponse{
+			Caller:         "ci",
+			Snippets:       3,
+			ContentBytes:   42,
+			CreatedLastDay: 1,
+			Quota: snippets.QuotaResponse{
+				MaxSnippets:    10,
+				DailyCreations: 5,
+			},
+		})
+	})
+
+	t.Run("Quota errors", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		transport := snippets.NewTransport(mockService, nopslog.NewNoplogger())
+		handler := transport.Routes()
+
+		// ================================================
+		// Create httpexpect instance
+		expect := httpexpect.WithConfig(httpexpect.Config{
+			Client: &http.Client{
+				Transport: httpexpect.NewBinder(handler),
+			},
+			Reporter: httpexpect.NewAssertReporter(t),
+		})
+
+		// ================================================
+		// Describe mock calls
+		gomock.InOrder(
+			mockService.EXPECT().Create(gomock.Any(), gomock.Any()).Return(snippets.Snippet{}, &service.Error{
+				Type: service.TooManyRequests,
+				Base: snippets.ErrDailyCreationsQuota,
+			}),
+			mockService.EXPECT().Create(gomock.Any(), gomock.Any()).Return(snippets.Snippet{}, &service.Error{
+				Type: service.QuotaExceeded,
+				Base: snippets.ErrSnippetsQuota,
+			}),
+		)
+
+		// ================================================
+		// Run test
+		body := map[string]any{"title": "Title", "content": "Content", "expires_at": time.Now().Add(time.Hour)}
+
+		expect.POST("/").WithJSON(body).
+			Expect().
+			Status(http.StatusTooManyRequests).
+			JSON().Object().HasValue("error", snippets.ErrDailyCreationsQuota.Error())
+
+		expect.POST("/").WithJSON(body).
+			Expect().
+			Status(http.StatusForbidden).
+			JSON().Object().HasValue("error", snippets.ErrSnippetsQuota.Error())
+	})
+}
@@ -0,0 +1,164 @@
+package snippets
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DailyViews is an amount of reads of a snippet during a day (UTC)
+type DailyViews struct {
+	SnippetID uint
+	Day       time.Time
+	Views     uint64
+}
+
+// SnippetStats represents reads statistics of a single snippet
+type SnippetStats struct {
+	SnippetID  uint
+	TotalViews uint64
+	// Daily holds a day-by-day histogram, days without reads are included with zero views
+	Daily []DailyViews
+}
+
+// PopularSnippet represents a snippet with the amount of its reads
+type PopularSnippet struct {
+	ID    uint
+	Title string
+	Views uint64
+}
+
+// ViewRecorder records reads of snippets
+type ViewRecorder interface {
+	RecordView(id uint)
+}
+
+// ViewStorage persists views counters
+type ViewStorage interface {
+	AddViews(ctx context.Context, views []DailyViews) error
+}
+
+type viewKey struct {
+	snippetID uint
+	day       time.Time
+}
+
+// ViewCounter implements ViewRecorder. Views are buffered in memory and flushed to storage in batches,
+// so a read of a snippet doesn't cause a write to DB.
+// Views that are not flushed yet are lost if the process crashes.
+type ViewCounter struct {
+	storage ViewStorage
+	logger  *slog.Logger
+
+	now func() time.Time
+
+	mu      sync.Mutex
+	pending map[viewKey]uint64
+}
+
+// NewViewCounter returns new instance of ViewCounter
+func NewViewCounter(storage ViewStorage, logger *slog.Logger, nowFunc func() time.Time) *ViewCounter {
+	return &ViewCounter{
+		storage: storage,
+		logger:  logger,
+
+		now: nowFunc,
+
+		pending: make(map[viewKey]uint64),
+	}
+}
+
+// RecordView adds a single read of a snippet to the buffer
+func (c *ViewCounter) RecordView(id uint) {
+	key := viewKey{
+		snippetID: id,
+		day:       truncateToDay(c.now()),
+	}
+
+	c.mu.Lock()
+	c.pending[key]++
+	c.mu.Unlock()
+}
+
+// Flush writes buffered views to storage. If storage fails, views are returned to the buffer.
+func (c *ViewCounter) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[viewKey]uint64)
+	c.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	views := make([]DailyViews, 0, len(pending))
+	for key, count := range pending {
+		views = append(views, DailyViews{
+			SnippetID: key.snippetID,
+			Day:       key.day,
+			Views:     count,
+		})
+	}
+
+	if err := c.storage.AddViews(ctx, views); err != nil {
+		c.mu.Lock()
+		for key, count := range pending {
+			c.pending[key] += count
+		}
+		c.mu.Unlock()
+
+		return fmt.Errorf("failed to flush views: %w", err)
+	}
+
+	return nil
+}
+
+// Run flushes views every interval until ctx is done. Remaining views are flushed on exit.
+func (c *ViewCounter) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, flushCancel := context.WithTimeout(context.Background(), interval)
+			defer flushCancel()
+
+			//nolint:contextcheck // ctx is already done, the last flush needs a live one.
+			if err := c.Flush(flushCtx); err != nil {
+				c.logger.Error("failed to flush views on shutdown", slog.Any("err", err))
+			}
+			return nil
+		case <-ticker.C:
+			if err := c.Flush(ctx); err != nil {
+				c.logger.Error("failed to flush views", slog.Any("err", err))
+			}
+		}
+	}
+}
+
+// histogram returns views of every day in [since, until], days without views are filled with zeros
+func histogram(snippetID uint, since, until time.Time, views []DailyViews) []DailyViews {
+	byDay := make(map[time.Time]uint64, len(views))
+	for _, v := range views {
+		byDay[truncateToDay(v.Day)] += v.Views
+	}
+
+	var result []DailyViews
+	for day := truncateToDay(since); !day.After(until); day = day.AddDate(0, 0, 1) {
+		result = append(result, DailyViews{
+			SnippetID: snippetID,
+			Day:       day,
+			Views:     byDay[day],
+		})
+	}
+
+	return result
+}
+
+func truncateToDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,101 @@
+package snippets_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+)
+
+// viewStorage is an in-memory snippets.ViewStorage
+type viewStorage struct {
+	err   error
+	calls [][]snippets.DailyViews
+}
+
+func (s *viewStorage) AddViews(_ context.Context, views []snippets.DailyViews) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.calls = append(s.calls, views)
+	return nil
+}
+
+func TestViewCounter_Flush(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2024, 10, 7, 0, 0, 0, 0, time.UTC)
+
+	t.Run("Views are aggregated by snippet and day", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		now := day.Add(23 * time.Hour)
+
+		storage := &viewStorage{}
+		counter := snippets.NewViewCounter(storage, nopslog.NewNoplogger(), func() time.Time { return now })
+
+		counter.RecordView(1)
+		counter.RecordView(1)
+		counter.RecordView(2)
+		now = now.Add(2 * time.Hour)
+		counter.RecordView(1)
+
+		require.NoError(t, counter.Flush(ctx))
+		require.Len(t, storage.calls, 1)
+		assert.ElementsMatch(t, []snippets.DailyViews{
+			{SnippetID: 1, Day: day, Views: 2},
+			{SnippetID: 2, Day: day, Views: 1},
+			{SnippetID: 1, Day: day.AddDate(0, 0, 1), Views: 1},
+		}, storage.calls[0])
+
+		// Nothing to flush: storage is not called
+		require.NoError(t, counter.Flush(ctx))
+		assert.Len(t, storage.calls, 1)
+	})
+
+	t.Run("Views are kept if storage fails", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+
+		storage := &viewStorage{err: errors.New("unexpected error")}
+		counter := snippets.NewViewCounter(storage, nopslog.NewNoplogger(), func() time.Time { return day })
+
+		counter.RecordView(1)
+		require.Error(t, counter.Flush(ctx))
+
+		counter.RecordView(1)
+		storage.err = nil
+		require.NoError(t, counter.Flush(ctx))
+
+		assert.Equal(t, [][]snippets.DailyViews{
+			{{SnippetID: 1, Day: day, Views: 2}},
+		}, storage.calls)
+	})
+}
+
+func TestViewCounter_Run(t *testing.T) {
+	t.Parallel()
+
+	day := time.Date(2024, 10, 7, 0, 0, 0, 0, time.UTC)
+
+	storage := &viewStorage{}
+	counter := snippets.NewViewCounter(storage, nopslog.NewNoplogger(), func() time.Time { return day })
+	counter.RecordView(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Views are flushed on shutdown
+	require.NoError(t, counter.Run(ctx, time.Hour))
+	assert.Equal(t, [][]snippets.DailyViews{
+		{{SnippetID: 1, Day: day, Views: 1}},
+	}, storage.calls)
+}
@@ -0,0 +1,236 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+//go:generate go run go.uber.org/mock/mockgen -typed -source=dispatcher.go -destination ./dispatcher_mock_test.go -package webhooks_test -mock_names DeliveryStorage=MockDeliveryStorage
+
+// Default settings of Dispatcher
+const (
+	DefaultMaxAttempts = 10
+	DefaultTimeout     = 10 * time.Second
+
+	defaultBatchSize   = 20
+	defaultBackoffBase = 10 * time.Second
+	defaultBackoffMax  = time.Hour
+
+	// maxErrorLength limits the length of errors saved to DB
+	maxErrorLength = 1000
+)
+
+// DeliveryStorage is used to manipulate the deliveries queue
+type DeliveryStorage interface {
+	Claim(ctx context.Context, limit uint, lease time.Duration) ([]Delivery, error)
+	MarkDelivered(ctx context.Context, id uint64, statusCode int) error
+	MarkFailed(ctx context.Context, id uint64, failure Failure) error
+}
+
+// Dispatcher sends queued deliveries to subscribers.
+// A delivery is retried with exponential backoff until a 2xx response is received or the attempts are exhausted.
+// Several dispatchers may share the same queue: a delivery is claimed by a single one at a time.
+type Dispatcher struct {
+	storage DeliveryStorage
+	client  *http.Client
+	logger  *slog.Logger
+
+	now func() time.Time
+
+	batchSize   uint
+	maxAttempts uint
+	backoffBase time.Duration
+	backoffMax  time.Duration
+}
+
+// DispatcherOption configures optional Dispatcher settings
+type DispatcherOption func(*Dispatcher)
+
+// WithHTTPClient sets a client used to send webhooks. Its timeout must be shorter than DeliveryLease.
+// The client is trusted with destinations, use NewHTTPClient unless the subscribers are trusted too.
+func WithHTTPClient(client *http.Client) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.client = client
+	}
+}
+
+// WithMaxAttempts sets a number of attempts after which a delivery is given up
+func WithMaxAttempts(attempts uint) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.maxAttempts = attempts
+	}
+}
+
+// WithBackoff sets a delay before the first retry, every next delay is doubled up to maxDelay
+func WithBackoff(base, maxDelay time.Duration) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.backoffBase = base
+		d.backoffMax = maxDelay
+	}
+}
+
+// NewDispatcher returns new instance of Dispatcher
+func NewDispatcher(
+	storage DeliveryStorage,
+	logger *slog.Logger,
+	nowFunc func() time.Time,
+	opts ...DispatcherOption,
+) *Dispatcher {
+	d := &Dispatcher{
+		storage: storage,
+		client:  NewHTTPClient(DefaultTimeout),
+		logger:  logger,
+
+		now: nowFunc,
+
+		batchSize:   defaultBatchSize,
+		maxAttempts: DefaultMaxAttempts,
+		backoffBase: defaultBackoffBase,
+		backoffMax:  defaultBackoffMax,
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// DeliveryLease is how long a claimed delivery is hidden from other dispatchers.
+// If a dispatcher dies before the attempt is recorded, the delivery is retried after the lease.
+const DeliveryLease = time.Minute
+
+// DeliverPending sends all deliveries that are due. Deliveries of a batch are sent concurrently.
+func (d *Dispatcher) DeliverPending(ctx context.Context) error {
+	for {
+		deliveries, err := d.storage.Claim(ctx, d.batchSize, DeliveryLease)
+		if err != nil {
+			return fmt.Errorf("failed to claim deliveries: %w", err)
+		}
+
+		gr, grCtx := errgroup.WithContext(ctx)
+		for _, delivery := range deliveries {
+			gr.Go(func() error {
+				return d.deliver(grCtx, delivery)
+			})
+		}
+
+		if err = gr.Wait(); err != nil {
+			return err
+		}
+
+		if uint(len(deliveries)) < d.batchSize {
+			return nil
+		}
+	}
+}
+
+// Run delivers pending webhooks every interval until ctx is done
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := d.DeliverPending(ctx); err != nil {
+				d.logger.Error("failed to deliver webhooks", slog.Any("err", err))
+			}
+		}
+	}
+}
+
+// deliver makes a single delivery attempt and records its result.
+// Only storage errors are returned, failed attempts are recorded and retried later.
+func (d *Dispatcher) deliver(ctx context.Context, delivery Delivery) error {
+	statusCode, sendErr := d.send(ctx, delivery)
+	if sendErr == nil {
+		if err := d.storage.MarkDelivered(ctx, delivery.ID, statusCode); err != nil {
+			return fmt.Errorf("failed to record webhook delivery (ID: %d): %w", delivery.ID, err)
+		}
+		return nil
+	}
+
+	attempts := delivery.Attempts + 1
+	failure := Failure{
+		StatusCode: statusCode,
+		Error:      truncate(sendErr.Error(), maxErrorLength),
+		RetryIn:    d.backoff(attempts),
+		Final:      attempts >= d.maxAttempts,
+	}
+
+	d.logger.Warn(
+		"failed to deliver webhook",
+		slog.Uint64("delivery_id", delivery.ID),
+		slog.Uint64("subscription_id", uint64(delivery.SubscriptionID)),
+		slog.Uint64("attempt", uint64(attempts)),
+		slog.Bool("final", failure.Final),
+		slog.Any("err", sendErr),
+	)
+
+	if err := d.storage.MarkFailed(ctx, delivery.ID, failure); err != nil {
+		return fmt.Errorf("failed to record webhook delivery failure (ID: %d): %w", delivery.ID, err)
+	}
+
+	return nil
+}
+
+// send posts a signed delivery payload. It returns a response status code, 0 if there's no response.
+func (d *Dispatcher) send(ctx context.Context, delivery Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	timestamp := d.now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, delivery.Payload))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(EventHeader, string(delivery.EventType))
+	req.Header.Set(DeliveryHeader, delivery.EventID)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backoff returns a delay before the next attempt: base * 2^(attempts-1), at most backoffMax
+func (d *Dispatcher) backoff(attempts uint) time.Duration {
+	delay := d.backoffBase
+	for i := uint(1); i < attempts && delay < d.backoffMax; i++ {
+		delay *= 2
+	}
+
+	return min(delay, d.backoffMax)
+}
+
+func truncate(s string, maxLength int) string {
+	if len(s) <= maxLength {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxLength], "")
+}
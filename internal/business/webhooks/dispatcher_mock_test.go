@@ -0,0 +1,158 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: dispatcher.go
+//
+// Generated by this command:
+//
+//	mockgen -typed -source=dispatcher.go -destination ./dispatcher_mock_test.go -package webhooks_test -mock_names DeliveryStorage=MockDeliveryStorage
+//
+
+// Package webhooks_test is a generated GoMock package.
+package webhooks_test
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	webhooks "github.com/titusjaka/go-sample/v2/internal/business/webhooks"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDeliveryStorage is a mock of DeliveryStorage interface.
+type MockDeliveryStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeliveryStorageMockRecorder
+	isgomock struct{}
+}
+
+// MockDeliveryStorageMockRecorder is the mock recorder for MockDeliveryStorage.
+type MockDeliveryStorageMockRecorder struct {
+	mock *MockDeliveryStorage
+}
+
+// NewMockDeliveryStorage creates a new mock instance.
+func NewMockDeliveryStorage(ctrl *gomock.Controller) *MockDeliveryStorage {
+	mock := &MockDeliveryStorage{ctrl: ctrl}
+	mock.recorder = &MockDeliveryStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeliveryStorage) EXPECT() *MockDeliveryStorageMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockDeliveryStorage) Claim(ctx context.Context, limit uint, lease time.Duration) ([]webhooks.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", ctx, limit, lease)
+	ret0, _ := ret[0].([]webhooks.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockDeliveryStorageMockRecorder) Claim(ctx, limit, lease any) *MockDeliveryStorageClaimCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockDeliveryStorage)(nil).Claim), ctx, limit, lease)
+	return &MockDeliveryStorageClaimCall{Call: call}
+}
+
+// MockDeliveryStorageClaimCall wrap *gomock.Call
+type MockDeliveryStorageClaimCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDeliveryStorageClaimCall) Return(arg0 []webhooks.Delivery, arg1 error) *MockDeliveryStorageClaimCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDeliveryStorageClaimCall) Do(f func(context.Context, uint, time.Duration) ([]webhooks.Delivery, error)) *MockDeliveryStorageClaimCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDeliveryStorageClaimCall) DoAndReturn(f func(context.Context, uint, time.Duration) ([]webhooks.Delivery, error)) *MockDeliveryStorageClaimCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MarkDelivered mocks base method.
+func (m *MockDeliveryStorage) MarkDelivered(ctx context.Context, id uint64, statusCode int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkDelivered", ctx, id, statusCode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkDelivered indicates an expected call of MarkDelivered.
+func (mr *MockDeliveryStorageMockRecorder) MarkDelivered(ctx, id, statusCode any) *MockDeliveryStorageMarkDeliveredCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkDelivered", reflect.TypeOf((*MockDeliveryStorage)(nil).MarkDelivered), ctx, id, statusCode)
+	return &MockDeliveryStorageMarkDeliveredCall{Call: call}
+}
+
+// MockDeliveryStorageMarkDeliveredCall wrap *gomock.Call
+type MockDeliveryStorageMarkDeliveredCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDeliveryStorageMarkDeliveredCall) Return(arg0 error) *MockDeliveryStorageMarkDeliveredCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDeliveryStorageMarkDeliveredCall) Do(f func(context.Context, uint64, int) error) *MockDeliveryStorageMarkDeliveredCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDeliveryStorageMarkDeliveredCall) DoAndReturn(f func(context.Context, uint64, int) error) *MockDeliveryStorageMarkDeliveredCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// MarkFailed mocks base method.
+func (m *MockDeliveryStorage) MarkFailed(ctx context.Context, id uint64, failure webhooks.Failure) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkFailed", ctx, id, failure)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkFailed indicates an expected call of MarkFailed.
+func (mr *MockDeliveryStorageMockRecorder) MarkFailed(ctx, id, failure any) *MockDeliveryStorageMarkFailedCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkFailed", reflect.TypeOf((*MockDeliveryStorage)(nil).MarkFailed), ctx, id, failure)
+	return &MockDeliveryStorageMarkFailedCall{Call: call}
+}
+
+// MockDeliveryStorageMarkFailedCall wrap *gomock.Call
+type MockDeliveryStorageMarkFailedCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockDeliveryStorageMarkFailedCall) Return(arg0 error) *MockDeliveryStorageMarkFailedCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockDeliveryStorageMarkFailedCall) Do(f func(context.Context, uint64, webhooks.Failure) error) *MockDeliveryStorageMarkFailedCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockDeliveryStorageMarkFailedCall) DoAndReturn(f func(context.Context, uint64, webhooks.Failure) error) *MockDeliveryStorageMarkFailedCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -0,0 +1,323 @@
+package webhooks_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/business/webhooks"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+)
+
+func TestDispatcher_DeliverPending(t *testing.T) {
+	t.Parallel()
+
+	fakeNow := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+	payload := []byte(`{"id":"e1","type":"snippet.created","data":{"id":1}}`)
+
+	newDelivery := func(url string, attempts uint) webhooks.Delivery {
+		return webhooks.Delivery{
+			ID:             5,
+			SubscriptionID: 2,
+			URL:            url,
+			Secret:         "0123456789abcdef",
+			EventID:        "e1",
+			EventType:      snippets.EventCreated,
+			Payload:        payload,
+			Attempts:       attempts,
+		}
+	}
+
+	t.Run("Signed payload is delivered", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Receiver
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, payload, body)
+
+			timestamp, err := strconv.ParseInt(r.Header.Get(webhooks.TimestampHeader), 10, 64)
+			assert.NoError(t, err)
+			assert.Equal(t, fakeNow.Unix(), timestamp)
+			assert.True(t, webhooks.Verify("0123456789abcdef", r.Header.Get(webhooks.SignatureHeader), timestamp, body))
+
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			assert.Equal(t, string(snippets.EventCreated), r.Header.Get(webhooks.EventHeader))
+			assert.Equal(t, "e1", r.Header.Get(webhooks.DeliveryHeader))
+
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		t.Cleanup(receiver.Close)
+
+		// ===============================================
+		// Init Mocks and Dispatcher
+		mockStorage := NewMockDeliveryStorage(ctrl)
+
+		dispatcher := webhooks.NewDispatcher(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return fakeNow },
+			webhooks.WithHTTPClient(receiver.Client()),
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		gomock.InOrder(
+			mockStorage.EXPECT().Claim(ctx, gomock.Any(), gomock.Any()).
+				Return([]webhooks.Delivery{newDelivery(receiver.URL, 0)}, nil),
+			mockStorage.EXPECT().MarkDelivered(gomock.Any(), uint64(5), http.StatusNoContent).Return(nil),
+		)
+
+		// ===============================================
+		// Run Test
+		require.NoError(t, dispatcher.DeliverPending(ctx))
+	})
+
+	t.Run("Failed delivery is retried with backoff", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Receiver
+		var calls atomic.Int32
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		t.Cleanup(receiver.Close)
+
+		// ===============================================
+		// Init Mocks and Dispatcher
+		mockStorage := NewMockDeliveryStorage(ctrl)
+
+		dispatcher := webhooks.NewDispatcher(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return fakeNow },
+			webhooks.WithHTTPClient(receiver.Client()),
+			webhooks.WithBackoff(10*time.Second, time.Hour),
+			webhooks.WithMaxAttempts(5),
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		gomock.InOrder(
+			mockStorage.EXPECT().Claim(ctx, gomock.Any(), gomock.Any()).
+				Return([]webhooks.Delivery{newDelivery(receiver.URL, 2)}, nil),
+			mockStorage.EXPECT().MarkFailed(gomock.Any(), uint64(5), webhooks.Failure{
+				StatusCode: http.StatusInternalServerError,
+				Error:      "unexpected response status: 500 Internal Server Error",
+				RetryIn:    40 * time.Second,
+				Final:      false,
+			}).Return(nil),
+		)
+
+		// ===============================================
+		// Run Test
+		require.NoError(t, dispatcher.DeliverPending(ctx))
+		assert.EqualValues(t, 1, calls.Load())
+	})
+
+	t.Run("Delivery is given up when attempts are exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Receiver
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusGone)
+		}))
+		t.Cleanup(receiver.Close)
+
+		// ===============================================
+		// Init Mocks and Dispatcher
+		mockStorage := NewMockDeliveryStorage(ctrl)
+
+		dispatcher := webhooks.NewDispatcher(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return fakeNow },
+			webhooks.WithHTTPClient(receiver.Client()),
+			webhooks.WithBackoff(10*time.Second, time.Hour),
+			webhooks.WithMaxAttempts(20),
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		gomock.InOrder(
+			mockStorage.EXPECT().Claim(ctx, gomock.Any(), gomock.Any()).
+				Return([]webhooks.Delivery{newDelivery(receiver.URL, 19)}, nil),
+			mockStorage.EXPECT().MarkFailed(gomock.Any(), uint64(5), webhooks.Failure{
+				StatusCode: http.StatusGone,
+				Error:      "unexpected response status: 410 Gone",
+				RetryIn:    time.Hour,
+				Final:      true,
+			}).Return(nil),
+		)
+
+		// ===============================================
+		// Run Test
+		require.NoError(t, dispatcher.DeliverPending(ctx))
+	})
+
+	t.Run("Unreachable receiver", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		receiver := httptest.NewServer(http.NotFoundHandler())
+		receiver.Close()
+
+		// ===============================================
+		// Init Mocks and Dispatcher
+		mockStorage := NewMockDeliveryStorage(ctrl)
+
+		dispatcher := webhooks.NewDispatcher(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return fakeNow },
+			webhooks.WithHTTPClient(receiver.Client()),
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		gomock.InOrder(
+			mockStorage.EXPECT().Claim(ctx, gomock.Any(), gomock.Any()).
+				Return([]webhooks.Delivery{newDelivery(receiver.URL, 0)}, nil),
+			mockStorage.EXPECT().MarkFailed(gomock.Any(), uint64(5), gomock.Cond(func(failure webhooks.Failure) bool {
+				return failure.StatusCode == 0 && failure.Error != "" && !failure.Final
+			})).Return(nil),
+		)
+
+		// ===============================================
+		// Run Test
+		require.NoError(t, dispatcher.DeliverPending(ctx))
+	})
+
+	t.Run("Internal receivers are refused", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Receiver
+		var calls atomic.Int32
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		t.Cleanup(receiver.Close)
+
+		// ===============================================
+		// Init Mocks and Dispatcher
+		mockStorage := NewMockDeliveryStorage(ctrl)
+
+		dispatcher := webhooks.NewDispatcher(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return fakeNow },
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		gomock.InOrder(
+			mockStorage.EXPECT().Claim(ctx, gomock.Any(), gomock.Any()).
+				Return([]webhooks.Delivery{newDelivery(receiver.URL, 0)}, nil),
+			mockStorage.EXPECT().MarkFailed(gomock.Any(), uint64(5), gomock.Cond(func(failure webhooks.Failure) bool {
+				return failure.StatusCode == 0 && strings.Contains(failure.Error, webhooks.ErrNonPublicAddress.Error())
+			})).Return(nil),
+		)
+
+		// ===============================================
+		// Run Test
+		require.NoError(t, dispatcher.DeliverPending(ctx))
+		assert.Zero(t, calls.Load())
+	})
+
+	t.Run("Redirects are not followed", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Receivers
+		var calls atomic.Int32
+		target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		t.Cleanup(target.Close)
+
+		receiver := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+		t.Cleanup(receiver.Close)
+
+		// ===============================================
+		// Init Mocks and Dispatcher
+		mockStorage := NewMockDeliveryStorage(ctrl)
+
+		dispatcher := webhooks.NewDispatcher(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return fakeNow },
+			webhooks.WithHTTPClient(webhooks.NewLoopbackHTTPClient(time.Second)),
+		)
+
+		// ===============================================
+		// Describe Mock Calls
+		gomock.InOrder(
+			mockStorage.EXPECT().Claim(ctx, gomock.Any(), gomock.Any()).
+				Return([]webhooks.Delivery{newDelivery(receiver.URL, 0)}, nil),
+			mockStorage.EXPECT().MarkFailed(gomock.Any(), uint64(5), gomock.Cond(func(failure webhooks.Failure) bool {
+				return failure.StatusCode == http.StatusTemporaryRedirect
+			})).Return(nil),
+		)
+
+		// ===============================================
+		// Run Test
+		require.NoError(t, dispatcher.DeliverPending(ctx))
+		assert.Zero(t, calls.Load())
+	})
+
+	t.Run("Storage error", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		mockStorage := NewMockDeliveryStorage(ctrl)
+
+		dispatcher := webhooks.NewDispatcher(
+			mockStorage,
+			nopslog.NewNoplogger(),
+			func() time.Time { return fakeNow },
+		)
+
+		mockStorage.EXPECT().Claim(ctx, gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+
+		require.Error(t, dispatcher.DeliverPending(ctx))
+	})
+}
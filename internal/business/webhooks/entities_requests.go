@@ -0,0 +1,72 @@
+package webhooks
+
+import (
+	"errors"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+)
+
+// ListWebhooksRequest represents a request struct for GET /webhooks?limit=<x>&offset=<y> method
+type ListWebhooksRequest struct {
+	Limit  uint `schema:"limit"`
+	Offset uint `schema:"offset"`
+}
+
+// WebhookRequest represents a request struct for POST /webhooks and PUT /webhooks/{webhook_id} methods
+type WebhookRequest struct {
+	URL    string        `json:"url"`
+	Events []events.Type `json:"events"`
+	// Secret is generated on creation and kept on update if it's empty
+	Secret string `json:"secret"`
+	// Active is true by default
+	Active *bool `json:"active"`
+}
+
+// Validate implements ozzo-validation.Validatable interface and used to check user request
+func (r *WebhookRequest) Validate() error {
+	return validation.ValidateStruct(
+		r,
+		validation.Field(&r.URL, validation.Required, validation.Length(1, 2000), validation.By(webhookURL)),
+		validation.Field(&r.Events, validation.Required, validation.Each(validation.In(knownEvents()...))),
+		validation.Field(&r.Secret, validation.Length(16, 256)),
+	)
+}
+
+// webhookURL checks that a value is an absolute http(s) URL of a public host.
+// Names are resolved on delivery, so only IP literals and localhost are checked here.
+func webhookURL(value any) error {
+	raw, _ := value.(string)
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("must be an absolute http or https URL")
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("must point to a public address")
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil && !isPublic(addr) {
+		return errors.New("must point to a public address")
+	}
+
+	return nil
+}
+
+// knownEvents returns event types that can be subscribed to
+func knownEvents() []any {
+	types := snippets.EventTypes()
+
+	result := make([]any, len(types))
+	for i, t := range types {
+		result[i] = t
+	}
+	return result
+}
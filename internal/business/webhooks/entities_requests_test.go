@@ -0,0 +1,120 @@
+package webhooks_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/business/webhooks"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/utils/testutils"
+)
+
+func TestWebhookRequest_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		request webhooks.WebhookRequest
+		wantErr string
+	}{
+		{
+			name: "Valid WebhookRequest",
+			request: webhooks.WebhookRequest{
+				URL:    "https://example.com/hooks/snippets",
+				Events: []events.Type{snippets.EventCreated, snippets.EventExpired},
+				Secret: "0123456789abcdef",
+			},
+			wantErr: "",
+		},
+		{
+			name: "Invalid: empty URL",
+			request: webhooks.WebhookRequest{
+				Events: []events.Type{snippets.EventCreated},
+			},
+			wantErr: "url: cannot be blank.",
+		},
+		{
+			name: "Invalid: not an http URL",
+			request: webhooks.WebhookRequest{
+				URL:    "ftp://example.com/hooks",
+				Events: []events.Type{snippets.EventCreated},
+			},
+			wantErr: "url: must be an absolute http or https URL.",
+		},
+		{
+			name: "Invalid: relative URL",
+			request: webhooks.WebhookRequest{
+				URL:    "/hooks",
+				Events: []events.Type{snippets.EventCreated},
+			},
+			wantErr: "url: must be an absolute http or https URL.",
+		},
+		{
+			name: "Invalid: loopback address",
+			request: webhooks.WebhookRequest{
+				URL:    "http://127.0.0.1:4040/snippets",
+				Events: []events.Type{snippets.EventCreated},
+			},
+			wantErr: "url: must point to a public address.",
+		},
+		{
+			name: "Invalid: metadata address",
+			request: webhooks.WebhookRequest{
+				URL:    "http://169.254.169.254/latest/meta-data",
+				Events: []events.Type{snippets.EventCreated},
+			},
+			wantErr: "url: must point to a public address.",
+		},
+		{
+			name: "Invalid: private IPv6 address",
+			request: webhooks.WebhookRequest{
+				URL:    "https://[fd00::1]/hooks",
+				Events: []events.Type{snippets.EventCreated},
+			},
+			wantErr: "url: must point to a public address.",
+		},
+		{
+			name: "Invalid: localhost",
+			request: webhooks.WebhookRequest{
+				URL:    "http://LocalHost./hooks",
+				Events: []events.Type{snippets.EventCreated},
+			},
+			wantErr: "url: must point to a public address.",
+		},
+		{
+			name: "Invalid: no events",
+			request: webhooks.WebhookRequest{
+				URL: "https://example.com/hooks",
+			},
+			wantErr: "events: cannot be blank.",
+		},
+		{
+			name: "Invalid: unknown event",
+			request: webhooks.WebhookRequest{
+				URL:    "https://example.com/hooks",
+				Events: []events.Type{snippets.EventCreated, "snippet.viewed"},
+			},
+			wantErr: "events: (1: must be a valid value.).",
+		},
+		{
+			name: "Invalid: short secret",
+			request: webhooks.WebhookRequest{
+				URL:    "https://example.com/hooks",
+				Events: []events.Type{snippets.EventCreated},
+				Secret: strings.Repeat("a", 15),
+			},
+			wantErr: "secret: the length must be between 16 and 256.",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.request.Validate()
+			testutils.AssertError(t, tt.wantErr, err)
+		})
+	}
+}
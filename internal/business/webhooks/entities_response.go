@@ -0,0 +1,73 @@
+package webhooks
+
+import (
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+// WebhookResponse represents a common webhook-response struct.
+// Secret is only returned when it's created or rotated, Stats only for a single webhook.
+type WebhookResponse struct {
+	ID        uint           `json:"id"`
+	URL       string         `json:"url"`
+	Events    []events.Type  `json:"events"`
+	Active    bool           `json:"active"`
+	Secret    string         `json:"secret,omitempty"`
+	Stats     *StatsResponse `json:"stats,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// StatsResponse represents delivery statistics of a webhook
+type StatsResponse struct {
+	Pending         uint       `json:"pending"`
+	Delivered       uint       `json:"delivered"`
+	Failed          uint       `json:"failed"`
+	Attempts        uint       `json:"attempts"`
+	LastError       string     `json:"last_error,omitempty"`
+	LastErrorAt     *time.Time `json:"last_error_at,omitempty"`
+	LastDeliveredAt *time.Time `json:"last_delivered_at,omitempty"`
+}
+
+// ListWebhooksResponse represents a response struct for GET /webhooks?limit=<x>&offset=<y> method
+type ListWebhooksResponse struct {
+	Webhooks   []WebhookResponse  `json:"webhooks,omitempty"`
+	Pagination service.Pagination `json:"pagination"`
+}
+
+// convertToListWebhooksResponse is used to map []Subscription -> []WebhookResponse
+func convertToListWebhooksResponse(subscriptions []Subscription) []WebhookResponse {
+	response := make([]WebhookResponse, len(subscriptions))
+	for i := range subscriptions {
+		response[i] = convertToWebhookResponse(subscriptions[i])
+	}
+	return response
+}
+
+// convertToWebhookResponse is used to map Subscription -> WebhookResponse
+func convertToWebhookResponse(subscription Subscription) WebhookResponse {
+	return WebhookResponse{
+		ID:        subscription.ID,
+		URL:       subscription.URL,
+		Events:    subscription.Events,
+		Active:    subscription.Active,
+		Secret:    subscription.Secret,
+		CreatedAt: subscription.CreatedAt,
+		UpdatedAt: subscription.UpdatedAt,
+	}
+}
+
+// convertToStatsResponse is used to map Stats -> StatsResponse
+func convertToStatsResponse(stats Stats) *StatsResponse {
+	return &StatsResponse{
+		Pending:         stats.Pending,
+		Delivered:       stats.Delivered,
+		Failed:          stats.Failed,
+		Attempts:        stats.Attempts,
+		LastError:       stats.LastError,
+		LastErrorAt:     stats.LastErrorAt,
+		LastDeliveredAt: stats.LastDeliveredAt,
+	}
+}
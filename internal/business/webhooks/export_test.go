@@ -0,0 +1,14 @@
+package webhooks
+
+import (
+	"net/http"
+	"net/netip"
+	"time"
+)
+
+// NewLoopbackHTTPClient returns a client like NewHTTPClient that also connects to loopback addresses of test servers
+func NewLoopbackHTTPClient(timeout time.Duration) *http.Client {
+	return newHTTPClient(timeout, func(addr netip.Addr) bool {
+		return addr.IsLoopback() || isPublic(addr)
+	})
+}
@@ -0,0 +1,187 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+//go:generate go run go.uber.org/mock/mockgen -typed -source=service.go -destination ./service_mock_test.go -package webhooks_test -mock_names Storage=MockStorage
+
+// secretSize is a size of generated secrets in bytes
+const secretSize = 32
+
+// Storage is used to manipulate data in DB
+type Storage interface {
+	Get(ctx context.Context, id uint) (Subscription, error)
+	List(ctx context.Context, pagination service.Pagination) ([]Subscription, error)
+	Total(ctx context.Context) (uint, error)
+	Create(ctx context.Context, subscription Subscription) (uint, error)
+	Update(ctx context.Context, subscription Subscription) error
+	Delete(ctx context.Context, id uint) error
+	Stats(ctx context.Context, id uint) (Stats, error)
+	Enqueue(ctx context.Context, batch []events.Event) error
+}
+
+// WebhookService represents service struct. It holds storage and logger.
+// It implements events.Publisher: published events are queued for delivery to subscriptions.
+type WebhookService struct {
+	storage Storage
+	logger  *slog.Logger
+
+	now func() time.Time
+}
+
+// NewService returns new instance of WebhookService
+func NewService(storage Storage, logger *slog.Logger, nowFunc func() time.Time) *WebhookService {
+	return &WebhookService{
+		storage: storage,
+		logger:  logger,
+
+		now: nowFunc,
+	}
+}
+
+// Get returns a single subscription with its delivery statistics. The secret is not returned.
+func (s *WebhookService) Get(ctx context.Context, id uint) (Subscription, Stats, *service.Error) {
+	subscription, err := s.storage.Get(ctx, id)
+	if err != nil {
+		return Subscription{}, Stats{}, s.wrapErr("get", err)
+	}
+
+	stats, err := s.storage.Stats(ctx, id)
+	if err != nil {
+		return Subscription{}, Stats{}, s.wrapErr("get stats of", err)
+	}
+
+	subscription.Secret = ""
+	return subscription, stats, nil
+}
+
+// List returns a list of subscriptions and a pagination struct. Secrets are not returned.
+func (s *WebhookService) List(ctx context.Context, limit uint, offset uint) ([]Subscription, service.Pagination, *service.Error) {
+	total, err := s.storage.Total(ctx)
+	if err != nil {
+		s.logger.Error("failed to query total amount of webhooks", slog.Any("err", err))
+		return nil, service.Pagination{}, &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to query total amount of webhooks: %w", err),
+		}
+	}
+
+	pagination := snippets.NewPagination(limit, offset, total)
+
+	subscriptions, err := s.storage.List(ctx, pagination)
+	if err != nil {
+		s.logger.Error("failed to list webhooks", slog.Any("err", err))
+		return nil, pagination, &service.Error{
+			Type: service.InternalError,
+			Base: fmt.Errorf("failed to list webhooks: %w", err),
+		}
+	}
+
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+
+	return subscriptions, pagination, nil
+}
+
+// Create creates a single subscription. A random secret is generated if it's not set.
+// The returned subscription holds the secret, it's the only time the secret is returned.
+func (s *WebhookService) Create(ctx context.Context, subscription Subscription) (Subscription, *service.Error) {
+	if subscription.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return Subscription{}, s.wrapErr("create", err)
+		}
+		subscription.Secret = secret
+	}
+
+	now := s.now()
+	subscription.CreatedAt = now
+	subscription.UpdatedAt = now
+
+	id, err := s.storage.Create(ctx, subscription)
+	if err != nil {
+		return Subscription{}, s.wrapErr("create", err)
+	}
+
+	subscription.ID = id
+	return subscription, nil
+}
+
+// Update replaces the URL, the events and the state of a subscription.
+// The secret is rotated only if it's set, the returned subscription holds the secret in this case.
+func (s *WebhookService) Update(ctx context.Context, subscription Subscription) (Subscription, *service.Error) {
+	current, err := s.storage.Get(ctx, subscription.ID)
+	if err != nil {
+		return Subscription{}, s.wrapErr("update", err)
+	}
+
+	secret := subscription.Secret
+	if secret == "" {
+		subscription.Secret = current.Secret
+	}
+
+	subscription.CreatedAt = current.CreatedAt
+	subscription.UpdatedAt = s.now()
+
+	if err = s.storage.Update(ctx, subscription); err != nil {
+		return Subscription{}, s.wrapErr("update", err)
+	}
+
+	subscription.Secret = secret
+	return subscription, nil
+}
+
+// Delete removes a single subscription. Its pending deliveries are dropped.
+func (s *WebhookService) Delete(ctx context.Context, id uint) *service.Error {
+	if err := s.storage.Delete(ctx, id); err != nil {
+		return s.wrapErr("delete", err)
+	}
+
+	return nil
+}
+
+// Publish implements events.Publisher. Events are queued for every active subscription to their types.
+func (s *WebhookService) Publish(ctx context.Context, batch ...events.Event) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	return s.storage.Enqueue(ctx, batch)
+}
+
+// wrapErr converts storage errors to service errors
+func (s *WebhookService) wrapErr(operation string, err error) *service.Error {
+	if errors.Is(err, ErrNotFound) {
+		return &service.Error{
+			Type: service.NotFound,
+			Base: ErrNotFound,
+		}
+	}
+
+	s.logger.Error("failed to process webhook", slog.String("operation", operation), slog.Any("err", err))
+	return &service.Error{
+		Type: service.InternalError,
+		Base: fmt.Errorf("failed to %s webhook: %w", operation, err),
+	}
+}
+
+func generateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+
+	return hex.EncodeToString(secret), nil
+}
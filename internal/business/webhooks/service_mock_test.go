@@ -0,0 +1,353 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service.go
+//
+// Generated by this command:
+//
+//	mockgen -typed -source=service.go -destination ./service_mock_test.go -package webhooks_test -mock_names Storage=MockStorage
+//
+
+// Package webhooks_test is a generated GoMock package.
+package webhooks_test
+
+import (
+	context "context"
+	reflect "reflect"
+
+	webhooks "github.com/titusjaka/go-sample/v2/internal/business/webhooks"
+	events "github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+	service "github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStorage is a mock of Storage interface.
+type MockStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockStorageMockRecorder
+	isgomock struct{}
+}
+
+// MockStorageMockRecorder is the mock recorder for MockStorage.
+type MockStorageMockRecorder struct {
+	mock *MockStorage
+}
+
+// NewMockStorage creates a new mock instance.
+func NewMockStorage(ctrl *gomock.Controller) *MockStorage {
+	mock := &MockStorage{ctrl: ctrl}
+	mock.recorder = &MockStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockStorage) Create(ctx context.Context, subscription webhooks.Subscription) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, subscription)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockStorageMockRecorder) Create(ctx, subscription any) *MockStorageCreateCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockStorage)(nil).Create), ctx, subscription)
+	return &MockStorageCreateCall{Call: call}
+}
+
+// MockStorageCreateCall wrap *gomock.Call
+type MockStorageCreateCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageCreateCall) Return(arg0 uint, arg1 error) *MockStorageCreateCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageCreateCall) Do(f func(context.Context, webhooks.Subscription) (uint, error)) *MockStorageCreateCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageCreateCall) DoAndReturn(f func(context.Context, webhooks.Subscription) (uint, error)) *MockStorageCreateCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Delete mocks base method.
+func (m *MockStorage) Delete(ctx context.Context, id uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockStorageMockRecorder) Delete(ctx, id any) *MockStorageDeleteCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockStorage)(nil).Delete), ctx, id)
+	return &MockStorageDeleteCall{Call: call}
+}
+
+// MockStorageDeleteCall wrap *gomock.Call
+type MockStorageDeleteCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageDeleteCall) Return(arg0 error) *MockStorageDeleteCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageDeleteCall) Do(f func(context.Context, uint) error) *MockStorageDeleteCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageDeleteCall) DoAndReturn(f func(context.Context, uint) error) *MockStorageDeleteCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Enqueue mocks base method.
+func (m *MockStorage) Enqueue(ctx context.Context, batch []events.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enqueue", ctx, batch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockStorageMockRecorder) Enqueue(ctx, batch any) *MockStorageEnqueueCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockStorage)(nil).Enqueue), ctx, batch)
+	return &MockStorageEnqueueCall{Call: call}
+}
+
+// MockStorageEnqueueCall wrap *gomock.Call
+type MockStorageEnqueueCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageEnqueueCall) Return(arg0 error) *MockStorageEnqueueCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageEnqueueCall) Do(f func(context.Context, []events.Event) error) *MockStorageEnqueueCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageEnqueueCall) DoAndReturn(f func(context.Context, []events.Event) error) *MockStorageEnqueueCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Get mocks base method.
+func (m *MockStorage) Get(ctx context.Context, id uint) (webhooks.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, id)
+	ret0, _ := ret[0].(webhooks.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockStorageMockRecorder) Get(ctx, id any) *MockStorageGetCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockStorage)(nil).Get), ctx, id)
+	return &MockStorageGetCall{Call: call}
+}
+
+// MockStorageGetCall wrap *gomock.Call
+type MockStorageGetCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageGetCall) Return(arg0 webhooks.Subscription, arg1 error) *MockStorageGetCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageGetCall) Do(f func(context.Context, uint) (webhooks.Subscription, error)) *MockStorageGetCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageGetCall) DoAndReturn(f func(context.Context, uint) (webhooks.Subscription, error)) *MockStorageGetCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// List mocks base method.
+func (m *MockStorage) List(ctx context.Context, pagination service.Pagination) ([]webhooks.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, pagination)
+	ret0, _ := ret[0].([]webhooks.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockStorageMockRecorder) List(ctx, pagination any) *MockStorageListCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockStorage)(nil).List), ctx, pagination)
+	return &MockStorageListCall{Call: call}
+}
+
+// MockStorageListCall wrap *gomock.Call
+type MockStorageListCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageListCall) Return(arg0 []webhooks.Subscription, arg1 error) *MockStorageListCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageListCall) Do(f func(context.Context, service.Pagination) ([]webhooks.Subscription, error)) *MockStorageListCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageListCall) DoAndReturn(f func(context.Context, service.Pagination) ([]webhooks.Subscription, error)) *MockStorageListCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Stats mocks base method.
+func (m *MockStorage) Stats(ctx context.Context, id uint) (webhooks.Stats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats", ctx, id)
+	ret0, _ := ret[0].(webhooks.Stats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockStorageMockRecorder) Stats(ctx, id any) *MockStorageStatsCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockStorage)(nil).Stats), ctx, id)
+	return &MockStorageStatsCall{Call: call}
+}
+
+// MockStorageStatsCall wrap *gomock.Call
+type MockStorageStatsCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageStatsCall) Return(arg0 webhooks.Stats, arg1 error) *MockStorageStatsCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageStatsCall) Do(f func(context.Context, uint) (webhooks.Stats, error)) *MockStorageStatsCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageStatsCall) DoAndReturn(f func(context.Context, uint) (webhooks.Stats, error)) *MockStorageStatsCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Total mocks base method.
+func (m *MockStorage) Total(ctx context.Context) (uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Total", ctx)
+	ret0, _ := ret[0].(uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Total indicates an expected call of Total.
+func (mr *MockStorageMockRecorder) Total(ctx any) *MockStorageTotalCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Total", reflect.TypeOf((*MockStorage)(nil).Total), ctx)
+	return &MockStorageTotalCall{Call: call}
+}
+
+// MockStorageTotalCall wrap *gomock.Call
+type MockStorageTotalCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageTotalCall) Return(arg0 uint, arg1 error) *MockStorageTotalCall {
+	c.Call = c.Call.Return(arg0, arg1)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageTotalCall) Do(f func(context.Context) (uint, error)) *MockStorageTotalCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageTotalCall) DoAndReturn(f func(context.Context) (uint, error)) *MockStorageTotalCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
+// Update mocks base method.
+func (m *MockStorage) Update(ctx context.Context, subscription webhooks.Subscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, subscription)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockStorageMockRecorder) Update(ctx, subscription any) *MockStorageUpdateCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockStorage)(nil).Update), ctx, subscription)
+	return &MockStorageUpdateCall{Call: call}
+}
+
+// MockStorageUpdateCall wrap *gomock.Call
+type MockStorageUpdateCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockStorageUpdateCall) Return(arg0 error) *MockStorageUpdateCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockStorageUpdateCall) Do(f func(context.Context, webhooks.Subscription) error) *MockStorageUpdateCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockStorageUpdateCall) DoAndReturn(f func(context.Context, webhooks.Subscription) error) *MockStorageUpdateCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
@@ -0,0 +1,255 @@
+package webhooks_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/business/webhooks"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+func TestWebhookService_Get(t *testing.T) {
+	t.Parallel()
+
+	fakeNow := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Secret is not returned", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+		webhookService := webhooks.NewService(mockStorage, nopslog.NewNoplogger(), func() time.Time { return fakeNow })
+
+		stored := webhooks.Subscription{
+			ID:     1,
+			URL:    "https://example.com/hooks",
+			Secret: "0123456789abcdef",
+			Events: []events.Type{snippets.EventCreated},
+			Active: true,
+		}
+		stats := webhooks.Stats{Delivered: 3, Attempts: 4, LastError: "unexpected response status: 502 Bad Gateway"}
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Get(ctx, uint(1)).Return(stored, nil)
+		mockStorage.EXPECT().Stats(ctx, uint(1)).Return(stats, nil)
+
+		// ===============================================
+		// Run Test
+		expected := stored
+		expected.Secret = ""
+
+		subscription, actualStats, svcErr := webhookService.Get(ctx, 1)
+		require.Nil(t, svcErr)
+		assert.Equal(t, expected, subscription)
+		assert.Equal(t, stats, actualStats)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		mockStorage := NewMockStorage(ctrl)
+		webhookService := webhooks.NewService(mockStorage, nopslog.NewNoplogger(), func() time.Time { return fakeNow })
+
+		mockStorage.EXPECT().Get(ctx, uint(1)).Return(webhooks.Subscription{}, webhooks.ErrNotFound)
+
+		_, _, svcErr := webhookService.Get(ctx, 1)
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.NotFound, svcErr.Type)
+	})
+}
+
+func TestWebhookService_Create(t *testing.T) {
+	t.Parallel()
+
+	fakeNow := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Secret is generated", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+		webhookService := webhooks.NewService(mockStorage, nopslog.NewNoplogger(), func() time.Time { return fakeNow })
+
+		// ===============================================
+		// Describe Mock Calls
+		var stored webhooks.Subscription
+		mockStorage.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, subscription webhooks.Subscription) (uint, error) {
+				stored = subscription
+				return 3, nil
+			},
+		)
+
+		// ===============================================
+		// Run Test
+		subscription, svcErr := webhookService.Create(ctx, webhooks.Subscription{
+			URL:    "https://example.com/hooks",
+			Events: []events.Type{snippets.EventCreated},
+			Active: true,
+		})
+		require.Nil(t, svcErr)
+
+		assert.EqualValues(t, 3, subscription.ID)
+		assert.Len(t, subscription.Secret, 64)
+		assert.Equal(t, subscription.Secret, stored.Secret)
+		assert.Equal(t, fakeNow, stored.CreatedAt)
+		assert.Equal(t, fakeNow, stored.UpdatedAt)
+	})
+
+	t.Run("Storage error", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		mockStorage := NewMockStorage(ctrl)
+		webhookService := webhooks.NewService(mockStorage, nopslog.NewNoplogger(), func() time.Time { return fakeNow })
+
+		mockStorage.EXPECT().Create(ctx, gomock.Any()).Return(uint(0), errors.New("unexpected error"))
+
+		_, svcErr := webhookService.Create(ctx, webhooks.Subscription{Secret: "0123456789abcdef"})
+		require.NotNil(t, svcErr)
+		assert.Equal(t, service.InternalError, svcErr.Type)
+	})
+}
+
+func TestWebhookService_Update(t *testing.T) {
+	t.Parallel()
+
+	fakeCreated := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+	fakeNow := time.Date(2020, 10, 8, 12, 0, 0, 0, time.UTC)
+
+	current := webhooks.Subscription{
+		ID:        1,
+		URL:       "https://example.com/hooks",
+		Secret:    "old secret value",
+		Events:    []events.Type{snippets.EventCreated},
+		Active:    true,
+		CreatedAt: fakeCreated,
+		UpdatedAt: fakeCreated,
+	}
+
+	t.Run("Secret is kept", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+		webhookService := webhooks.NewService(mockStorage, nopslog.NewNoplogger(), func() time.Time { return fakeNow })
+
+		update := webhooks.Subscription{
+			ID:     1,
+			URL:    "https://example.com/v2/hooks",
+			Events: []events.Type{snippets.EventDeleted},
+		}
+
+		expectedStored := update
+		expectedStored.Secret = current.Secret
+		expectedStored.CreatedAt = fakeCreated
+		expectedStored.UpdatedAt = fakeNow
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Get(ctx, uint(1)).Return(current, nil)
+		mockStorage.EXPECT().Update(ctx, expectedStored).Return(nil)
+
+		// ===============================================
+		// Run Test
+		expected := expectedStored
+		expected.Secret = ""
+
+		subscription, svcErr := webhookService.Update(ctx, update)
+		require.Nil(t, svcErr)
+		assert.Equal(t, expected, subscription)
+	})
+
+	t.Run("Secret is rotated", func(t *testing.T) {
+		t.Parallel()
+
+		ctx := context.Background()
+		ctrl := gomock.NewController(t)
+
+		// ===============================================
+		// Init Mocks and Service
+		mockStorage := NewMockStorage(ctrl)
+		webhookService := webhooks.NewService(mockStorage, nopslog.NewNoplogger(), func() time.Time { return fakeNow })
+
+		update := current
+		update.Secret = "new secret value"
+
+		// ===============================================
+		// Describe Mock Calls
+		mockStorage.EXPECT().Get(ctx, uint(1)).Return(current, nil)
+		mockStorage.EXPECT().Update(ctx, gomock.Cond(func(s webhooks.Subscription) bool {
+			return s.Secret == "new secret value"
+		})).Return(nil)
+
+		// ===============================================
+		// Run Test
+		subscription, svcErr := webhookService.Update(ctx, update)
+		require.Nil(t, svcErr)
+		assert.Equal(t, "new secret value", subscription.Secret)
+	})
+}
+
+func TestWebhookService_Delete(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	mockStorage := NewMockStorage(ctrl)
+	webhookService := webhooks.NewService(mockStorage, nopslog.NewNoplogger(), time.Now)
+
+	mockStorage.EXPECT().Delete(ctx, uint(1)).Return(nil)
+	mockStorage.EXPECT().Delete(ctx, uint(2)).Return(webhooks.ErrNotFound)
+
+	assert.Nil(t, webhookService.Delete(ctx, 1))
+
+	svcErr := webhookService.Delete(ctx, 2)
+	require.NotNil(t, svcErr)
+	assert.Equal(t, service.NotFound, svcErr.Type)
+}
+
+func TestWebhookService_Publish(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	mockStorage := NewMockStorage(ctrl)
+	webhookService := webhooks.NewService(mockStorage, nopslog.NewNoplogger(), time.Now)
+
+	event, err := events.New(snippets.EventCreated, time.Now(), snippets.EventData{ID: 1})
+	require.NoError(t, err)
+
+	mockStorage.EXPECT().Enqueue(ctx, []events.Event{event}).Return(nil)
+
+	require.NoError(t, webhookService.Publish(ctx, event))
+	// Nothing is enqueued for an empty batch
+	require.NoError(t, webhookService.Publish(ctx))
+}
@@ -0,0 +1,38 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Headers of webhook requests
+const (
+	// SignatureHeader holds "sha256=" followed by a hex-encoded HMAC-SHA256 of "<timestamp>.<body>"
+	SignatureHeader = "X-Webhook-Signature"
+	// TimestampHeader holds the Unix time the request was signed at
+	TimestampHeader = "X-Webhook-Timestamp"
+	// EventHeader holds the event type
+	EventHeader = "X-Webhook-Event"
+	// DeliveryHeader holds the event ID, it's the same for all retries of a delivery
+	DeliveryHeader = "X-Webhook-Delivery"
+)
+
+const signaturePrefix = "sha256="
+
+// Sign returns a signature of a webhook request body sent at timestamp (Unix time).
+// The timestamp is signed as well, so receivers can reject replayed requests.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature of a webhook request in constant time
+func Verify(secret, signature string, timestamp int64, body []byte) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
@@ -0,0 +1,25 @@
+package webhooks_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/webhooks"
+)
+
+func TestSign(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`{"id":"1","type":"snippet.created"}`)
+
+	signature := webhooks.Sign("secret", 1728302400, body)
+
+	// echo -n '1728302400.{"id":"1","type":"snippet.created"}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=0cb6b91413bdff2f18c2b981b152ea2d4190155aa6a6ffa5d5763648f38f2f0a", signature)
+
+	assert.True(t, webhooks.Verify("secret", signature, 1728302400, body))
+	assert.False(t, webhooks.Verify("another secret", signature, 1728302400, body))
+	assert.False(t, webhooks.Verify("secret", signature, 1728302401, body))
+	assert.False(t, webhooks.Verify("secret", signature, 1728302400, []byte(`{}`)))
+}
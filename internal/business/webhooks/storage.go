@@ -0,0 +1,451 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+// Statuses of failed deliveries
+const (
+	statusPending = "pending"
+	statusFailed  = "failed"
+)
+
+// ErrNotFound error used to signal higher level about sql.ErrNoRows error
+var ErrNotFound = errors.New("not found")
+
+// PGStorage implements storage interface and provides methods to manipulate data in PostgreSQL storage
+type PGStorage struct {
+	conn *sql.DB
+}
+
+// NewPGStorage returns a new instance of PGStorage
+func NewPGStorage(conn *sql.DB) *PGStorage {
+	return &PGStorage{
+		conn: conn,
+	}
+}
+
+// subscriptionColumns are selected by scanSubscription
+const subscriptionColumns = `
+	id,
+	url,
+	secret,
+	array_to_json(events),
+	active,
+	created_at,
+	updated_at
+`
+
+// Get returns a single subscription from storage
+func (pg *PGStorage) Get(ctx context.Context, id uint) (Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1`
+
+	subscription, err := scanSubscription(postgres.Conn(ctx, pg.conn).QueryRowContext(ctx, query, id))
+	switch {
+	case err == nil:
+		return subscription, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return Subscription{}, ErrNotFound
+	default:
+		return Subscription{}, err
+	}
+}
+
+// List returns a list of subscriptions from storage
+func (pg *PGStorage) List(ctx context.Context, pagination service.Pagination) ([]Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions ORDER BY created_at DESC, id DESC %s`
+
+	rows, err := postgres.Conn(ctx, pg.conn).QueryContext(
+		ctx,
+		fmt.Sprintf(query, snippets.ConvertPaginationToSQLExpression(pagination)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var results []Subscription
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, subscription)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error from iterating webhook subscriptions rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// Total returns a total amount of subscriptions
+func (pg *PGStorage) Total(ctx context.Context) (uint, error) {
+	var total uint
+	if err := postgres.Conn(ctx, pg.conn).QueryRowContext(ctx, `SELECT COUNT(*) FROM webhook_subscriptions`).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count webhook subscriptions: %w", err)
+	}
+
+	return total, nil
+}
+
+// Create saves a single subscription to storage
+func (pg *PGStorage) Create(ctx context.Context, subscription Subscription) (uint, error) {
+	query := `
+		INSERT INTO webhook_subscriptions
+		(
+			url,
+			secret,
+			events,
+			active,
+			created_at,
+			updated_at
+		)
+		VALUES
+		(
+			$1,
+			$2,
+			$3,
+			$4,
+			$5,
+			$6
+		)
+		RETURNING id
+	`
+
+	var id uint
+	if err := postgres.Conn(ctx, pg.conn).QueryRowContext(
+		ctx,
+		query,
+		subscription.URL,
+		subscription.Secret,
+		eventTypesToStrings(subscription.Events),
+		subscription.Active,
+		subscription.CreatedAt,
+		subscription.UpdatedAt,
+	).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to add webhook subscription: %w", err)
+	}
+
+	return id, nil
+}
+
+// Update replaces the URL, the secret, the events and the state of a subscription
+func (pg *PGStorage) Update(ctx context.Context, subscription Subscription) error {
+	query := `
+		UPDATE webhook_subscriptions
+		SET
+			url = $2,
+			secret = $3,
+			events = $4,
+			active = $5,
+			updated_at = $6
+		WHERE id = $1
+	`
+
+	result, err := postgres.Conn(ctx, pg.conn).ExecContext(
+		ctx,
+		query,
+		subscription.ID,
+		subscription.URL,
+		subscription.Secret,
+		eventTypesToStrings(subscription.Events),
+		subscription.Active,
+		subscription.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+
+	switch affected, err := result.RowsAffected(); {
+	case err != nil:
+		return fmt.Errorf("failed to update webhook subscription: %w", err)
+	case affected == 0:
+		return ErrNotFound
+	default:
+		return nil
+	}
+}
+
+// Delete removes a subscription with all its deliveries
+func (pg *PGStorage) Delete(ctx context.Context, id uint) error {
+	result, err := postgres.Conn(ctx, pg.conn).ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	switch affected, err := result.RowsAffected(); {
+	case err != nil:
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	case affected == 0:
+		return ErrNotFound
+	default:
+		return nil
+	}
+}
+
+// Stats returns delivery statistics of a subscription
+func (pg *PGStorage) Stats(ctx context.Context, id uint) (Stats, error) {
+	query := `
+		SELECT
+			COUNT(d.id) FILTER (WHERE d.status = 'pending'),
+			COUNT(d.id) FILTER (WHERE d.status = 'delivered'),
+			COUNT(d.id) FILTER (WHERE d.status = 'failed'),
+			COALESCE(SUM(d.attempts), 0),
+			COALESCE(s.last_error, ''),
+			s.last_error_at,
+			MAX(d.delivered_at)
+		FROM webhook_subscriptions s
+			LEFT JOIN webhook_deliveries d ON d.subscription_id = s.id
+		WHERE s.id = $1
+		GROUP BY s.id
+	`
+
+	var stats Stats
+	switch err := postgres.Conn(ctx, pg.conn).QueryRowContext(ctx, query, id).Scan(
+		&stats.Pending,
+		&stats.Delivered,
+		&stats.Failed,
+		&stats.Attempts,
+		&stats.LastError,
+		&stats.LastErrorAt,
+		&stats.LastDeliveredAt,
+	); {
+	case err == nil:
+		return stats, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return Stats{}, ErrNotFound
+	default:
+		return Stats{}, fmt.Errorf("failed to get webhook subscription stats: %w", err)
+	}
+}
+
+// Enqueue queues events for every active subscription to their types.
+// An event is queued for a subscription only once, even if it's enqueued several times.
+func (pg *PGStorage) Enqueue(ctx context.Context, batch []events.Event) error {
+	query := `
+		INSERT INTO webhook_deliveries
+		(
+			subscription_id,
+			event_id,
+			event_type,
+			payload,
+			next_attempt_at,
+			created_at
+		)
+		SELECT
+			s.id,
+			e.id,
+			e.type,
+			e.payload::jsonb,
+			NOW(),
+			NOW()
+		FROM UNNEST($1::text[], $2::text[], $3::text[]) AS e (id, type, payload)
+			JOIN webhook_subscriptions s ON s.active AND e.type = ANY (s.events)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING
+	`
+
+	ids := make([]string, len(batch))
+	types := make([]string, len(batch))
+	payloads := make([]string, len(batch))
+	for i, event := range batch {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+
+		ids[i] = event.ID
+		types[i] = string(event.Type)
+		payloads[i] = string(payload)
+	}
+
+	if _, err := postgres.Conn(ctx, pg.conn).ExecContext(ctx, query, ids, types, payloads); err != nil {
+		return fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// Claim returns up to limit pending deliveries that are due and leases them:
+// they're not returned again until the lease expires, even by other server instances.
+// Deliveries of inactive subscriptions are kept pending until the subscription is activated.
+func (pg *PGStorage) Claim(ctx context.Context, limit uint, lease time.Duration) ([]Delivery, error) {
+	query := `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+		FROM webhook_subscriptions s
+		WHERE
+			s.id = d.subscription_id
+			AND d.id IN (
+				SELECT pd.id
+				FROM webhook_deliveries pd
+					JOIN webhook_subscriptions ps ON ps.id = pd.subscription_id
+				WHERE
+					pd.status = 'pending'
+					AND pd.next_attempt_at <= NOW()
+					AND ps.active
+				ORDER BY pd.next_attempt_at, pd.id
+				LIMIT $1
+				FOR UPDATE OF pd SKIP LOCKED
+			)
+		RETURNING
+			d.id,
+			d.subscription_id,
+			s.url,
+			s.secret,
+			d.event_id,
+			d.event_type,
+			d.payload,
+			d.attempts
+	`
+
+	rows, err := postgres.Conn(ctx, pg.conn).QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var results []Delivery
+	for rows.Next() {
+		var delivery Delivery
+		if err = rows.Scan(
+			&delivery.ID,
+			&delivery.SubscriptionID,
+			&delivery.URL,
+			&delivery.Secret,
+			&delivery.EventID,
+			&delivery.EventType,
+			&delivery.Payload,
+			&delivery.Attempts,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery row: %w", err)
+		}
+		results = append(results, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error from iterating webhook deliveries rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// MarkDelivered records a successful delivery attempt
+func (pg *PGStorage) MarkDelivered(ctx context.Context, id uint64, statusCode int) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET
+			status = 'delivered',
+			attempts = attempts + 1,
+			last_status_code = $2,
+			delivered_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := postgres.Conn(ctx, pg.conn).ExecContext(ctx, query, id, statusCode); err != nil {
+		return fmt.Errorf("failed to mark webhook delivery as delivered: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed delivery attempt and schedules a retry unless the failure is final.
+// The error is saved as the last error of the subscription as well.
+func (pg *PGStorage) MarkFailed(ctx context.Context, id uint64, failure Failure) error {
+	query := `
+		WITH failed AS (
+			UPDATE webhook_deliveries
+			SET
+				status = $2,
+				attempts = attempts + 1,
+				next_attempt_at = NOW() + $3 * INTERVAL '1 millisecond',
+				last_error = $4,
+				last_status_code = NULLIF($5, 0)
+			WHERE id = $1
+			RETURNING subscription_id
+		)
+		UPDATE webhook_subscriptions s
+		SET
+			last_error = $4,
+			last_error_at = NOW()
+		FROM failed
+		WHERE s.id = failed.subscription_id
+	`
+
+	status := statusPending
+	if failure.Final {
+		status = statusFailed
+	}
+
+	if _, err := postgres.Conn(ctx, pg.conn).ExecContext(
+		ctx,
+		query,
+		id,
+		status,
+		failure.RetryIn.Milliseconds(),
+		failure.Error,
+		failure.StatusCode,
+	); err != nil {
+		return fmt.Errorf("failed to mark webhook delivery as failed: %w", err)
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanSubscription scans a row selected with subscriptionColumns
+func scanSubscription(row rowScanner) (Subscription, error) {
+	var (
+		subscription Subscription
+		eventTypes   []byte
+	)
+
+	if err := row.Scan(
+		&subscription.ID,
+		&subscription.URL,
+		&subscription.Secret,
+		&eventTypes,
+		&subscription.Active,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Subscription{}, err
+		}
+		return Subscription{}, fmt.Errorf("failed to scan webhook subscription row: %w", err)
+	}
+
+	if err := json.Unmarshal(eventTypes, &subscription.Events); err != nil {
+		return Subscription{}, fmt.Errorf("failed to decode webhook subscription events: %w", err)
+	}
+
+	return subscription, nil
+}
+
+func eventTypesToStrings(types []events.Type) []string {
+	result := make([]string, len(types))
+	for i, t := range types {
+		result[i] = string(t)
+	}
+	return result
+}
@@ -0,0 +1,132 @@
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/business/webhooks"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres/pgtest"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+const envFile = "../../../.env"
+
+func TestPGStorage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip integration test due to 'short' flag")
+	}
+	t.Parallel()
+
+	pgConn := pgtest.InitTestDatabase(
+		t,
+		pgtest.WithConfigFiles(envFile),
+	)
+
+	ctx := context.Background()
+	pgStorage := webhooks.NewPGStorage(pgConn)
+
+	fakeTimeCreated := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+	fakeTimeUpdated := time.Date(2020, 10, 8, 12, 0, 0, 0, time.UTC)
+
+	created := webhooks.Subscription{
+		URL:       "https://example.com/hooks",
+		Secret:    "0123456789abcdef",
+		Events:    []events.Type{snippets.EventCreated, snippets.EventDeleted},
+		Active:    true,
+		CreatedAt: fakeTimeCreated,
+		UpdatedAt: fakeTimeCreated,
+	}
+
+	t.Run("Create and update a subscription", func(t *testing.T) {
+		id, err := pgStorage.Create(ctx, created)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, id)
+
+		created.ID = id
+
+		subscription, err := pgStorage.Get(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, created, subscription)
+
+		inactive := created
+		inactive.Active = false
+		inactive.UpdatedAt = fakeTimeUpdated
+		require.NoError(t, pgStorage.Update(ctx, inactive))
+
+		subscription, err = pgStorage.Get(ctx, id)
+		require.NoError(t, err)
+		assert.False(t, subscription.Active)
+
+		list, err := pgStorage.List(ctx, service.Pagination{Limit: 10})
+		require.NoError(t, err)
+		assert.Len(t, list, 1)
+
+		_, err = pgStorage.Get(ctx, 42)
+		assert.ErrorIs(t, err, webhooks.ErrNotFound)
+	})
+
+	t.Run("Queue deliveries", func(t *testing.T) {
+		// Events are not queued for inactive subscriptions, so reactivate it first
+		require.NoError(t, pgStorage.Update(ctx, created))
+
+		first, err := events.New(snippets.EventCreated, fakeTimeCreated, snippets.EventData{ID: 1})
+		require.NoError(t, err)
+		second, err := events.New(snippets.EventExpired, fakeTimeCreated, snippets.EventData{ID: 2})
+		require.NoError(t, err)
+
+		require.NoError(t, pgStorage.Enqueue(ctx, []events.Event{first, second}))
+		// Enqueueing the same event again doesn't duplicate the delivery
+		require.NoError(t, pgStorage.Enqueue(ctx, []events.Event{first}))
+
+		deliveries, err := pgStorage.Claim(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1, "only subscribed event types are queued")
+		assert.Equal(t, first.ID, deliveries[0].EventID)
+		assert.Equal(t, created.URL, deliveries[0].URL)
+		assert.Equal(t, created.Secret, deliveries[0].Secret)
+
+		var payload events.Event
+		require.NoError(t, json.Unmarshal(deliveries[0].Payload, &payload))
+		assert.Equal(t, first.ID, payload.ID)
+
+		// Claimed deliveries are leased
+		leased, err := pgStorage.Claim(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, leased)
+
+		require.NoError(t, pgStorage.MarkFailed(ctx, deliveries[0].ID, webhooks.Failure{
+			StatusCode: 500,
+			Error:      "unexpected response status: 500 Internal Server Error",
+		}))
+
+		retried, err := pgStorage.Claim(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, retried, 1)
+		assert.EqualValues(t, 1, retried[0].Attempts)
+
+		require.NoError(t, pgStorage.MarkDelivered(ctx, retried[0].ID, 204))
+
+		stats, err := pgStorage.Stats(ctx, created.ID)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, stats.Delivered)
+		assert.EqualValues(t, 2, stats.Attempts)
+		assert.Equal(t, "unexpected response status: 500 Internal Server Error", stats.LastError)
+		assert.NotNil(t, stats.LastErrorAt)
+		assert.NotNil(t, stats.LastDeliveredAt)
+	})
+
+	t.Run("Delete a subscription", func(t *testing.T) {
+		require.NoError(t, pgStorage.Delete(ctx, created.ID))
+		assert.ErrorIs(t, pgStorage.Delete(ctx, created.ID), webhooks.ErrNotFound)
+
+		_, err := pgStorage.Stats(ctx, created.ID)
+		assert.ErrorIs(t, err, webhooks.ErrNotFound)
+	})
+}
@@ -0,0 +1,210 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/gorilla/schema"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/api"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+//go:generate go run go.uber.org/mock/mockgen -typed -source=transport.go -destination ./transport_mock_test.go -package webhooks_test -mock_names Service=MockService
+
+// Service is used to manipulate webhook subscriptions
+type Service interface {
+	Get(ctx context.Context, id uint) (Subscription, Stats, *service.Error)
+	List(ctx context.Context, limit uint, offset uint) ([]Subscription, service.Pagination, *service.Error)
+	Create(ctx context.Context, subscription Subscription) (Subscription, *service.Error)
+	Update(ctx context.Context, subscription Subscription) (Subscription, *service.Error)
+	Delete(ctx context.Context, id uint) *service.Error
+}
+
+// Transport is a struct that holds all endpoints for webhooks
+type Transport struct {
+	logger  *slog.Logger
+	service Service
+}
+
+// NewTransport creates a new Transport instance
+func NewTransport(s Service, l *slog.Logger) *Transport {
+	return &Transport{
+		logger:  l,
+		service: s,
+	}
+}
+
+// Routes initialize all endpoints for route /webhooks
+func (t *Transport) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", t.listWebhooks)
+	r.Post("/", t.createWebhook)
+	r.Route("/{webhook_id}", func(r chi.Router) {
+		r.Get("/", t.getWebhook)
+		r.Put("/", t.updateWebhook)
+		r.Delete("/", t.deleteWebhook)
+	})
+
+	return r
+}
+
+// listWebhooks is an endpoint for GET /webhooks method
+func (t *Transport) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	var listReq ListWebhooksRequest
+	if err := schema.NewDecoder().Decode(&listReq, r.URL.Query()); err != nil {
+		t.logger.Error("failed to decode request params", slog.Any("err", err))
+		_ = render.Render(w, r, api.ErrBadRequest(err))
+		return
+	}
+
+	subscriptions, pagination, svcErr := t.service.List(r.Context(), listReq.Limit, listReq.Offset)
+	if svcErr != nil {
+		t.logger.Error("failed to list webhooks", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	render.JSON(w, r, &ListWebhooksResponse{
+		Webhooks:   convertToListWebhooksResponse(subscriptions),
+		Pagination: pagination,
+	})
+}
+
+// getWebhook is an endpoint for GET /webhooks/{webhook_id} method
+func (t *Transport) getWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID, svcErr := parseWebhookID(r)
+	if svcErr != nil {
+		t.logger.Error("failed to parse webhook id", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	subscription, stats, svcErr := t.service.Get(r.Context(), webhookID)
+	if svcErr != nil {
+		t.logger.Error("failed to get webhook", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	response := convertToWebhookResponse(subscription)
+	response.Stats = convertToStatsResponse(stats)
+
+	render.JSON(w, r, response)
+}
+
+// createWebhook is an endpoint for POST /webhooks method
+func (t *Transport) createWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookReq, ok := t.decodeWebhookRequest(w, r)
+	if !ok {
+		return
+	}
+
+	subscription, svcErr := t.service.Create(r.Context(), convertToSubscription(0, webhookReq))
+	if svcErr != nil {
+		t.logger.Error("failed to create webhook", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	render.JSON(w, r, convertToWebhookResponse(subscription))
+}
+
+// updateWebhook is an endpoint for PUT /webhooks/{webhook_id} method
+func (t *Transport) updateWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID, svcErr := parseWebhookID(r)
+	if svcErr != nil {
+		t.logger.Error("failed to parse webhook id", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	webhookReq, ok := t.decodeWebhookRequest(w, r)
+	if !ok {
+		return
+	}
+
+	subscription, svcErr := t.service.Update(r.Context(), convertToSubscription(webhookID, webhookReq))
+	if svcErr != nil {
+		t.logger.Error("failed to update webhook", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	render.JSON(w, r, convertToWebhookResponse(subscription))
+}
+
+// deleteWebhook is an endpoint for DELETE /webhooks/{webhook_id} method
+func (t *Transport) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID, svcErr := parseWebhookID(r)
+	if svcErr != nil {
+		t.logger.Error("failed to parse webhook id", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	if svcErr = t.service.Delete(r.Context(), webhookID); svcErr != nil {
+		t.logger.Error("failed to delete webhook", slog.Any("svc_err", svcErr))
+		_ = render.Render(w, r, api.NewErrResponse(svcErr))
+		return
+	}
+
+	render.NoContent(w, r)
+}
+
+// decodeWebhookRequest decodes and validates WebhookRequest. It renders an error response on failure.
+func (t *Transport) decodeWebhookRequest(w http.ResponseWriter, r *http.Request) (WebhookRequest, bool) {
+	var webhookReq WebhookRequest
+	if err := render.Decode(r, &webhookReq); err != nil {
+		t.logger.Error("failed to decode request params", slog.Any("err", err))
+		_ = render.Render(w, r, api.ErrBadRequest(err))
+		return WebhookRequest{}, false
+	}
+
+	if validationErr := webhookReq.Validate(); validationErr != nil {
+		t.logger.Info("request is not valid", slog.Any("validation_err", validationErr))
+		_ = render.Render(w, r, api.ErrBadRequest(validationErr))
+		return WebhookRequest{}, false
+	}
+
+	return webhookReq, true
+}
+
+// convertToSubscription is used to map WebhookRequest -> Subscription
+func convertToSubscription(id uint, webhookReq WebhookRequest) Subscription {
+	active := true
+	if webhookReq.Active != nil {
+		active = *webhookReq.Active
+	}
+
+	return Subscription{
+		ID:     id,
+		URL:    webhookReq.URL,
+		Secret: webhookReq.Secret,
+		Events: webhookReq.Events,
+		Active: active,
+	}
+}
+
+func parseWebhookID(r *http.Request) (uint, *service.Error) {
+	id, err := strconv.Atoi(chi.URLParam(r, "webhook_id"))
+	switch {
+	case err != nil:
+		return 0, &service.Error{
+			Type: service.BadRequest,
+			Base: err,
+		}
+	case id <= 0:
+		return 0, &service.Error{
+			Type: service.BadRequest,
+			Base: fmt.Errorf("invalid id param: %d", id),
+		}
+	default:
+		return uint(id), nil
+	}
+}
@@ -0,0 +1,305 @@
+package webhooks_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"go.uber.org/mock/gomock"
+
+	"github.com/titusjaka/go-sample/v2/internal/business/snippets"
+	"github.com/titusjaka/go-sample/v2/internal/business/webhooks"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+func newExpect(t *testing.T, mockService *MockService) *httpexpect.Expect {
+	t.Helper()
+
+	transport := webhooks.NewTransport(mockService, nopslog.NewNoplogger())
+
+	return httpexpect.WithConfig(httpexpect.Config{
+		Client: &http.Client{
+			Transport: httpexpect.NewBinder(transport.Routes()),
+		},
+		Reporter: httpexpect.NewAssertReporter(t),
+	})
+}
+
+func TestTransport_listWebhooks(t *testing.T) {
+	t.Parallel()
+
+	// ================================================
+	// Init mocks and service
+	ctrl := gomock.NewController(t)
+
+	mockService := NewMockService(ctrl)
+	expect := newExpect(t, mockService)
+
+	// ================================================
+	// Init test data
+	fakeTime := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	list := []webhooks.Subscription{
+		{
+			ID:        1,
+			URL:       "https://example.com/hooks",
+			Events:    []events.Type{snippets.EventCreated},
+			Active:    true,
+			CreatedAt: fakeTime,
+			UpdatedAt: fakeTime,
+		},
+	}
+
+	pagination := service.Pagination{
+		Limit:       10,
+		Offset:      0,
+		Total:       1,
+		TotalPages:  1,
+		CurrentPage: 1,
+	}
+
+	// ================================================
+	// Describe mock calls
+	mockService.EXPECT().List(gomock.Any(), uint(10), uint(0)).Return(list, pagination, nil)
+
+	// ================================================
+	// Run test
+	expect.GET("/").
+		WithQuery("limit", 10).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().IsEqual(webhooks.ListWebhooksResponse{
+		Webhooks: []webhooks.WebhookResponse{
+			{
+				ID:        1,
+				URL:       "https://example.com/hooks",
+				Events:    []events.Type{snippets.EventCreated},
+				Active:    true,
+				CreatedAt: fakeTime,
+				UpdatedAt: fakeTime,
+			},
+		},
+		Pagination: pagination,
+	})
+}
+
+func TestTransport_getWebhook(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Successfully get webhook with stats", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		expect := newExpect(t, mockService)
+
+		// ================================================
+		// Init test data
+		fakeTime := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+
+		subscription := webhooks.Subscription{
+			ID:        1,
+			URL:       "https://example.com/hooks",
+			Events:    []events.Type{snippets.EventExpired},
+			CreatedAt: fakeTime,
+			UpdatedAt: fakeTime,
+		}
+
+		stats := webhooks.Stats{
+			Pending:     1,
+			Failed:      2,
+			Attempts:    21,
+			LastError:   "unexpected response status: 502 Bad Gateway",
+			LastErrorAt: &fakeTime,
+		}
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Get(gomock.Any(), uint(1)).Return(subscription, stats, nil)
+
+		// ================================================
+		// Run test
+		expect.GET("/1").
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().IsEqual(map[string]any{
+			"id":         1,
+			"url":        "https://example.com/hooks",
+			"events":     []string{"snippet.expired"},
+			"active":     false,
+			"created_at": fakeTime,
+			"updated_at": fakeTime,
+			"stats": map[string]any{
+				"pending":       1,
+				"delivered":     0,
+				"failed":        2,
+				"attempts":      21,
+				"last_error":    "unexpected response status: 502 Bad Gateway",
+				"last_error_at": fakeTime,
+			},
+		})
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		expect := newExpect(t, mockService)
+
+		mockService.EXPECT().Get(gomock.Any(), uint(1)).Return(
+			webhooks.Subscription{},
+			webhooks.Stats{},
+			&service.Error{Type: service.NotFound, Base: webhooks.ErrNotFound},
+		)
+
+		expect.GET("/1").
+			Expect().
+			Status(http.StatusNotFound)
+	})
+}
+
+func TestTransport_createWebhook(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Successfully create webhook", func(t *testing.T) {
+		t.Parallel()
+
+		// ================================================
+		// Init mocks and service
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		expect := newExpect(t, mockService)
+
+		fakeTime := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+
+		// ================================================
+		// Describe mock calls
+		mockService.EXPECT().Create(gomock.Any(), webhooks.Subscription{
+			URL:    "https://example.com/hooks",
+			Events: []events.Type{snippets.EventCreated, snippets.EventDeleted},
+			Active: true,
+		}).Return(webhooks.Subscription{
+			ID:        1,
+			URL:       "https://example.com/hooks",
+			Secret:    "generated secret",
+			Events:    []events.Type{snippets.EventCreated, snippets.EventDeleted},
+			Active:    true,
+			CreatedAt: fakeTime,
+			UpdatedAt: fakeTime,
+		}, nil)
+
+		// ================================================
+		// Run test
+		expect.POST("/").
+			WithJSON(webhooks.WebhookRequest{
+				URL:    "https://example.com/hooks",
+				Events: []events.Type{snippets.EventCreated, snippets.EventDeleted},
+			}).
+			Expect().
+			Status(http.StatusOK).
+			JSON().Object().IsEqual(webhooks.WebhookResponse{
+			ID:        1,
+			URL:       "https://example.com/hooks",
+			Secret:    "generated secret",
+			Events:    []events.Type{snippets.EventCreated, snippets.EventDeleted},
+			Active:    true,
+			CreatedAt: fakeTime,
+			UpdatedAt: fakeTime,
+		})
+	})
+
+	t.Run("Bad request: validation error", func(t *testing.T) {
+		t.Parallel()
+
+		ctrl := gomock.NewController(t)
+
+		mockService := NewMockService(ctrl)
+		expect := newExpect(t, mockService)
+
+		expect.POST("/").
+			WithJSON(webhooks.WebhookRequest{
+				URL:    "mailto:admin@example.com",
+				Events: []events.Type{snippets.EventCreated},
+			}).
+			Expect().
+			Status(http.StatusBadRequest).
+			JSON().Object().IsEqual(map[string]any{
+			"error": "url: must be an absolute http or https URL.",
+		})
+	})
+}
+
+func TestTransport_updateWebhook(t *testing.T) {
+	t.Parallel()
+
+	// ================================================
+	// Init mocks and service
+	ctrl := gomock.NewController(t)
+
+	mockService := NewMockService(ctrl)
+	expect := newExpect(t, mockService)
+
+	fakeTime := time.Date(2020, 10, 7, 12, 0, 0, 0, time.UTC)
+	inactive := false
+
+	// ================================================
+	// Describe mock calls
+	mockService.EXPECT().Update(gomock.Any(), webhooks.Subscription{
+		ID:     1,
+		URL:    "https://example.com/hooks",
+		Events: []events.Type{snippets.EventExpired},
+		Active: false,
+	}).Return(webhooks.Subscription{
+		ID:        1,
+		URL:       "https://example.com/hooks",
+		Events:    []events.Type{snippets.EventExpired},
+		CreatedAt: fakeTime,
+		UpdatedAt: fakeTime,
+	}, nil)
+
+	// ================================================
+	// Run test
+	expect.PUT("/1").
+		WithJSON(webhooks.WebhookRequest{
+			URL:    "https://example.com/hooks",
+			Events: []events.Type{snippets.EventExpired},
+			Active: &inactive,
+		}).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().IsEqual(webhooks.WebhookResponse{
+		ID:        1,
+		URL:       "https://example.com/hooks",
+		Events:    []events.Type{snippets.EventExpired},
+		CreatedAt: fakeTime,
+		UpdatedAt: fakeTime,
+	})
+}
+
+func TestTransport_deleteWebhook(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+
+	mockService := NewMockService(ctrl)
+	expect := newExpect(t, mockService)
+
+	mockService.EXPECT().Delete(gomock.Any(), uint(1)).Return(nil)
+
+	expect.DELETE("/1").
+		Expect().
+		Status(http.StatusNoContent)
+
+	expect.DELETE("/abc").
+		Expect().
+		Status(http.StatusBadRequest)
+}
@@ -0,0 +1,56 @@
+package webhooks
+
+import (
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+)
+
+// Subscription model struct. Events of the listed types are delivered to URL signed with Secret.
+type Subscription struct {
+	ID        uint
+	URL       string
+	Secret    string
+	Events    []events.Type
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Stats describes deliveries of a subscription
+type Stats struct {
+	Pending   uint
+	Delivered uint
+	Failed    uint
+	// Attempts is a total number of delivery attempts, including retries
+	Attempts uint
+
+	LastError       string
+	LastErrorAt     *time.Time
+	LastDeliveredAt *time.Time
+}
+
+// Delivery is a single event queued for a single subscription
+type Delivery struct {
+	ID             uint64
+	SubscriptionID uint
+	URL            string
+	Secret         string
+	EventID        string
+	EventType      events.Type
+	// Payload is a JSON-encoded event sent as a request body
+	Payload []byte
+	// Attempts is a number of previous delivery attempts
+	Attempts uint
+}
+
+// Failure describes a failed delivery attempt
+type Failure struct {
+	// StatusCode is a response status code, 0 if no response was received
+	StatusCode int
+	Error      string
+	// RetryIn is a delay before the next attempt, ignored if Final is set
+	RetryIn time.Duration
+	// Final means the delivery is given up and won't be retried
+	Final bool
+}
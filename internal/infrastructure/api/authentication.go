@@ -2,11 +2,14 @@ package api
 
 import (
 	"context"
+	"crypto/subtle"
 	"log/slog"
 	"net/http"
 	"strings"
 
 	"github.com/go-chi/render"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
 )
 
 type authenticatorKey int
@@ -31,9 +34,18 @@ func AuthorizationHeader(next http.Handler) http.Handler {
 	})
 }
 
+// DefaultCaller is the name of the caller authenticated with the shared token of InternalCommunication.
+const DefaultCaller = "default"
+
 // InternalCommunication performs bearer authentication with provided token
 // for internal service communication purposes.
 func InternalCommunication(token string, logger *slog.Logger) func(next http.Handler) http.Handler {
+	return Callers(map[string]string{DefaultCaller: token}, logger)
+}
+
+// Callers performs bearer authentication with tokens of named callers, tokens are keyed by caller names.
+// The name of the authenticated caller is placed in the context, see service.CallerFromContext.
+func Callers(tokens map[string]string, logger *slog.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			logError := func() {
@@ -46,14 +58,24 @@ func InternalCommunication(token string, logger *slog.Logger) func(next http.Han
 				)
 			}
 
-			if v, ok := r.Context().Value(AuthorizationHeaderKey).(string); !ok || v != token {
+			v, ok := r.Context().Value(AuthorizationHeaderKey).(string)
+			if !ok {
 				logError()
 
 				_ = render.Render(w, r, ErrUnauthorized())
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			for caller, token := range tokens {
+				if subtle.ConstantTimeCompare([]byte(v), []byte(token)) == 1 {
+					next.ServeHTTP(w, r.WithContext(service.ContextWithCaller(r.Context(), caller)))
+					return
+				}
+			}
+
+			logError()
+
+			_ = render.Render(w, r, ErrUnauthorized())
 		})
 	}
 }
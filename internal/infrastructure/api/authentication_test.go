@@ -13,6 +13,7 @@ import (
 
 	"github.com/titusjaka/go-sample/v2/internal/infrastructure/api"
 	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
 )
 
 func TestApi_InternalCommunication(t *testing.T) {
@@ -115,3 +116,56 @@ func TestApi_InternalCommunication(t *testing.T) {
 		})
 	})
 }
+
+func TestApi_Callers(t *testing.T) {
+	tokens := map[string]string{
+		"ci":   "12345",
+		"cron": "67890",
+	}
+
+	router := chi.NewRouter()
+	router.Use(render.SetContentType(render.ContentTypeJSON))
+	router.Use(api.AuthorizationHeader)
+	router.Use(api.Callers(tokens, nopslog.NewNoplogger()))
+
+	router.Get("/", func(w http.ResponseWriter, req *http.Request) {
+		caller, ok := service.CallerFromContext(req.Context())
+		assert.True(t, ok)
+		_, _ = w.Write([]byte(caller))
+	})
+
+	tests := []struct {
+		name           string
+		token          string
+		expectedStatus int
+		expectedCaller string
+	}{
+		{name: "First caller", token: "12345", expectedStatus: http.StatusOK, expectedCaller: "ci"},
+		{name: "Second caller", token: "67890", expectedStatus: http.StatusOK, expectedCaller: "cron"},
+		{name: "Unknown token", token: "wrong token", expectedStatus: http.StatusUnauthorized},
+		{name: "Empty auth header", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest(http.MethodGet, "/", nil)
+			require.NoError(t, err)
+			if tt.token != "" {
+				request.Header.Add("Authorization", "Bearer "+tt.token)
+			}
+
+			recorder := httptest.NewRecorder()
+
+			router.ServeHTTP(recorder, request)
+			result := recorder.Result()
+			defer func() {
+				require.NoError(t, result.Body.Close())
+			}()
+
+			assert.Equal(t, tt.expectedStatus, result.StatusCode)
+			if tt.expectedCaller != "" {
+				assert.Equal(t, tt.expectedCaller, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,356 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/encryption"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying a client-generated idempotency key.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed from the idempotency store.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+
+	// defaultMaxIdempotentRequestSize fits a batch of 1000 snippets with the longest content
+	defaultMaxIdempotentRequestSize = 32 << 20
+)
+
+// errPassphraseMismatch is returned by open if the stored response is sealed with a different passphrase,
+// or with none, than the one of the replayed request
+var errPassphraseMismatch = errors.New("stored response is sealed with a different passphrase")
+
+// Flags of the first byte of a stored response body, they tell how the rest of it is sealed.
+const (
+	sealedWithPassphrase byte = 1 << iota
+	sealedWithEnvelope
+)
+
+// IdempotencyRecord is a stored result of a request made with an idempotency key.
+// A zero StatusCode means that the original request is still in progress.
+type IdempotencyRecord struct {
+	RequestHash []byte
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// IdempotencyStore persists idempotency keys and responses.
+type IdempotencyStore interface {
+	// Lock reserves the key for a request with the given hash.
+	// If the key is already taken (and not expired), the existing record is returned.
+	Lock(ctx context.Context, key string, requestHash []byte) (*IdempotencyRecord, error)
+	// Save stores the response of the request that holds the key.
+	Save(ctx context.Context, key string, record IdempotencyRecord) error
+	// Release removes the key, so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// IdempotencyOption configures optional parameters of the Idempotency middleware.
+type IdempotencyOption func(*idempotency)
+
+// WithResponseEnvelope encrypts stored responses with the envelope, so they never get into the store in clear.
+func WithResponseEnvelope(envelope *encryption.Envelope) IdempotencyOption {
+	return func(i *idempotency) {
+		i.envelope = envelope
+	}
+}
+
+// WithPassphraseHeader binds keys to the value of the header. Responses of requests that have the header
+// are encrypted with a key derived from its value, so content of protected resources isn't stored in clear.
+// Neither the value nor a fast hash of it is stored: a retry with a different value fails to open the response.
+func WithPassphraseHeader(header string) IdempotencyOption {
+	return func(i *idempotency) {
+		i.passphraseHeader = header
+	}
+}
+
+// WithMaxRequestSize limits the size of request bodies read to hash them, larger requests result in 413.
+func WithMaxRequestSize(size int64) IdempotencyOption {
+	return func(i *idempotency) {
+		i.maxRequestSize = size
+	}
+}
+
+type idempotency struct {
+	store  IdempotencyStore
+	logger *slog.Logger
+
+	envelope         *encryption.Envelope
+	passphraseHeader string
+	maxRequestSize   int64
+}
+
+// Idempotency makes POST requests with the "Idempotency-Key" header idempotent:
+// the response of the first request is stored and replayed on retries.
+// Keys are scoped to the authenticated caller, see service.CallerFromContext.
+// Reusing the same key with a different request results in 422,
+// a retry sent while the first request is in progress results in 409.
+// Server errors, 429 and 409 responses are not stored, so such requests can be retried.
+func Idempotency(store IdempotencyStore, logger *slog.Logger, opts ...IdempotencyOption) func(next http.Handler) http.Handler {
+	i := &idempotency{
+		store:          store,
+		logger:         logger,
+		maxRequestSize: defaultMaxIdempotentRequestSize,
+	}
+
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				_ = render.Render(w, r, ErrBadRequest(errors.New("idempotency key is too long")))
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, i.maxRequestSize))
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					_ = render.Render(w, r, ErrRequestTooLarge(errors.New("request body is too large")))
+					return
+				}
+				_ = render.Render(w, r, ErrBadRequest(err))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			key = scopeKey(r.Context(), key)
+			requestHash := hashRequest(r, body)
+
+			record, err := i.store.Lock(r.Context(), key, requestHash)
+			if err != nil {
+				logger.Error("failed to lock idempotency key", slog.Any("err", err))
+				_ = render.Render(w, r, ErrInternal(errors.New("internal error")))
+				return
+			}
+
+			if record != nil {
+				i.replay(w, r, requestHash, record)
+				return
+			}
+
+			i.serve(w, r, next, key, requestHash)
+		})
+	}
+}
+
+// serve runs the request that holds the key and stores its response.
+func (i *idempotency) serve(w http.ResponseWriter, r *http.Request, next http.Handler, key string, requestHash []byte) {
+	var buf bytes.Buffer
+
+	ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+	ww.Tee(&buf)
+
+	// The response is already sent, the key must be saved or released anyway.
+	ctx := context.WithoutCancel(r.Context())
+
+	defer func() {
+		if rvr := recover(); rvr != nil {
+			i.release(ctx, key)
+			panic(rvr)
+		}
+	}()
+
+	next.ServeHTTP(ww, r)
+
+	status := ww.Status()
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if !storable(status) {
+		i.release(ctx, key)
+		return
+	}
+
+	body, err := i.seal(r, buf.Bytes())
+	if err != nil {
+		// The client got the response, a retry can't be replayed and runs the request again
+		i.logger.Error("failed to seal idempotent response", slog.Any("err", err))
+		i.release(ctx, key)
+		return
+	}
+
+	err = i.store.Save(ctx, key, IdempotencyRecord{
+		RequestHash: requestHash,
+		StatusCode:  status,
+		ContentType: ww.Header().Get("Content-Type"),
+		Body:        body,
+	})
+	if err != nil {
+		i.logger.Error("failed to save idempotent response", slog.Any("err", err))
+	}
+}
+
+// storable reports whether a response with the status is replayed on retries. Server errors, exceeded limits
+// and conflicts with concurrent requests are transient: the same request may succeed later.
+func storable(status int) bool {
+	return status < http.StatusInternalServerError &&
+		status != http.StatusTooManyRequests &&
+		status != http.StatusConflict
+}
+
+func (i *idempotency) release(ctx context.Context, key string) {
+	if err := i.store.Release(ctx, key); err != nil {
+		i.logger.Error("failed to release idempotency key", slog.Any("err", err))
+	}
+}
+
+func (i *idempotency) replay(w http.ResponseWriter, r *http.Request, requestHash []byte, record *IdempotencyRecord) {
+	switch {
+	case !bytes.Equal(record.RequestHash, requestHash):
+		_ = render.Render(w, r, ErrUnprocessableEntity(
+			errors.New("idempotency key is already used with a different request"),
+		))
+	case record.StatusCode == 0:
+		_ = render.Render(w, r, ErrConflict(
+			errors.New("request with the same idempotency key is in progress"),
+		))
+	default:
+		body, err := i.open(r, record.Body)
+		if errors.Is(err, errPassphraseMismatch) {
+			_ = render.Render(w, r, ErrUnprocessableEntity(
+				errors.New("idempotency key is already used with a different request"),
+			))
+			return
+		}
+		if err != nil {
+			i.logger.Error("failed to open idempotent response", slog.Any("err", err))
+			_ = render.Render(w, r, ErrInternal(errors.New("internal error")))
+			return
+		}
+
+		if record.ContentType != "" {
+			w.Header().Set("Content-Type", record.ContentType)
+		}
+		w.Header().Set(IdempotentReplayedHeader, "true")
+		w.WriteHeader(record.StatusCode)
+		_, _ = w.Write(body)
+	}
+}
+
+// seal encrypts the response body with a key derived from the passphrase of the request, if there's one,
+// and then with the envelope, if it's configured. The first byte of the result tells which of them are used.
+func (i *idempotency) seal(r *http.Request, body []byte) ([]byte, error) {
+	var flags byte
+
+	if passphrase := i.passphrase(r); passphrase != "" {
+		salt, err := encryption.NewSalt()
+		if err != nil {
+			return nil, fmt.Errorf("generate salt: %w", err)
+		}
+
+		sealed, err := encryption.Seal(encryption.DeriveKey(passphrase, salt), body)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt with passphrase: %w", err)
+		}
+
+		body = append(salt, sealed...)
+		flags |= sealedWithPassphrase
+	}
+
+	if i.envelope != nil {
+		ciphertext, wrappedKey, err := i.envelope.Seal(body)
+		if err != nil {
+			return nil, err
+		}
+
+		body = append(append([]byte{byte(len(wrappedKey))}, wrappedKey...), ciphertext...)
+		flags |= sealedWithEnvelope
+	}
+
+	return append([]byte{flags}, body...), nil
+}
+
+// open reverses seal
+func (i *idempotency) open(r *http.Request, body []byte) ([]byte, error) {
+	if len(body) == 0 {
+		return nil, errors.New("stored response is empty")
+	}
+
+	flags, body := body[0], body[1:]
+
+	passphrase := i.passphrase(r)
+	if (flags&sealedWithPassphrase != 0) != (passphrase != "") {
+		return nil, errPassphraseMismatch
+	}
+
+	if flags&sealedWithEnvelope != 0 {
+		if i.envelope == nil {
+			return nil, errors.New("stored response is encrypted, but no envelope is configured")
+		}
+		if len(body) == 0 || len(body) < 1+int(body[0]) {
+			return nil, errors.New("stored response is corrupted")
+		}
+
+		wrappedKey, ciphertext := body[1:1+int(body[0])], body[1+int(body[0]):]
+
+		var err error
+		if body, err = i.envelope.Open(ciphertext, wrappedKey); err != nil {
+			return nil, err
+		}
+	}
+
+	if flags&sealedWithPassphrase != 0 {
+		if len(body) < encryption.SaltSize {
+			return nil, errors.New("stored response is corrupted")
+		}
+
+		salt, sealed := body[:encryption.SaltSize], body[encryption.SaltSize:]
+
+		var err error
+		if body, err = encryption.Open(encryption.DeriveKey(passphrase, salt), sealed); err != nil {
+			return nil, fmt.Errorf("%w: %w", errPassphraseMismatch, err)
+		}
+	}
+
+	return body, nil
+}
+
+func (i *idempotency) passphrase(r *http.Request) string {
+	if i.passphraseHeader == "" {
+		return ""
+	}
+
+	return r.Header.Get(i.passphraseHeader)
+}
+
+// hashRequest binds an idempotency key to the method, path and body of the request.
+// The passphrase is left out: requests often have no body, so a stored hash of it could be brute-forced offline.
+// It's bound by the encryption of the stored response instead, see open.
+func hashRequest(r *http.Request, body []byte) []byte {
+	h := sha256.New()
+	_, _ = io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	_, _ = h.Write(body)
+	return h.Sum(nil)
+}
+
+// scopeKey prefixes the key with the name of the caller, so callers can't replay responses of each other.
+// The length of the name is a part of the prefix, so names and keys with colons can't be mixed up.
+func scopeKey(ctx context.Context, key string) string {
+	caller, _ := service.CallerFromContext(ctx)
+	return strconv.Itoa(len(caller)) + ":" + caller + ":" + key
+}
@@ -0,0 +1,367 @@
+package api_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/api"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/encryption"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/service"
+)
+
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]api.IdempotencyRecord
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]api.IdempotencyRecord)}
+}
+
+func (s *memoryIdempotencyStore) Lock(_ context.Context, key string, requestHash []byte) (*api.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok {
+		return &record, nil
+	}
+
+	s.records[key] = api.IdempotencyRecord{RequestHash: requestHash}
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Save(_ context.Context, key string, record api.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = record
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+func TestApi_Idempotency(t *testing.T) {
+	t.Parallel()
+
+	newRouter := func(store api.IdempotencyStore, status int, calls *int) *chi.Mux {
+		router := chi.NewRouter()
+		router.Use(render.SetContentType(render.ContentTypeJSON))
+		router.Use(api.Idempotency(store, nopslog.NewNoplogger()))
+
+		router.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			*calls++
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_, _ = w.Write(body)
+		})
+
+		return router
+	}
+
+	doRequest := func(t *testing.T, router http.Handler, key, body string) *http.Response {
+		t.Helper()
+
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if key != "" {
+			request.Header.Set(api.IdempotencyKeyHeader, key)
+		}
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		return recorder.Result()
+	}
+
+	readBody := func(t *testing.T, response *http.Response) string {
+		t.Helper()
+
+		defer func() {
+			require.NoError(t, response.Body.Close())
+		}()
+
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	t.Run("Replay the original response", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		router := newRouter(newMemoryIdempotencyStore(), http.StatusCreated, &calls)
+
+		first := doRequest(t, router, "key-1", `{"id":1}`)
+		assert.Equal(t, http.StatusCreated, first.StatusCode)
+		assert.Equal(t, `{"id":1}`, readBody(t, first))
+
+		second := doRequest(t, router, "key-1", `{"id":1}`)
+		assert.Equal(t, http.StatusCreated, second.StatusCode)
+		assert.Equal(t, "true", second.Header.Get(api.IdempotentReplayedHeader))
+		assert.Equal(t, "application/json", second.Header.Get("Content-Type"))
+		assert.Equal(t, `{"id":1}`, readBody(t, second))
+
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Key reused with a different body", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		router := newRouter(newMemoryIdempotencyStore(), http.StatusCreated, &calls)
+
+		first := doRequest(t, router, "key-1", `{"id":1}`)
+		assert.Equal(t, http.StatusCreated, first.StatusCode)
+		_ = readBody(t, first)
+
+		second := doRequest(t, router, "key-1", `{"id":2}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, second.StatusCode)
+		_ = readBody(t, second)
+
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Request in progress", func(t *testing.T) {
+		t.Parallel()
+
+		var inProgress *http.Response
+
+		router := chi.NewRouter()
+		router.Use(api.Idempotency(newMemoryIdempotencyStore(), nopslog.NewNoplogger()))
+
+		// The retry is sent while the original request still holds the key.
+		router.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			if inProgress == nil {
+				inProgress = doRequest(t, router, "key-1", `{"id":1}`)
+			}
+			w.WriteHeader(http.StatusCreated)
+		})
+
+		response := doRequest(t, router, "key-1", `{"id":1}`)
+		assert.Equal(t, http.StatusCreated, response.StatusCode)
+		_ = readBody(t, response)
+
+		require.NotNil(t, inProgress)
+		assert.Equal(t, http.StatusConflict, inProgress.StatusCode)
+		_ = readBody(t, inProgress)
+	})
+
+	t.Run("Transient errors are not stored", func(t *testing.T) {
+		t.Parallel()
+
+		for _, status := range []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusConflict} {
+			t.Run(http.StatusText(status), func(t *testing.T) {
+				t.Parallel()
+
+				var calls int
+				router := newRouter(newMemoryIdempotencyStore(), status, &calls)
+
+				for range 2 {
+					response := doRequest(t, router, "key-1", `{"id":1}`)
+					assert.Equal(t, status, response.StatusCode)
+					assert.Empty(t, response.Header.Get(api.IdempotentReplayedHeader))
+					_ = readBody(t, response)
+				}
+
+				assert.Equal(t, 2, calls)
+			})
+		}
+	})
+
+	t.Run("Requests without key are passed through", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		router := newRouter(newMemoryIdempotencyStore(), http.StatusCreated, &calls)
+
+		for range 2 {
+			response := doRequest(t, router, "", `{"id":1}`)
+			assert.Equal(t, http.StatusCreated, response.StatusCode)
+			assert.Empty(t, response.Header.Get(api.IdempotentReplayedHeader))
+			_ = readBody(t, response)
+		}
+
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("Key is too long", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		router := newRouter(newMemoryIdempotencyStore(), http.StatusCreated, &calls)
+
+		response := doRequest(t, router, strings.Repeat("k", 256), `{"id":1}`)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+		_ = readBody(t, response)
+
+		assert.Zero(t, calls)
+	})
+}
+
+func TestApi_IdempotencyProtectedResponses(t *testing.T) {
+	t.Parallel()
+
+	const passphraseHeader = "X-Passphrase"
+
+	kek, err := encryption.NewKey()
+	require.NoError(t, err)
+	envelope, err := encryption.NewEnvelope(kek)
+	require.NoError(t, err)
+
+	newRouter := func(store api.IdempotencyStore, calls *int, opts ...api.IdempotencyOption) *chi.Mux {
+		router := chi.NewRouter()
+		router.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				caller := r.Header.Get("X-Caller")
+				next.ServeHTTP(w, r.WithContext(service.ContextWithCaller(r.Context(), caller)))
+			})
+		})
+		router.Use(api.Idempotency(store, nopslog.NewNoplogger(), opts...))
+
+		router.Post("/", func(w http.ResponseWriter, r *http.Request) {
+			*calls++
+			body, _ := io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write(body)
+		})
+
+		return router
+	}
+
+	doRequest := func(t *testing.T, router http.Handler, caller, passphrase, body string) (int, string) {
+		t.Helper()
+
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		request.Header.Set(api.IdempotencyKeyHeader, "key-1")
+		request.Header.Set("X-Caller", caller)
+		if passphrase != "" {
+			request.Header.Set(passphraseHeader, passphrase)
+		}
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		return recorder.Code, recorder.Body.String()
+	}
+
+	t.Run("Keys are scoped to the caller", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		router := newRouter(newMemoryIdempotencyStore(), &calls)
+
+		for _, caller := range []string{"alice", "bob", "alice"} {
+			status, body := doRequest(t, router, caller, "", `{"content":"secret"}`)
+			assert.Equal(t, http.StatusCreated, status)
+			assert.Equal(t, `{"content":"secret"}`, body)
+		}
+
+		assert.Equal(t, 2, calls, "bob doesn't get the response of alice")
+	})
+
+	t.Run("Responses aren't stored in clear", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name       string
+			passphrase string
+			opts       []api.IdempotencyOption
+		}{
+			{name: "envelope", opts: []api.IdempotencyOption{api.WithResponseEnvelope(envelope)}},
+			{name: "passphrase", passphrase: "pa$$word", opts: []api.IdempotencyOption{api.WithPassphraseHeader(passphraseHeader)}},
+			{name: "passphrase and envelope", passphrase: "pa$$word", opts: []api.IdempotencyOption{
+				api.WithPassphraseHeader(passphraseHeader),
+				api.WithResponseEnvelope(envelope),
+			}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				var calls int
+				store := newMemoryIdempotencyStore()
+				router := newRouter(store, &calls, tt.opts...)
+
+				for range 2 {
+					status, body := doRequest(t, router, "alice", tt.passphrase, `{"content":"secret"}`)
+					assert.Equal(t, http.StatusCreated, status)
+					assert.Equal(t, `{"content":"secret"}`, body)
+				}
+				assert.Equal(t, 1, calls)
+
+				require.Len(t, store.records, 1)
+				for _, record := range store.records {
+					assert.NotContains(t, string(record.Body), "secret")
+				}
+			})
+		}
+	})
+
+	t.Run("Key reused with a different passphrase", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		router := newRouter(newMemoryIdempotencyStore(), &calls, api.WithPassphraseHeader(passphraseHeader))
+
+		status, _ := doRequest(t, router, "alice", "first", `{"content":"secret"}`)
+		assert.Equal(t, http.StatusCreated, status)
+
+		for _, passphrase := range []string{"second", ""} {
+			status, body := doRequest(t, router, "alice", passphrase, `{"content":"secret"}`)
+			assert.Equal(t, http.StatusUnprocessableEntity, status)
+			assert.NotContains(t, body, "secret")
+		}
+
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Passphrases don't get into request hashes", func(t *testing.T) {
+		t.Parallel()
+
+		var hashes [][]byte
+		for _, passphrase := range []string{"first", "second"} {
+			var calls int
+			store := newMemoryIdempotencyStore()
+			router := newRouter(store, &calls, api.WithPassphraseHeader(passphraseHeader))
+
+			status, _ := doRequest(t, router, "alice", passphrase, "")
+			assert.Equal(t, http.StatusCreated, status)
+
+			require.Len(t, store.records, 1)
+			for _, record := range store.records {
+				hashes = append(hashes, record.RequestHash)
+			}
+		}
+
+		assert.Equal(t, hashes[0], hashes[1])
+	})
+
+	t.Run("Request is too large", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+		router := newRouter(newMemoryIdempotencyStore(), &calls, api.WithMaxRequestSize(8))
+
+		status, _ := doRequest(t, router, "alice", "", `{"content":"secret"}`)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+		assert.Zero(t, calls)
+	})
+}
@@ -66,6 +66,30 @@ func ErrNotFound(err error) *ErrResponse {
 	}
 }
 
+// ErrConflict handler returns the pre-defined 409 schema.
+func ErrConflict(err error) *ErrResponse {
+	return &ErrResponse{
+		Error:      err.Error(),
+		statusCode: http.StatusConflict,
+	}
+}
+
+// ErrUnprocessableEntity handler returns the pre-defined 422 schema.
+func ErrUnprocessableEntity(err error) *ErrResponse {
+	return &ErrResponse{
+		Error:      err.Error(),
+		statusCode: http.StatusUnprocessableEntity,
+	}
+}
+
+// ErrRequestTooLarge handler returns the pre-defined 413 schema.
+func ErrRequestTooLarge(err error) *ErrResponse {
+	return &ErrResponse{
+		Error:      err.Error(),
+		statusCode: http.StatusRequestEntityTooLarge,
+	}
+}
+
 // ErrUnauthorized handler returns the pre-defined 401 schema.
 func ErrUnauthorized() *ErrResponse {
 	return &ErrResponse{
@@ -74,6 +98,22 @@ func ErrUnauthorized() *ErrResponse {
 	}
 }
 
+// ErrTooManyRequests handler returns the pre-defined 429 schema.
+func ErrTooManyRequests(err error) *ErrResponse {
+	return &ErrResponse{
+		Error:      err.Error(),
+		statusCode: http.StatusTooManyRequests,
+	}
+}
+
+// ErrQuotaExceeded handler returns the pre-defined 403 schema with the exceeded quota.
+func ErrQuotaExceeded(err error) *ErrResponse {
+	return &ErrResponse{
+		Error:      err.Error(),
+		statusCode: http.StatusForbidden,
+	}
+}
+
 // NewErrResponse wraps Error into HTTP error response
 func NewErrResponse(err *service.Error) *ErrResponse {
 	internalErr := errors.New("internal error")
@@ -92,6 +132,10 @@ func NewErrResponse(err *service.Error) *ErrResponse {
 		return ErrUnauthorized()
 	case service.NotFound:
 		return ErrNotFound(err.Base)
+	case service.TooManyRequests:
+		return ErrTooManyRequests(err.Base)
+	case service.QuotaExceeded:
+		return ErrQuotaExceeded(err.Base)
 	default:
 		if err.Base != nil {
 			return ErrInternal(err.Base)
@@ -72,6 +72,22 @@ func TestNewErrResponse(t *testing.T) {
 			},
 			expectedStatusCode: http.StatusNotFound,
 		},
+		{
+			name: "TooManyRequests",
+			svcError: &service.Error{
+				Type: service.TooManyRequests,
+				Base: errors.New("tooManyRequests"),
+			},
+			expectedStatusCode: http.StatusTooManyRequests,
+		},
+		{
+			name: "QuotaExceeded",
+			svcError: &service.Error{
+				Type: service.QuotaExceeded,
+				Base: errors.New("quotaExceeded"),
+			},
+			expectedStatusCode: http.StatusForbidden,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
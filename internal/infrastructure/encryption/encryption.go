@@ -0,0 +1,123 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// The parameters below are chosen to match the primitives available in WebCrypto
+// (PBKDF2-HMAC-SHA256 and AES-256-GCM), so clients are able to encrypt or decrypt
+// content on their side using the same salt and passphrase.
+const (
+	// KeySize is the size of AES-256 key in bytes
+	KeySize = 32
+	// SaltSize is the size of a random salt used to derive a key from a passphrase
+	SaltSize = 16
+	// PBKDF2Iterations is the number of PBKDF2 iterations used to derive a key from a passphrase
+	PBKDF2Iterations = 600_000
+)
+
+// ErrDecrypt is returned when data can't be decrypted: either the key is wrong, or the data is corrupted
+var ErrDecrypt = errors.New("unable to decrypt data: wrong key or corrupted data")
+
+// NewKey returns a new random AES-256 key
+func NewKey() ([]byte, error) {
+	return randomBytes(KeySize)
+}
+
+// NewSalt returns a new random salt for DeriveKey
+func NewSalt() ([]byte, error) {
+	return randomBytes(SaltSize)
+}
+
+// DeriveKey derives an AES-256 key from a passphrase using PBKDF2-HMAC-SHA256
+func DeriveKey(passphrase string, salt []byte) []byte {
+	return pbkdf2.Key([]byte(passphrase), salt, PBKDF2Iterations, KeySize, sha256.New)
+}
+
+// Seal encrypts and authenticates plaintext with AES-256-GCM.
+// The result is a random 12-byte nonce followed by the ciphertext and the tag.
+func Seal(key, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := randomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open decrypts data produced by Seal
+func Open(key, sealed []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	return plaintext, nil
+}
+
+// LoadKeyFile reads a base64-encoded AES-256 key from a file
+func LoadKeyFile(path string) ([]byte, error) {
+	content, err := os.ReadFile(path) //nolint:gosec // path is provided by the operator
+	if err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, fmt.Errorf("decode key file: %w", err)
+	}
+
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key size: expected %d bytes, got %d", KeySize, len(key))
+	}
+
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("init AES cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("init GCM: %w", err)
+	}
+
+	return aead, nil
+}
+
+func randomBytes(size int) ([]byte, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("read random bytes: %w", err)
+	}
+	return b, nil
+}
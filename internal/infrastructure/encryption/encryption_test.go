@@ -0,0 +1,145 @@
+package encryption_test
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/encryption"
+)
+
+func TestSealOpen(t *testing.T) {
+	t.Run("Successfully decrypt sealed data", func(t *testing.T) {
+		key, err := encryption.NewKey()
+		require.NoError(t, err)
+
+		sealed, err := encryption.Seal(key, []byte("very secret content"))
+		require.NoError(t, err)
+		assert.NotContains(t, string(sealed), "very secret content")
+
+		plaintext, err := encryption.Open(key, sealed)
+		require.NoError(t, err)
+		assert.Equal(t, "very secret content", string(plaintext))
+	})
+
+	t.Run("Wrong key", func(t *testing.T) {
+		key, err := encryption.NewKey()
+		require.NoError(t, err)
+
+		anotherKey, err := encryption.NewKey()
+		require.NoError(t, err)
+
+		sealed, err := encryption.Seal(key, []byte("very secret content"))
+		require.NoError(t, err)
+
+		_, err = encryption.Open(anotherKey, sealed)
+		require.ErrorIs(t, err, encryption.ErrDecrypt)
+	})
+
+	t.Run("Corrupted data", func(t *testing.T) {
+		key, err := encryption.NewKey()
+		require.NoError(t, err)
+
+		_, err = encryption.Open(key, []byte("short"))
+		require.ErrorIs(t, err, encryption.ErrDecrypt)
+	})
+}
+
+func TestDeriveKey(t *testing.T) {
+	salt, err := encryption.NewSalt()
+	require.NoError(t, err)
+
+	key := encryption.DeriveKey("correct horse battery staple", salt)
+	assert.Len(t, key, encryption.KeySize)
+	assert.Equal(t, key, encryption.DeriveKey("correct horse battery staple", salt))
+	assert.NotEqual(t, key, encryption.DeriveKey("wrong passphrase", salt))
+}
+
+func TestEnvelope(t *testing.T) {
+	t.Run("Successfully decrypt sealed data", func(t *testing.T) {
+		kek, err := encryption.NewKey()
+		require.NoError(t, err)
+
+		envelope, err := encryption.NewEnvelope(kek)
+		require.NoError(t, err)
+
+		ciphertext, wrappedKey, err := envelope.Seal([]byte("very secret content"))
+		require.NoError(t, err)
+
+		plaintext, err := envelope.Open(ciphertext, wrappedKey)
+		require.NoError(t, err)
+		assert.Equal(t, "very secret content", string(plaintext))
+	})
+
+	t.Run("Wrong key encryption key", func(t *testing.T) {
+		kek, err := encryption.NewKey()
+		require.NoError(t, err)
+
+		anotherKEK, err := encryption.NewKey()
+		require.NoError(t, err)
+
+		envelope, err := encryption.NewEnvelope(kek)
+		require.NoError(t, err)
+
+		anotherEnvelope, err := encryption.NewEnvelope(anotherKEK)
+		require.NoError(t, err)
+
+		ciphertext, wrappedKey, err := envelope.Seal([]byte("very secret content"))
+		require.NoError(t, err)
+
+		_, err = anotherEnvelope.Open(ciphertext, wrappedKey)
+		require.ErrorIs(t, err, encryption.ErrDecrypt)
+	})
+
+	t.Run("Invalid key size", func(t *testing.T) {
+		_, err := encryption.NewEnvelope([]byte("short"))
+		require.Error(t, err)
+	})
+}
+
+func TestLoadKeyFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectedErr bool
+	}{
+		{
+			name:    "Valid key",
+			content: base64.StdEncoding.EncodeToString(make([]byte, encryption.KeySize)) + "\n",
+		},
+		{
+			name:        "Invalid base64",
+			content:     "not a base64 string!",
+			expectedErr: true,
+		},
+		{
+			name:        "Invalid key size",
+			content:     base64.StdEncoding.EncodeToString([]byte("short")),
+			expectedErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "key")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			key, err := encryption.LoadKeyFile(path)
+			if tt.expectedErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, key, encryption.KeySize)
+		})
+	}
+
+	t.Run("Missing file", func(t *testing.T) {
+		_, err := encryption.LoadKeyFile(filepath.Join(t.TempDir(), "missing"))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,53 @@
+package encryption
+
+import (
+	"fmt"
+)
+
+// Envelope implements envelope encryption: every piece of data is encrypted with its own random data key,
+// and the data key is encrypted (wrapped) with a single key encryption key (KEK), which never leaves the server.
+type Envelope struct {
+	kek []byte
+}
+
+// NewEnvelope returns a new Envelope using the provided key encryption key
+func NewEnvelope(kek []byte) (*Envelope, error) {
+	if len(kek) != KeySize {
+		return nil, fmt.Errorf("invalid key size: expected %d bytes, got %d", KeySize, len(kek))
+	}
+
+	return &Envelope{kek: kek}, nil
+}
+
+// Seal encrypts plaintext with a new data key and returns the ciphertext and the wrapped data key
+func (e *Envelope) Seal(plaintext []byte) (ciphertext []byte, wrappedKey []byte, err error) {
+	dataKey, err := NewKey()
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate data key: %w", err)
+	}
+
+	if ciphertext, err = Seal(dataKey, plaintext); err != nil {
+		return nil, nil, fmt.Errorf("encrypt data: %w", err)
+	}
+
+	if wrappedKey, err = Seal(e.kek, dataKey); err != nil {
+		return nil, nil, fmt.Errorf("wrap data key: %w", err)
+	}
+
+	return ciphertext, wrappedKey, nil
+}
+
+// Open unwraps the data key and decrypts the ciphertext with it
+func (e *Envelope) Open(ciphertext []byte, wrappedKey []byte) ([]byte, error) {
+	dataKey, err := Open(e.kek, wrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+
+	plaintext, err := Open(dataKey, ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt data: %w", err)
+	}
+
+	return plaintext, nil
+}
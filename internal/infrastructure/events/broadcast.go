@@ -0,0 +1,200 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TypeReset is the type of the event a subscription starts with if it can't be resumed from the requested event:
+// events may have been missed, so the subscriber has to reload the state it follows.
+// The event has no ID, and its data is an empty JSON object.
+const TypeReset Type = "reset"
+
+// ErrUnknownEvent is returned by History when the requested event is unknown or already purged
+var ErrUnknownEvent = errors.New("event is unknown or already purged")
+
+// History returns events written after the given one, it's used to resume subscriptions
+type History interface {
+	// After returns up to limit events written after the event with the given ID,
+	// or ErrUnknownEvent if there's no such event
+	After(ctx context.Context, id string, limit uint) ([]Event, error)
+}
+
+// Settings of Broadcaster subscriptions
+const (
+	subscriptionBufferSize = 64
+	maxReplayedEvents      = 1000
+)
+
+// Broadcaster is a Publisher that fans events out to subscribers. Publish never blocks: a subscriber
+// that can't keep up is dropped and its subscription is closed, so it may resubscribe from the last received event.
+type Broadcaster struct {
+	history History
+
+	mu            sync.Mutex
+	subscriptions map[*Subscription]struct{}
+	closed        bool
+}
+
+// NewBroadcaster returns new instance of Broadcaster. History is optional, without it subscriptions can't be resumed.
+func NewBroadcaster(history History) *Broadcaster {
+	return &Broadcaster{
+		history:       history,
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe returns a subscription to events published from now on. If lastEventID is set,
+// events written after it are delivered first, or a TypeReset event if the subscription can't be resumed from it:
+// the event is unknown, or more than maxReplayedEvents events were written after it.
+func (b *Broadcaster) Subscribe(ctx context.Context, lastEventID string) (*Subscription, error) {
+	sub := &Subscription{
+		broadcaster: b,
+		replayed:    make(map[string]struct{}),
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		sub.events = make(chan Event)
+		close(sub.events)
+		return sub, nil
+	}
+	// Events published while the history is read are buffered in sub.pending
+	b.subscriptions[sub] = struct{}{}
+	b.mu.Unlock()
+
+	var backlog []Event
+	if lastEventID != "" {
+		var err error
+		if b.history != nil {
+			// One more event is read to tell a full backlog from a truncated one
+			backlog, err = b.history.After(ctx, lastEventID, maxReplayedEvents+1)
+		}
+
+		switch {
+		case b.history == nil, errors.Is(err, ErrUnknownEvent), err == nil && len(backlog) > maxReplayedEvents:
+			backlog = []Event{{Type: TypeReset, OccurredAt: time.Now().UTC(), Data: json.RawMessage("{}")}}
+		case err != nil:
+			sub.Close()
+			return nil, fmt.Errorf("failed to get events after %s: %w", lastEventID, err)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub.events = make(chan Event, len(backlog)+subscriptionBufferSize)
+	for _, event := range backlog {
+		if event.ID != "" {
+			sub.replayed[event.ID] = struct{}{}
+		}
+		sub.events <- event
+	}
+
+	pending := sub.pending
+	sub.pending = nil
+	if _, ok := b.subscriptions[sub]; !ok {
+		// The subscriber was dropped or the broadcaster was closed while the history was read
+		close(sub.events)
+		return sub, nil
+	}
+
+	for _, event := range pending {
+		b.send(sub, event)
+	}
+
+	return sub, nil
+}
+
+// Publish implements Publisher
+func (b *Broadcaster) Publish(_ context.Context, events ...Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscriptions {
+		for _, event := range events {
+			if !b.send(sub, event) {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// Close closes all subscriptions, subscriptions made after Close are closed immediately
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscriptions {
+		b.drop(sub)
+	}
+}
+
+// send passes an event to a subscriber and reports whether the subscriber is still subscribed.
+// b.mu must be held.
+func (b *Broadcaster) send(sub *Subscription, event Event) bool {
+	if sub.events == nil {
+		if len(sub.pending) == subscriptionBufferSize {
+			b.drop(sub)
+			return false
+		}
+
+		sub.pending = append(sub.pending, event)
+		return true
+	}
+
+	if _, ok := sub.replayed[event.ID]; ok {
+		return true
+	}
+
+	select {
+	case sub.events <- event:
+		return true
+	default:
+		b.drop(sub)
+		return false
+	}
+}
+
+// drop unsubscribes a subscriber, b.mu must be held
+func (b *Broadcaster) drop(sub *Subscription) {
+	if _, ok := b.subscriptions[sub]; !ok {
+		return
+	}
+
+	delete(b.subscriptions, sub)
+	if sub.events != nil {
+		close(sub.events)
+	}
+}
+
+// Subscription receives events from Broadcaster
+type Subscription struct {
+	broadcaster *Broadcaster
+
+	events   chan Event
+	pending  []Event
+	replayed map[string]struct{}
+}
+
+// Events returns the channel of events. The channel is closed when the subscription is closed,
+// the broadcaster is closed or the subscriber fell behind.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close unsubscribes from the broadcaster
+func (s *Subscription) Close() {
+	s.broadcaster.mu.Lock()
+	defer s.broadcaster.mu.Unlock()
+
+	s.broadcaster.drop(s)
+}
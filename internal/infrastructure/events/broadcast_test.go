@@ -0,0 +1,214 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+)
+
+func TestBroadcaster(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	occurredAt := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	newEvent := func(t *testing.T, id uint) events.Event {
+		t.Helper()
+
+		event, err := events.New("snippet.created", occurredAt, map[string]uint{"id": id})
+		require.NoError(t, err)
+		return event
+	}
+
+	receive := func(t *testing.T, sub *events.Subscription) []string {
+		t.Helper()
+
+		var ids []string
+		for {
+			select {
+			case event, ok := <-sub.Events():
+				if !ok {
+					return ids
+				}
+				ids = append(ids, event.ID)
+			default:
+				return ids
+			}
+		}
+	}
+
+	t.Run("Events are passed to every subscriber", func(t *testing.T) {
+		t.Parallel()
+
+		broadcaster := events.NewBroadcaster(nil)
+
+		first, err := broadcaster.Subscribe(ctx, "")
+		require.NoError(t, err)
+		second, err := broadcaster.Subscribe(ctx, "")
+		require.NoError(t, err)
+
+		event := newEvent(t, 1)
+		require.NoError(t, broadcaster.Publish(ctx, event))
+
+		assert.Equal(t, []string{event.ID}, receive(t, first))
+		assert.Equal(t, []string{event.ID}, receive(t, second))
+
+		second.Close()
+		second.Close()
+
+		another := newEvent(t, 2)
+		require.NoError(t, broadcaster.Publish(ctx, another))
+		assert.Equal(t, []string{another.ID}, receive(t, first))
+	})
+
+	t.Run("Subscription is resumed from history", func(t *testing.T) {
+		t.Parallel()
+
+		seen, missed, live := newEvent(t, 1), newEvent(t, 2), newEvent(t, 3)
+
+		broadcaster := events.NewBroadcaster(historyFunc(func(_ context.Context, id string, _ uint) ([]events.Event, error) {
+			assert.Equal(t, seen.ID, id)
+			return []events.Event{missed}, nil
+		}))
+
+		sub, err := broadcaster.Subscribe(ctx, seen.ID)
+		require.NoError(t, err)
+
+		// The missed event may be notified again, it's not repeated
+		require.NoError(t, broadcaster.Publish(ctx, missed, live))
+		assert.Equal(t, []string{missed.ID, live.ID}, receive(t, sub))
+	})
+
+	t.Run("Subscription can't be resumed from an unknown event", func(t *testing.T) {
+		t.Parallel()
+
+		live := newEvent(t, 1)
+
+		broadcaster := events.NewBroadcaster(historyFunc(func(context.Context, string, uint) ([]events.Event, error) {
+			return nil, events.ErrUnknownEvent
+		}))
+
+		sub, err := broadcaster.Subscribe(ctx, "purged")
+		require.NoError(t, err)
+		require.NoError(t, broadcaster.Publish(ctx, live))
+
+		reset := <-sub.Events()
+		assert.Equal(t, events.TypeReset, reset.Type)
+		assert.Empty(t, reset.ID)
+		assert.JSONEq(t, `{}`, string(reset.Data))
+
+		assert.Equal(t, live.ID, (<-sub.Events()).ID)
+	})
+
+	t.Run("Subscription can't be resumed after too many events", func(t *testing.T) {
+		t.Parallel()
+
+		broadcaster := events.NewBroadcaster(historyFunc(func(_ context.Context, _ string, limit uint) ([]events.Event, error) {
+			backlog := make([]events.Event, limit)
+			for i := range backlog {
+				backlog[i] = newEvent(t, uint(i))
+			}
+			return backlog, nil
+		}))
+
+		sub, err := broadcaster.Subscribe(ctx, "seen")
+		require.NoError(t, err)
+
+		assert.Equal(t, events.TypeReset, (<-sub.Events()).Type)
+		assert.Empty(t, receive(t, sub), "the truncated backlog isn't replayed")
+	})
+
+	t.Run("Subscription is resumed from the longest backlog", func(t *testing.T) {
+		t.Parallel()
+
+		var backlog []events.Event
+
+		broadcaster := events.NewBroadcaster(historyFunc(func(_ context.Context, _ string, limit uint) ([]events.Event, error) {
+			backlog = make([]events.Event, limit-1)
+			for i := range backlog {
+				backlog[i] = newEvent(t, uint(i))
+			}
+			return backlog, nil
+		}))
+
+		sub, err := broadcaster.Subscribe(ctx, "seen")
+		require.NoError(t, err)
+
+		assert.Len(t, receive(t, sub), len(backlog))
+	})
+
+	t.Run("Subscription can't be resumed without history", func(t *testing.T) {
+		t.Parallel()
+
+		broadcaster := events.NewBroadcaster(nil)
+
+		sub, err := broadcaster.Subscribe(ctx, "seen")
+		require.NoError(t, err)
+
+		assert.Equal(t, events.TypeReset, (<-sub.Events()).Type)
+	})
+
+	t.Run("History error", func(t *testing.T) {
+		t.Parallel()
+
+		broadcaster := events.NewBroadcaster(historyFunc(func(context.Context, string, uint) ([]events.Event, error) {
+			return nil, errors.New("connection refused")
+		}))
+
+		_, err := broadcaster.Subscribe(ctx, "unknown")
+		assert.ErrorContains(t, err, "connection refused")
+	})
+
+	t.Run("Slow subscriber is dropped", func(t *testing.T) {
+		t.Parallel()
+
+		broadcaster := events.NewBroadcaster(nil)
+
+		sub, err := broadcaster.Subscribe(ctx, "")
+		require.NoError(t, err)
+
+		batch := make([]events.Event, 100)
+		for i := range batch {
+			batch[i] = newEvent(t, uint(i))
+		}
+		require.NoError(t, broadcaster.Publish(ctx, batch...))
+
+		received := receive(t, sub)
+		assert.NotEmpty(t, received)
+		assert.Less(t, len(received), len(batch))
+
+		_, ok := <-sub.Events()
+		assert.False(t, ok, "subscription is closed")
+	})
+
+	t.Run("Close ends subscriptions", func(t *testing.T) {
+		t.Parallel()
+
+		broadcaster := events.NewBroadcaster(nil)
+
+		sub, err := broadcaster.Subscribe(ctx, "")
+		require.NoError(t, err)
+
+		broadcaster.Close()
+		_, ok := <-sub.Events()
+		assert.False(t, ok)
+
+		late, err := broadcaster.Subscribe(ctx, "")
+		require.NoError(t, err)
+		_, ok = <-late.Events()
+		assert.False(t, ok)
+	})
+}
+
+// historyFunc is a function that implements events.History
+type historyFunc func(ctx context.Context, id string, limit uint) ([]events.Event, error)
+
+func (f historyFunc) After(ctx context.Context, id string, limit uint) ([]events.Event, error) {
+	return f(ctx, id, limit)
+}
@@ -0,0 +1,48 @@
+// Package events describes domain events shared between business packages.
+// Producers (e.g. snippets) publish events, consumers (e.g. webhooks) deliver them further.
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Type is a type of event, e.g. "snippet.created"
+type Type string
+
+// Event is a single domain event. Data holds a JSON-encoded payload specific for the event type.
+type Event struct {
+	ID         string          `json:"id"`
+	Type       Type            `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Publisher accepts events for further delivery
+type Publisher interface {
+	Publish(ctx context.Context, events ...Event) error
+}
+
+// New creates an event with a random ID and JSON-encoded data
+func New(eventType Type, occurredAt time.Time, data any) (Event, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Event{}, fmt.Errorf("failed to generate event ID: %w", err)
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to encode event data: %w", err)
+	}
+
+	return Event{
+		ID:         hex.EncodeToString(id),
+		Type:       eventType,
+		OccurredAt: occurredAt,
+		Data:       encoded,
+	}, nil
+}
@@ -0,0 +1,32 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	occurredAt := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	first, err := events.New("snippet.created", occurredAt, map[string]uint{"id": 1})
+	require.NoError(t, err)
+
+	second, err := events.New("snippet.created", occurredAt, map[string]uint{"id": 1})
+	require.NoError(t, err)
+
+	assert.Len(t, first.ID, 32)
+	assert.NotEqual(t, first.ID, second.ID)
+	assert.Equal(t, events.Type("snippet.created"), first.Type)
+	assert.Equal(t, occurredAt, first.OccurredAt)
+	assert.JSONEq(t, `{"id": 1}`, string(first.Data))
+
+	_, err = events.New("snippet.created", occurredAt, func() {})
+	assert.Error(t, err)
+}
@@ -0,0 +1,114 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Outbox is a durable queue of events written in the same transaction as the changes they describe
+type Outbox interface {
+	// Claim returns up to limit unpublished events in the order they were written.
+	// Claimed events are hidden from other relays for the lease duration.
+	Claim(ctx context.Context, limit uint, lease time.Duration) ([]Event, error)
+	MarkPublished(ctx context.Context, ids []string) error
+	// MarkFailed records a failed attempt, the event is retried later with exponential backoff
+	MarkFailed(ctx context.Context, id string, reason string) error
+}
+
+// Default settings of Relay
+const (
+	defaultRelayBatchSize = 100
+	defaultRelayLease     = time.Minute
+)
+
+// Relay moves events from an outbox to sinks with at-least-once semantics:
+// an event is marked as published only after every sink has accepted it, otherwise it's retried
+// for all sinks. Sinks must tolerate repeated events. A failed event doesn't block the following ones,
+// so the order of events is not guaranteed.
+type Relay struct {
+	outbox Outbox
+	sinks  []Publisher
+	logger *slog.Logger
+
+	batchSize uint
+}
+
+// NewRelay returns new instance of Relay
+func NewRelay(outbox Outbox, logger *slog.Logger, sinks ...Publisher) *Relay {
+	return &Relay{
+		outbox: outbox,
+		sinks:  sinks,
+		logger: logger,
+
+		batchSize: defaultRelayBatchSize,
+	}
+}
+
+// RelayPending publishes all claimable events of the outbox
+func (r *Relay) RelayPending(ctx context.Context) error {
+	for {
+		batch, err := r.outbox.Claim(ctx, r.batchSize, defaultRelayLease)
+		if err != nil {
+			return fmt.Errorf("failed to claim outbox events: %w", err)
+		}
+
+		published := make([]string, 0, len(batch))
+		for _, event := range batch {
+			if publishErr := r.publish(ctx, event); publishErr != nil {
+				r.logger.Warn(
+					"failed to publish outbox event",
+					slog.String("event_id", event.ID),
+					slog.String("type", string(event.Type)),
+					slog.Any("err", publishErr),
+				)
+
+				if err = r.outbox.MarkFailed(ctx, event.ID, publishErr.Error()); err != nil {
+					return fmt.Errorf("failed to mark outbox event as failed: %w", err)
+				}
+				continue
+			}
+
+			published = append(published, event.ID)
+		}
+
+		if len(published) > 0 {
+			if err = r.outbox.MarkPublished(ctx, published); err != nil {
+				return fmt.Errorf("failed to mark outbox events as published: %w", err)
+			}
+		}
+
+		if uint(len(batch)) < r.batchSize {
+			return nil
+		}
+	}
+}
+
+// Run relays events every interval until ctx is done
+func (r *Relay) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := r.RelayPending(ctx); err != nil {
+				r.logger.Error("failed to relay outbox events", slog.Any("err", err))
+			}
+		}
+	}
+}
+
+// publish sends an event to every sink, it stops at the first failure
+func (r *Relay) publish(ctx context.Context, event Event) error {
+	for _, sink := range r.sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+)
+
+func TestRelay_RelayPending(t *testing.T) {
+	t.Parallel()
+
+	occurredAt := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	newEvent := func(t *testing.T, id uint) events.Event {
+		t.Helper()
+
+		event, err := events.New("snippet.created", occurredAt, map[string]uint{"id": id})
+		require.NoError(t, err)
+		return event
+	}
+
+	t.Run("Events are published to every sink", func(t *testing.T) {
+		t.Parallel()
+
+		first, second := newEvent(t, 1), newEvent(t, 2)
+		outbox := &fakeOutbox{pending: []events.Event{first, second}}
+
+		bus := events.NewBus()
+		var received []string
+		bus.Subscribe(func(_ context.Context, event events.Event) error {
+			received = append(received, event.ID)
+			return nil
+		})
+
+		relay := events.NewRelay(outbox, nopslog.NewNoplogger(), bus, events.NewLogSink(nopslog.NewNoplogger()))
+
+		require.NoError(t, relay.RelayPending(context.Background()))
+		assert.Equal(t, []string{first.ID, second.ID}, received)
+		assert.Equal(t, []string{first.ID, second.ID}, outbox.published)
+		assert.Empty(t, outbox.failed)
+	})
+
+	t.Run("Failed event is retried later", func(t *testing.T) {
+		t.Parallel()
+
+		first, second := newEvent(t, 1), newEvent(t, 2)
+		outbox := &fakeOutbox{pending: []events.Event{first, second}}
+
+		bus := events.NewBus()
+		bus.Subscribe(func(_ context.Context, event events.Event) error {
+			if event.ID == first.ID {
+				return errors.New("connection refused")
+			}
+			return nil
+		})
+
+		relay := events.NewRelay(outbox, nopslog.NewNoplogger(), bus)
+
+		require.NoError(t, relay.RelayPending(context.Background()))
+		assert.Equal(t, []string{second.ID}, outbox.published)
+		assert.Equal(t, map[string]string{first.ID: "connection refused"}, outbox.failed)
+	})
+
+	t.Run("Outbox error", func(t *testing.T) {
+		t.Parallel()
+
+		outbox := &fakeOutbox{err: errors.New("connection refused")}
+		relay := events.NewRelay(outbox, nopslog.NewNoplogger(), events.NewBus())
+
+		assert.ErrorContains(t, relay.RelayPending(context.Background()), "connection refused")
+	})
+}
+
+// fakeOutbox is an in-memory events.Outbox, every Claim call returns all pending events
+type fakeOutbox struct {
+	pending   []events.Event
+	published []string
+	failed    map[string]string
+	err       error
+}
+
+func (o *fakeOutbox) Claim(_ context.Context, _ uint, _ time.Duration) ([]events.Event, error) {
+	if o.err != nil {
+		return nil, o.err
+	}
+
+	batch := o.pending
+	o.pending = nil
+	return batch, nil
+}
+
+func (o *fakeOutbox) MarkPublished(_ context.Context, ids []string) error {
+	o.published = append(o.published, ids...)
+	return nil
+}
+
+func (o *fakeOutbox) MarkFailed(_ context.Context, id string, reason string) error {
+	if o.failed == nil {
+		o.failed = make(map[string]string)
+	}
+	o.failed[id] = reason
+	return nil
+}
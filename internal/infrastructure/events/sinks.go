@@ -0,0 +1,90 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// LogSink is a Publisher that writes events to a logger
+type LogSink struct {
+	logger *slog.Logger
+}
+
+// NewLogSink returns new instance of LogSink
+func NewLogSink(logger *slog.Logger) *LogSink {
+	return &LogSink{
+		logger: logger,
+	}
+}
+
+// Publish implements Publisher
+func (s *LogSink) Publish(ctx context.Context, events ...Event) error {
+	for _, event := range events {
+		s.logger.InfoContext(
+			ctx,
+			"event",
+			slog.String("id", event.ID),
+			slog.String("type", string(event.Type)),
+			slog.Time("occurred_at", event.OccurredAt),
+			slog.String("data", string(event.Data)),
+		)
+	}
+
+	return nil
+}
+
+// Handler processes a single event
+type Handler func(ctx context.Context, event Event) error
+
+// Bus is a Publisher that passes events to in-process subscribers synchronously.
+// A handler error fails the whole Publish call, so the event is retried for all handlers.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[Type][]Handler
+	all      []Handler
+}
+
+// NewBus returns new instance of Bus
+func NewBus() *Bus {
+	return &Bus{
+		handlers: make(map[Type][]Handler),
+	}
+}
+
+// Subscribe registers a handler for events of the given types, or for all events if no types are passed
+func (b *Bus) Subscribe(handler Handler, types ...Type) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(types) == 0 {
+		b.all = append(b.all, handler)
+		return
+	}
+
+	for _, t := range types {
+		b.handlers[t] = append(b.handlers[t], handler)
+	}
+}
+
+// Publish implements Publisher
+func (b *Bus) Publish(ctx context.Context, events ...Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, event := range events {
+		for _, handler := range b.handlers[event.Type] {
+			if err := handler(ctx, event); err != nil {
+				return err
+			}
+		}
+
+		for _, handler := range b.all {
+			if err := handler(ctx, event); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
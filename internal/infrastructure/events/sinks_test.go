@@ -0,0 +1,56 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+)
+
+func TestBus_Publish(t *testing.T) {
+	t.Parallel()
+
+	occurredAt := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	created, err := events.New("snippet.created", occurredAt, map[string]uint{"id": 1})
+	require.NoError(t, err)
+	deleted, err := events.New("snippet.deleted", occurredAt, map[string]uint{"id": 1})
+	require.NoError(t, err)
+
+	t.Run("Handlers receive subscribed events", func(t *testing.T) {
+		t.Parallel()
+
+		bus := events.NewBus()
+
+		var typed, all []events.Type
+		bus.Subscribe(func(_ context.Context, event events.Event) error {
+			typed = append(typed, event.Type)
+			return nil
+		}, "snippet.deleted")
+		bus.Subscribe(func(_ context.Context, event events.Event) error {
+			all = append(all, event.Type)
+			return nil
+		})
+
+		require.NoError(t, bus.Publish(context.Background(), created, deleted))
+		assert.Equal(t, []events.Type{"snippet.deleted"}, typed)
+		assert.Equal(t, []events.Type{"snippet.created", "snippet.deleted"}, all)
+	})
+
+	t.Run("Handler error fails Publish", func(t *testing.T) {
+		t.Parallel()
+
+		bus := events.NewBus()
+		bus.Subscribe(func(_ context.Context, _ events.Event) error {
+			return errors.New("handler failed")
+		}, "snippet.created")
+
+		assert.EqualError(t, bus.Publish(context.Background(), created), "handler failed")
+		assert.NoError(t, bus.Publish(context.Background(), deleted))
+	})
+}
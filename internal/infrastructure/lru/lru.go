@@ -0,0 +1,132 @@
+// Package lru implements an in-process cache that evicts least recently used entries and expires stale ones.
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache is a fixed-size cache of values by keys, it's safe for concurrent use.
+// Once the cache is full, adding an entry evicts the least recently used one.
+// Every entry has its own time to live, an expired entry is never returned.
+type Cache[K comparable, V any] struct {
+	capacity int
+	now      func() time.Time
+
+	mu        sync.Mutex
+	entries   map[K]*list.Element
+	order     *list.List // the most recently used entries are in front
+	evictions uint64
+}
+
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// New returns a new instance of Cache holding up to capacity entries
+func New[K comparable, V any](capacity int, nowFunc func() time.Time) *Cache[K, V] {
+	return &Cache[K, V]{
+		capacity: max(capacity, 1),
+		now:      nowFunc,
+		entries:  make(map[K]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+// Get returns the value of key, if it's cached and hasn't expired, and marks it as recently used
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	e := element.Value.(*entry[K, V])
+	if !c.now().Before(e.expiresAt) {
+		c.remove(element)
+
+		var zero V
+		return zero, false
+	}
+
+	c.order.MoveToFront(element)
+	return e.value, true
+}
+
+// Set caches the value of key for ttl, the least recently used entry is evicted if the cache is full.
+// A non-positive ttl removes the key instead.
+func (c *Cache[K, V]) Set(key K, value V, ttl time.Duration) {
+	if ttl <= 0 {
+		c.Delete(key)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(ttl)
+
+	if element, ok := c.entries[key]; ok {
+		e := element.Value.(*entry[K, V])
+		e.value = value
+		e.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	if c.order.Len() >= c.capacity {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+
+	c.entries[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+}
+
+// Delete removes key from the cache and reports whether it was cached
+func (c *Cache[K, V]) Delete(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if ok {
+		c.remove(element)
+	}
+
+	return ok
+}
+
+// Purge removes all entries
+func (c *Cache[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.entries)
+	c.order.Init()
+}
+
+// Len returns the number of cached entries, expired ones included until they're evicted or read
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// Evictions returns the number of entries evicted to make room for new ones
+func (c *Cache[K, V]) Evictions() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.evictions
+}
+
+func (c *Cache[K, V]) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*entry[K, V]).key)
+}
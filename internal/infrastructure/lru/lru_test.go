@@ -0,0 +1,109 @@
+package lru_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/lru"
+)
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+	cache := lru.New[string, int](2, func() time.Time { return now })
+
+	t.Run("Cached values are returned", func(t *testing.T) {
+		cache.Set("a", 1, time.Minute)
+		cache.Set("b", 2, time.Minute)
+
+		value, ok := cache.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 1, value)
+
+		_, ok = cache.Get("missing")
+		assert.False(t, ok)
+	})
+
+	t.Run("The least recently used entry is evicted", func(t *testing.T) {
+		// "a" has been read after "b" was added, so "b" is the least recently used one
+		cache.Set("c", 3, time.Minute)
+
+		_, ok := cache.Get("b")
+		assert.False(t, ok)
+
+		_, ok = cache.Get("a")
+		assert.True(t, ok)
+		_, ok = cache.Get("c")
+		assert.True(t, ok)
+
+		assert.Equal(t, 2, cache.Len())
+		assert.EqualValues(t, 1, cache.Evictions())
+	})
+
+	t.Run("Setting an existing key replaces the value", func(t *testing.T) {
+		cache.Set("a", 10, time.Minute)
+
+		value, ok := cache.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 10, value)
+		assert.Equal(t, 2, cache.Len())
+		assert.EqualValues(t, 1, cache.Evictions())
+	})
+
+	t.Run("Expired entries aren't returned", func(t *testing.T) {
+		cache.Set("short", 4, time.Second)
+		now = now.Add(time.Second)
+
+		_, ok := cache.Get("short")
+		assert.False(t, ok)
+
+		cache.Set("never", 5, 0)
+		_, ok = cache.Get("never")
+		assert.False(t, ok)
+	})
+
+	t.Run("Delete and purge", func(t *testing.T) {
+		cache.Set("a", 1, time.Minute)
+		cache.Set("b", 2, time.Minute)
+
+		assert.True(t, cache.Delete("a"))
+		assert.False(t, cache.Delete("a"))
+
+		cache.Purge()
+		assert.Zero(t, cache.Len())
+
+		_, ok := cache.Get("b")
+		assert.False(t, ok)
+	})
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	cache := lru.New[int, string](100, time.Now)
+
+	var wg sync.WaitGroup
+	for worker := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range 1000 {
+				key := (worker*1000 + i) % 150
+				cache.Set(key, fmt.Sprint(key), time.Minute)
+				if value, ok := cache.Get(key); ok {
+					assert.Equal(t, fmt.Sprint(key), value)
+				}
+				cache.Delete(key - 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, cache.Len(), 100)
+}
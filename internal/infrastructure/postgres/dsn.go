@@ -0,0 +1,219 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgservicefile"
+)
+
+// parseDSN returns settings of a URL or keyword/value DSN, keyed by libpq keywords.
+// An empty DSN has no settings.
+func parseDSN(dsn string) (map[string]string, error) {
+	var (
+		settings = map[string]string{}
+		err      error
+	)
+	switch {
+	case strings.TrimSpace(dsn) == "":
+	case isURLDSN(dsn):
+		settings, err = parseURLDSN(dsn)
+	default:
+		settings, err = parseKeywordDSN(dsn)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// pgx accepts database as a synonym, it must not be shadowed by dbname of the flags
+	if database, ok := settings["database"]; ok {
+		delete(settings, "database")
+		settings["dbname"] = database
+	}
+
+	return settings, nil
+}
+
+func isURLDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://")
+}
+
+// parseURLDSN parses a postgres:// URL. Several hosts are allowed, as in host1:5432,host2:5433.
+func parseURLDSN(dsn string) (map[string]string, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil {
+		// url.Error has the whole DSN in it, the password must not get into logs
+		if urlErr := new(url.Error); errors.As(err, &urlErr) {
+			return nil, urlErr.Err
+		}
+		return nil, err
+	}
+
+	settings := make(map[string]string)
+
+	if parsed.User != nil {
+		settings["user"] = parsed.User.Username()
+		if password, ok := parsed.User.Password(); ok {
+			settings["password"] = password
+		}
+	}
+
+	var hosts, ports []string
+	for _, host := range strings.Split(parsed.Host, ",") {
+		if host == "" {
+			continue
+		}
+
+		// A host without a port, or an IPv6 address without brackets
+		if net.ParseIP(strings.Trim(host, "[]")) != nil || !strings.Contains(host, ":") {
+			hosts = append(hosts, strings.Trim(host, "[]"))
+			continue
+		}
+
+		h, p, err := net.SplitHostPort(host)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split host and port of %q: %w", host, err)
+		}
+
+		if h != "" {
+			hosts = append(hosts, h)
+		}
+
+		if p != "" {
+			ports = append(ports, p)
+		}
+	}
+
+	if len(hosts) > 0 {
+		settings["host"] = strings.Join(hosts, ",")
+	}
+
+	if len(ports) > 0 {
+		settings["port"] = strings.Join(ports, ",")
+	}
+
+	if database := strings.TrimPrefix(parsed.Path, "/"); database != "" {
+		settings["dbname"] = database
+	}
+
+	for key, values := range parsed.Query() {
+		settings[key] = values[len(values)-1]
+	}
+
+	return settings, nil
+}
+
+// parseKeywordDSN parses a keyword/value DSN, as in "host=localhost password='it\'s secret'".
+// Values are either single-quoted or end at a space, a backslash escapes the next character in both cases.
+func parseKeywordDSN(dsn string) (map[string]string, error) {
+	settings := make(map[string]string)
+
+	s := dsn
+	for {
+		s = strings.TrimLeft(s, " \t\n\r\v\f")
+		if s == "" {
+			return settings, nil
+		}
+
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("missing %q after %q", "=", strings.Fields(s)[0])
+		}
+
+		key := strings.TrimSpace(s[:eq])
+		if key == "" || strings.ContainsAny(key, " \t\n\r\v\f") {
+			return nil, fmt.Errorf("invalid keyword %q", key)
+		}
+
+		s = strings.TrimLeft(s[eq+1:], " \t\n\r\v\f")
+
+		quoted := strings.HasPrefix(s, "'")
+		if quoted {
+			s = s[1:]
+		}
+
+		var (
+			value  strings.Builder
+			closed bool
+			i      int
+		)
+		for ; i < len(s); i++ {
+			c := s[i]
+			if c == '\\' && i+1 < len(s) {
+				i++
+				value.WriteByte(s[i])
+				continue
+			}
+
+			if (quoted && c == '\'') || (!quoted && strings.IndexByte(" \t\n\r\v\f", c) >= 0) {
+				closed = true
+				break
+			}
+
+			value.WriteByte(c)
+		}
+
+		if quoted && !closed {
+			return nil, fmt.Errorf("unterminated quoted value of %q", key)
+		}
+
+		settings[key] = value.String()
+		s = s[min(i+1, len(s)):]
+	}
+}
+
+// mergeService merges settings of a service from the service file into settings.
+// The file is ~/.pg_service.conf, unless another one is passed.
+func mergeService(settings map[string]string, serviceFile, name string) error {
+	if serviceFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to find service file: %w", err)
+		}
+
+		serviceFile = filepath.Join(home, ".pg_service.conf")
+	}
+
+	services, err := pgservicefile.ReadServicefile(serviceFile)
+	if err != nil {
+		return fmt.Errorf("failed to read service file %q: %w", serviceFile, err)
+	}
+
+	service, err := services.GetService(name)
+	if err != nil {
+		return fmt.Errorf("failed to find service %q in %q: %w", name, serviceFile, err)
+	}
+
+	maps.Copy(settings, service.Settings)
+
+	return nil
+}
+
+// formatConnString formats settings as a keyword/value connection string.
+// Keywords are sorted, so the same settings always give the same string.
+func formatConnString(settings map[string]string) string {
+	pairs := make([]string, 0, len(settings))
+	for _, key := range slices.Sorted(maps.Keys(settings)) {
+		pairs = append(pairs, key+"="+quoteConnValue(settings[key]))
+	}
+
+	// A quoted value must not be followed by a trailing space, pgx fails to parse it then
+	return strings.Join(pairs, " ")
+}
+
+// quoteConnValue quotes a value of a keyword/value connection string, if it's empty or has spaces, quotes or backslashes
+func quoteConnValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\n\r\v\f'\\") {
+		return value
+	}
+
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
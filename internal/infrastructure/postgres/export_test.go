@@ -0,0 +1,3 @@
+package postgres
+
+var SplitReplicaAddress = splitReplicaAddress
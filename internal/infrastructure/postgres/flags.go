@@ -1,10 +1,17 @@
 package postgres
 
 import (
+	"cmp"
 	"database/sql"
 	"fmt"
+	"maps"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib" // pgx driver
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 )
 
 // Flags represents PostgreSQL connection flags
@@ -16,36 +23,199 @@ type Flags struct {
 	Password string `kong:"optional,group='Postgres',name=postgres-password,env=POSTGRES_PASSWORD,help='PostgreSQL password.'" json:"-"`
 	Database string `kong:"optional,group='Postgres',name=postgres-database,default=postgres,env=POSTGRES_DATABASE,help='PostgreSQL database.'"`
 
-	TLSMode string `kong:"optional,group='Postgres',name=postgres-tls-mode,default=disable,env=POSTGRES_TLS_MODE,help='PostgreSQL TLS mode.'"`
+	DSN         string `kong:"optional,group='Postgres',name=postgres-dsn,env=POSTGRES_DSN,help='PostgreSQL URL or keyword/value DSN. Its settings take precedence over the other flags of the group.'" json:"-"`
+	Service     string `kong:"optional,group='Postgres',name=postgres-service,env='POSTGRES_SERVICE,PGSERVICE',help='Name of a service from the service file. Its settings take precedence over the discrete flags.'"`
+	ServiceFile string `kong:"optional,group='Postgres',name=postgres-service-file,env='POSTGRES_SERVICE_FILE,PGSERVICEFILE',help='Path to the service file, ~/.pg_service.conf by default.'"`
+	PassFile    string `kong:"optional,group='Postgres',name=postgres-passfile,env='POSTGRES_PASSFILE,PGPASSFILE',help='Path to the password file used when no password is set, ~/.pgpass by default.'"`
+
+	TLSMode       string `kong:"optional,group='Postgres',name=postgres-tls-mode,default=disable,env=POSTGRES_TLS_MODE,help='PostgreSQL TLS mode.'"`
+	TLSRootCert   string `kong:"optional,group='Postgres',name=postgres-tls-root-cert,env=POSTGRES_TLS_ROOT_CERT,help='Path to the CA bundle the server certificate is verified with, system roots are used if empty.'"`
+	TLSCert       string `kong:"optional,group='Postgres',name=postgres-tls-cert,env=POSTGRES_TLS_CERT,help='Path to the client certificate, it requires --postgres-tls-key.'"`
+	TLSKey        string `kong:"optional,group='Postgres',name=postgres-tls-key,env=POSTGRES_TLS_KEY,help='Path to the private key of the client certificate.'"`
+	TLSServerName string `kong:"optional,group='Postgres',name=postgres-tls-server-name,env=POSTGRES_TLS_SERVER_NAME,help='Server name the certificate is verified against in verify-full mode, the host by default.'"`
+
+	MaxOpenConns    int           `kong:"optional,group='Postgres',name=postgres-max-open-conns,default=0,env=POSTGRES_MAX_OPEN_CONNS,help='Maximum number of open connections, 0 means unlimited (the size of a pgx pool is chosen by pgx then).'"`
+	MaxIdleConns    int           `kong:"optional,group='Postgres',name=postgres-max-idle-conns,default=2,env=POSTGRES_MAX_IDLE_CONNS,help='Maximum number of idle connections kept open, 0 means none. A pgx pool keeps all of them.'"`
+	ConnMaxLifetime time.Duration `kong:"optional,group='Postgres',name=postgres-conn-max-lifetime,default=30m,env=POSTGRES_CONN_MAX_LIFETIME,help='How long a connection is used before it is replaced, 0 means forever.'"`
+	ConnMaxIdleTime time.Duration `kong:"optional,group='Postgres',name=postgres-conn-max-idle-time,default=5m,env=POSTGRES_CONN_MAX_IDLE_TIME,help='How long an idle connection is kept open, 0 means forever.'"`
+
+	StatementTimeout                time.Duration `kong:"optional,group='Postgres',name=postgres-statement-timeout,default=0,env=POSTGRES_STATEMENT_TIMEOUT,help='statement_timeout of every connection, 0 means the server default. It applies to migrations too.'"`
+	LockTimeout                     time.Duration `kong:"optional,group='Postgres',name=postgres-lock-timeout,default=0,env=POSTGRES_LOCK_TIMEOUT,help='lock_timeout of every connection, 0 means the server default.'"`
+	IdleInTransactionSessionTimeout time.Duration `kong:"optional,group='Postgres',name=postgres-idle-in-transaction-session-timeout,default=0,env=POSTGRES_IDLE_IN_TRANSACTION_SESSION_TIMEOUT,help='idle_in_transaction_session_timeout of every connection, 0 means the server default.'"`
+	ApplicationName                 string        `kong:"optional,group='Postgres',name=postgres-application-name,default=go-sample,env=POSTGRES_APPLICATION_NAME,help='application_name of every connection, shown in pg_stat_activity.'"`
+	SearchPath                      string        `kong:"optional,group='Postgres',name=postgres-search-path,env=POSTGRES_SEARCH_PATH,help='search_path of every connection, e.g. app,public. Empty means the server default.'"`
+
+	ConnectDeadline   time.Duration `kong:"optional,group='Postgres',name=postgres-connect-deadline,default=1m,env=POSTGRES_CONNECT_DEADLINE,help='How long to wait for PostgreSQL to become reachable on start, 0 means a single attempt.'"`
+	ConnectBackoff    time.Duration `kong:"optional,group='Postgres',name=postgres-connect-backoff,default=500ms,env=POSTGRES_CONNECT_BACKOFF,help='Delay before the second attempt to connect, every next delay is doubled. It must be positive.'"`
+	ConnectMaxBackoff time.Duration `kong:"optional,group='Postgres',name=postgres-connect-max-backoff,default=10s,env=POSTGRES_CONNECT_MAX_BACKOFF,help='Maximum delay between attempts to connect.'"`
 }
 
 // OpenStdSQLDB opens a new connection to the PostgreSQL database
 // using the standard library's sql package.
 func (p Flags) OpenStdSQLDB() (*sql.DB, error) {
-	db, err := sql.Open("pgx/v5", p.BuildConnectionString())
+	db, err := p.openStdSQLDB()
 	if err != nil {
-		return nil, fmt.Errorf("open PostgreSQL connection: %w", err)
+		return nil, err
 	}
 
 	if err = db.Ping(); err != nil {
+		_ = db.Close()
 		return nil, fmt.Errorf("ping PostgreSQL: %w", err)
 	}
 	return db, nil
 }
 
-func (p Flags) BuildConnectionString() string {
-	connString := fmt.Sprintf(
-		"host=%s port=%d user=%s dbname=%s sslmode=%s ",
-		p.Host,
-		p.Port,
-		p.Username,
-		p.Database,
-		p.TLSMode,
-	)
+// openStdSQLDB opens the database without connecting to it, only the flags are validated
+func (p Flags) openStdSQLDB() (*sql.DB, error) {
+	config, err := p.connConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	db := stdlib.OpenDB(*config)
+
+	db.SetMaxOpenConns(p.MaxOpenConns)
+	db.SetMaxIdleConns(p.MaxIdleConns)
+	db.SetConnMaxLifetime(p.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(p.ConnMaxIdleTime)
+
+	return db, nil
+}
+
+// connConfig returns a pgx config of a single connection with the TLS flags applied
+func (p Flags) connConfig() (*pgx.ConnConfig, error) {
+	settings, err := p.connSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := pgx.ParseConfig(formatConnString(settings))
+	if err != nil {
+		return nil, fmt.Errorf("open PostgreSQL connection: %w", err)
+	}
+
+	if err = p.configureTLS(&config.Config, settings["sslmode"]); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// BuildConnectionString returns a keyword/value connection string with all values escaped.
+// Settings of the DSN take precedence over the ones of the service, and both of them over the discrete flags.
+// Session settings are passed as runtime parameters, so they're applied by the server on every connection.
+// If no password is set, pgx looks it up in the password file.
+func (p Flags) BuildConnectionString() (string, error) {
+	settings, err := p.connSettings()
+	if err != nil {
+		return "", err
+	}
+
+	return formatConnString(settings), nil
+}
+
+// connSettings returns settings of the connection string keyed by libpq keywords
+func (p Flags) connSettings() (map[string]string, error) {
+	settings := map[string]string{
+		"host":    p.Host,
+		"port":    strconv.FormatUint(uint64(p.Port), 10),
+		"user":    p.Username,
+		"dbname":  p.Database,
+		"sslmode": p.TLSMode,
+	}
 
 	if p.Password != "" {
-		connString += fmt.Sprintf("password=%s ", p.Password)
+		settings["password"] = p.Password
+	}
+
+	if p.PassFile != "" {
+		settings["passfile"] = p.PassFile
+	}
+
+	for _, param := range p.runtimeParams() {
+		settings[param[0]] = param[1]
+	}
+
+	dsn, err := parseDSN(p.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("parse PostgreSQL DSN: %w", err)
+	}
+
+	if service := cmp.Or(dsn["service"], p.Service); service != "" {
+		serviceFile := cmp.Or(dsn["servicefile"], p.ServiceFile)
+		if err = mergeService(settings, serviceFile, service); err != nil {
+			return nil, err
+		}
+	}
+
+	maps.Copy(settings, dsn)
+
+	// The service is already merged, pgx must not read it once again
+	delete(settings, "service")
+	delete(settings, "servicefile")
+
+	return settings, nil
+}
+
+// WithDatabase returns a copy of the flags connecting to another database, even if the DSN has its own one
+func (p Flags) WithDatabase(database string) Flags {
+	p.Database = database
+	p.DSN = overrideDSN(p.DSN, "dbname", database)
+
+	return p
+}
+
+// WithHost returns a copy of the flags connecting to another server, even if the DSN has its own one
+func (p Flags) WithHost(host string, port uint32) Flags {
+	p.Host = host
+	p.Port = port
+	p.DSN = overrideDSN(p.DSN, "host", host)
+	p.DSN = overrideDSN(p.DSN, "port", strconv.FormatUint(uint64(port), 10))
+
+	return p
+}
+
+// overrideDSN appends a setting to a DSN, so it takes precedence over the same setting of the DSN itself
+func overrideDSN(dsn, key, value string) string {
+	switch {
+	case dsn == "":
+		return ""
+	case isURLDSN(dsn):
+		// Query parameters take precedence over the rest of the URL, so the DSN stays valid even if it can't be parsed here
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		return dsn + separator + key + "=" + url.QueryEscape(value)
+	default:
+		return dsn + " " + key + "=" + quoteConnValue(value)
+	}
+}
+
+// runtimeParams returns session settings that differ from the server defaults
+func (p Flags) runtimeParams() [][2]string {
+	var params [][2]string
+
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{name: "statement_timeout", value: p.StatementTimeout},
+		{name: "lock_timeout", value: p.LockTimeout},
+		{name: "idle_in_transaction_session_timeout", value: p.IdleInTransactionSessionTimeout},
+	} {
+		if timeout.value > 0 {
+			params = append(params, [2]string{timeout.name, fmt.Sprintf("%d", timeout.value.Milliseconds())})
+		}
+	}
+
+	if p.ApplicationName != "" {
+		params = append(params, [2]string{"application_name", p.ApplicationName})
+	}
+
+	if p.SearchPath != "" {
+		params = append(params, [2]string{"search_path", p.SearchPath})
 	}
 
-	return connString
+	return params
 }
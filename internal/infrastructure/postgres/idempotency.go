@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/api"
+)
+
+// defaultIdempotencyLockLease is how long a key is held by a request in progress, if the store isn't told otherwise
+const defaultIdempotencyLockLease = time.Minute
+
+// maxIdempotencyLockAttempts bounds retries of Lock when the key is released between its queries
+const maxIdempotencyLockAttempts = 3
+
+// IdempotencyStore implements api.IdempotencyStore on top of PostgreSQL.
+type IdempotencyStore struct {
+	db    *sql.DB
+	ttl   time.Duration
+	lease time.Duration
+}
+
+// IdempotencyStoreOption configures optional IdempotencyStore parameters
+type IdempotencyStoreOption func(*IdempotencyStore)
+
+// WithLockLease sets how long a key is held by a request in progress. If the request doesn't save its response
+// in time, e.g. the process crashed, the key is taken over by a retry.
+func WithLockLease(lease time.Duration) IdempotencyStoreOption {
+	return func(s *IdempotencyStore) {
+		s.lease = lease
+	}
+}
+
+// NewIdempotencyStore creates a new IdempotencyStore. Saved responses expire after ttl.
+func NewIdempotencyStore(db *sql.DB, ttl time.Duration, opts ...IdempotencyStoreOption) *IdempotencyStore {
+	s := &IdempotencyStore{
+		db:    db,
+		ttl:   ttl,
+		lease: defaultIdempotencyLockLease,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Lock reserves the key for the lock lease or returns the record that already holds it.
+// Expired keys, including ones whose lease has passed, are taken over as if they never existed.
+func (s *IdempotencyStore) Lock(ctx context.Context, key string, requestHash []byte) (*api.IdempotencyRecord, error) {
+	for attempt := 1; ; attempt++ {
+		record, err := s.lock(ctx, key, requestHash)
+		// The key was released between the queries, it's free now
+		if errors.Is(err, sql.ErrNoRows) && attempt < maxIdempotencyLockAttempts {
+			continue
+		}
+
+		return record, err
+	}
+}
+
+func (s *IdempotencyStore) lock(ctx context.Context, key string, requestHash []byte) (*api.IdempotencyRecord, error) {
+	const lockQuery = `INSERT INTO idempotency_keys (key, request_hash, expires_at)
+VALUES ($1, $2, NOW() + MAKE_INTERVAL(secs => $3))
+ON CONFLICT (key) DO UPDATE
+	SET request_hash = EXCLUDED.request_hash,
+		status_code  = 0,
+		content_type = '',
+		body         = NULL,
+		created_at   = NOW(),
+		expires_at   = EXCLUDED.expires_at
+	WHERE idempotency_keys.expires_at <= NOW()
+RETURNING key`
+
+	err := s.db.QueryRowContext(ctx, lockQuery, key, requestHash, s.lease.Seconds()).Scan(&key)
+	if err == nil {
+		return nil, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("lock idempotency key: %w", err)
+	}
+
+	const selectQuery = `SELECT request_hash, status_code, content_type, body
+FROM idempotency_keys
+WHERE key = $1`
+
+	var record api.IdempotencyRecord
+
+	err = s.db.QueryRowContext(ctx, selectQuery, key).Scan(
+		&record.RequestHash,
+		&record.StatusCode,
+		&record.ContentType,
+		&record.Body,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get idempotency key: %w", err)
+	}
+
+	return &record, nil
+}
+
+// Save stores the response for the key, it's kept for the TTL from now on.
+func (s *IdempotencyStore) Save(ctx context.Context, key string, record api.IdempotencyRecord) error {
+	const query = `UPDATE idempotency_keys
+SET status_code  = $2,
+	content_type = $3,
+	body         = $4,
+	expires_at   = NOW() + MAKE_INTERVAL(secs => $6)
+WHERE key = $1 AND request_hash = $5`
+
+	_, err := s.db.ExecContext(
+		ctx,
+		query,
+		key,
+		record.StatusCode,
+		record.ContentType,
+		record.Body,
+		record.RequestHash,
+		s.ttl.Seconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("save idempotent response: %w", err)
+	}
+
+	return nil
+}
+
+// Release removes the key.
+func (s *IdempotencyStore) Release(ctx context.Context, key string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1`, key); err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired purges expired keys and returns the number of deleted ones.
+func (s *IdempotencyStore) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired idempotency keys: %w", err)
+	}
+
+	return result.RowsAffected()
+}
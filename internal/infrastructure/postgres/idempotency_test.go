@@ -0,0 +1,123 @@
+package postgres_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/api"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres/pgtest"
+)
+
+const envFile = "../../../.env"
+
+func TestIdempotencyStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip integration test due to 'short' flag")
+	}
+	t.Parallel()
+
+	pgConn := pgtest.InitTestDatabase(
+		t,
+		pgtest.WithConfigFiles(envFile),
+	)
+
+	ctx := context.Background()
+	store := postgres.NewIdempotencyStore(pgConn, time.Hour)
+
+	requestHash := []byte("request hash")
+
+	t.Run("Lock a new key", func(t *testing.T) {
+		record, err := store.Lock(ctx, "key-1", requestHash)
+		require.NoError(t, err)
+		assert.Nil(t, record)
+	})
+
+	t.Run("Locked key is in progress", func(t *testing.T) {
+		record, err := store.Lock(ctx, "key-1", requestHash)
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.Zero(t, record.StatusCode)
+		assert.Equal(t, requestHash, record.RequestHash)
+	})
+
+	t.Run("Saved response is returned", func(t *testing.T) {
+		err := store.Save(ctx, "key-1", api.IdempotencyRecord{
+			RequestHash: requestHash,
+			StatusCode:  http.StatusCreated,
+			ContentType: "application/json",
+			Body:        []byte(`{"id":1}`),
+		})
+		require.NoError(t, err)
+
+		record, err := store.Lock(ctx, "key-1", []byte("another hash"))
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.Equal(t, api.IdempotencyRecord{
+			RequestHash: requestHash,
+			StatusCode:  http.StatusCreated,
+			ContentType: "application/json",
+			Body:        []byte(`{"id":1}`),
+		}, *record)
+	})
+
+	t.Run("Released key can be locked again", func(t *testing.T) {
+		require.NoError(t, store.Release(ctx, "key-1"))
+
+		record, err := store.Lock(ctx, "key-1", requestHash)
+		require.NoError(t, err)
+		assert.Nil(t, record)
+	})
+
+	t.Run("Abandoned key is taken over after its lease", func(t *testing.T) {
+		leasedStore := postgres.NewIdempotencyStore(pgConn, time.Hour, postgres.WithLockLease(-time.Hour))
+
+		record, err := leasedStore.Lock(ctx, "key-3", requestHash)
+		require.NoError(t, err)
+		assert.Nil(t, record)
+
+		record, err = leasedStore.Lock(ctx, "key-3", []byte("another hash"))
+		require.NoError(t, err)
+		assert.Nil(t, record)
+	})
+
+	t.Run("Saved response outlives the lease", func(t *testing.T) {
+		leasedStore := postgres.NewIdempotencyStore(pgConn, time.Hour, postgres.WithLockLease(-time.Hour))
+
+		record, err := leasedStore.Lock(ctx, "key-4", requestHash)
+		require.NoError(t, err)
+		assert.Nil(t, record)
+
+		err = leasedStore.Save(ctx, "key-4", api.IdempotencyRecord{
+			RequestHash: requestHash,
+			StatusCode:  http.StatusCreated,
+		})
+		require.NoError(t, err)
+
+		record, err = leasedStore.Lock(ctx, "key-4", []byte("another hash"))
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.Equal(t, http.StatusCreated, record.StatusCode)
+	})
+
+	t.Run("Expired keys are taken over and purged", func(t *testing.T) {
+		expiredStore := postgres.NewIdempotencyStore(pgConn, -time.Hour, postgres.WithLockLease(-time.Hour))
+
+		record, err := expiredStore.Lock(ctx, "key-2", requestHash)
+		require.NoError(t, err)
+		assert.Nil(t, record)
+
+		record, err = expiredStore.Lock(ctx, "key-2", []byte("another hash"))
+		require.NoError(t, err)
+		assert.Nil(t, record)
+
+		deleted, err := store.DeleteExpired(ctx)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, deleted)
+	})
+}
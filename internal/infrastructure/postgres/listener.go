@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// listenRetryDelay is a pause before the lost listening connection is reestablished
+const listenRetryDelay = 5 * time.Second
+
+// ListenOption configures optional parameters of Listen
+type ListenOption func(*listenOptions)
+
+type listenOptions struct {
+	onListen func(ctx context.Context)
+}
+
+// OnListen sets a function called every time the channel is listened to, i.e. once the connection is (re)established.
+// Notifications sent before may have been missed, so it's the place to drop the state they keep up to date.
+func OnListen(fn func(ctx context.Context)) ListenOption {
+	return func(o *listenOptions) {
+		o.onListen = fn
+	}
+}
+
+// Listen calls handle for every notification sent to channel until ctx is done.
+// Notifications are received over a dedicated connection, it's reestablished if lost.
+// Notifications sent while the connection is down are missed, see OnListen.
+func Listen(
+	ctx context.Context,
+	db *sql.DB,
+	logger *slog.Logger,
+	channel string,
+	handle func(ctx context.Context, payload string),
+	opts ...ListenOption,
+) error {
+	var o listenOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	for {
+		err := listen(ctx, db, channel, handle, o)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		logger.Error("failed to listen for notifications", slog.String("channel", channel), slog.Any("err", err))
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+func listen(
+	ctx context.Context,
+	db *sql.DB,
+	channel string,
+	handle func(ctx context.Context, payload string),
+	o listenOptions,
+) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	return conn.Raw(func(driverConn any) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected driver connection %T", driverConn)
+		}
+
+		pgxConn := stdlibConn.Conn()
+		if _, err := pgxConn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return fmt.Errorf("listen %s: %w", channel, err)
+		}
+
+		if o.onListen != nil {
+			o.onListen(ctx)
+		}
+
+		for {
+			notification, err := pgxConn.WaitForNotification(ctx)
+			if err != nil {
+				return fmt.Errorf("wait for notification: %w", err)
+			}
+
+			handle(ctx, notification.Payload)
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+)
+
+// OutboxChannel is a channel notified with IDs of events written to the outbox
+const OutboxChannel = "outbox_events"
+
+// maxOutboxRetryDelay is the longest delay between attempts to publish an event, in seconds
+const maxOutboxRetryDelay = 3600
+
+// Execer executes queries, it's implemented by *sql.DB and *sql.Tx.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// WriteOutbox adds events to the outbox and notifies OutboxChannel about them. Pass the transaction
+// of the change the events describe, so the events are published if and only if the change is committed.
+func WriteOutbox(ctx context.Context, tx Execer, batch ...events.Event) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	const query = `WITH inserted AS (
+	INSERT INTO outbox_events (event_id, event_type, occurred_at, data)
+	SELECT e.id, e.type, e.occurred_at, e.data::jsonb
+	FROM UNNEST($1::text[], $2::text[], $3::timestamp[], $4::text[]) AS e (id, type, occurred_at, data)
+	RETURNING event_id
+)
+SELECT PG_NOTIFY($5, event_id)
+FROM inserted`
+
+	ids := make([]string, len(batch))
+	types := make([]string, len(batch))
+	occurredAt := make([]time.Time, len(batch))
+	data := make([]string, len(batch))
+	for i, event := range batch {
+		ids[i] = event.ID
+		types[i] = string(event.Type)
+		occurredAt[i] = event.OccurredAt.UTC()
+		data[i] = string(event.Data)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, ids, types, occurredAt, data, OutboxChannel); err != nil {
+		return fmt.Errorf("write outbox events: %w", err)
+	}
+
+	return nil
+}
+
+// Outbox implements events.Outbox on top of PostgreSQL.
+type Outbox struct {
+	db *sql.DB
+}
+
+// NewOutbox creates a new Outbox.
+func NewOutbox(db *sql.DB) *Outbox {
+	return &Outbox{
+		db: db,
+	}
+}
+
+// Claim returns up to limit unpublished events in the order they were written and leases them.
+func (o *Outbox) Claim(ctx context.Context, limit uint, lease time.Duration) ([]events.Event, error) {
+	const query = `WITH claimed AS (
+	UPDATE outbox_events
+	SET available_at = NOW() + MAKE_INTERVAL(secs => $2)
+	WHERE id IN (
+		SELECT id
+		FROM outbox_events
+		WHERE published_at IS NULL AND available_at <= NOW()
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, event_id, event_type, occurred_at, data
+)
+SELECT event_id, event_type, occurred_at, data
+FROM claimed
+ORDER BY id`
+
+	rows, err := o.db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("claim outbox events: %w", err)
+	}
+
+	return scanEvents(rows)
+}
+
+// Get returns events with the given IDs in the order they were written.
+// Purged events are skipped.
+func (o *Outbox) Get(ctx context.Context, ids ...string) ([]events.Event, error) {
+	const query = `SELECT event_id, event_type, occurred_at, data
+FROM outbox_events
+WHERE event_id = ANY($1)
+ORDER BY id`
+
+	rows, err := o.db.QueryContext(ctx, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("get outbox events: %w", err)
+	}
+
+	return scanEvents(rows)
+}
+
+// After returns up to limit events written after the event with the given ID, it implements events.History.
+// If the event is unknown or already purged, there's nothing to resume from and events.ErrUnknownEvent is returned.
+//
+// Events are ordered by the sequence of the outbox, and a sequence value is taken when an event is written,
+// not when its transaction is committed. An event of a long transaction may be committed after a later one
+// was already delivered, so a subscriber resumed from the later one never gets it. The outbox is written
+// in short transactions of single changes, such a gap needs two changes committed in a reverse order
+// within the same moment and a reconnection between them.
+func (o *Outbox) After(ctx context.Context, id string, limit uint) ([]events.Event, error) {
+	var seq int64
+
+	err := o.db.QueryRowContext(ctx, `SELECT id FROM outbox_events WHERE event_id = $1`, id).Scan(&seq)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, events.ErrUnknownEvent
+	case err != nil:
+		return nil, fmt.Errorf("get outbox event %s: %w", id, err)
+	}
+
+	const query = `SELECT event_id, event_type, occurred_at, data
+FROM outbox_events
+WHERE id > $1
+ORDER BY id
+LIMIT $2`
+
+	rows, err := o.db.QueryContext(ctx, query, seq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get outbox events after %s: %w", id, err)
+	}
+
+	return scanEvents(rows)
+}
+
+// Watch passes events written to the outbox by any replica to sink until ctx is done.
+// Events are passed as soon as they are committed, they don't wait for the relay.
+func (o *Outbox) Watch(ctx context.Context, logger *slog.Logger, sink events.Publisher) error {
+	return Listen(ctx, o.db, logger, OutboxChannel, func(ctx context.Context, id string) {
+		batch, err := o.Get(ctx, id)
+		if err != nil {
+			logger.Error("failed to get notified outbox event", slog.String("event_id", id), slog.Any("err", err))
+			return
+		}
+
+		if err = sink.Publish(ctx, batch...); err != nil {
+			logger.Warn("failed to pass outbox event", slog.String("event_id", id), slog.Any("err", err))
+		}
+	})
+}
+
+// MarkPublished marks events as published.
+func (o *Outbox) MarkPublished(ctx context.Context, ids []string) error {
+	const query = `UPDATE outbox_events
+SET published_at = NOW(),
+	last_error   = NULL
+WHERE event_id = ANY($1)`
+
+	if _, err := o.db.ExecContext(ctx, query, ids); err != nil {
+		return fmt.Errorf("mark outbox events as published: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed attempt to publish an event.
+// The next attempt is delayed by 2^attempts seconds, at most by an hour.
+func (o *Outbox) MarkFailed(ctx context.Context, id string, reason string) error {
+	const query = `UPDATE outbox_events
+SET attempts     = attempts + 1,
+	last_error   = $2,
+	available_at = NOW() + MAKE_INTERVAL(secs => LEAST(POWER(2, attempts), $3))
+WHERE event_id = $1`
+
+	if _, err := o.db.ExecContext(ctx, query, id, reason, maxOutboxRetryDelay); err != nil {
+		return fmt.Errorf("mark outbox event as failed: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePublished purges events published more than retention ago and returns the number of deleted ones.
+func (o *Outbox) DeletePublished(ctx context.Context, retention time.Duration) (int64, error) {
+	const query = `DELETE FROM outbox_events
+WHERE published_at <= NOW() - MAKE_INTERVAL(secs => $1)`
+
+	result, err := o.db.ExecContext(ctx, query, retention.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("delete published outbox events: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+func scanEvents(rows *sql.Rows) ([]events.Event, error) {
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var batch []events.Event
+	for rows.Next() {
+		var event events.Event
+		if err := rows.Scan(&event.ID, &event.Type, &event.OccurredAt, &event.Data); err != nil {
+			return nil, fmt.Errorf("scan outbox event: %w", err)
+		}
+		batch = append(batch, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate outbox events: %w", err)
+	}
+
+	return batch, nil
+}
@@ -0,0 +1,88 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/events"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres/pgtest"
+)
+
+func TestOutbox(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip integration test due to 'short' flag")
+	}
+	t.Parallel()
+
+	pgConn := pgtest.InitTestDatabase(
+		t,
+		pgtest.WithConfigFiles(envFile),
+	)
+
+	ctx := context.Background()
+	outbox := postgres.NewOutbox(pgConn)
+
+	occurredAt := time.Date(2024, 10, 7, 12, 0, 0, 0, time.UTC)
+
+	first, err := events.New("snippet.created", occurredAt, map[string]uint{"id": 1})
+	require.NoError(t, err)
+	second, err := events.New("snippet.deleted", occurredAt, map[string]uint{"id": 1})
+	require.NoError(t, err)
+
+	t.Run("Events are written in a transaction", func(t *testing.T) {
+		tx, err := pgConn.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		require.NoError(t, postgres.WriteOutbox(ctx, tx, first, second))
+		require.NoError(t, tx.Rollback())
+
+		batch, err := outbox.Claim(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, batch, "events of a rolled back transaction are discarded")
+
+		require.NoError(t, postgres.WriteOutbox(ctx, pgConn, first, second))
+	})
+
+	t.Run("Claim leases events", func(t *testing.T) {
+		batch, err := outbox.Claim(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, batch, 2)
+		assert.Equal(t, first.ID, batch[0].ID)
+		assert.Equal(t, first.Type, batch[0].Type)
+		assert.True(t, occurredAt.Equal(batch[0].OccurredAt))
+		assert.JSONEq(t, string(first.Data), string(batch[0].Data))
+		assert.Equal(t, second.ID, batch[1].ID)
+
+		leased, err := outbox.Claim(ctx, 10, time.Minute)
+		require.NoError(t, err)
+		assert.Empty(t, leased)
+	})
+
+	t.Run("Get events after the given one", func(t *testing.T) {
+		batch, err := outbox.Get(ctx, second.ID, first.ID)
+		require.NoError(t, err)
+		require.Len(t, batch, 2)
+		assert.Equal(t, first.ID, batch[0].ID)
+
+		batch, err = outbox.After(ctx, first.ID, 10)
+		require.NoError(t, err)
+		require.Len(t, batch, 1)
+		assert.Equal(t, second.ID, batch[0].ID)
+
+		_, err = outbox.After(ctx, "unknown", 10)
+		require.ErrorIs(t, err, events.ErrUnknownEvent)
+	})
+
+	t.Run("Published events are purged", func(t *testing.T) {
+		require.NoError(t, outbox.MarkPublished(ctx, []string{first.ID}))
+		require.NoError(t, outbox.MarkFailed(ctx, second.ID, "connection refused"))
+
+		deleted, err := outbox.DeletePublished(ctx, 0)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, deleted)
+	})
+}
@@ -33,7 +33,7 @@ func CreateTemplateDatabase(flags Flags, migrations fs.FS) error {
 		return fmt.Errorf("close inital DB connection: %w", err)
 	}
 
-	flags.Postgres.Database = flags.TestDatabaseTemplate
+	flags.Postgres = flags.Postgres.WithDatabase(flags.TestDatabaseTemplate)
 
 	db, err := flags.Postgres.OpenStdSQLDB()
 	if err != nil {
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/alecthomas/kong"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/require"
 
 	kongdotenv "github.com/titusjaka/kong-dotenv-go"
@@ -45,7 +46,7 @@ func InitTestDatabase(t testing.TB, opts ...Option) *sql.DB {
 
 	// Init connection with test DB
 	testFlags := initialFlags
-	testFlags.Postgres.Database = databaseName
+	testFlags.Postgres = testFlags.Postgres.WithDatabase(databaseName)
 	testDB, err := testFlags.Postgres.OpenStdSQLDB()
 	require.NoError(t, err)
 
@@ -127,3 +128,30 @@ func tearDownTestDatabase(t testing.TB, testConn *sql.DB, initFlags Flags, dbNam
 	// Drop test DB
 	DropDatabase(t, commonConn, dbNameToDrop, true)
 }
+
+// InitTestPool creates a new dummy database the same way as InitTestDatabase,
+// and returns a native pgx pool connected to it. The pool is closed before the database is dropped.
+func InitTestPool(t testing.TB, opts ...Option) *pgxpool.Pool {
+	t.Helper()
+
+	conf := defaultConfig()
+	for _, opt := range opts {
+		opt(conf)
+	}
+
+	testDB := InitTestDatabase(t, opts...)
+
+	var databaseName string
+	err := testDB.QueryRow("SELECT current_database()").Scan(&databaseName)
+	require.NoError(t, err)
+
+	testFlags := cmp.Or(conf.flags, FlagsFromEnv(t, conf.configFiles...))
+	testFlags.Postgres = testFlags.Postgres.WithDatabase(databaseName)
+
+	pool, err := testFlags.Postgres.OpenPool(context.Background())
+	require.NoError(t, err)
+
+	t.Cleanup(pool.Close)
+
+	return pool
+}
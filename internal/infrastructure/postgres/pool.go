@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PoolOption configures a pgx connection pool
+type PoolOption func(*pgxpool.Config)
+
+// WithMaxConns limits the number of connections of the pool
+func WithMaxConns(n int32) PoolOption {
+	return func(c *pgxpool.Config) {
+		c.MaxConns = n
+	}
+}
+
+// WithMinConns sets the number of connections the pool keeps open even when they're idle
+func WithMinConns(n int32) PoolOption {
+	return func(c *pgxpool.Config) {
+		c.MinConns = n
+	}
+}
+
+// WithMaxConnLifetime sets how long a connection is used before it's closed and replaced
+func WithMaxConnLifetime(d time.Duration) PoolOption {
+	return func(c *pgxpool.Config) {
+		c.MaxConnLifetime = d
+	}
+}
+
+// WithMaxConnIdleTime sets how long an idle connection is kept open
+func WithMaxConnIdleTime(d time.Duration) PoolOption {
+	return func(c *pgxpool.Config) {
+		c.MaxConnIdleTime = d
+	}
+}
+
+// OpenPool opens a new pgx connection pool to the PostgreSQL database.
+// Unlike OpenStdSQLDB, it gives access to pgx features: batches, COPY, native types and statement caching.
+func (p Flags) OpenPool(ctx context.Context, opts ...PoolOption) (*pgxpool.Pool, error) {
+	settings, err := p.connSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := pgxpool.ParseConfig(formatConnString(settings))
+	if err != nil {
+		return nil, fmt.Errorf("parse PostgreSQL pool config: %w", err)
+	}
+
+	if err = p.configureTLS(&config.ConnConfig.Config, settings["sslmode"]); err != nil {
+		return nil, err
+	}
+
+	p.applyPoolFlags(config)
+
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("open PostgreSQL pool: %w", err)
+	}
+
+	if err = pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("ping PostgreSQL: %w", err)
+	}
+	return pool, nil
+}
+
+// applyPoolFlags applies pool settings of the flags to a pgx pool config.
+// Zero limits mean the same as for OpenStdSQLDB: the size is left to pgx, and connections are never replaced.
+func (p Flags) applyPoolFlags(config *pgxpool.Config) {
+	if p.MaxOpenConns > 0 {
+		config.MaxConns = int32(min(p.MaxOpenConns, math.MaxInt32)) //nolint:gosec // it's capped right here
+	}
+
+	config.MaxConnLifetime = cmp.Or(p.ConnMaxLifetime, forever)
+	config.MaxConnIdleTime = cmp.Or(p.ConnMaxIdleTime, forever)
+}
+
+// forever is the longest duration, it's used where pgx has no special value for no limit
+const forever = time.Duration(math.MaxInt64)
+
+// PgxConn executes queries with pgx, it's implemented by *pgxpool.Pool, *pgx.Conn and pgx.Tx.
+type PgxConn interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// PgxExecer adapts a pgx connection or transaction to Execer, so helpers like WriteOutbox are used with pgx too
+func PgxExecer(conn PgxConn) Execer {
+	return pgxExecer{conn: conn}
+}
+
+type pgxExecer struct {
+	conn PgxConn
+}
+
+// ExecContext implements Execer
+func (e pgxExecer) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	tag, err := e.conn.Exec(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.RowsAffected(tag.RowsAffected()), nil
+}
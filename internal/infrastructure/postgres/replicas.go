@@ -0,0 +1,234 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ReplicaFlags represents connection flags of PostgreSQL read replicas.
+// Replicas share credentials and session settings with the primary, only their addresses differ.
+type ReplicaFlags struct {
+	Hosts               []string      `kong:"optional,group='Postgres',name=postgres-replica-hosts,env=POSTGRES_REPLICA_HOSTS,help='Read replicas as host:port pairs separated by commas, the port of the primary is used if omitted.'"`
+	HealthCheckInterval time.Duration `kong:"optional,group='Postgres',name=postgres-replica-health-check-interval,default=5s,env=POSTGRES_REPLICA_HEALTH_CHECK_INTERVAL,help='How often replicas are pinged. Unhealthy replicas are skipped until they respond again.'"`
+}
+
+// Replica is a read replica of the primary database
+type Replica struct {
+	Name string
+	DB   *sql.DB
+}
+
+// Open opens connections to the replicas of the primary. Replicas aren't connected to yet,
+// so an unreachable replica doesn't prevent the start, it's considered unhealthy instead.
+func (f ReplicaFlags) Open(primary Flags) ([]Replica, error) {
+	replicas := make([]Replica, 0, len(f.Hosts))
+
+	closeAll := func() {
+		for _, replica := range replicas {
+			_ = replica.DB.Close()
+		}
+	}
+
+	for _, address := range f.Hosts {
+		host, port, err := splitReplicaAddress(address, primary.Port)
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+
+		db, err := primary.WithHost(host, port).openStdSQLDB()
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("open PostgreSQL replica %q: %w", address, err)
+		}
+
+		replicas = append(replicas, Replica{Name: address, DB: db})
+	}
+
+	return replicas, nil
+}
+
+func splitReplicaAddress(address string, defaultPort uint32) (string, uint32, error) {
+	address = strings.TrimSpace(address)
+
+	// A host without a port, or an IPv6 address without brackets
+	if net.ParseIP(strings.Trim(address, "[]")) != nil || !strings.Contains(address, ":") {
+		return strings.Trim(address, "[]"), defaultPort, nil
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid PostgreSQL replica address %q: %w", address, err)
+	}
+
+	parsed, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port of PostgreSQL replica %q: %w", address, err)
+	}
+
+	return host, uint32(parsed), nil
+}
+
+// Router routes read-only queries to healthy replicas in turn, and everything else to the primary.
+//
+// Replicas are considered unhealthy until the first health check passes, and after a failed health check or read.
+// Reads of a request that has written anything go to the primary, see ReadYourWrites.
+type Router struct {
+	primary  *sql.DB
+	replicas []*replica
+	logger   *slog.Logger
+
+	next atomic.Uint64
+}
+
+type replica struct {
+	Replica
+	healthy atomic.Bool
+}
+
+// NewRouter returns a new instance of Router
+func NewRouter(primary *sql.DB, logger *slog.Logger, replicas ...Replica) *Router {
+	r := &Router{
+		primary: primary,
+		logger:  logger,
+	}
+
+	for _, rep := range replicas {
+		r.replicas = append(r.replicas, &replica{Replica: rep})
+	}
+
+	return r
+}
+
+// Primary returns the primary database
+func (r *Router) Primary() *sql.DB {
+	return r.primary
+}
+
+// Read calls fn with a healthy replica, or with the primary if there's none, or if the request has written anything.
+// If fn fails on a replica, it's called once again with the primary: the replica may be down,
+// or it may not have a row written just now yet. Errors other than sql.ErrNoRows mark the replica unhealthy.
+func (r *Router) Read(ctx context.Context, fn func(db *sql.DB) error) error {
+	rep := r.pick(ctx)
+	if rep == nil {
+		return fn(r.primary)
+	}
+
+	err := fn(rep.DB)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) && rep.healthy.CompareAndSwap(true, false) {
+		r.logger.Warn(
+			"⚠️ ➡ read from replica failed, it is skipped until the next health check",
+			slog.String("replica", rep.Name),
+			slog.Any("err", err),
+		)
+	}
+
+	return fn(r.primary)
+}
+
+// pick returns the next healthy replica, or nil if reads must go to the primary
+func (r *Router) pick(ctx context.Context) *replica {
+	if written(ctx) || len(r.replicas) == 0 {
+		return nil
+	}
+
+	start := r.next.Add(1)
+	for i := range uint64(len(r.replicas)) {
+		if rep := r.replicas[(start+i)%uint64(len(r.replicas))]; rep.healthy.Load() {
+			return rep
+		}
+	}
+
+	return nil
+}
+
+// Run checks health of replicas every interval until ctx is done. The first check is made right away.
+func (r *Router) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.checkHealth(ctx, interval)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Router) checkHealth(ctx context.Context, timeout time.Duration) {
+	for _, rep := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := rep.DB.PingContext(pingCtx)
+		cancel()
+
+		switch healthy := err == nil; {
+		case ctx.Err() != nil:
+			return
+		case rep.healthy.Swap(healthy) == healthy:
+		case healthy:
+			r.logger.Info("🐘 ➡ replica is healthy", slog.String("replica", rep.Name))
+		default:
+			r.logger.Warn("⚠️ ➡ replica is unhealthy", slog.String("replica", rep.Name), slog.Any("err", err))
+		}
+	}
+}
+
+// Close closes connections to the replicas, the primary is left open
+func (r *Router) Close() error {
+	var errs []error
+	for _, rep := range r.replicas {
+		if err := rep.DB.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close replica %q: %w", rep.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+type writtenKey struct{}
+
+// ReadYourWrites is a middleware making reads of a request go to the primary once the request has written anything,
+// so a request never misses its own writes because of replication lag
+func ReadYourWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), writtenKey{}, &atomic.Bool{})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// MarkWritten records that the request of ctx writes to the primary, it's a no-op outside of ReadYourWrites
+func MarkWritten(ctx context.Context) {
+	if flag, ok := ctx.Value(writtenKey{}).(*atomic.Bool); ok {
+		flag.Store(true)
+	}
+}
+
+// ReadFromPrimary returns a context whose reads go to the primary, as if it has written anything.
+// It's used where a stale read of a replica lasts longer than the request, e.g. when the result is cached.
+func ReadFromPrimary(ctx context.Context) context.Context {
+	flag := &atomic.Bool{}
+	flag.Store(true)
+
+	return context.WithValue(ctx, writtenKey{}, flag)
+}
+
+func written(ctx context.Context) bool {
+	flag, ok := ctx.Value(writtenKey{}).(*atomic.Bool)
+	return ok && flag.Load()
+}
@@ -0,0 +1,236 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres"
+)
+
+func TestRouter(t *testing.T) {
+	t.Parallel()
+
+	cert := newTestCA(t).issue(t, 100, "db.internal").tlsCertificate(t)
+
+	flags := postgres.Flags{
+		Host:     "127.0.0.1",
+		Port:     startFakePostgres(t, cert).port,
+		Username: "postgres",
+		Database: "postgres",
+		TLSMode:  "require",
+	}
+
+	primary, err := flags.OpenStdSQLDB()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = primary.Close()
+	})
+
+	replicaFlags := postgres.ReplicaFlags{
+		Hosts: []string{net.JoinHostPort("127.0.0.1", strconv.Itoa(int(startFakePostgres(t, cert).port)))},
+	}
+
+	replicas, err := replicaFlags.Open(flags)
+	require.NoError(t, err)
+	require.Len(t, replicas, 1)
+
+	router := postgres.NewRouter(primary, nopslog.NewNoplogger(), replicas...)
+	t.Cleanup(func() {
+		_ = router.Close()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	// readFrom returns the database fn is called with
+	readFrom := func(ctx context.Context) *sql.DB {
+		var used *sql.DB
+		require.NoError(t, router.Read(ctx, func(db *sql.DB) error {
+			used = db
+			return nil
+		}))
+		return used
+	}
+
+	// Replicas are unhealthy until the first health check
+	assert.Same(t, primary, readFrom(ctx))
+
+	go func() {
+		_ = router.Run(ctx, 50*time.Millisecond)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return readFrom(ctx) == replicas[0].DB
+	}, 5*time.Second, 10*time.Millisecond)
+
+	t.Run("Reads of a request go to the primary after a write", func(t *testing.T) {
+		var before, after *sql.DB
+
+		handler := postgres.ReadYourWrites(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			before = readFrom(r.Context())
+			postgres.MarkWritten(r.Context())
+			after = readFrom(r.Context())
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Same(t, replicas[0].DB, before)
+		assert.Same(t, primary, after)
+
+		// Other requests aren't affected
+		assert.Same(t, replicas[0].DB, readFrom(ctx))
+	})
+
+	t.Run("Missing rows are read again from the primary", func(t *testing.T) {
+		var calls []*sql.DB
+		err := router.Read(ctx, func(db *sql.DB) error {
+			calls = append(calls, db)
+			if db != primary {
+				return sql.ErrNoRows
+			}
+			return nil
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []*sql.DB{replicas[0].DB, primary}, calls)
+
+		// ErrNoRows doesn't make the replica unhealthy
+		assert.Same(t, replicas[0].DB, readFrom(ctx))
+	})
+
+	t.Run("Failed read falls back to the primary", func(t *testing.T) {
+		errFailed := errors.New("replica failed")
+
+		var calls []*sql.DB
+		err := router.Read(ctx, func(db *sql.DB) error {
+			calls = append(calls, db)
+			if db != primary {
+				return errFailed
+			}
+			return nil
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []*sql.DB{replicas[0].DB, primary}, calls)
+
+		// The replica comes back after the next health check
+		assert.Eventually(t, func() bool {
+			return readFrom(ctx) == replicas[0].DB
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestRouter_UnreachableReplica(t *testing.T) {
+	t.Parallel()
+
+	cert := newTestCA(t).issue(t, 100, "db.internal").tlsCertificate(t)
+
+	flags := postgres.Flags{
+		Host:     "127.0.0.1",
+		Port:     startFakePostgres(t, cert).port,
+		Username: "postgres",
+		Database: "postgres",
+		TLSMode:  "require",
+	}
+
+	primary, err := flags.OpenStdSQLDB()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = primary.Close()
+	})
+
+	// Opening doesn't connect, so an unreachable replica doesn't fail the start
+	replicas, err := postgres.ReplicaFlags{Hosts: []string{net.JoinHostPort("127.0.0.1", strconv.Itoa(int(freePort(t))))}}.Open(flags)
+	require.NoError(t, err)
+
+	router := postgres.NewRouter(primary, nopslog.NewNoplogger(), replicas...)
+	t.Cleanup(func() {
+		_ = router.Close()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	go func() {
+		_ = router.Run(ctx, 50*time.Millisecond)
+	}()
+
+	for range 5 {
+		require.NoError(t, router.Read(ctx, func(db *sql.DB) error {
+			assert.Same(t, primary, db)
+			return nil
+		}))
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestSplitReplicaAddress(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		address string
+		host    string
+		port    uint32
+	}{
+		{name: "Host and port", address: "replica-1.internal:6432", host: "replica-1.internal", port: 6432},
+		{name: "Port of the primary", address: " replica-1.internal", host: "replica-1.internal", port: 5432},
+		{name: "IPv6 with port", address: "[::1]:6432", host: "::1", port: 6432},
+		{name: "IPv6 without port", address: "::1", host: "::1", port: 5432},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			host, port, err := postgres.SplitReplicaAddress(tt.address, 5432)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.host, host)
+			assert.Equal(t, tt.port, port)
+		})
+	}
+
+	t.Run("Invalid address", func(t *testing.T) {
+		t.Parallel()
+
+		for _, address := range []string{"replica:port", "replica:70000", "replica:1:2"} {
+			_, err := postgres.ReplicaFlags{Hosts: []string{"replica-1.internal", address}}.Open(postgres.Flags{Port: 5432})
+			require.Error(t, err, address)
+		}
+	})
+}
+
+func TestFlags_WithHost(t *testing.T) {
+	t.Parallel()
+
+	for _, dsn := range []string{
+		"",
+		"postgres://primary.internal/snippets",
+		"postgres://primary.internal:5433/snippets?sslmode=disable",
+		"host=primary.internal port=5433 dbname=snippets",
+	} {
+		flags := postgres.Flags{Host: "primary.internal", Port: 5432, Database: "snippets", DSN: dsn}
+
+		connString, err := flags.WithHost("replica.internal", 6432).BuildConnectionString()
+		require.NoError(t, err)
+
+		config, err := pgx.ParseConfig(connString)
+		require.NoError(t, err)
+		assert.Equal(t, "replica.internal", config.Host, dsn)
+		assert.EqualValues(t, 6432, config.Port, dsn)
+		assert.Equal(t, "snippets", config.Database, dsn)
+	}
+}
@@ -0,0 +1,204 @@
+package postgres
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// configureTLS applies the TLS flags to a pgx config. Files are validated right away,
+// and then read again by every new connection if they have changed, so rotated certificates
+// are picked up without a restart. Connections that are already established keep their certificates.
+func (p Flags) configureTLS(config *pgconn.Config, mode string) error {
+	if p.TLSRootCert == "" && p.TLSCert == "" && p.TLSKey == "" && p.TLSServerName == "" {
+		return nil
+	}
+
+	tlsConfigs := []*tls.Config{config.TLSConfig}
+	for _, fallback := range config.Fallbacks {
+		tlsConfigs = append(tlsConfigs, fallback.TLSConfig)
+	}
+
+	tlsConfigs = slices.DeleteFunc(tlsConfigs, func(c *tls.Config) bool { return c == nil })
+	if len(tlsConfigs) == 0 {
+		return errors.New("TLS flags are set, but TLS is disabled by the TLS mode")
+	}
+
+	if (p.TLSCert == "") != (p.TLSKey == "") {
+		return errors.New("both TLS certificate and key of the client are required")
+	}
+
+	var (
+		clientCert *reloadingFile[*tls.Certificate]
+		rootCAs    *reloadingFile[*x509.CertPool]
+	)
+
+	if p.TLSCert != "" {
+		clientCert = newReloadingFile(loadClientCert, p.TLSCert, p.TLSKey)
+		if _, err := clientCert.load(); err != nil {
+			return err
+		}
+	}
+
+	if p.TLSRootCert != "" {
+		rootCAs = newReloadingFile(loadRootCAs, p.TLSRootCert)
+		if _, err := rootCAs.load(); err != nil {
+			return err
+		}
+	}
+
+	// The same as for libpq: require with a root certificate verifies the chain as verify-ca does
+	verifyChain := mode == "verify-ca" || mode == "verify-full" || (mode == "require" && rootCAs != nil)
+	verifyHost := mode == "verify-full"
+
+	for _, tlsConfig := range tlsConfigs {
+		if p.TLSServerName != "" {
+			tlsConfig.ServerName = p.TLSServerName
+		}
+
+		if clientCert != nil {
+			tlsConfig.Certificates = nil
+			tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				return clientCert.load()
+			}
+		}
+
+		if verifyChain {
+			// The chain is verified with the latest roots in VerifyConnection, the default verification can't reload them
+			tlsConfig.InsecureSkipVerify = true
+			tlsConfig.VerifyPeerCertificate = nil
+			var serverName string
+			if verifyHost {
+				serverName = tlsConfig.ServerName
+			}
+
+			tlsConfig.VerifyConnection = verifyConnection(rootCAs, serverName)
+		}
+	}
+
+	return nil
+}
+
+// verifyConnection returns a function verifying the server certificate with the root CAs.
+// System roots are used if there're no root CAs. If serverName is set, the certificate must match it.
+func verifyConnection(rootCAs *reloadingFile[*x509.CertPool], serverName string) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return errors.New("server has no certificate")
+		}
+
+		opts := x509.VerifyOptions{
+			DNSName:       serverName,
+			Intermediates: x509.NewCertPool(),
+		}
+
+		if rootCAs != nil {
+			roots, err := rootCAs.load()
+			if err != nil {
+				return err
+			}
+
+			opts.Roots = roots
+		}
+
+		for _, cert := range state.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+
+		if _, err := state.PeerCertificates[0].Verify(opts); err != nil {
+			return fmt.Errorf("verify server certificate: %w", err)
+		}
+
+		return nil
+	}
+}
+
+func loadClientCert(paths ...string) (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(paths[0], paths[1])
+	if err != nil {
+		return nil, fmt.Errorf("load TLS client certificate: %w", err)
+	}
+
+	return &cert, nil
+}
+
+func loadRootCAs(paths ...string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		return nil, fmt.Errorf("load TLS root certificate: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("load TLS root certificate: no certificates in %q", paths[0])
+	}
+
+	return pool, nil
+}
+
+// reloadingFile is a value parsed from files, it's parsed again once any of the files has changed.
+// If the files can't be parsed after a change, e.g. while they're being rotated, the previous value is used.
+type reloadingFile[T any] struct {
+	paths []string
+	parse func(paths ...string) (T, error)
+
+	mu     sync.Mutex
+	stamp  string
+	value  T
+	loaded bool
+}
+
+func newReloadingFile[T any](parse func(paths ...string) (T, error), paths ...string) *reloadingFile[T] {
+	return &reloadingFile[T]{
+		paths: paths,
+		parse: parse,
+	}
+}
+
+func (f *reloadingFile[T]) load() (T, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stamps := make([]string, len(f.paths))
+	for i, path := range f.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return f.fallback(fmt.Errorf("stat %q: %w", path, err))
+		}
+
+		stamps[i] = fmt.Sprintf("%d:%d", info.ModTime().UnixNano(), info.Size())
+	}
+
+	stamp := strings.Join(stamps, ",")
+	if f.loaded && stamp == f.stamp {
+		return f.value, nil
+	}
+
+	value, err := f.parse(f.paths...)
+	if err != nil {
+		return f.fallback(err)
+	}
+
+	f.stamp = stamp
+	f.value = value
+	f.loaded = true
+
+	return value, nil
+}
+
+// fallback returns the previous value if there's one, or err otherwise
+func (f *reloadingFile[T]) fallback(err error) (T, error) {
+	if f.loaded {
+		return f.value, nil
+	}
+
+	var zero T
+	return zero, err
+}
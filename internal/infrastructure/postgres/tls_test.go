@@ -0,0 +1,384 @@
+package postgres_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres"
+)
+
+func TestFlags_TLS(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t)
+	serverCert := ca.issue(t, 100, "db.internal")
+
+	dir := t.TempDir()
+	rootCertFile := writeTestPEM(t, dir, "root.crt", ca.certPEM)
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	ca.issue(t, 1, "").write(t, certFile, keyFile)
+
+	server := startFakePostgres(t, serverCert.tlsCertificate(t))
+
+	flags := postgres.Flags{
+		Host:          "127.0.0.1",
+		Port:          server.port,
+		Username:      "postgres",
+		Database:      "postgres",
+		TLSMode:       "verify-full",
+		TLSRootCert:   rootCertFile,
+		TLSCert:       certFile,
+		TLSKey:        keyFile,
+		TLSServerName: "db.internal",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	t.Run("Client certificate is presented and reloaded for new connections", func(t *testing.T) {
+		pool, err := flags.OpenPool(ctx)
+		require.NoError(t, err)
+		t.Cleanup(pool.Close)
+
+		assert.EqualValues(t, 1, server.lastClientSerial(t))
+
+		rotated := ca.issue(t, 2, "")
+		rotated.write(t, certFile, keyFile)
+
+		// Established connections keep the old certificate, new ones use the rotated one
+		pool.Reset()
+		require.NoError(t, pool.Ping(ctx))
+
+		assert.EqualValues(t, 2, server.lastClientSerial(t))
+	})
+
+	t.Run("Server name must match in verify-full mode", func(t *testing.T) {
+		flags := flags
+		flags.TLSServerName = "other.internal"
+
+		_, err := flags.OpenPool(ctx)
+		require.ErrorContains(t, err, "verify server certificate")
+	})
+
+	t.Run("Server name isn't checked in verify-ca mode", func(t *testing.T) {
+		flags := flags
+		flags.TLSMode = "verify-ca"
+		flags.TLSServerName = "other.internal"
+
+		db, err := flags.OpenStdSQLDB()
+		require.NoError(t, err)
+		require.NoError(t, db.Close())
+	})
+
+	t.Run("Server certificate must be issued by the root CA", func(t *testing.T) {
+		flags := flags
+		flags.TLSRootCert = writeTestPEM(t, t.TempDir(), "root.crt", newTestCA(t).certPEM)
+
+		_, err := flags.OpenPool(ctx)
+		require.ErrorContains(t, err, "verify server certificate")
+	})
+}
+
+func TestFlags_TLSValidation(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "client.crt")
+	keyFile := filepath.Join(dir, "client.key")
+	newTestCA(t).issue(t, 1, "").write(t, certFile, keyFile)
+
+	tests := []struct {
+		name  string
+		flags postgres.Flags
+	}{
+		{
+			name:  "TLS is disabled",
+			flags: postgres.Flags{TLSMode: "disable", TLSCert: certFile, TLSKey: keyFile},
+		},
+		{
+			name:  "Certificate without a key",
+			flags: postgres.Flags{TLSMode: "require", TLSCert: certFile},
+		},
+		{
+			name:  "Missing root certificate",
+			flags: postgres.Flags{TLSMode: "verify-full", TLSRootCert: filepath.Join(dir, "missing.crt")},
+		},
+		{
+			name:  "Root certificate isn't PEM",
+			flags: postgres.Flags{TLSMode: "verify-full", TLSRootCert: keyFile},
+		},
+		{
+			name:  "Key doesn't match the certificate",
+			flags: postgres.Flags{TLSMode: "require", TLSCert: certFile, TLSKey: writeTestKey(t, dir)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Port 1 is never listened, so the error can only come from the validation
+			tt.flags.Host = "127.0.0.1"
+			tt.flags.Port = 1
+
+			_, err := tt.flags.OpenStdSQLDB()
+			require.Error(t, err)
+			assert.NotContains(t, err.Error(), "ping")
+		})
+	}
+}
+
+// ===================================================================================================================
+// Fake PostgreSQL server that accepts TLS connections and answers to pings
+
+type fakePostgres struct {
+	port          uint32
+	clientSerials chan *big.Int
+}
+
+func startFakePostgres(t *testing.T, cert tls.Certificate) *fakePostgres {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	return serveFakePostgres(t, listener, cert)
+}
+
+// serveFakePostgres serves connections of the listener until the test ends
+func serveFakePostgres(t *testing.T, listener net.Listener, cert tls.Certificate) *fakePostgres {
+	t.Helper()
+
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	server := &fakePostgres{
+		port:          uint32(listener.Addr().(*net.TCPAddr).Port), //nolint:gosec // it's a TCP port
+		clientSerials: make(chan *big.Int, 16),
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequestClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go server.serve(conn, tlsConfig)
+		}
+	}()
+
+	return server
+}
+
+func (s *fakePostgres) serve(conn net.Conn, tlsConfig *tls.Config) {
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if _, err := pgproto3.NewBackend(conn, conn).ReceiveStartupMessage(); err != nil {
+		return
+	}
+
+	if _, err := conn.Write([]byte("S")); err != nil {
+		return
+	}
+
+	tlsConn := tls.Server(conn, tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		return
+	}
+
+	var serial *big.Int
+	if peers := tlsConn.ConnectionState().PeerCertificates; len(peers) > 0 {
+		serial = peers[0].SerialNumber
+	}
+	s.clientSerials <- serial
+
+	backend := pgproto3.NewBackend(tlsConn, tlsConn)
+	if _, err := backend.ReceiveStartupMessage(); err != nil {
+		return
+	}
+
+	backend.Send(&pgproto3.AuthenticationOk{})
+	backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+	if err := backend.Flush(); err != nil {
+		return
+	}
+
+	for {
+		msg, err := backend.Receive()
+		if err != nil {
+			return
+		}
+
+		switch msg.(type) {
+		case *pgproto3.Query:
+			backend.Send(&pgproto3.EmptyQueryResponse{})
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+			if err = backend.Flush(); err != nil {
+				return
+			}
+		case *pgproto3.Terminate:
+			return
+		}
+	}
+}
+
+// lastClientSerial returns the serial number of the client certificate of the latest connection
+func (s *fakePostgres) lastClientSerial(t *testing.T) int64 {
+	t.Helper()
+
+	var serial *big.Int
+	for {
+		select {
+		case serial = <-s.clientSerials:
+		default:
+			require.NotNil(t, serial, "no client certificate received")
+			return serial.Int64()
+		}
+	}
+}
+
+// ===================================================================================================================
+// Certificates
+
+type testCA struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+}
+
+type testCert struct {
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue issues a server certificate for dnsName, or a client one if dnsName is empty
+func (ca *testCA) issue(t *testing.T, serial int64, dnsName string) testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "postgres"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	if dnsName != "" {
+		template.Subject.CommonName = dnsName
+		template.DNSNames = []string{dnsName}
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return testCert{
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func (c testCert) tlsCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	cert, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	require.NoError(t, err)
+
+	return cert
+}
+
+// write writes the certificate and its key, their modification time is moved forward to make the change visible
+func (c testCert) write(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+
+	modTime := time.Now().Add(time.Minute)
+	if info, err := os.Stat(certFile); err == nil {
+		modTime = info.ModTime().Add(time.Minute)
+	} else {
+		require.True(t, errors.Is(err, os.ErrNotExist))
+	}
+
+	for file, data := range map[string][]byte{certFile: c.certPEM, keyFile: c.keyPEM} {
+		require.NoError(t, os.WriteFile(file, data, 0o600))
+		require.NoError(t, os.Chtimes(file, modTime, modTime))
+	}
+}
+
+func writeTestPEM(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	return path
+}
+
+func writeTestKey(t *testing.T, dir string) string {
+	t.Helper()
+
+	return writeTestPEM(t, dir, "other.key", newTestCA(t).issue(t, 3, "").keyPEM)
+}
@@ -0,0 +1,221 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	// serializationFailure and deadlockDetected are SQLSTATE codes of transactions
+	// that failed because of concurrent ones and succeed if they're retried
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+
+	defaultTxMaxAttempts = 3
+	txRetryBackoff       = 20 * time.Millisecond
+)
+
+// Querier runs queries, it's implemented by *sql.DB, *sql.Tx and Tx.
+type Querier interface {
+	Execer
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Tx is a transaction started by BeginTx, it's implemented by *sql.Tx.
+type Tx interface {
+	Querier
+	Commit() error
+	Rollback() error
+}
+
+// TxManager runs functions in transactions. The transaction is put into the context of the function,
+// so storage calls made with that context use it transparently, see Conn and BeginTx.
+type TxManager struct {
+	db     *sql.DB
+	logger *slog.Logger
+
+	maxAttempts int
+}
+
+// TxOption configures optional TxManager parameters
+type TxOption func(*TxManager)
+
+// WithMaxAttempts sets how many times a transaction is run before a serialization failure is returned
+func WithMaxAttempts(attempts int) TxOption {
+	return func(m *TxManager) {
+		m.maxAttempts = max(attempts, 1)
+	}
+}
+
+// NewTxManager returns a new instance of TxManager
+func NewTxManager(db *sql.DB, logger *slog.Logger, opts ...TxOption) *TxManager {
+	m := &TxManager{
+		db:          db,
+		logger:      logger,
+		maxAttempts: defaultTxMaxAttempts,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// RunInTx runs fn in a transaction with the given isolation level, it's committed if fn returns nil
+// and rolled back otherwise. Serialization failures and deadlocks are retried with the whole fn,
+// so fn must not have side effects outside the database.
+//
+// If ctx already has a transaction of the same database, fn joins it and the isolation level is ignored:
+// the outer transaction is committed or retried as a whole.
+func (m *TxManager) RunInTx(ctx context.Context, isolation sql.IsolationLevel, fn func(ctx context.Context) error) error {
+	if _, ok := txFromContext(ctx, m.db); ok {
+		return fn(ctx)
+	}
+
+	backoff := txRetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := m.runOnce(ctx, isolation, fn)
+		if err == nil || attempt >= m.maxAttempts || !IsRetryable(err) {
+			return err
+		}
+
+		m.logger.Warn(
+			"🔁 ➡ transaction conflicted with a concurrent one, retrying",
+			slog.Int("attempt", attempt),
+			slog.Any("err", err),
+		)
+
+		timer := time.NewTimer(backoff/2 + rand.N(backoff/2+1)) //nolint:gosec // jitter doesn't need a secure source
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		backoff *= 2
+	}
+}
+
+func (m *TxManager) runOnce(ctx context.Context, isolation sql.IsolationLevel, fn func(ctx context.Context) error) error {
+	tx, err := m.db.BeginTx(ctx, &sql.TxOptions{Isolation: isolation})
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, &ctxTx{db: m.db, tx: tx})); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// IsRetryable reports whether err is a serialization failure or a deadlock,
+// the transaction that got it may succeed if it's run again
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected)
+}
+
+type txKey struct{}
+
+type ctxTx struct {
+	db         *sql.DB
+	tx         *sql.Tx
+	savepoints int
+}
+
+func txFromContext(ctx context.Context, db *sql.DB) (*ctxTx, bool) {
+	current, ok := ctx.Value(txKey{}).(*ctxTx)
+	return current, ok && current.db == db
+}
+
+// InTx reports whether ctx has a transaction started by TxManager
+func InTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*ctxTx)
+	return ok
+}
+
+// Conn returns the transaction of ctx if it belongs to db, or db itself otherwise
+func Conn(ctx context.Context, db *sql.DB) Querier {
+	if current, ok := txFromContext(ctx, db); ok {
+		return current.tx
+	}
+
+	return db
+}
+
+// BeginTx starts a transaction of db. If ctx already has a transaction of db, a savepoint of it is returned instead:
+// committing it releases the savepoint, rolling it back undoes only the changes made since the savepoint.
+func BeginTx(ctx context.Context, db *sql.DB) (Tx, error) {
+	current, ok := txFromContext(ctx, db)
+	if !ok {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		return tx, nil
+	}
+
+	current.savepoints++
+	sp := &savepoint{
+		Querier: current.tx,
+		ctx:     ctx,
+		name:    "sp_" + strconv.Itoa(current.savepoints),
+	}
+
+	if _, err := current.tx.ExecContext(ctx, "SAVEPOINT "+sp.name); err != nil {
+		return nil, err
+	}
+
+	return sp, nil
+}
+
+type savepoint struct {
+	Querier
+
+	ctx  context.Context
+	name string
+	done bool
+}
+
+// Commit implements Tx
+func (s *savepoint) Commit() error {
+	if s.done {
+		return sql.ErrTxDone
+	}
+	s.done = true
+
+	_, err := s.ExecContext(s.ctx, "RELEASE SAVEPOINT "+s.name)
+	return err
+}
+
+// Rollback implements Tx
+func (s *savepoint) Rollback() error {
+	if s.done {
+		return sql.ErrTxDone
+	}
+	s.done = true
+
+	_, err := s.ExecContext(s.ctx, "ROLLBACK TO SAVEPOINT "+s.name)
+	return err
+}
@@ -0,0 +1,202 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres/pgtest"
+)
+
+func TestTxManager(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip integration test due to 'short' flag")
+	}
+	t.Parallel()
+
+	pgConn := pgtest.InitTestDatabase(
+		t,
+		pgtest.WithConfigFiles(envFile),
+	)
+
+	ctx := context.Background()
+	manager := postgres.NewTxManager(pgConn, nopslog.NewNoplogger())
+
+	_, err := pgConn.ExecContext(ctx, `CREATE TABLE tx_test (id INT PRIMARY KEY, value TEXT NOT NULL)`)
+	require.NoError(t, err)
+
+	insert := func(ctx context.Context, id int, value string) error {
+		_, err := postgres.Conn(ctx, pgConn).ExecContext(ctx, `INSERT INTO tx_test (id, value) VALUES ($1, $2)`, id, value)
+		return err
+	}
+
+	value := func(t *testing.T, id int) string {
+		t.Helper()
+
+		var value string
+		switch err := pgConn.QueryRowContext(ctx, `SELECT value FROM tx_test WHERE id = $1`, id).Scan(&value); {
+		case errors.Is(err, sql.ErrNoRows):
+			return ""
+		default:
+			require.NoError(t, err)
+			return value
+		}
+	}
+
+	t.Run("Changes are committed", func(t *testing.T) {
+		err := manager.RunInTx(ctx, sql.LevelDefault, func(ctx context.Context) error {
+			if err := insert(ctx, 1, "committed"); err != nil {
+				return err
+			}
+
+			// The change isn't visible outside the transaction until it's committed
+			assert.Empty(t, value(t, 1))
+			return nil
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "committed", value(t, 1))
+	})
+
+	t.Run("Changes are rolled back on error", func(t *testing.T) {
+		errFailed := errors.New("failed")
+
+		err := manager.RunInTx(ctx, sql.LevelDefault, func(ctx context.Context) error {
+			if err := insert(ctx, 2, "rolled back"); err != nil {
+				return err
+			}
+			return errFailed
+		})
+		require.ErrorIs(t, err, errFailed)
+
+		assert.Empty(t, value(t, 2))
+	})
+
+	t.Run("Nested transactions join the outer one", func(t *testing.T) {
+		errFailed := errors.New("failed")
+
+		err := manager.RunInTx(ctx, sql.LevelDefault, func(ctx context.Context) error {
+			require.NoError(t, manager.RunInTx(ctx, sql.LevelSerializable, func(ctx context.Context) error {
+				return insert(ctx, 3, "inner")
+			}))
+			return errFailed
+		})
+		require.ErrorIs(t, err, errFailed)
+
+		assert.Empty(t, value(t, 3), "the inner transaction is rolled back with the outer one")
+	})
+
+	t.Run("BeginTx makes a savepoint of the outer transaction", func(t *testing.T) {
+		err := manager.RunInTx(ctx, sql.LevelDefault, func(ctx context.Context) error {
+			if err := insert(ctx, 4, "outer"); err != nil {
+				return err
+			}
+
+			tx, err := postgres.BeginTx(ctx, pgConn)
+			if err != nil {
+				return err
+			}
+
+			if _, err = tx.ExecContext(ctx, `INSERT INTO tx_test (id, value) VALUES (5, 'savepoint')`); err != nil {
+				return err
+			}
+
+			return tx.Rollback()
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "outer", value(t, 4))
+		assert.Empty(t, value(t, 5), "changes since the savepoint are rolled back")
+	})
+
+	t.Run("Serialization failures are retried", func(t *testing.T) {
+		require.NoError(t, insert(ctx, 6, "initial"))
+
+		var attempts int
+		err := manager.RunInTx(ctx, sql.LevelRepeatableRead, func(ctx context.Context) error {
+			attempts++
+
+			var current string
+			if err := postgres.Conn(ctx, pgConn).QueryRowContext(ctx, `SELECT value FROM tx_test WHERE id = 6`).Scan(&current); err != nil {
+				return err
+			}
+
+			// A concurrent update of the row read by the first attempt makes it fail
+			if attempts == 1 {
+				if _, err := pgConn.ExecContext(ctx, `UPDATE tx_test SET value = 'concurrent' WHERE id = 6`); err != nil {
+					return err
+				}
+			}
+
+			_, err := postgres.Conn(ctx, pgConn).ExecContext(
+				ctx,
+				`UPDATE tx_test SET value = $1 WHERE id = 6`,
+				fmt.Sprintf("%s, retried", current),
+			)
+			return err
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, attempts)
+		assert.Equal(t, "concurrent, retried", value(t, 6))
+	})
+
+	t.Run("Serialization failure is returned after the last attempt", func(t *testing.T) {
+		require.NoError(t, insert(ctx, 7, "initial"))
+
+		manager := postgres.NewTxManager(pgConn, nopslog.NewNoplogger(), postgres.WithMaxAttempts(2))
+
+		var attempts int
+		err := manager.RunInTx(ctx, sql.LevelRepeatableRead, func(ctx context.Context) error {
+			attempts++
+
+			if _, err := postgres.Conn(ctx, pgConn).ExecContext(ctx, `SELECT value FROM tx_test WHERE id = 7`); err != nil {
+				return err
+			}
+
+			if _, err := pgConn.ExecContext(ctx, `UPDATE tx_test SET value = 'concurrent' WHERE id = 7`); err != nil {
+				return err
+			}
+
+			_, err := postgres.Conn(ctx, pgConn).ExecContext(ctx, `UPDATE tx_test SET value = 'mine' WHERE id = 7`)
+			return err
+		})
+		require.Error(t, err)
+
+		assert.True(t, postgres.IsRetryable(err))
+		assert.Equal(t, 2, attempts)
+		assert.Equal(t, "concurrent", value(t, 7))
+	})
+}
+
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "Serialization failure", err: &pgconn.PgError{Code: "40001"}, want: true},
+		{name: "Deadlock", err: fmt.Errorf("update: %w", &pgconn.PgError{Code: "40P01"}), want: true},
+		{name: "Unique violation", err: &pgconn.PgError{Code: "23505"}},
+		{name: "Other error", err: errors.New("failed")},
+		{name: "No error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, postgres.IsRetryable(tt.err))
+		})
+	}
+}
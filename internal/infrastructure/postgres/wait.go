@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// permanentConnectErrors are SQLSTATE codes of refused connections that retries don't fix:
+// invalid_password, invalid_authorization_specification and invalid_catalog_name (no such database)
+var permanentConnectErrors = []string{"28P01", "28000", "3D000"}
+
+// Wait blocks until PostgreSQL accepts connections, or the connect deadline passes, or ctx is done.
+// Attempts are retried with exponential backoff and jitter, every failed attempt is logged.
+// Invalid flags, e.g. a malformed DSN or a missing certificate, are reported right away,
+// as well as rejected credentials and a missing database.
+func (p Flags) Wait(ctx context.Context, logger *slog.Logger) error {
+	if p.ConnectDeadline > 0 && (p.ConnectBackoff <= 0 || p.ConnectMaxBackoff < p.ConnectBackoff) {
+		return errors.New("connect backoff must be positive and not greater than the max connect backoff")
+	}
+
+	config, err := p.connConfig()
+	if err != nil {
+		return err
+	}
+
+	if p.ConnectDeadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.ConnectDeadline)
+		defer cancel()
+	}
+
+	started := time.Now()
+	backoff := p.ConnectBackoff
+
+	for attempt := 1; ; attempt++ {
+		err = ping(ctx, config)
+		if err == nil {
+			if attempt > 1 {
+				logger.Info(
+					"🐘 ➡ PostgreSQL is reachable",
+					slog.Int("attempts", attempt),
+					slog.Duration("waited", time.Since(started)),
+				)
+			}
+
+			return nil
+		}
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && slices.Contains(permanentConnectErrors, pgErr.Code) {
+			return fmt.Errorf("PostgreSQL refused the connection: %w", err)
+		}
+
+		delay := jitter(backoff)
+		if deadline, _ := ctx.Deadline(); p.ConnectDeadline <= 0 || time.Until(deadline) < delay {
+			return fmt.Errorf("PostgreSQL isn't reachable after %d attempt(s): %w", attempt, err)
+		}
+
+		logger.Warn(
+			"⏳ ➡ PostgreSQL isn't reachable yet, retrying",
+			slog.Int("attempt", attempt),
+			slog.Duration("retry_in", delay),
+			slog.Any("err", err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("PostgreSQL isn't reachable after %d attempt(s): %w", attempt, err)
+		case <-time.After(delay):
+		}
+
+		backoff = min(backoff*2, p.ConnectMaxBackoff)
+	}
+}
+
+// ping opens a single connection and closes it right away
+func ping(ctx context.Context, config *pgx.ConnConfig) error {
+	conn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = conn.Close(context.WithoutCancel(ctx))
+	}()
+
+	return conn.Ping(ctx)
+}
+
+// jitter returns a random delay between a half of d and d, so replicas started together don't retry in lockstep
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+
+	return d/2 + rand.N(d/2+1) //nolint:gosec // it's not used for security
+}
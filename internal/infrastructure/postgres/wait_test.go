@@ -0,0 +1,175 @@
+package postgres_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/nopslog"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/postgres"
+)
+
+func TestFlags_Wait(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCA(t)
+	serverCert := ca.issue(t, 100, "db.internal").tlsCertificate(t)
+
+	newFlags := func(port uint32) postgres.Flags {
+		return postgres.Flags{
+			Host:              "127.0.0.1",
+			Port:              port,
+			Username:          "postgres",
+			Database:          "postgres",
+			TLSMode:           "require",
+			ConnectDeadline:   5 * time.Second,
+			ConnectBackoff:    20 * time.Millisecond,
+			ConnectMaxBackoff: 100 * time.Millisecond,
+		}
+	}
+
+	t.Run("PostgreSQL becomes reachable", func(t *testing.T) {
+		t.Parallel()
+
+		port := freePort(t)
+
+		go func() {
+			time.Sleep(300 * time.Millisecond)
+
+			listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			serveFakePostgres(t, listener, serverCert)
+		}()
+
+		logs := &bytes.Buffer{}
+		flags := newFlags(port)
+
+		require.NoError(t, flags.Wait(context.Background(), slog.New(slog.NewTextHandler(logs, nil))))
+		assert.Contains(t, logs.String(), "retrying")
+		assert.Contains(t, logs.String(), "PostgreSQL is reachable")
+	})
+
+	t.Run("Deadline passes", func(t *testing.T) {
+		t.Parallel()
+
+		logs := &bytes.Buffer{}
+		flags := newFlags(freePort(t))
+		flags.ConnectDeadline = 300 * time.Millisecond
+
+		started := time.Now()
+		err := flags.Wait(context.Background(), slog.New(slog.NewTextHandler(logs, nil)))
+		require.ErrorContains(t, err, "isn't reachable after")
+		assert.Less(t, time.Since(started), time.Second)
+		assert.Greater(t, strings.Count(logs.String(), "retrying"), 1)
+	})
+
+	t.Run("Single attempt without a deadline", func(t *testing.T) {
+		t.Parallel()
+
+		logs := &bytes.Buffer{}
+		flags := newFlags(freePort(t))
+		flags.ConnectDeadline = 0
+
+		err := flags.Wait(context.Background(), slog.New(slog.NewTextHandler(logs, nil)))
+		require.ErrorContains(t, err, "after 1 attempt(s)")
+		assert.Empty(t, logs.String())
+	})
+
+	t.Run("Rejected connections aren't retried", func(t *testing.T) {
+		t.Parallel()
+
+		for _, code := range []string{"28P01", "28000", "3D000"} {
+			t.Run(code, func(t *testing.T) {
+				t.Parallel()
+
+				listener, err := net.Listen("tcp", "127.0.0.1:0")
+				require.NoError(t, err)
+				serveRejectingPostgres(t, listener, code)
+
+				logs := &bytes.Buffer{}
+				flags := newFlags(uint32(listener.Addr().(*net.TCPAddr).Port)) //nolint:gosec // it's a TCP port
+				flags.TLSMode = "disable"
+
+				var pgErr *pgconn.PgError
+				err = flags.Wait(context.Background(), slog.New(slog.NewTextHandler(logs, nil)))
+				require.ErrorAs(t, err, &pgErr)
+				assert.Equal(t, code, pgErr.Code)
+				assert.Empty(t, logs.String())
+			})
+		}
+	})
+
+	t.Run("Backoff must be positive", func(t *testing.T) {
+		t.Parallel()
+
+		for _, flags := range []postgres.Flags{
+			{ConnectDeadline: time.Second, ConnectBackoff: 0, ConnectMaxBackoff: time.Second},
+			{ConnectDeadline: time.Second, ConnectBackoff: time.Second, ConnectMaxBackoff: 0},
+		} {
+			require.ErrorContains(t, flags.Wait(context.Background(), nopslog.NewNoplogger()), "backoff")
+		}
+	})
+
+	t.Run("Invalid flags aren't retried", func(t *testing.T) {
+		t.Parallel()
+
+		logs := &bytes.Buffer{}
+		flags := newFlags(freePort(t))
+		flags.TLSCert = "client.crt"
+
+		require.Error(t, flags.Wait(context.Background(), slog.New(slog.NewTextHandler(logs, nil))))
+		assert.Empty(t, logs.String())
+	})
+}
+
+// serveRejectingPostgres refuses every connection of the listener with the SQLSTATE code until the test ends
+func serveRejectingPostgres(t *testing.T, listener net.Listener, code string) {
+	t.Helper()
+
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			backend := pgproto3.NewBackend(conn, conn)
+			if _, err = backend.ReceiveStartupMessage(); err == nil {
+				backend.Send(&pgproto3.ErrorResponse{Severity: "FATAL", Code: code, Message: "connection rejected"})
+				_ = backend.Flush()
+			}
+
+			_ = conn.Close()
+		}
+	}()
+}
+
+// freePort returns a port nobody listens to
+func freePort(t *testing.T) uint32 {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	return uint32(port) //nolint:gosec // it's a TCP port
+}
@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+)
+
+type callerKey struct{}
+
+// ContextWithCaller returns a copy of ctx that holds the name of the authenticated API caller
+func ContextWithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext returns the name of the authenticated API caller.
+// It reports false if ctx doesn't come from an API request, e.g. for CLI commands.
+func CallerFromContext(ctx context.Context) (string, bool) {
+	caller, ok := ctx.Value(callerKey{}).(string)
+	return caller, ok
+}
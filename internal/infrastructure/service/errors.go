@@ -14,6 +14,10 @@ const (
 	NotFound
 	// Forbidden means user has no access for a resource
 	Forbidden
+	// TooManyRequests denotes that user exceeded a rate limit and may retry later
+	TooManyRequests
+	// QuotaExceeded denotes that user has used up a quota and has to free resources first
+	QuotaExceeded
 )
 
 // Error error represents any business or infrastructure error
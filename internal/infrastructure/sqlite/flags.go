@@ -0,0 +1,43 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	_ "modernc.org/sqlite" // pure-Go SQLite driver
+)
+
+// busyTimeout is how long a connection waits for a lock held by another connection, in milliseconds
+const busyTimeout = 5000
+
+// Flags represents SQLite database flags
+// and provides methods to open the database.
+type Flags struct {
+	Path string `kong:"optional,group='SQLite',name=sqlite-path,default=go-sample.db,env=SQLITE_PATH,help='Path to the SQLite database file, it is created if missing.'"`
+}
+
+// OpenStdSQLDB opens the SQLite database using the standard library's sql package.
+// Foreign keys are enforced and the write-ahead log is enabled, so reads don't wait for writes.
+func (f Flags) OpenStdSQLDB() (*sql.DB, error) {
+	db, err := sql.Open("sqlite", f.BuildConnectionString())
+	if err != nil {
+		return nil, fmt.Errorf("open SQLite database: %w", err)
+	}
+
+	if err = db.Ping(); err != nil {
+		return nil, fmt.Errorf("ping SQLite: %w", err)
+	}
+	return db, nil
+}
+
+// BuildConnectionString returns a connection string of the database file with pragmas set for every connection
+func (f Flags) BuildConnectionString() string {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", busyTimeout))
+	params.Add("_txlock", "immediate")
+
+	return "file:" + f.Path + "?" + params.Encode()
+}
@@ -0,0 +1,53 @@
+package sqlitemigrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"net/http"
+
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+const migrationsTableName = "migrations"
+
+// Migrator represents a migration service of SQLite databases.
+// New migration files are created with pgmigrator.Create, the format is the same.
+type Migrator struct {
+	db     *sql.DB
+	source migrate.MigrationSource
+}
+
+// NewMigrator returns a new Migrator
+func NewMigrator(db *sql.DB, source fs.FS) *Migrator {
+	return &Migrator{
+		db: db,
+		source: &migrate.HttpFileSystemMigrationSource{
+			FileSystem: http.FS(source),
+		},
+	}
+}
+
+// Up applies all migrations
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	return m.apply(ctx, migrate.Up, 0)
+}
+
+// Down rollback a number of migrations
+func (m *Migrator) Down(ctx context.Context, maxSteps int) (int, error) {
+	return m.apply(ctx, migrate.Down, maxSteps)
+}
+
+// apply runs migrations, every migration is applied in its own transaction.
+// SQLite allows a single writer at a time, so concurrent migrators just wait for each other.
+func (m *Migrator) apply(ctx context.Context, direction migrate.MigrationDirection, maxSteps int) (int, error) {
+	migrationSet := &migrate.MigrationSet{TableName: migrationsTableName}
+
+	applied, err := migrationSet.ExecMaxContext(ctx, m.db, "sqlite3", m.source, direction, maxSteps)
+	if err != nil {
+		return 0, fmt.Errorf("apply database migrations: %w", err)
+	}
+
+	return applied, nil
+}
@@ -0,0 +1,58 @@
+package sqlitemigrator_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/sqlite"
+	"github.com/titusjaka/go-sample/v2/internal/infrastructure/sqlite/sqlitemigrator"
+	sqlitemigrations "github.com/titusjaka/go-sample/v2/migrations/sqlite"
+)
+
+func TestMigrator(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	db, err := sqlite.Flags{Path: filepath.Join(t.TempDir(), "test.db")}.OpenStdSQLDB()
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	migrator := sqlitemigrator.NewMigrator(db, sqlitemigrations.Dir)
+
+	tableExists := func(name string) bool {
+		var count int
+		require.NoError(t, db.QueryRowContext(
+			ctx,
+			`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`,
+			name,
+		).Scan(&count))
+		return count > 0
+	}
+
+	// ===============================================
+	// Apply all migrations
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Positive(t, applied)
+	assert.True(t, tableExists("snippets"))
+
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, applied, "applied migrations are skipped")
+
+	// ===============================================
+	// Revert all migrations
+	reverted, err := migrator.Down(ctx, 0)
+	require.NoError(t, err)
+	assert.Positive(t, reverted)
+	assert.False(t, tableExists("snippets"))
+	assert.False(t, tableExists("snippet_contents"))
+}
@@ -12,8 +12,9 @@ import (
 type App struct {
 	EnvFile kongdotenv.ENVFileConfig `kong:"optional,name=env-file,help='Path to .env file'"`
 
-	Migrate commands.MigrateCmd `kong:"cmd,name=migrate,help='Create a new migration, apply (or rollback) migrations to DB.'"`
-	Server  commands.ServerCmd  `kong:"cmd,name=server,default=1,help='Start the HTTP server.'"`
+	Migrate  commands.MigrateCmd  `kong:"cmd,name=migrate,help='Create a new migration, apply (or rollback) migrations to DB.'"`
+	Server   commands.ServerCmd   `kong:"cmd,name=server,default=1,help='Start the HTTP server.'"`
+	Snippets commands.SnippetsCmd `kong:"cmd,name=snippets,help='Import snippets and other maintenance operations.'"`
 }
 
 var (
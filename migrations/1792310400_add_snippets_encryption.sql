@@ -0,0 +1,11 @@
+-- +migrate Up
+ALTER TABLE snippets
+	ADD COLUMN encryption      smallint NOT NULL DEFAULT 0,
+	ADD COLUMN passphrase_salt bytea,
+	ADD COLUMN wrapped_key     bytea;
+
+-- +migrate Down
+ALTER TABLE snippets
+	DROP COLUMN encryption,
+	DROP COLUMN passphrase_salt,
+	DROP COLUMN wrapped_key;
@@ -0,0 +1,77 @@
+-- +migrate Up
+-- Contents are stored once per unique SHA-256 and may be compressed by the application:
+-- compression = 0 (none), 1 (gzip), 2 (zstd).
+-- ref_count is incremented by the application on insert and decremented by a trigger on delete.
+CREATE TABLE snippet_contents
+(
+	hash        bytea    NOT NULL PRIMARY KEY,
+	data        bytea    NOT NULL,
+	compression smallint NOT NULL DEFAULT 0,
+	size        integer  NOT NULL,
+	ref_count   integer  NOT NULL DEFAULT 0
+);
+
+INSERT INTO snippet_contents (hash, data, size, ref_count)
+SELECT
+	SHA256(CONVERT_TO(content, 'UTF8')),
+	CONVERT_TO(content, 'UTF8'),
+	OCTET_LENGTH(content),
+	COUNT(*)
+FROM snippets
+GROUP BY content;
+
+ALTER TABLE snippets ADD COLUMN content_hash bytea REFERENCES snippet_contents (hash);
+
+UPDATE snippets SET content_hash = SHA256(CONVERT_TO(content, 'UTF8'));
+
+ALTER TABLE snippets
+	ALTER COLUMN content_hash SET NOT NULL,
+	DROP COLUMN content;
+
+CREATE INDEX idx_snippets_content_hash ON snippets (content_hash);
+
+-- +migrate StatementBegin
+CREATE FUNCTION release_snippet_content() RETURNS trigger AS
+$$
+BEGIN
+	UPDATE snippet_contents SET ref_count = ref_count - 1 WHERE hash = OLD.content_hash;
+	DELETE FROM snippet_contents WHERE hash = OLD.content_hash AND ref_count <= 0;
+	RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+CREATE TRIGGER trg_snippets_release_content
+	AFTER DELETE
+	ON snippets
+	FOR EACH ROW
+EXECUTE FUNCTION release_snippet_content();
+
+-- +migrate Down
+-- +migrate StatementBegin
+DO
+$$
+BEGIN
+	IF EXISTS (SELECT 1 FROM snippet_contents WHERE compression <> 0) THEN
+		RAISE EXCEPTION 'compressed snippet contents can not be restored by SQL migration';
+	END IF;
+END
+$$;
+-- +migrate StatementEnd
+
+DROP TRIGGER trg_snippets_release_content ON snippets;
+
+DROP FUNCTION release_snippet_content();
+
+ALTER TABLE snippets ADD COLUMN content text;
+
+UPDATE snippets s
+SET content = CONVERT_FROM(c.data, 'UTF8')
+FROM snippet_contents c
+WHERE c.hash = s.content_hash;
+
+ALTER TABLE snippets
+	ALTER COLUMN content SET NOT NULL,
+	DROP COLUMN content_hash;
+
+DROP TABLE snippet_contents;
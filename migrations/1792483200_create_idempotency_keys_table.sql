@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE idempotency_keys
+(
+	key          text                        NOT NULL PRIMARY KEY,
+	request_hash bytea                       NOT NULL,
+	status_code  integer                     NOT NULL DEFAULT 0,
+	content_type text                        NOT NULL DEFAULT '',
+	body         bytea,
+	created_at   timestamp WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+	expires_at   timestamp WITHOUT TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+
+-- +migrate Down
+DROP TABLE idempotency_keys;
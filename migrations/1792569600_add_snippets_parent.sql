@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE snippets
+	ADD COLUMN parent_id integer REFERENCES snippets (id) ON DELETE SET NULL;
+
+CREATE INDEX idx_snippets_parent_id ON snippets (parent_id);
+
+-- +migrate Down
+ALTER TABLE snippets
+	DROP COLUMN parent_id;
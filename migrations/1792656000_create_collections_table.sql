@@ -0,0 +1,24 @@
+-- +migrate Up
+CREATE TABLE collections
+(
+	id         serial                      NOT NULL PRIMARY KEY,
+	name       text                        NOT NULL,
+	created_at timestamp WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+	updated_at timestamp WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_collections_created_at ON collections (created_at);
+
+CREATE TABLE collection_snippets
+(
+	collection_id integer NOT NULL REFERENCES collections (id) ON DELETE CASCADE,
+	snippet_id    integer NOT NULL REFERENCES snippets (id) ON DELETE CASCADE,
+	position      integer NOT NULL,
+	PRIMARY KEY (collection_id, snippet_id)
+);
+
+CREATE INDEX idx_collection_snippets_snippet_id ON collection_snippets (snippet_id);
+
+-- +migrate Down
+DROP TABLE collection_snippets;
+DROP TABLE collections;
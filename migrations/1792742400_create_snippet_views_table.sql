@@ -0,0 +1,13 @@
+-- +migrate Up
+CREATE TABLE snippet_views
+(
+	snippet_id integer NOT NULL REFERENCES snippets (id) ON DELETE CASCADE,
+	day        date    NOT NULL,
+	views      bigint  NOT NULL DEFAULT 0,
+	PRIMARY KEY (snippet_id, day)
+);
+
+CREATE INDEX idx_snippet_views_day ON snippet_views (day);
+
+-- +migrate Down
+DROP TABLE snippet_views;
@@ -0,0 +1,15 @@
+-- +migrate Up
+CREATE TABLE snippet_comments
+(
+	id         serial                      NOT NULL PRIMARY KEY,
+	snippet_id integer                     NOT NULL REFERENCES snippets (id) ON DELETE CASCADE,
+	body       text                        NOT NULL,
+	line       integer,
+	created_at timestamp WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+	updated_at timestamp WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_snippet_comments_snippet_id ON snippet_comments (snippet_id, created_at);
+
+-- +migrate Down
+DROP TABLE snippet_comments;
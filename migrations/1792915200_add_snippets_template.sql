@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE snippets
+	ADD COLUMN template jsonb;
+
+-- +migrate Down
+ALTER TABLE snippets
+	DROP COLUMN template;
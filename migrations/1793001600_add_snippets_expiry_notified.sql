@@ -0,0 +1,14 @@
+-- +migrate Up
+ALTER TABLE snippets
+	ADD COLUMN expiry_notified boolean NOT NULL DEFAULT FALSE;
+
+-- Snippets that have already expired or been deleted are not announced.
+UPDATE snippets SET expiry_notified = TRUE WHERE expires_at <= NOW();
+
+CREATE INDEX idx_snippets_expiry_pending ON snippets (expires_at) WHERE NOT expiry_notified;
+
+-- +migrate Down
+DROP INDEX idx_snippets_expiry_pending;
+
+ALTER TABLE snippets
+	DROP COLUMN expiry_notified;
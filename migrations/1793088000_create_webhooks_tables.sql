@@ -0,0 +1,36 @@
+-- +migrate Up
+CREATE TABLE webhook_subscriptions
+(
+	id            serial                      NOT NULL PRIMARY KEY,
+	url           text                        NOT NULL,
+	secret        text                        NOT NULL,
+	events        text[]                      NOT NULL,
+	active        boolean                     NOT NULL DEFAULT TRUE,
+	last_error    text,
+	last_error_at timestamp WITHOUT TIME ZONE,
+	created_at    timestamp WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+	updated_at    timestamp WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE webhook_deliveries
+(
+	id               bigserial                   NOT NULL PRIMARY KEY,
+	subscription_id  integer                     NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+	event_id         text                        NOT NULL,
+	event_type       text                        NOT NULL,
+	payload          jsonb                       NOT NULL,
+	status           text                        NOT NULL DEFAULT 'pending',
+	attempts         integer                     NOT NULL DEFAULT 0,
+	next_attempt_at  timestamp WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+	last_error       text,
+	last_status_code integer,
+	created_at       timestamp WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+	delivered_at     timestamp WITHOUT TIME ZONE,
+	UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+-- +migrate Down
+DROP TABLE webhook_deliveries;
+DROP TABLE webhook_subscriptions;
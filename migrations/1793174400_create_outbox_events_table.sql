@@ -0,0 +1,20 @@
+-- +migrate Up
+CREATE TABLE outbox_events
+(
+	id           bigserial                   NOT NULL PRIMARY KEY,
+	event_id     text                        NOT NULL UNIQUE,
+	event_type   text                        NOT NULL,
+	occurred_at  timestamp WITHOUT TIME ZONE NOT NULL,
+	data         jsonb                       NOT NULL,
+	attempts     integer                     NOT NULL DEFAULT 0,
+	last_error   text,
+	available_at timestamp WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+	created_at   timestamp WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+	published_at timestamp WITHOUT TIME ZONE
+);
+
+CREATE INDEX idx_outbox_events_pending ON outbox_events (available_at) WHERE published_at IS NULL;
+CREATE INDEX idx_outbox_events_published_at ON outbox_events (published_at);
+
+-- +migrate Down
+DROP TABLE outbox_events;
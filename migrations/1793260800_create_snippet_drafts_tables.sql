@@ -0,0 +1,37 @@
+-- +migrate Up
+-- Replacing the content of a snippet releases the previous content, the same way deleting the snippet does.
+-- The new content is referenced by the application beforehand, so an unchanged content keeps its counter.
+CREATE TRIGGER trg_snippets_release_replaced_content
+	AFTER UPDATE OF content_hash
+	ON snippets
+	FOR EACH ROW
+EXECUTE FUNCTION release_snippet_content();
+
+-- Drafts hold the live content of snippets being edited. Edits are applied to a draft one by one,
+-- each edit increments the draft revision. The draft is saved to the snippet up to persisted_revision.
+CREATE TABLE snippet_drafts
+(
+	snippet_id         integer                     NOT NULL PRIMARY KEY REFERENCES snippets (id) ON DELETE CASCADE,
+	content            text                        NOT NULL,
+	revision           bigint                      NOT NULL DEFAULT 0,
+	persisted_revision bigint                      NOT NULL DEFAULT 0,
+	created_at         timestamp WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+	updated_at         timestamp WITHOUT TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE snippet_edits
+(
+	snippet_id integer                     NOT NULL REFERENCES snippet_drafts (snippet_id) ON DELETE CASCADE,
+	revision   bigint                      NOT NULL,
+	client_id  text                        NOT NULL,
+	operation  jsonb                       NOT NULL,
+	created_at timestamp WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (snippet_id, revision)
+);
+
+-- +migrate Down
+DROP TABLE snippet_edits;
+
+DROP TABLE snippet_drafts;
+
+DROP TRIGGER trg_snippets_release_replaced_content ON snippets;
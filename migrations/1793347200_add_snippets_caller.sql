@@ -0,0 +1,21 @@
+-- +migrate Up
+-- caller is the name of the API caller that created a snippet, empty for snippets created by CLI commands.
+-- content_size is the size of the plain content in bytes, it's counted towards quotas of the caller.
+ALTER TABLE snippets
+	ADD COLUMN caller       text    NOT NULL DEFAULT '',
+	ADD COLUMN content_size integer NOT NULL DEFAULT 0;
+
+-- Sizes of encrypted contents are approximated by their ciphertext.
+UPDATE snippets s
+SET content_size = c.size
+FROM snippet_contents c
+WHERE c.hash = s.content_hash;
+
+CREATE INDEX idx_snippets_caller_created_at ON snippets (caller, created_at);
+
+-- +migrate Down
+DROP INDEX idx_snippets_caller_created_at;
+
+ALTER TABLE snippets
+	DROP COLUMN caller,
+	DROP COLUMN content_size;
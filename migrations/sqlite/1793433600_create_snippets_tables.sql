@@ -0,0 +1,75 @@
+-- +migrate Up
+-- SQLite dialect of snippets tables, it matches PostgreSQL migrations up to 1793347200_add_snippets_caller.
+-- Times are stored as Unix time in microseconds, days of views as Unix time of the midnight (UTC) in microseconds.
+-- Contents are stored once per unique SHA-256 and may be compressed by the application:
+-- compression = 0 (none), 1 (gzip), 2 (zstd).
+-- ref_count is incremented by the application on insert and decremented by a trigger on delete.
+CREATE TABLE snippet_contents
+(
+	hash        blob    NOT NULL PRIMARY KEY,
+	data        blob    NOT NULL,
+	compression integer NOT NULL DEFAULT 0,
+	size        integer NOT NULL,
+	ref_count   integer NOT NULL DEFAULT 0
+);
+
+-- Template holds JSON-encoded variables of template snippets, NULL for regular snippets
+CREATE TABLE snippets
+(
+	id              integer NOT NULL PRIMARY KEY AUTOINCREMENT,
+	title           text    NOT NULL,
+	content_hash    blob    NOT NULL REFERENCES snippet_contents (hash),
+	created_at      integer NOT NULL,
+	updated_at      integer NOT NULL,
+	expires_at      integer NOT NULL,
+	encryption      integer NOT NULL DEFAULT 0,
+	passphrase_salt blob,
+	wrapped_key     blob,
+	parent_id       integer REFERENCES snippets (id) ON DELETE SET NULL,
+	template        text,
+	expiry_notified integer NOT NULL DEFAULT 0,
+	caller          text    NOT NULL DEFAULT '',
+	content_size    integer NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_snippets_created_at ON snippets (created_at);
+
+CREATE INDEX idx_snippets_expires_at ON snippets (expires_at);
+
+CREATE INDEX idx_snippets_content_hash ON snippets (content_hash);
+
+CREATE INDEX idx_snippets_parent_id ON snippets (parent_id);
+
+CREATE INDEX idx_snippets_expiry_pending ON snippets (expires_at) WHERE NOT expiry_notified;
+
+CREATE INDEX idx_snippets_caller_created_at ON snippets (caller, created_at);
+
+-- +migrate StatementBegin
+CREATE TRIGGER trg_snippets_release_content
+	AFTER DELETE
+	ON snippets
+	FOR EACH ROW
+BEGIN
+	UPDATE snippet_contents SET ref_count = ref_count - 1 WHERE hash = OLD.content_hash;
+	DELETE FROM snippet_contents WHERE hash = OLD.content_hash AND ref_count <= 0;
+END;
+-- +migrate StatementEnd
+
+CREATE TABLE snippet_views
+(
+	snippet_id integer NOT NULL REFERENCES snippets (id) ON DELETE CASCADE,
+	day        integer NOT NULL,
+	views      integer NOT NULL DEFAULT 0,
+	PRIMARY KEY (snippet_id, day)
+);
+
+CREATE INDEX idx_snippet_views_day ON snippet_views (day);
+
+-- +migrate Down
+DROP TABLE snippet_views;
+
+DROP TRIGGER trg_snippets_release_content;
+
+DROP TABLE snippets;
+
+DROP TABLE snippet_contents;
@@ -0,0 +1,16 @@
+-- +migrate Up
+-- Replacing the content of a snippet releases the previous content, the same way deleting the snippet does.
+-- The new content is referenced by the application beforehand, so an unchanged content keeps its counter.
+-- +migrate StatementBegin
+CREATE TRIGGER trg_snippets_release_replaced_content
+	AFTER UPDATE OF content_hash
+	ON snippets
+	FOR EACH ROW
+BEGIN
+	UPDATE snippet_contents SET ref_count = ref_count - 1 WHERE hash = OLD.content_hash;
+	DELETE FROM snippet_contents WHERE hash = OLD.content_hash AND ref_count <= 0;
+END;
+-- +migrate StatementEnd
+
+-- +migrate Down
+DROP TRIGGER trg_snippets_release_replaced_content;
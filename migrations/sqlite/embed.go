@@ -0,0 +1,11 @@
+package sqlite
+
+import (
+	"embed"
+)
+
+// Dir is used to hold embedded migration files of the SQLite dialect.
+// Only snippets tables are migrated: other modules need PostgreSQL.
+//
+//go:embed *.sql
+var Dir embed.FS